@@ -37,15 +37,23 @@ func InitConfig(logger *otelzap.Logger) {
 	viper.SetDefault("otel.collector_url", "localhost:4317")
 	viper.SetDefault("otel.insecure_mode", false)
 
+	viper.SetDefault("log.level", "info")
+
+	viper.SetDefault("db.url", "")
 	viper.SetDefault("db.host", "db")
+	viper.SetDefault("db.read_host", "")
 	viper.SetDefault("db.port", 5432)
 	viper.SetDefault("db.user", "thor")
 	viper.SetDefault("db.pass", "odinson")
 	viper.SetDefault("db.name", "thunderdome")
 	viper.SetDefault("db.sslmode", "disable")
+	viper.SetDefault("db.sslrootcert", "")
+	viper.SetDefault("db.sslcert", "")
+	viper.SetDefault("db.sslkey", "")
 	viper.SetDefault("db.max_open_conns", 25)
 	viper.SetDefault("db.max_idle_conns", 25)
 	viper.SetDefault("db.conn_max_lifetime", 5)
+	viper.SetDefault("db.conn_max_idle_time", 2)
 
 	viper.SetDefault("smtp.enabled", true)
 	viper.SetDefault("smtp.host", "localhost")
@@ -62,6 +70,7 @@ func InitConfig(logger *otelzap.Logger) {
 	viper.SetDefault("config.avatar_service", "gravatar")
 	viper.SetDefault("config.toast_timeout", 1000)
 	viper.SetDefault("config.allow_guests", true)
+	viper.SetDefault("config.generate_guest_names", true)
 	viper.SetDefault("config.allow_registration", true)
 	viper.SetDefault("config.allow_jira_import", true)
 	viper.SetDefault("config.allow_csv_import", true)
@@ -117,15 +126,23 @@ func InitConfig(logger *otelzap.Logger) {
 	_ = viper.BindEnv("otel.collector_url", "OTEL_COLLECTOR_URL")
 	_ = viper.BindEnv("otel.insecure_mode", "OTEL_INSECURE_MODE")
 
+	_ = viper.BindEnv("log.level", "LOG_LEVEL")
+
+	_ = viper.BindEnv("db.url", "DATABASE_URL")
 	_ = viper.BindEnv("db.host", "DB_HOST")
+	_ = viper.BindEnv("db.read_host", "DB_READ_HOST")
 	_ = viper.BindEnv("db.port", "DB_PORT")
 	_ = viper.BindEnv("db.user", "DB_USER")
 	_ = viper.BindEnv("db.pass", "DB_PASS")
 	_ = viper.BindEnv("db.name", "DB_NAME")
 	_ = viper.BindEnv("db.sslmode", "DB_SSLMODE")
+	_ = viper.BindEnv("db.sslrootcert", "DB_SSLROOTCERT")
+	_ = viper.BindEnv("db.sslcert", "DB_SSLCERT")
+	_ = viper.BindEnv("db.sslkey", "DB_SSLKEY")
 	_ = viper.BindEnv("db.max_open_conns", "DB_MAX_OPEN_CONNS")
 	_ = viper.BindEnv("db.max_idle_conns", "DB_MAX_IDLE_CONNS")
 	_ = viper.BindEnv("db.conn_max_lifetime", "DB_CONN_MAX_LIFETIME")
+	_ = viper.BindEnv("db.conn_max_idle_time", "DB_CONN_MAX_IDLE_TIME")
 
 	_ = viper.BindEnv("smtp.enabled", "SMTP_ENABLED")
 	_ = viper.BindEnv("smtp.host", "SMTP_HOST")
@@ -143,6 +160,7 @@ func InitConfig(logger *otelzap.Logger) {
 	_ = viper.BindEnv("config.avatar_service", "CONFIG_AVATAR_SERVICE")
 	_ = viper.BindEnv("config.toast_timeout", "CONFIG_TOAST_TIMEOUT")
 	_ = viper.BindEnv("config.allow_guests", "CONFIG_ALLOW_GUESTS")
+	_ = viper.BindEnv("config.generate_guest_names", "CONFIG_GENERATE_GUEST_NAMES")
 	_ = viper.BindEnv("config.allow_registration", "CONFIG_ALLOW_REGISTRATION")
 	_ = viper.BindEnv("config.allow_jira_import", "CONFIG_ALLOW_JIRA_IMPORT")
 	_ = viper.BindEnv("config.allow_CSV_import", "CONFIG_ALLOW_CSV_IMPORT")
@@ -3,7 +3,7 @@ package alert
 import (
 	"context"
 	"database/sql"
-	"errors"
+	"fmt"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
@@ -114,7 +114,7 @@ func (d *Service) AlertsCreate(ctx context.Context, Name string, Type string, Co
 		RegisteredOnly,
 	); err != nil {
 		d.Logger.Ctx(ctx).Error("insert error", zap.Error(err))
-		return errors.New("error attempting to add new alert")
+		return fmt.Errorf("error attempting to add new alert: %w", err)
 	}
 
 	return nil
@@ -137,7 +137,7 @@ func (d *Service) AlertsUpdate(ctx context.Context, ID string, Name string, Type
 		RegisteredOnly,
 	); err != nil {
 		d.Logger.Ctx(ctx).Error("update error", zap.Error(err))
-		return errors.New("error attempting to update alert")
+		return fmt.Errorf("error attempting to update alert: %w", err)
 	}
 
 	return nil
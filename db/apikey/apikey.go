@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -59,7 +60,7 @@ func (d *Service) GenerateApiKey(ctx context.Context, UserID string, KeyName str
 	).Scan(&APIKEY.CreatedDate)
 	if e != nil {
 		d.Logger.Ctx(ctx).Error("user_apikey_add query error", zap.Error(e))
-		return nil, errors.New("unable to create new api key")
+		return nil, fmt.Errorf("unable to create new api key: %w", e)
 	}
 
 	return APIKEY, nil
@@ -3,8 +3,15 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"embed"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/uptrace/opentelemetry-go-extra/otelzap"
@@ -18,6 +25,7 @@ import (
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5/pgconn"
 	_ "github.com/jackc/pgx/v5/stdlib" // necessary for postgres
 	"github.com/microcosm-cc/bluemonday"
 )
@@ -25,6 +33,79 @@ import (
 //go:embed migrations/*.sql
 var fs embed.FS
 
+// sslModes are the postgres sslmode values supported by the driver
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// ConnectionString builds the postgres DSN from the config, including the optional ssl cert paths,
+// when a connection URL is configured it is validated and used instead of the individual settings
+func (c *Config) ConnectionString() (string, error) {
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			return "", fmt.Errorf("invalid db url: %w", err)
+		}
+		if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+			return "", fmt.Errorf("invalid db url scheme %q", u.Scheme)
+		}
+		if u.Host == "" {
+			return "", errors.New("invalid db url: missing host")
+		}
+
+		return c.URL, nil
+	}
+
+	if !Contains(sslModes, c.SSLMode) {
+		return "", fmt.Errorf("invalid db sslmode %q", c.SSLMode)
+	}
+
+	psqlInfo := fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		c.Host,
+		c.Port,
+		c.User,
+		c.Password,
+		c.Name,
+		c.SSLMode,
+	)
+	if c.SSLRootCert != "" {
+		psqlInfo += fmt.Sprintf(" sslrootcert=%s", c.SSLRootCert)
+	}
+	if c.SSLCert != "" {
+		psqlInfo += fmt.Sprintf(" sslcert=%s", c.SSLCert)
+	}
+	if c.SSLKey != "" {
+		psqlInfo += fmt.Sprintf(" sslkey=%s", c.SSLKey)
+	}
+
+	return psqlInfo, nil
+}
+
+// ReadConnectionString builds the postgres DSN for the read replica, the same as ConnectionString
+// with ReadHost in place of the host, the URL keeps its port unless ReadHost includes one
+func (c *Config) ReadConnectionString() (string, error) {
+	if c.ReadHost == "" {
+		return "", errors.New("no db read host configured")
+	}
+
+	replica := *c
+	if c.URL != "" {
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			return "", fmt.Errorf("invalid db url: %w", err)
+		}
+		host := c.ReadHost
+		if _, _, err := net.SplitHostPort(host); err != nil && u.Port() != "" {
+			host = net.JoinHostPort(host, u.Port())
+		}
+		u.Host = host
+		replica.URL = u.String()
+	} else {
+		replica.Host = c.ReadHost
+	}
+
+	return replica.ConnectionString()
+}
+
 // New runs db migrations, sets up a db connection pool
 // and sets previously active users to false during startup
 func New(AdminEmail string, config *Config, logger *otelzap.Logger) *Service {
@@ -45,15 +126,10 @@ func New(AdminEmail string, config *Config, logger *otelzap.Logger) *Service {
 		Logger:              logger,
 	}
 
-	psqlInfo := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		d.Config.Host,
-		d.Config.Port,
-		d.Config.User,
-		d.Config.Password,
-		d.Config.Name,
-		d.Config.SSLMode,
-	)
+	psqlInfo, err := d.Config.ConnectionString()
+	if err != nil {
+		d.Logger.Ctx(ctx).Fatal("invalid database configuration", zap.Error(err))
+	}
 
 	pdb, err := otelsql.Open("pgx", psqlInfo, otelsql.WithAttributes(
 		semconv.DBSystemPostgreSQL,
@@ -62,9 +138,24 @@ func New(AdminEmail string, config *Config, logger *otelzap.Logger) *Service {
 		d.Logger.Ctx(ctx).Fatal("error connecting to the database: ", zap.Error(err))
 	}
 	d.DB = pdb
-	d.DB.SetMaxOpenConns(d.Config.MaxOpenConns)
-	d.DB.SetMaxIdleConns(d.Config.MaxIdleConns)
-	d.DB.SetConnMaxLifetime(time.Duration(d.Config.ConnMaxLifetime) * time.Minute)
+	d.configurePool(d.DB)
+
+	// reads use the primary unless a read replica is configured
+	d.ReadDB = d.DB
+	if d.Config.ReadHost != "" {
+		readInfo, err := d.Config.ReadConnectionString()
+		if err != nil {
+			d.Logger.Ctx(ctx).Fatal("invalid database read replica configuration", zap.Error(err))
+		}
+		rdb, err := otelsql.Open("pgx", readInfo, otelsql.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+		))
+		if err != nil {
+			d.Logger.Ctx(ctx).Fatal("error connecting to the database read replica: ", zap.Error(err))
+		}
+		d.ReadDB = rdb
+		d.configurePool(d.ReadDB)
+	}
 
 	err = otelsql.RegisterDBStatsMetrics(pdb, otelsql.WithAttributes(
 		semconv.DBSystemPostgreSQL,
@@ -107,3 +198,135 @@ func New(AdminEmail string, config *Config, logger *otelzap.Logger) *Service {
 
 	return d
 }
+
+// configurePool applies the configured connection pool limits to the database handle
+func (d *Service) configurePool(pool *sql.DB) {
+	pool.SetMaxOpenConns(d.Config.MaxOpenConns)
+	pool.SetMaxIdleConns(d.Config.MaxIdleConns)
+	pool.SetConnMaxLifetime(time.Duration(d.Config.ConnMaxLifetime) * time.Minute)
+	pool.SetConnMaxIdleTime(time.Duration(d.Config.ConnMaxIdleTime) * time.Minute)
+}
+
+// Ping verifies a database connection can be established, used by the health check
+func (d *Service) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	return d.DB.PingContext(ctx)
+}
+
+// IsRetryable checks whether the error was caused by a lost or refused database connection
+// such as a postgres restart, meaning the operation can be retried once the pool reconnects
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || pgconn.SafeToRetry(err) {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// class 08 connection exceptions and server shutdown/crash
+		return strings.HasPrefix(pgErr.Code, "08") ||
+			pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// IsUniqueViolation checks whether the error was caused by a unique constraint violation
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// TxError is returned by WithTx when beginning or committing the transaction itself fails
+type TxError struct {
+	Op  string
+	Err error
+}
+
+func (e *TxError) Error() string {
+	return fmt.Sprintf("%s transaction: %v", e.Op, e.Err)
+}
+
+func (e *TxError) Unwrap() error {
+	return e.Err
+}
+
+// retryAttempts is how many times Retry runs an operation that keeps failing with a retryable error
+const retryAttempts = 3
+
+// retryBackoff is how long Retry waits before its first retry, doubling after each attempt
+var retryBackoff = 100 * time.Millisecond
+
+// Retry runs fn again while it fails with a retryable connection error, backing off between attempts
+// so an operation issued while postgres restarts succeeds once the pool reconnects
+func Retry(ctx context.Context, fn func() error) error {
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt == retryAttempts || !IsRetryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// WithTx runs fn in a transaction, committing when fn returns nil and rolling back when it returns an error or panics
+// so callers can compose several operations into one atomic workflow, when beginning the transaction or fn fails
+// with a retryable connection error the whole transaction is retried, so fn may run more than once,
+// a failed commit is never retried as the transaction may have been applied
+func WithTx(ctx context.Context, DB *sql.DB, fn func(tx *sql.Tx) error) error {
+	var commitErr error
+	err := Retry(ctx, func() error {
+		err := runTx(ctx, DB, fn)
+		var txErr *TxError
+		if errors.As(err, &txErr) && txErr.Op == "commit" {
+			commitErr = err
+			return nil
+		}
+		return err
+	})
+	if commitErr != nil {
+		return commitErr
+	}
+
+	return err
+}
+
+// runTx runs fn in a single transaction attempt
+func runTx(ctx context.Context, DB *sql.DB, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := DB.BeginTx(ctx, nil)
+	if err != nil {
+		return &TxError{Op: "begin", Err: err}
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &TxError{Op: "commit", Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+// Package dbtest provides a fake database/sql driver for testing the data services without postgres
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Handler answers a statement with its arguments, returning the rows of a query or the rows affected by an exec
+type Handler func(args []driver.Value) ([][]driver.Value, error)
+
+type handler struct {
+	fragment string
+	columns  []string
+	exec     bool
+	fn       Handler
+}
+
+// DB is a fake database answering each statement with the last registered handler whose fragment
+// the statement contains, so a test can override the handlers registered before it,
+// statements without a handler fail and handlers run one at a time
+type DB struct {
+	mu        sync.Mutex
+	handlers  []handler
+	calls     []string
+	commits   int
+	rollbacks int
+}
+
+// New returns a fake database without any handlers
+func New() *DB {
+	return &DB{}
+}
+
+// Open returns a connection pool to the fake database closed when the test ends
+func (f *DB) Open(t testing.TB) *sql.DB {
+	DB := sql.OpenDB(connector{f})
+	t.Cleanup(func() { DB.Close() })
+
+	return DB
+}
+
+// Query answers queries containing fragment with the rows returned by fn
+func (f *DB) Query(fragment string, columns []string, fn Handler) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.handlers = append(f.handlers, handler{fragment: fragment, columns: columns, fn: fn})
+}
+
+// Rows answers queries containing fragment with the given rows
+func (f *DB) Rows(fragment string, columns []string, rows ...[]driver.Value) {
+	f.Query(fragment, columns, func(args []driver.Value) ([][]driver.Value, error) {
+		return rows, nil
+	})
+}
+
+// Exec answers execs containing fragment with the rows affected returned by fn
+func (f *DB) Exec(fragment string, fn func(args []driver.Value) (int64, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.handlers = append(f.handlers, handler{fragment: fragment, exec: true, fn: func(args []driver.Value) ([][]driver.Value, error) {
+		affected, err := fn(args)
+		return [][]driver.Value{{affected}}, err
+	}})
+}
+
+// Affected answers execs containing fragment with the given rows affected
+func (f *DB) Affected(fragment string, rows int64) {
+	f.Exec(fragment, func(args []driver.Value) (int64, error) {
+		return rows, nil
+	})
+}
+
+// Calls returns how many statements containing fragment were run, an empty fragment counts them all
+func (f *DB) Calls(fragment string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	count := 0
+	for _, query := range f.calls {
+		if strings.Contains(query, fragment) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Commits returns how many transactions were committed
+func (f *DB) Commits() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.commits
+}
+
+// Rollbacks returns how many transactions were rolled back
+func (f *DB) Rollbacks() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.rollbacks
+}
+
+// run answers the statement with its handler
+func (f *DB) run(query string, exec bool, args []driver.Value) ([]string, [][]driver.Value, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, query)
+	for i := len(f.handlers) - 1; i >= 0; i-- {
+		h := f.handlers[i]
+		if h.exec == exec && strings.Contains(query, h.fragment) {
+			rows, err := h.fn(args)
+			return h.columns, rows, err
+		}
+	}
+
+	return nil, nil, fmt.Errorf("dbtest: no handler for %q", query)
+}
+
+type connector struct{ f *DB }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return &conn{c.f}, nil }
+func (c connector) Driver() driver.Driver                        { return fakeDriver{c.f} }
+
+type fakeDriver struct{ f *DB }
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) { return &conn{d.f}, nil }
+
+type conn struct{ f *DB }
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) { return &stmt{f: c.f, query: query}, nil }
+func (c *conn) Close() error                              { return nil }
+func (c *conn) Begin() (driver.Tx, error)                 { return &tx{c.f}, nil }
+
+type tx struct{ f *DB }
+
+func (t *tx) Commit() error {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	t.f.commits++
+	return nil
+}
+
+func (t *tx) Rollback() error {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	t.f.rollbacks++
+	return nil
+}
+
+type stmt struct {
+	f     *DB
+	query string
+}
+
+func (s *stmt) Close() error  { return nil }
+func (s *stmt) NumInput() int { return -1 }
+
+// CheckNamedValue converts arguments like the default converter
+// while passing through the slices given for ANY and unnest as pgx does
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if v, err := driver.DefaultParameterConverter.ConvertValue(nv.Value); err == nil {
+		nv.Value = v
+	}
+	return nil
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	_, rows, err := s.f.run(s.query, true, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(rows[0][0].(int64)), nil
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	columns, values, err := s.f.run(s.query, false, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rows{columns: columns, values: values}, nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *rows) Columns() []string { return r.columns }
+func (r *rows) Close() error      { return nil }
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS thunderdome.poker_invite;
\ No newline at end of file
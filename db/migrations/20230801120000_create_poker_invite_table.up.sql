@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS thunderdome.poker_invite (
+    id VARCHAR(64) NOT NULL PRIMARY KEY,
+    poker_id UUID NOT NULL REFERENCES thunderdome.poker(id) ON DELETE CASCADE,
+    created_by UUID REFERENCES thunderdome.users(id) ON DELETE CASCADE,
+    expire_date TIMESTAMPTZ NOT NULL,
+    created_date TIMESTAMPTZ DEFAULT NOW()
+);
+CREATE INDEX poker_invite_poker_id_idx ON thunderdome.poker_invite(poker_id);
@@ -0,0 +1,27 @@
+CREATE OR REPLACE PROCEDURE thunderdome.poker_story_activate(IN pokerid uuid, IN storyid uuid)
+LANGUAGE plpgsql
+AS $procedure$
+BEGIN
+    -- set current active to false
+    UPDATE thunderdome.poker_story SET updated_date = NOW(), active = false WHERE poker_id = pokerid AND active = true;
+    -- set id active to true
+    UPDATE thunderdome.poker_story SET updated_date = NOW(), active = true, skipped = false, points = '', votestart_time = NOW(), votes = '[]'::jsonb WHERE id = storyid;
+    -- set battle voting_locked and active_story_id
+    UPDATE thunderdome.poker SET last_active = NOW(), updated_date = NOW(), voting_locked = false, active_story_id = storyid WHERE id = pokerid;
+    COMMIT;
+END;
+$procedure$;
+
+CREATE OR REPLACE PROCEDURE thunderdome.poker_story_finalize(IN pokerid uuid, IN storyid uuid, IN storypoints character varying)
+ LANGUAGE plpgsql
+AS $procedure$
+BEGIN
+    -- set points and deactivate
+    UPDATE thunderdome.poker_story SET updated_date = NOW(), active = false, points = storypoints WHERE id = storyid;
+    -- reset battle active_story_id
+    UPDATE thunderdome.poker SET updated_date = NOW(), last_active = NOW(), active_story_id = null WHERE id = pokerid;
+    COMMIT;
+END;
+$procedure$;
+
+ALTER TABLE thunderdome.poker_story DROP COLUMN finalized_date;
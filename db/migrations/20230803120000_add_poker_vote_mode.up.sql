@@ -0,0 +1,15 @@
+ALTER TABLE thunderdome.poker ADD COLUMN vote_mode VARCHAR(32) DEFAULT 'points';
+
+DROP FUNCTION thunderdome.poker_create(leaderid uuid, pokername character varying, pointsallowed jsonb, autovoting boolean, pointaveragerounding character varying, hidevoteridentity boolean, joincode text, leadercode text, teamid uuid, OUT pokerid uuid);
+CREATE FUNCTION thunderdome.poker_create(leaderid uuid, pokername character varying, pointsallowed jsonb, autovoting boolean, pointaveragerounding character varying, hidevoteridentity boolean, joincode text, leadercode text, teamid uuid, votemode character varying, OUT pokerid uuid)
+ RETURNS uuid
+ LANGUAGE plpgsql
+AS $function$
+BEGIN
+    INSERT INTO thunderdome.poker (owner_id, name, point_values_allowed, auto_finish_voting, point_average_rounding, hide_voter_identity, join_code, leader_code, team_id, vote_mode)
+        VALUES (leaderid, pokername, pointsAllowed, autoVoting, pointAverageRounding, hideVoterIdentity, joinCode, leaderCode, teamid, votemode)
+        RETURNING id INTO pokerid;
+    INSERT INTO thunderdome.poker_facilitator (poker_id, user_id) VALUES (pokerid, leaderid);
+    INSERT INTO thunderdome.poker_user (poker_id, user_id) VALUES (pokerid, leaderid);
+END;
+$function$;
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker ADD COLUMN archived BOOLEAN DEFAULT false;
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker DROP COLUMN custom_scale;
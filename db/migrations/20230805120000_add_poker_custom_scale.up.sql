@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker ADD COLUMN custom_scale JSONB DEFAULT '[]'::jsonb;
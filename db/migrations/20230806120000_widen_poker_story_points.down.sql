@@ -0,0 +1,2 @@
+ALTER TYPE thunderdome.UsersVote ALTER ATTRIBUTE vote TYPE VARCHAR(3);
+ALTER TABLE thunderdome.poker_story ALTER COLUMN points TYPE VARCHAR(3) USING LEFT(points, 3);
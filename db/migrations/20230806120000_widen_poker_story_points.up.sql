@@ -0,0 +1,2 @@
+ALTER TABLE thunderdome.poker_story ALTER COLUMN points TYPE VARCHAR(32);
+ALTER TYPE thunderdome.UsersVote ALTER ATTRIBUTE vote TYPE VARCHAR(32);
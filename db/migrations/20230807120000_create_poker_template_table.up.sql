@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS thunderdome.poker_template (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_id UUID NOT NULL REFERENCES thunderdome.users(id) ON DELETE CASCADE,
+    name VARCHAR(256) NOT NULL,
+    point_values_allowed JSONB DEFAULT '[]'::jsonb,
+    auto_finish_voting BOOLEAN DEFAULT true,
+    point_average_rounding VARCHAR(5) DEFAULT 'ceil',
+    hide_voter_identity BOOLEAN DEFAULT false,
+    vote_mode VARCHAR(32) DEFAULT 'points',
+    custom_scale JSONB DEFAULT '[]'::jsonb,
+    created_date TIMESTAMPTZ DEFAULT NOW(),
+    updated_date TIMESTAMPTZ DEFAULT NOW()
+);
+CREATE INDEX poker_template_owner_id_idx ON thunderdome.poker_template(owner_id);
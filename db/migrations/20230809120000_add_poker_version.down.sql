@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS poker_facilitator_version_bump ON thunderdome.poker_facilitator;
+DROP TRIGGER IF EXISTS poker_user_version_bump ON thunderdome.poker_user;
+DROP TRIGGER IF EXISTS poker_story_version_bump ON thunderdome.poker_story;
+DROP TRIGGER IF EXISTS poker_version_bump ON thunderdome.poker;
+DROP FUNCTION IF EXISTS thunderdome.poker_child_version_bump();
+DROP FUNCTION IF EXISTS thunderdome.poker_version_bump();
+ALTER TABLE thunderdome.poker DROP COLUMN version;
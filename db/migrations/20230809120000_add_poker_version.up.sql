@@ -0,0 +1,32 @@
+ALTER TABLE thunderdome.poker ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+CREATE OR REPLACE FUNCTION thunderdome.poker_version_bump() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION thunderdome.poker_child_version_bump() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        UPDATE thunderdome.poker SET version = version WHERE id = OLD.poker_id;
+        RETURN OLD;
+    END IF;
+    UPDATE thunderdome.poker SET version = version WHERE id = NEW.poker_id;
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER poker_version_bump BEFORE UPDATE ON thunderdome.poker
+    FOR EACH ROW EXECUTE FUNCTION thunderdome.poker_version_bump();
+CREATE TRIGGER poker_story_version_bump AFTER INSERT OR UPDATE OR DELETE ON thunderdome.poker_story
+    FOR EACH ROW EXECUTE FUNCTION thunderdome.poker_child_version_bump();
+CREATE TRIGGER poker_user_version_bump AFTER INSERT OR UPDATE OR DELETE ON thunderdome.poker_user
+    FOR EACH ROW EXECUTE FUNCTION thunderdome.poker_child_version_bump();
+CREATE TRIGGER poker_facilitator_version_bump AFTER INSERT OR UPDATE OR DELETE ON thunderdome.poker_facilitator
+    FOR EACH ROW EXECUTE FUNCTION thunderdome.poker_child_version_bump();
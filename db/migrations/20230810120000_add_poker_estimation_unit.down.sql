@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker DROP COLUMN estimation_unit;
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker ADD COLUMN estimation_unit VARCHAR(32) NOT NULL DEFAULT 'points';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS thunderdome.poker_story_reference_id_idx;
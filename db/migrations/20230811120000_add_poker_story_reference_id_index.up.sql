@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS poker_story_reference_id_idx ON thunderdome.poker_story (reference_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS thunderdome.poker_story_poker_id_position_idx;
+DROP TRIGGER IF EXISTS poker_story_position_set ON thunderdome.poker_story;
+DROP FUNCTION IF EXISTS thunderdome.poker_story_position_set();
+ALTER TABLE thunderdome.poker_story DROP COLUMN position;
@@ -0,0 +1,26 @@
+ALTER TABLE thunderdome.poker_story ADD COLUMN position INTEGER;
+
+UPDATE thunderdome.poker_story ps SET position = o.rn
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY poker_id ORDER BY created_date) AS rn
+    FROM thunderdome.poker_story
+) o
+WHERE ps.id = o.id;
+
+CREATE OR REPLACE FUNCTION thunderdome.poker_story_position_set() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    IF NEW.position IS NULL THEN
+        SELECT COALESCE(MAX(position), 0) + 1 INTO NEW.position
+        FROM thunderdome.poker_story WHERE poker_id = NEW.poker_id;
+    END IF;
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER poker_story_position_set BEFORE INSERT ON thunderdome.poker_story
+    FOR EACH ROW EXECUTE FUNCTION thunderdome.poker_story_position_set();
+
+ALTER TABLE thunderdome.poker_story ALTER COLUMN position SET NOT NULL;
+CREATE INDEX IF NOT EXISTS poker_story_poker_id_position_idx ON thunderdome.poker_story (poker_id, position);
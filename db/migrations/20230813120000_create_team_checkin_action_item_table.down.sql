@@ -0,0 +1 @@
+DROP TABLE IF EXISTS thunderdome.team_checkin_action_item;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS thunderdome.team_checkin_action_item (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    team_id UUID NOT NULL REFERENCES thunderdome.team(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES thunderdome.users(id) ON DELETE CASCADE,
+    assignee_id UUID REFERENCES thunderdome.users(id) ON DELETE SET NULL,
+    content TEXT NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT false,
+    created_date TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_date TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX team_checkin_action_item_team_id_idx ON thunderdome.team_checkin_action_item(team_id);
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker DROP COLUMN min_voters_to_finalize;
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker ADD COLUMN min_voters_to_finalize INTEGER NOT NULL DEFAULT 0;
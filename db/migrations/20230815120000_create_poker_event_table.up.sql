@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS thunderdome.poker_event (
+    id BIGSERIAL PRIMARY KEY,
+    poker_id UUID NOT NULL REFERENCES thunderdome.poker(id) ON DELETE CASCADE,
+    story_id UUID,
+    user_id UUID REFERENCES thunderdome.users(id) ON DELETE SET NULL,
+    event_type VARCHAR(64) NOT NULL,
+    value TEXT NOT NULL DEFAULT '',
+    created_date TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX poker_event_poker_id_idx ON thunderdome.poker_event(poker_id, id);
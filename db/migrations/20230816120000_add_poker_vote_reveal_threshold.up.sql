@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker ADD COLUMN vote_reveal_threshold INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS thunderdome.poker_short_code_idx;
+ALTER TABLE thunderdome.poker DROP COLUMN short_code;
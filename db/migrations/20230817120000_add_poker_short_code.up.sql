@@ -0,0 +1,2 @@
+ALTER TABLE thunderdome.poker ADD COLUMN short_code VARCHAR(6);
+CREATE UNIQUE INDEX poker_short_code_idx ON thunderdome.poker (short_code);
@@ -0,0 +1 @@
+ALTER TYPE thunderdome.UsersVote DROP ATTRIBUTE complexity;
@@ -0,0 +1 @@
+ALTER TYPE thunderdome.UsersVote ADD ATTRIBUTE complexity VARCHAR(32);
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker DROP COLUMN tie_break_strategy;
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker ADD COLUMN tie_break_strategy VARCHAR(32) NOT NULL DEFAULT 'round-up';
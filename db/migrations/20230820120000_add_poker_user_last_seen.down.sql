@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker_user DROP COLUMN last_seen;
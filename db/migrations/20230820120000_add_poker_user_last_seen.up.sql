@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker_user ADD COLUMN last_seen TIMESTAMP;
@@ -0,0 +1,16 @@
+CREATE OR REPLACE PROCEDURE thunderdome.poker_story_activate(IN pokerid uuid, IN storyid uuid)
+LANGUAGE plpgsql
+AS $procedure$
+BEGIN
+    -- set current active to false
+    UPDATE thunderdome.poker_story SET updated_date = NOW(), active = false WHERE poker_id = pokerid AND active = true;
+    -- set id active to true
+    UPDATE thunderdome.poker_story SET updated_date = NOW(), active = true, skipped = false, points = '', points_numeric = null,
+        votestart_time = NOW(), finalized_date = null, votes = '[]'::jsonb WHERE id = storyid;
+    -- set battle voting_locked and active_story_id
+    UPDATE thunderdome.poker SET last_active = NOW(), updated_date = NOW(), voting_locked = false, active_story_id = storyid WHERE id = pokerid;
+    COMMIT;
+END;
+$procedure$;
+
+ALTER TABLE thunderdome.poker_story DROP COLUMN votes_revealed;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS thunderdome.users_guest_email_unique_idx;
+ALTER TABLE thunderdome.users DROP COLUMN guest_email;
@@ -0,0 +1,2 @@
+ALTER TABLE thunderdome.users ADD COLUMN guest_email VARCHAR(320);
+CREATE UNIQUE INDEX users_guest_email_unique_idx ON thunderdome.users (LOWER(guest_email));
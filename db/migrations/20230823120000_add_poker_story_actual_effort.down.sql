@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker_story DROP COLUMN actual_effort;
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker_story ADD COLUMN actual_effort VARCHAR(32);
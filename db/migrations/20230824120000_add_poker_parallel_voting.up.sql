@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker ADD COLUMN parallel_voting BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS thunderdome.poker_idempotency_key;
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS thunderdome.poker_idempotency_key (
+    scope VARCHAR(16) NOT NULL,
+    scope_id UUID NOT NULL,
+    idempotency_key VARCHAR(128) NOT NULL,
+    poker_id UUID NOT NULL REFERENCES thunderdome.poker(id) ON DELETE CASCADE,
+    story_id UUID REFERENCES thunderdome.poker_story(id) ON DELETE CASCADE,
+    created_date TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (scope, scope_id, idempotency_key)
+);
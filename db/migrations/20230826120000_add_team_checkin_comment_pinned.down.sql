@@ -0,0 +1 @@
+ALTER TABLE thunderdome.team_checkin_comment DROP COLUMN pinned;
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.team_checkin_comment ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker_story ADD COLUMN locked BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS thunderdome.poker_story_active_group_idx;
+ALTER TABLE thunderdome.poker_story DROP COLUMN group_id;
@@ -0,0 +1,2 @@
+ALTER TABLE thunderdome.poker_story ADD COLUMN group_id VARCHAR(64);
+CREATE UNIQUE INDEX poker_story_active_group_idx ON thunderdome.poker_story (poker_id, group_id) WHERE active = true AND group_id IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker DROP COLUMN require_named_users;
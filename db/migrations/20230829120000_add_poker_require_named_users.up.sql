@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker ADD COLUMN require_named_users BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker ADD COLUMN paused BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker_user DROP COLUMN reveal_identity;
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker_user ADD COLUMN reveal_identity BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1 @@
+ALTER TABLE thunderdome.poker ADD COLUMN vote_aliases JSONB NOT NULL DEFAULT '{}'::jsonb;
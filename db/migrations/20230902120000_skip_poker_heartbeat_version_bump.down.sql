@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS poker_user_update_version_bump ON thunderdome.poker_user;
+DROP TRIGGER IF EXISTS poker_user_version_bump ON thunderdome.poker_user;
+CREATE TRIGGER poker_user_version_bump AFTER INSERT OR UPDATE OR DELETE ON thunderdome.poker_user
+    FOR EACH ROW EXECUTE FUNCTION thunderdome.poker_child_version_bump();
@@ -0,0 +1,7 @@
+-- heartbeats only touch last_seen, which isn't part of the game state, so they shouldn't lock the game row or bump its version
+DROP TRIGGER IF EXISTS poker_user_version_bump ON thunderdome.poker_user;
+CREATE TRIGGER poker_user_version_bump AFTER INSERT OR DELETE ON thunderdome.poker_user
+    FOR EACH ROW EXECUTE FUNCTION thunderdome.poker_child_version_bump();
+CREATE TRIGGER poker_user_update_version_bump AFTER UPDATE ON thunderdome.poker_user
+    FOR EACH ROW WHEN ((to_jsonb(OLD) - 'last_seen') IS DISTINCT FROM (to_jsonb(NEW) - 'last_seen'))
+    EXECUTE FUNCTION thunderdome.poker_child_version_bump();
@@ -0,0 +1,3 @@
+-- archived_date records when the game was archived so its duration can be measured to an explicit end
+ALTER TABLE thunderdome.poker ADD COLUMN archived_date TIMESTAMPTZ;
+UPDATE thunderdome.poker SET archived_date = updated_date WHERE archived = true;
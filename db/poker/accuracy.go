@@ -0,0 +1,129 @@
+package poker
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// RecordActualEffort records the effort a story actually took once delivered, in the same unit as its estimate,
+// an empty actual clears it, archived games are allowed as actuals are usually known after the game is done
+func (d *Service) RecordActualEffort(PokerID string, StoryID string, Actual string) error {
+	if err := db.ValidateUUID(PokerID, StoryID); err != nil {
+		return err
+	}
+	if err := validateActualEffort(Actual); err != nil {
+		return err
+	}
+
+	res, err := d.DB.Exec(
+		`UPDATE thunderdome.poker_story SET actual_effort = NULLIF($3, ''), updated_date = NOW()
+		WHERE poker_id = $1 AND id = $2;`,
+		PokerID, StoryID, Actual,
+	)
+	if err != nil {
+		d.Logger.Error("update poker story actual_effort error", zap.Error(err))
+		return fmt.Errorf("unable to record actual effort: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return thunderdome.ErrStoryNotFound
+	}
+
+	return nil
+}
+
+// validateActualEffort checks the actual effort is empty or a non-negative number that fits the actual_effort column
+func validateActualEffort(Actual string) error {
+	if Actual == "" {
+		return nil
+	}
+	if err := validateStoryPoints(Actual); err != nil {
+		return err
+	}
+	if value, ok := pointValueToFloat(Actual); !ok || value < 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("%w: actual effort must be a non-negative number", thunderdome.ErrValidation)
+	}
+
+	return nil
+}
+
+// GetEstimationAccuracy compares the games finalized estimates to the actual effort recorded for its stories
+func (d *Service) GetEstimationAccuracy(PokerID string) (*thunderdome.AccuracyReport, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
+	rows, err := d.DB.Query(
+		`SELECT id, name, points, points_numeric, skipped, COALESCE(actual_effort, '')
+		FROM thunderdome.poker_story WHERE poker_id = $1 ORDER BY position;`,
+		PokerID,
+	)
+	if err != nil {
+		d.Logger.Error("get poker estimation accuracy query error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get estimation accuracy: %w", err)
+	}
+	defer rows.Close()
+
+	stories := make([]*thunderdome.Story, 0)
+	actuals := make(map[string]string)
+	for rows.Next() {
+		var s thunderdome.Story
+		var Actual string
+		if err := rows.Scan(&s.Id, &s.Name, &s.Points, &s.PointsNumeric, &s.Skipped, &Actual); err != nil {
+			d.Logger.Error("get poker estimation accuracy scan error", zap.Error(err))
+			continue
+		}
+		stories = append(stories, &s)
+		actuals[s.Id] = Actual
+	}
+
+	return calculateAccuracy(PokerID, stories, actuals), nil
+}
+
+// calculateAccuracy compares the numeric estimates of finalized (non-skipped) stories to their actual effort,
+// stories missing either value are left out, accuracy is one minus the total absolute variance relative to the total actual effort
+func calculateAccuracy(PokerID string, Stories []*thunderdome.Story, Actuals map[string]string) *thunderdome.AccuracyReport {
+	report := &thunderdome.AccuracyReport{
+		PokerID: PokerID,
+		Stories: make([]*thunderdome.StoryAccuracy, 0),
+	}
+
+	var totalVariance float64
+	for _, s := range Stories {
+		if s.Points == "" || s.Skipped {
+			continue
+		}
+		estimate, ok := storyPointsToFloat(s)
+		if !ok {
+			continue
+		}
+		actual, ok := pointValueToFloat(Actuals[s.Id])
+		if !ok {
+			continue
+		}
+
+		report.Stories = append(report.Stories, &thunderdome.StoryAccuracy{
+			StoryID:  s.Id,
+			Name:     s.Name,
+			Estimate: s.Points,
+			Actual:   Actuals[s.Id],
+			Variance: actual - estimate,
+		})
+		report.TotalEstimated += estimate
+		report.TotalActual += actual
+		totalVariance += math.Abs(actual - estimate)
+	}
+	report.StoriesCompared = len(report.Stories)
+
+	if report.TotalActual > 0 {
+		report.Accuracy = math.Max(0, 1-totalVariance/report.TotalActual)
+	} else if report.StoriesCompared > 0 && totalVariance == 0 {
+		report.Accuracy = 1
+	}
+
+	return report
+}
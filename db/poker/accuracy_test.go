@@ -0,0 +1,83 @@
+package poker
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestCalculateAccuracy calls calculateAccuracy with stories that have recorded actuals and some that don't
+// and makes sure only finalized stories with both an estimate and an actual produce the accuracy figure
+func TestCalculateAccuracy(t *testing.T) {
+	stories := []*thunderdome.Story{
+		{Id: "exact", Points: "3"},
+		{Id: "under", Points: "5"},
+		{Id: "over", Points: "8"},
+		{Id: "no-actual", Points: "2"},
+		{Id: "unestimated"},
+		{Id: "skipped", Points: "3", Skipped: true},
+		{Id: "unsure", Points: "?"},
+	}
+	actuals := map[string]string{
+		"exact":       "3",
+		"under":       "8",
+		"over":        "5",
+		"unestimated": "13",
+		"skipped":     "1",
+		"unsure":      "2",
+	}
+
+	report := calculateAccuracy("game", stories, actuals)
+
+	if report.StoriesCompared != 3 {
+		t.Fatalf(`expected 3 stories compared got %d`, report.StoriesCompared)
+	}
+	if report.TotalEstimated != 16 || report.TotalActual != 16 {
+		t.Fatalf(`expected 16 estimated and 16 actual got %v and %v`, report.TotalEstimated, report.TotalActual)
+	}
+	// 6 points of absolute variance across 16 actual points
+	if math.Abs(report.Accuracy-0.625) > 1e-9 {
+		t.Fatalf(`expected accuracy 0.625 got %v`, report.Accuracy)
+	}
+	if report.Stories[1].StoryID != "under" || report.Stories[1].Variance != 3 {
+		t.Fatalf(`expected under estimated story to have a variance of 3 got %+v`, report.Stories[1])
+	}
+	if report.Stories[2].Variance != -3 {
+		t.Fatalf(`expected over estimated story to have a variance of -3 got %+v`, report.Stories[2])
+	}
+}
+
+// TestCalculateAccuracyEdges calls calculateAccuracy without any comparable stories and with zero effort
+// and makes sure the accuracy doesn't divide by zero or go negative
+func TestCalculateAccuracyEdges(t *testing.T) {
+	if report := calculateAccuracy("game", nil, nil); report.StoriesCompared != 0 || report.Accuracy != 0 {
+		t.Fatalf(`expected no accuracy without compared stories got %+v`, report)
+	}
+
+	zero := []*thunderdome.Story{{Id: "zero", Points: "0"}}
+	if report := calculateAccuracy("game", zero, map[string]string{"zero": "0"}); report.Accuracy != 1 {
+		t.Fatalf(`expected matching zero effort to be accurate got %v`, report.Accuracy)
+	}
+
+	wild := []*thunderdome.Story{{Id: "wild", Points: "40"}}
+	if report := calculateAccuracy("game", wild, map[string]string{"wild": "1"}); report.Accuracy != 0 {
+		t.Fatalf(`expected accuracy to floor at 0 got %v`, report.Accuracy)
+	}
+}
+
+// TestValidateActualEffort calls validateActualEffort with numeric, empty, and invalid actuals
+// and makes sure only non-numeric or negative actuals fail validation
+func TestValidateActualEffort(t *testing.T) {
+	for _, actual := range []string{"", "3", "0.5", "½"} {
+		if err := validateActualEffort(actual); err != nil {
+			t.Fatalf(`expected actual %q to be valid got %v`, actual, err)
+		}
+	}
+	for _, actual := range []string{"?", "-1", "NaN", "a lot"} {
+		if err := validateActualEffort(actual); !errors.Is(err, thunderdome.ErrValidation) {
+			t.Fatalf(`expected actual %q to fail validation got %v`, actual, err)
+		}
+	}
+}
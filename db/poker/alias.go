@@ -0,0 +1,83 @@
+package poker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// voteAliasesMax is the most vote aliases a game can have
+const voteAliasesMax = 100
+
+// validateVoteAliases checks each alias and the vote value it stands for are set and short enough to be votes,
+// the aliased values are checked against the games scale when voting as the scale can change
+func validateVoteAliases(Aliases map[string]string) error {
+	if len(Aliases) > voteAliasesMax {
+		return fmt.Errorf("%w: at most %d vote aliases are allowed", thunderdome.ErrValidation, voteAliasesMax)
+	}
+	for alias, value := range Aliases {
+		if normalizeAlias(alias) == "" || strings.TrimSpace(value) == "" {
+			return fmt.Errorf("%w: vote aliases and their values can't be empty", thunderdome.ErrValidation)
+		}
+		if err := validateStoryPoints(alias); err != nil {
+			return err
+		}
+		if err := validateStoryPoints(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// voteScaleValues are the values that can be voted for the vote mode, custom scale and allowed point values
+func voteScaleValues(VoteMode string, CustomScale []thunderdome.ScaleValue, PointValuesAllowed []string) []string {
+	if VoteMode == thunderdome.PokerVoteModeFistOfFive {
+		return thunderdome.FistOfFiveValues
+	}
+	if len(CustomScale) > 0 {
+		return scaleLabels(CustomScale)
+	}
+
+	return PointValuesAllowed
+}
+
+// resolveVoteValue normalizes the vote to its canonical scale value, matching the scale exactly first,
+// then the games aliases and then the scale ignoring case and extra spaces, once a game has aliases
+// a vote that doesn't resolve to a scale value is rejected, without aliases it's left for the usual validation
+func resolveVoteValue(Aliases map[string]string, Scale []string, VoteValue string) (string, error) {
+	if VoteValue == "" {
+		return VoteValue, nil
+	}
+	for _, value := range Scale {
+		if value == VoteValue {
+			return value, nil
+		}
+	}
+
+	normalized := normalizeAlias(VoteValue)
+	for alias, value := range Aliases {
+		if normalizeAlias(alias) == normalized {
+			VoteValue = strings.TrimSpace(value)
+			normalized = normalizeAlias(VoteValue)
+			break
+		}
+	}
+	for _, value := range Scale {
+		if normalizeAlias(value) == normalized {
+			return value, nil
+		}
+	}
+
+	if len(Aliases) > 0 {
+		return "", fmt.Errorf("%w: unknown vote value %q", thunderdome.ErrValidation, VoteValue)
+	}
+
+	return VoteValue, nil
+}
+
+// normalizeAlias lowercases the value collapsing its whitespace so "Extra  large" matches "extra large"
+func normalizeAlias(Value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(Value), " "))
+}
@@ -0,0 +1,64 @@
+package poker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestResolveVoteValue calls resolveVoteValue with scale values, aliases and variants of both
+// and makes sure each resolves to its canonical scale value
+func TestResolveVoteValue(t *testing.T) {
+	scale := []string{"XS", "S", "M", "L", "XL", "?"}
+	aliases := map[string]string{"Extra Large": "XL", "tiny": "XS", "big": "xl"}
+
+	for vote, expected := range map[string]string{
+		"XL":            "XL",
+		"xl":            "XL",
+		" m ":           "M",
+		"Extra Large":   "XL",
+		"extra   LARGE": "XL",
+		"Tiny":          "XS",
+		"BIG":           "XL",
+		"?":             "?",
+		"":              "",
+	} {
+		got, err := resolveVoteValue(aliases, scale, vote)
+		if err != nil || got != expected {
+			t.Fatalf(`expected %q to resolve to %q got %q (%v)`, vote, expected, got, err)
+		}
+	}
+}
+
+// TestResolveVoteValueUnknown calls resolveVoteValue with values that aren't on the scale
+// and makes sure they're rejected once the game has aliases and left for the usual validation without
+func TestResolveVoteValueUnknown(t *testing.T) {
+	scale := []string{"1", "2", "3", "5", "8"}
+	aliases := map[string]string{"one": "1", "broken": "13"}
+
+	for _, vote := range []string{"4", "two", "broken"} {
+		if _, err := resolveVoteValue(aliases, scale, vote); !errors.Is(err, thunderdome.ErrValidation) {
+			t.Fatalf(`expected unknown vote %q to be invalid got %v`, vote, err)
+		}
+	}
+
+	if got, err := resolveVoteValue(nil, scale, "4"); err != nil || got != "4" {
+		t.Fatalf(`expected vote without aliases to be left as is got %q (%v)`, got, err)
+	}
+}
+
+// TestValidateVoteAliases calls validateVoteAliases with valid and invalid aliases
+func TestValidateVoteAliases(t *testing.T) {
+	if err := validateVoteAliases(map[string]string{"Extra Large": "XL"}); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	for _, aliases := range []map[string]string{
+		{" ": "XL"},
+		{"big": ""},
+	} {
+		if err := validateVoteAliases(aliases); !errors.Is(err, thunderdome.ErrValidation) {
+			t.Fatalf(`expected aliases %v to be invalid got %v`, aliases, err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// ArchiveGame archives the game making it read only
+func (d *Service) ArchiveGame(PokerID string) error {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return err
+	}
+
+	if _, err := d.DB.Exec(
+		`WITH deactivated AS (
+			UPDATE thunderdome.poker_story SET active = false, updated_date = NOW() WHERE poker_id = $1 AND active = true
+		)
+		UPDATE thunderdome.poker SET archived = true, archived_date = NOW(), active_story_id = null, voting_locked = true, updated_date = NOW()
+		WHERE id = $1;`,
+		PokerID,
+	); err != nil {
+		d.Logger.Error("archive poker error", zap.Error(err))
+		return fmt.Errorf("unable to archive poker: %w", err)
+	}
+
+	return nil
+}
+
+// ensureNotArchived returns thunderdome.ErrGameArchived when the game has been archived
+func (d *Service) ensureNotArchived(PokerID string) error {
+	var Archived bool
+
+	if err := d.DB.QueryRow(
+		`SELECT COALESCE(archived, false) FROM thunderdome.poker WHERE id = $1;`,
+		PokerID,
+	).Scan(&Archived); err != nil {
+		d.Logger.Error("get poker archived error", zap.Error(err))
+		return errors.New("not found")
+	}
+
+	if Archived {
+		return thunderdome.ErrGameArchived
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestArchivedGameRejectsWrites attempts each mutation on an archived game
+// and makes sure they're all rejected with ErrGameArchived without writing, while reads keep working
+func TestArchivedGameRejectsWrites(t *testing.T) {
+	svc, f := newTestService(t)
+	f.Rows("COALESCE(archived, false)", []string{"archived"}, []driver.Value{true})
+	f.Rows("COALESCE(archived, false), paused", []string{"archived", "paused"}, []driver.Value{true, false})
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	FacilitatorID := "1e2d3c4b-5a69-4788-9a6b-5c4d3e2f1a0b"
+	UserID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	StoryID := "9c8b7a6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+
+	mutations := map[string]func() error{
+		"UpdateGame": func() error {
+			return svc.UpdateGame(PokerID, "Revised", []string{"1", "2", "3"}, false, "ceil", false, "", "", "", thunderdome.PokerVoteModePoints)
+		},
+		"CreateStory": func() error {
+			_, err := svc.CreateStory(PokerID, "Story", "story", "", "", "", "", 99, "")
+			return err
+		},
+		"UpdateStory": func() error {
+			_, err := svc.UpdateStory(PokerID, StoryID, "Story", "story", "", "", "", "", 99)
+			return err
+		},
+		"DeleteStory": func() error {
+			_, err := svc.DeleteStory(PokerID, StoryID)
+			return err
+		},
+		"ActivateStoryVoting": func() error {
+			_, err := svc.ActivateStoryVoting(PokerID, StoryID)
+			return err
+		},
+		"SetVote": func() error {
+			_, _, err := svc.SetVote(PokerID, UserID, StoryID, "3", "")
+			return err
+		},
+		"RetractVote": func() error {
+			_, err := svc.RetractVote(PokerID, UserID, StoryID)
+			return err
+		},
+		"EndStoryVoting": func() error {
+			_, err := svc.EndStoryVoting(PokerID, StoryID, false)
+			return err
+		},
+		"SkipStory": func() error {
+			_, err := svc.SkipStory(PokerID, StoryID)
+			return err
+		},
+		"FinalizeStory": func() error {
+			_, err := svc.FinalizeStory(PokerID, StoryID, "3", false)
+			return err
+		},
+		"PauseGame": func() error {
+			return svc.PauseGame(PokerID, FacilitatorID)
+		},
+	}
+
+	for name, mutate := range mutations {
+		if err := mutate(); !errors.Is(err, thunderdome.ErrGameArchived) {
+			t.Fatalf(`expected %s on an archived game to return ErrGameArchived got %v`, name, err)
+		}
+	}
+	for _, write := range []string{"INSERT", "UPDATE", "DELETE"} {
+		if calls := f.Calls(write); calls != 0 {
+			t.Fatalf(`expected no %s statements on an archived game got %d`, write, calls)
+		}
+	}
+
+	f.Rows("FROM thunderdome.poker_story", storyColumns, storyRow(StoryID, false, "[]"))
+	if stories := svc.GetStories(PokerID, ""); len(stories) != 1 {
+		t.Fatalf(`expected the archived games stories to still be readable got %d`, len(stories))
+	}
+}
@@ -0,0 +1,93 @@
+package poker
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// GetStoryVoteBreakdown gets the users who voted on the story grouped by the value they voted,
+// once voting on the story has ended or its votes were revealed, in games that hide voter identity
+// each voter is an anonymous user unless they opted into revealing their identity,
+// no votes are grouped until the games vote reveal threshold is reached
+func (d *Service) GetStoryVoteBreakdown(PokerID string, StoryID string) (map[string][]*thunderdome.PokerUser, error) {
+	if err := db.ValidateUUID(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+
+	var HideVoterIdentity bool
+	var Active bool
+	var VotesRevealed bool
+	var RevealThreshold int
+	var v string
+	if err := d.DB.QueryRow(
+		`SELECT p.hide_voter_identity, ps.active, ps.votes_revealed, ps.votes, p.vote_reveal_threshold
+		FROM thunderdome.poker_story ps
+		JOIN thunderdome.poker p ON p.id = ps.poker_id
+		WHERE ps.poker_id = $1 AND ps.id = $2;`,
+		PokerID, StoryID,
+	).Scan(&HideVoterIdentity, &Active, &VotesRevealed, &v, &RevealThreshold); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, thunderdome.ErrStoryNotFound
+		}
+		d.Logger.Error("get poker story vote breakdown error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get story vote breakdown: %w", err)
+	}
+	if Active && !VotesRevealed {
+		return nil, thunderdome.ErrVotesHidden
+	}
+
+	Votes, err := decodeStoryVotes(v)
+	if err != nil {
+		d.Logger.Error("get poker story vote breakdown corrupt votes error", zap.String("story_id", StoryID), zap.Error(err))
+		return nil, err
+	}
+	Story := &thunderdome.Story{Active: Active, VotesRevealed: VotesRevealed, Votes: Votes}
+	maskStoryVotes(Story, "", RevealThreshold)
+
+	Users := d.getUsers(d.DB, PokerID)
+
+	return groupVotesByValue(Story.Votes, Users, HideVoterIdentity), nil
+}
+
+// groupVotesByValue groups the voters by the value they voted ordered by name, voters no longer in the users
+// are listed by ID only and when HideVoterIdentity is set every voter who hasn't opted into revealing their
+// identity is an empty anonymous user
+func groupVotesByValue(Votes []*thunderdome.Vote, Users []*thunderdome.PokerUser, HideVoterIdentity bool) map[string][]*thunderdome.PokerUser {
+	users := make(map[string]*thunderdome.PokerUser, len(Users))
+	for _, u := range Users {
+		users[u.Id] = u
+	}
+
+	breakdown := make(map[string][]*thunderdome.PokerUser)
+	for _, vote := range Votes {
+		if vote.VoteValue == "" {
+			continue
+		}
+		voter := &thunderdome.PokerUser{}
+		u, ok := users[vote.UserId]
+		if ok && (!HideVoterIdentity || u.RevealIdentity) {
+			voter = u
+		} else if !ok && !HideVoterIdentity {
+			voter = &thunderdome.PokerUser{Id: vote.UserId}
+		}
+		breakdown[vote.VoteValue] = append(breakdown[vote.VoteValue], voter)
+	}
+
+	for _, voters := range breakdown {
+		sort.SliceStable(voters, func(i, j int) bool {
+			if voters[i].Name == voters[j].Name {
+				return voters[i].Id < voters[j].Id
+			}
+			return voters[i].Name < voters[j].Name
+		})
+	}
+
+	return breakdown
+}
@@ -0,0 +1,129 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// breakdownStory is the story seeded into the fake database by newBreakdownService
+type breakdownStory struct {
+	hidden    bool
+	active    bool
+	revealed  bool
+	votes     string
+	threshold int64
+}
+
+// newBreakdownService returns a service whose fake database stands in for the story and the games users,
+// Ada and Linus have opted into revealing their identity while Grace hasn't
+func newBreakdownService(t *testing.T) (*Service, *breakdownStory) {
+	svc, f := newTestService(t)
+	story := &breakdownStory{}
+	f.Query("p.hide_voter_identity, ps.active, ps.votes_revealed", []string{"hide_voter_identity", "active", "votes_revealed", "votes", "vote_reveal_threshold"}, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{story.hidden, story.active, story.revealed, story.votes, story.threshold}}, nil
+	})
+	f.Rows("FROM thunderdome.poker_user pu", []string{"id", "name", "type", "avatar", "active", "spectator", "email", "reveal_identity"},
+		[]driver.Value{"u1", "Ada", "REGISTERED", "", true, false, "", true},
+		[]driver.Value{"u2", "Grace", "REGISTERED", "", true, false, "", false},
+		[]driver.Value{"u3", "Linus", "GUEST", "", true, false, "", true},
+	)
+
+	return svc, story
+}
+
+// TestGetStoryVoteBreakdown calls GetStoryVoteBreakdown on a story once voting ended
+// and makes sure voters are grouped by the value they voted ordered by name
+func TestGetStoryVoteBreakdown(t *testing.T) {
+	svc, breakdown := newBreakdownService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	StoryID := "9c8b7a6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+
+	breakdown.hidden, breakdown.active, breakdown.revealed = false, false, false
+	breakdown.votes = `[{"warriorId":"u3","vote":"5"},{"warriorId":"u1","vote":"5"},{"warriorId":"u2","vote":"8"},{"warriorId":"u4","vote":"8"}]`
+	groups, err := svc.GetStoryVoteBreakdown(PokerID, StoryID)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf(`expected 2 vote values got %v`, groups)
+	}
+	if fives := groups["5"]; len(fives) != 2 || fives[0].Name != "Ada" || fives[1].Name != "Linus" {
+		t.Fatalf(`expected Ada and Linus to have voted 5 got %v`, fives)
+	}
+	if eights := groups["8"]; len(eights) != 2 || eights[0].Id != "u4" || eights[1].Name != "Grace" {
+		t.Fatalf(`expected the departed u4 and Grace to have voted 8 got %v`, eights)
+	}
+}
+
+// TestGetStoryVoteBreakdownHidden calls GetStoryVoteBreakdown while voting is open
+// and in a game hiding voter identity, making sure votes stay hidden and voters anonymous
+func TestGetStoryVoteBreakdownHidden(t *testing.T) {
+	svc, breakdown := newBreakdownService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	StoryID := "9c8b7a6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+	breakdown.votes = `[{"warriorId":"u2","vote":"3"},{"warriorId":"u4","vote":"3"}]`
+
+	breakdown.hidden, breakdown.active, breakdown.revealed = false, true, false
+	if _, err := svc.GetStoryVoteBreakdown(PokerID, StoryID); !errors.Is(err, thunderdome.ErrVotesHidden) {
+		t.Fatalf(`expected ErrVotesHidden while voting is open got %v`, err)
+	}
+
+	breakdown.hidden, breakdown.active, breakdown.revealed = true, true, true
+	groups, err := svc.GetStoryVoteBreakdown(PokerID, StoryID)
+	if err != nil {
+		t.Fatalf(`unexpected error once votes were revealed %v`, err)
+	}
+	if threes := groups["3"]; len(threes) != 2 || threes[0].Id != "" || threes[0].Name != "" || threes[1].Id != "" {
+		t.Fatalf(`expected 2 anonymous voters for 3 got %v`, threes)
+	}
+}
+
+// TestGetStoryVoteBreakdownRevealIdentity calls GetStoryVoteBreakdown in a game hiding voter identity
+// and makes sure only the voters who opted into revealing their identity are named
+func TestGetStoryVoteBreakdownRevealIdentity(t *testing.T) {
+	svc, breakdown := newBreakdownService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	StoryID := "9c8b7a6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+
+	breakdown.hidden, breakdown.active, breakdown.revealed = true, false, false
+	breakdown.votes = `[{"warriorId":"u1","vote":"5"},{"warriorId":"u2","vote":"5"},{"warriorId":"u3","vote":"8"},{"warriorId":"u4","vote":"8"}]`
+	groups, err := svc.GetStoryVoteBreakdown(PokerID, StoryID)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if fives := groups["5"]; len(fives) != 2 || fives[0].Name != "" || fives[0].Id != "" || fives[1].Name != "Ada" {
+		t.Fatalf(`expected an anonymous voter and Ada to have voted 5 got %v`, fives)
+	}
+	if eights := groups["8"]; len(eights) != 2 || eights[0].Id != "" || eights[1].Name != "Linus" {
+		t.Fatalf(`expected an anonymous departed voter and Linus to have voted 8 got %v`, eights)
+	}
+}
+
+// TestGetStoryVoteBreakdownRevealThreshold calls GetStoryVoteBreakdown on a story with fewer voters than the games
+// vote reveal threshold and makes sure no voters are grouped until the threshold is reached
+func TestGetStoryVoteBreakdownRevealThreshold(t *testing.T) {
+	svc, breakdown := newBreakdownService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	StoryID := "9c8b7a6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+
+	breakdown.hidden, breakdown.active, breakdown.revealed, breakdown.threshold = false, false, false, 3
+	breakdown.votes = `[{"warriorId":"u1","vote":"5"},{"warriorId":"u2","vote":"8"}]`
+	groups, err := svc.GetStoryVoteBreakdown(PokerID, StoryID)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if len(groups) != 0 {
+		t.Fatalf(`expected no voters grouped below the reveal threshold got %v`, groups)
+	}
+
+	breakdown.votes = `[{"warriorId":"u1","vote":"5"},{"warriorId":"u2","vote":"8"},{"warriorId":"u3","vote":"8"}]`
+	if groups, err = svc.GetStoryVoteBreakdown(PokerID, StoryID); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if len(groups["5"]) != 1 || len(groups["8"]) != 2 {
+		t.Fatalf(`expected voters grouped once the reveal threshold was reached got %v`, groups)
+	}
+}
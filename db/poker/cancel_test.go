@@ -0,0 +1,95 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// cancelDB is a fake database standing in for a game with one story,
+// tracking the storys activation and votes and the games active story and voting lock
+type cancelDB struct {
+	storyID       string
+	active        bool
+	votes         string
+	activeStoryID string
+	votingLocked  bool
+	voteEnded     bool
+}
+
+func newCancelService(t *testing.T) (*Service, *cancelDB) {
+	svc, f := newTestService(t)
+	d := &cancelDB{}
+	f.Exec("UPDATE thunderdome.poker_story SET", func(args []driver.Value) (int64, error) {
+		if args[1].(string) != d.storyID || !d.active {
+			return 0, nil
+		}
+		d.active, d.votes = false, "[]"
+		return 1, nil
+	})
+	f.Exec("voteend_time", func(args []driver.Value) (int64, error) {
+		d.active, d.votes, d.voteEnded = false, "[]", true
+		return 1, nil
+	})
+	f.Exec("UPDATE thunderdome.poker SET", func(args []driver.Value) (int64, error) {
+		if d.activeStoryID == args[1].(string) {
+			d.activeStoryID, d.votingLocked = "", true
+		}
+		return 1, nil
+	})
+	f.Query("SELECT EXISTS(SELECT 1 FROM thunderdome.poker_story", []string{"exists"}, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{args[1].(string) == d.storyID}}, nil
+	})
+
+	return svc, d
+}
+
+// TestCancelStoryVoting cancels voting on the games active story and makes sure the game is left
+// with no active story, voting locked and no votes, without the round being ended
+func TestCancelStoryVoting(t *testing.T) {
+	svc, cancel := newCancelService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	StoryID := "3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b"
+	FacilitatorID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	cancel.storyID = StoryID
+	cancel.active, cancel.votes, cancel.activeStoryID, cancel.votingLocked = true, `[{"warriorId":"a","vote":"3"}]`, StoryID, false
+
+	if _, err := svc.CancelStoryVoting(PokerID, StoryID, FacilitatorID); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if cancel.active || cancel.votes != "[]" {
+		t.Fatalf(`expected the story to be inactive without votes got active %v votes %s`, cancel.active, cancel.votes)
+	}
+	if cancel.activeStoryID != "" || !cancel.votingLocked {
+		t.Fatalf(`expected no active story and voting locked got %q locked %v`, cancel.activeStoryID, cancel.votingLocked)
+	}
+	if cancel.voteEnded {
+		t.Fatalf(`expected cancelling not to end the round`)
+	}
+
+	if _, err := svc.CancelStoryVoting(PokerID, StoryID, FacilitatorID); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected ErrValidation cancelling an inactive story got %v`, err)
+	}
+	if _, err := svc.CancelStoryVoting(PokerID, "7c9e6679-7425-40de-944b-e07fc1f90ae7", FacilitatorID); !errors.Is(err, thunderdome.ErrStoryNotFound) {
+		t.Fatalf(`expected ErrStoryNotFound cancelling a missing story got %v`, err)
+	}
+}
+
+// TestCancelStoryVotingEndedConcurrently cancels voting on a story whose voting ended after it was activated
+// and makes sure the guarded update rejects it leaving the story and game alone
+func TestCancelStoryVotingEndedConcurrently(t *testing.T) {
+	svc, cancel := newCancelService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	StoryID := "3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b"
+	FacilitatorID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	cancel.storyID, cancel.active, cancel.votes, cancel.activeStoryID = StoryID, false, `[{"warriorId":"a","vote":"3"}]`, StoryID
+
+	if _, err := svc.CancelStoryVoting(PokerID, StoryID, FacilitatorID); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected ErrValidation cancelling a story whose voting already ended got %v`, err)
+	}
+	if cancel.votes == "[]" || cancel.activeStoryID != StoryID || cancel.votingLocked {
+		t.Fatalf(`expected the ended story and game to be left alone got votes %s active story %q locked %v`, cancel.votes, cancel.activeStoryID, cancel.votingLocked)
+	}
+}
@@ -0,0 +1,108 @@
+package poker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// gameConfigVersion is the version of the exported game settings format,
+// bump it when a change to thunderdome.PokerConfig can't be read by older instances
+const gameConfigVersion = 1
+
+// ExportGameSettings exports the games configuration (scale, visibility, anonymity, quorum) as versioned JSON
+// for CreateGameFromSettings, the games stories and votes are left out
+func (d *Service) ExportGameSettings(PokerID string) ([]byte, error) {
+	b, err := d.GetGame(PokerID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(gameConfig(b))
+}
+
+// CreateGameFromSettings creates a new game for the facilitator with the settings exported by ExportGameSettings
+func (d *Service) CreateGameFromSettings(ctx context.Context, FacilitatorID string, Name string, Settings []byte) (*thunderdome.Poker, error) {
+	if err := db.ValidateUUID(FacilitatorID); err != nil {
+		return nil, err
+	}
+	c, err := decodeGameConfig(Settings)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateGameTemplate(&thunderdome.PokerTemplate{
+		Name:                 Name,
+		PointValuesAllowed:   c.PointValuesAllowed,
+		PointAverageRounding: c.PointAverageRounding,
+		VoteMode:             c.VoteMode,
+		CustomScale:          c.CustomScale,
+	}); err != nil {
+		return nil, err
+	}
+
+	return d.CreateGame(ctx, FacilitatorID, Name, c.PointValuesAllowed, make([]*thunderdome.Story, 0),
+		c.AutoFinishVoting, c.PointAverageRounding, "", "", c.HideVoterIdentity, c.VoteMode, c.CustomScale, gameConfigSettings(c), "")
+}
+
+// gameConfig takes the portable configuration from the game
+func gameConfig(Game *thunderdome.Poker) *thunderdome.PokerConfig {
+	c := &thunderdome.PokerConfig{
+		Version:              gameConfigVersion,
+		VoteMode:             Game.VoteMode,
+		PointValuesAllowed:   Game.PointValuesAllowed,
+		CustomScale:          Game.CustomScale,
+		AutoFinishVoting:     Game.AutoFinishVoting,
+		PointAverageRounding: Game.PointAverageRounding,
+		HideVoterIdentity:    Game.HideVoterIdentity,
+		VoteRevealThreshold:  Game.VoteRevealThreshold,
+		MinVotersToFinalize:  Game.MinVotersToFinalize,
+		EstimationUnit:       Game.EstimationUnit,
+		TieBreakStrategy:     Game.TieBreakStrategy,
+		ParallelVoting:       Game.ParallelVoting,
+		RequireNamedUsers:    Game.RequireNamedUsers,
+		VoteAliases:          Game.VoteAliases,
+	}
+	if c.PointValuesAllowed == nil {
+		c.PointValuesAllowed = make([]string, 0)
+	}
+	if c.CustomScale == nil {
+		c.CustomScale = make([]thunderdome.ScaleValue, 0)
+	}
+	if c.VoteAliases == nil {
+		c.VoteAliases = make(map[string]string)
+	}
+
+	return c
+}
+
+// decodeGameConfig reads exported game settings, rejecting a missing or newer format version
+func decodeGameConfig(Settings []byte) (*thunderdome.PokerConfig, error) {
+	var c thunderdome.PokerConfig
+	if err := json.Unmarshal(Settings, &c); err != nil {
+		return nil, fmt.Errorf("%w: invalid game settings", thunderdome.ErrValidation)
+	}
+	if c.Version < 1 || c.Version > gameConfigVersion {
+		return nil, fmt.Errorf("%w: unsupported game settings version %d", thunderdome.ErrValidation, c.Version)
+	}
+	if c.VoteAliases == nil {
+		c.VoteAliases = make(map[string]string)
+	}
+
+	return &c, nil
+}
+
+// gameConfigSettings gets the settings to apply after creating the game, those CreateGame doesn't take
+func gameConfigSettings(Config *thunderdome.PokerConfig) thunderdome.PokerSettings {
+	return thunderdome.PokerSettings{
+		EstimationUnit:      &Config.EstimationUnit,
+		TieBreakStrategy:    &Config.TieBreakStrategy,
+		MinVotersToFinalize: &Config.MinVotersToFinalize,
+		VoteRevealThreshold: &Config.VoteRevealThreshold,
+		ParallelVoting:      &Config.ParallelVoting,
+		RequireNamedUsers:   &Config.RequireNamedUsers,
+		VoteAliases:         Config.VoteAliases,
+	}
+}
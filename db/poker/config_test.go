@@ -0,0 +1,82 @@
+package poker
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestGameConfigRoundTrip exports a game with every setting changed from its default, reads it back
+// and applies it to a new game making sure all the settings are preserved and the stories are left out
+func TestGameConfigRoundTrip(t *testing.T) {
+	game := &thunderdome.Poker{
+		Name:                 "Refinement",
+		VoteMode:             thunderdome.PokerVoteModeEffortComplexity,
+		PointValuesAllowed:   []string{"S", "M", "L"},
+		CustomScale:          []thunderdome.ScaleValue{{Label: "S", Ordinal: 1}, {Label: "M", Ordinal: 2}, {Label: "L", Ordinal: 3}},
+		AutoFinishVoting:     false,
+		PointAverageRounding: "floor",
+		HideVoterIdentity:    true,
+		VoteRevealThreshold:  3,
+		MinVotersToFinalize:  2,
+		EstimationUnit:       thunderdome.EstimationUnitDays,
+		TieBreakStrategy:     thunderdome.TieBreakUpperMedian,
+		ParallelVoting:       true,
+		RequireNamedUsers:    true,
+		VoteAliases:          map[string]string{"small": "S"},
+		Stories:              []*thunderdome.Story{{Id: "story", Name: "Secret story", Points: "M"}},
+	}
+
+	exported, err := json.Marshal(gameConfig(game))
+	if err != nil {
+		t.Fatalf(`unexpected error exporting %v`, err)
+	}
+	if strings.Contains(string(exported), "Secret story") {
+		t.Fatalf(`expected stories to be left out of the export got %s`, exported)
+	}
+
+	c, err := decodeGameConfig(exported)
+	if err != nil {
+		t.Fatalf(`unexpected error importing %v`, err)
+	}
+	if c.Version != gameConfigVersion {
+		t.Fatalf(`expected version %d got %d`, gameConfigVersion, c.Version)
+	}
+	settings := gameConfigSettings(c)
+	if _, _, err := gameSettingsUpdate(settings); err != nil {
+		t.Fatalf(`unexpected error validating imported settings %v`, err)
+	}
+
+	created := &thunderdome.Poker{
+		VoteMode:             c.VoteMode,
+		PointValuesAllowed:   c.PointValuesAllowed,
+		CustomScale:          c.CustomScale,
+		AutoFinishVoting:     c.AutoFinishVoting,
+		PointAverageRounding: c.PointAverageRounding,
+		HideVoterIdentity:    c.HideVoterIdentity,
+		EstimationUnit:       *settings.EstimationUnit,
+		TieBreakStrategy:     *settings.TieBreakStrategy,
+		MinVotersToFinalize:  *settings.MinVotersToFinalize,
+		VoteRevealThreshold:  *settings.VoteRevealThreshold,
+		ParallelVoting:       *settings.ParallelVoting,
+		RequireNamedUsers:    *settings.RequireNamedUsers,
+		VoteAliases:          settings.VoteAliases,
+	}
+	if !reflect.DeepEqual(gameConfig(created), gameConfig(game)) {
+		t.Fatalf(`expected imported settings %+v got %+v`, gameConfig(game), gameConfig(created))
+	}
+}
+
+// TestDecodeGameConfigVersion calls decodeGameConfig with unversioned, newer and malformed settings
+// and makes sure each returns a validation error
+func TestDecodeGameConfigVersion(t *testing.T) {
+	for _, settings := range []string{`{"voteMode":"points"}`, `{"version":2}`, `not json`} {
+		if _, err := decodeGameConfig([]byte(settings)); !errors.Is(err, thunderdome.ErrValidation) {
+			t.Fatalf(`expected settings %s to fail validation got %v`, settings, err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package poker
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// storyReferenceIDMaxLength is the length of the poker_story reference_id column
+const storyReferenceIDMaxLength = 128
+
+// ImportStoriesFromCSV adds a story to the game for each row of a tracker CSV export,
+// the mapped columns must all be in the header and any invalid row or failed insert rejects the whole import
+func (d *Service) ImportStoriesFromCSV(PokerID string, CSV []byte, Mapping thunderdome.StoryColumnMapping) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
+	stories, err := parseStoryCSV(CSV, Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := d.CreateStoriesBulk(PokerID, stories, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Stories, nil
+}
+
+// parseStoryCSV reads the stories from the CSV rows after the header using the column mapping,
+// header names are matched ignoring case and surrounding spaces and rows are numbered from the header as row 1
+func parseStoryCSV(CSV []byte, Mapping thunderdome.StoryColumnMapping) ([]*thunderdome.Story, error) {
+	if strings.TrimSpace(Mapping.Name) == "" {
+		return nil, fmt.Errorf("%w: a name column is required", thunderdome.ErrValidation)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(CSV))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: csv is empty", thunderdome.ErrValidation)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid csv header: %v", thunderdome.ErrValidation, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		// spreadsheet exports often start with a UTF-8 byte order mark
+		h = csvColumnKey(strings.TrimPrefix(h, "\ufeff"))
+		if _, ok := columns[h]; !ok {
+			columns[h] = i
+		}
+	}
+
+	missing := make([]string, 0)
+	column := func(name string) int {
+		if strings.TrimSpace(name) == "" {
+			return -1
+		}
+		i, ok := columns[csvColumnKey(name)]
+		if !ok {
+			missing = append(missing, fmt.Sprintf("%q", name))
+			return -1
+		}
+		return i
+	}
+	nameCol := column(Mapping.Name)
+	referenceCol := column(Mapping.ReferenceID)
+	descriptionCol := column(Mapping.Description)
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: csv is missing columns %s", thunderdome.ErrValidation, strings.Join(missing, ", "))
+	}
+
+	stories := make([]*thunderdome.Story, 0)
+	rowErrors := make([]string, 0)
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// the reader can't reliably continue past malformed quoting so stop at the first one
+			rowErrors = append(rowErrors, fmt.Sprintf("row %d: %v", row, err))
+			break
+		}
+		if csvRowBlank(record) {
+			continue
+		}
+
+		story := &thunderdome.Story{
+			Name:        csvField(record, nameCol),
+			Type:        "Story",
+			ReferenceId: csvField(record, referenceCol),
+			Description: csvField(record, descriptionCol),
+		}
+		switch {
+		case story.Name == "":
+			rowErrors = append(rowErrors, fmt.Sprintf("row %d: missing name", row))
+		case utf8.RuneCountInString(story.Name) > storyNameMaxLength:
+			rowErrors = append(rowErrors, fmt.Sprintf("row %d: name exceeds %d characters", row, storyNameMaxLength))
+		case utf8.RuneCountInString(story.ReferenceId) > storyReferenceIDMaxLength:
+			rowErrors = append(rowErrors, fmt.Sprintf("row %d: reference ID exceeds %d characters", row, storyReferenceIDMaxLength))
+		default:
+			stories = append(stories, story)
+		}
+	}
+
+	if len(rowErrors) > 0 {
+		return nil, fmt.Errorf("%w: %s", thunderdome.ErrValidation, strings.Join(rowErrors, "; "))
+	}
+	if len(stories) == 0 {
+		return nil, fmt.Errorf("%w: csv has no stories", thunderdome.ErrValidation)
+	}
+
+	return stories, nil
+}
+
+// csvColumnKey normalizes a header column name for matching
+func csvColumnKey(Name string) string {
+	return strings.ToLower(strings.TrimSpace(Name))
+}
+
+// csvField gets the trimmed field at the column, empty when unmapped or the row is short
+func csvField(Record []string, Column int) string {
+	if Column < 0 || Column >= len(Record) {
+		return ""
+	}
+
+	return strings.TrimSpace(Record[Column])
+}
+
+// csvRowBlank checks whether every field in the row is empty, e.g. trailing rows of commas
+func csvRowBlank(Record []string) bool {
+	for _, f := range Record {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+
+	return true
+}
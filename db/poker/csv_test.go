@@ -0,0 +1,131 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+var jiraMapping = thunderdome.StoryColumnMapping{
+	Name:        "Summary",
+	ReferenceID: "Issue key",
+	Description: "Description",
+}
+
+// TestParseStoryCSV calls parseStoryCSV with a JIRA style export including quoted fields
+// and makes sure each row after the header becomes a story with the mapped columns
+func TestParseStoryCSV(t *testing.T) {
+	csv := "\ufeffIssue key,Issue Type,Summary,Description\n" +
+		"TD-1,Story,Login page,\"Users can log in, and out\"\n" +
+		"TD-2,Bug,\"Fix \"\"remember me\"\" checkbox\",\"Spans\nlines\"\n" +
+		",,,\n" +
+		"TD-3,Story,  Logout  ,\n"
+
+	stories, err := parseStoryCSV([]byte(csv), jiraMapping)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if len(stories) != 3 {
+		t.Fatalf(`expected 3 stories got %d`, len(stories))
+	}
+	if stories[0].ReferenceId != "TD-1" || stories[0].Name != "Login page" || stories[0].Description != "Users can log in, and out" {
+		t.Fatalf(`expected first story mapped got %+v`, stories[0])
+	}
+	if stories[1].Name != `Fix "remember me" checkbox` || stories[1].Description != "Spans\nlines" {
+		t.Fatalf(`expected quoted fields unescaped got %+v`, stories[1])
+	}
+	if stories[2].Name != "Logout" || stories[2].Description != "" {
+		t.Fatalf(`expected trimmed name and empty description got %+v`, stories[2])
+	}
+	if stories[0].Type != "Story" {
+		t.Fatalf(`expected default story type got %q`, stories[0].Type)
+	}
+}
+
+// TestParseStoryCSVTrello calls parseStoryCSV with a Trello style export mapping only the name
+// and makes sure header names are matched ignoring case
+func TestParseStoryCSVTrello(t *testing.T) {
+	csv := "Card ID,Card Name,Card URL\nabc123,Onboarding flow,https://trello.com/c/abc123\n"
+
+	stories, err := parseStoryCSV([]byte(csv), thunderdome.StoryColumnMapping{Name: "card name", ReferenceID: "CARD ID"})
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if len(stories) != 1 || stories[0].Name != "Onboarding flow" || stories[0].ReferenceId != "abc123" {
+		t.Fatalf(`expected trello card mapped got %+v`, stories)
+	}
+}
+
+// TestParseStoryCSVMissingColumns calls parseStoryCSV with mapped columns not in the header
+// and makes sure each missing column is reported
+func TestParseStoryCSVMissingColumns(t *testing.T) {
+	_, err := parseStoryCSV([]byte("Key,Title\nTD-1,Login\n"), jiraMapping)
+	if !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected validation error got %v`, err)
+	}
+	if !strings.Contains(err.Error(), `"Summary", "Issue key", "Description"`) {
+		t.Fatalf(`expected missing columns in error got %v`, err)
+	}
+
+	if _, err := parseStoryCSV([]byte("Summary\nLogin\n"), thunderdome.StoryColumnMapping{}); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected validation error without a name column got %v`, err)
+	}
+}
+
+// TestParseStoryCSVRowErrors calls parseStoryCSV with invalid rows
+// and makes sure the import is rejected listing every invalid row
+func TestParseStoryCSVRowErrors(t *testing.T) {
+	csv := "Issue key,Summary,Description\n" +
+		"TD-1,Login,\n" +
+		"TD-2,,No name\n" +
+		"TD-3," + strings.Repeat("a", storyNameMaxLength+1) + ",\n" +
+		"TD-4\n"
+
+	_, err := parseStoryCSV([]byte(csv), jiraMapping)
+	if !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected validation error got %v`, err)
+	}
+	for _, expected := range []string{"row 3: missing name", "row 4: name exceeds", "row 5: missing name"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf(`expected %q in error got %v`, expected, err)
+		}
+	}
+	if strings.Contains(err.Error(), "row 2") {
+		t.Fatalf(`expected valid row not to be reported got %v`, err)
+	}
+
+	_, err = parseStoryCSV([]byte("Issue key,Summary,Description\nTD-1,\"unterminated,\n"), jiraMapping)
+	if !errors.Is(err, thunderdome.ErrValidation) || !strings.Contains(err.Error(), "row 2") {
+		t.Fatalf(`expected malformed quoting reported on row 2 got %v`, err)
+	}
+	if _, err := parseStoryCSV([]byte("Issue key,Summary,Description\n"), jiraMapping); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected validation error for csv without stories got %v`, err)
+	}
+}
+
+// TestImportStoriesFromCSVAllOrNothing imports a CSV whose last row fails to insert
+// and makes sure none of its stories are kept
+func TestImportStoriesFromCSVAllOrNothing(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	f.Exec("INSERT INTO thunderdome.poker_story", func(args []driver.Value) (int64, error) {
+		if args[3] == "TD-3" {
+			return 0, errors.New("insert failed")
+		}
+		return 1, nil
+	})
+	csv := "Issue key,Summary,Description\n" +
+		"TD-1,Login,Users can log in\n" +
+		"TD-2,Logout,Users can log out\n" +
+		"TD-3,Reset password,Users can reset their password\n"
+
+	if _, err := svc.ImportStoriesFromCSV(PokerID, []byte(csv), jiraMapping); err == nil {
+		t.Fatalf(`expected error when a row fails to insert`)
+	}
+	if f.Commits() != 0 || f.Rollbacks() != 1 {
+		t.Fatalf(`expected the whole import to be rolled back got %d commits and %d rollbacks`, f.Commits(), f.Rollbacks())
+	}
+}
@@ -0,0 +1,279 @@
+package poker
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// GetGameDuration gets the elapsed time between the game being created and its end, an archived game ends when
+// it was archived while any other game ends with its last voting round, a game without a finished round has no duration yet
+func (d *Service) GetGameDuration(PokerID string) (time.Duration, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return 0, err
+	}
+
+	var CreatedDate time.Time
+	var EndDate sql.NullTime
+
+	err := d.DB.QueryRow(
+		`SELECT p.created_date, COALESCE(p.archived_date, (
+			SELECT MAX(GREATEST(s.voteend_time, s.finalized_date)) FROM thunderdome.poker_story s WHERE s.poker_id = p.id
+		))
+		FROM thunderdome.poker p WHERE p.id = $1;`,
+		PokerID,
+	).Scan(&CreatedDate, &EndDate)
+	if err != nil {
+		d.Logger.Error("get poker duration error", zap.Error(err))
+		return 0, errors.New("not found")
+	}
+
+	return gameDuration(CreatedDate, EndDate), nil
+}
+
+// gameDuration calculates the time from the game being created until its end, zero without an end
+func gameDuration(CreatedDate time.Time, EndDate sql.NullTime) time.Duration {
+	if !EndDate.Valid || EndDate.Time.Before(CreatedDate) {
+		return 0
+	}
+
+	return EndDate.Time.Sub(CreatedDate)
+}
+
+// GetStoryVotingDurations gets how long voting took for each finalized story in the game keyed by story ID,
+// measured from voting being activated until the story was finalized or until voting ended depending on Until
+func (d *Service) GetStoryVotingDurations(PokerID string, Until string) (map[string]time.Duration, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+	if Until != thunderdome.VotingDurationUntilFinalized && Until != thunderdome.VotingDurationUntilVoteEnd {
+		return nil, fmt.Errorf("%w: unknown voting duration end %q", thunderdome.ErrValidation, Until)
+	}
+
+	rows, err := d.DB.Query(
+		`SELECT id, votestart_time, voteend_time, finalized_date, points, active
+		FROM thunderdome.poker_story
+		WHERE poker_id = $1 AND votestart_time IS NOT NULL AND voteend_time IS NOT NULL;`,
+		PokerID,
+	)
+	if err != nil {
+		d.Logger.Error("get poker story voting durations query error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get story voting durations: %w", err)
+	}
+	defer rows.Close()
+
+	stories := make([]*thunderdome.Story, 0)
+	for rows.Next() {
+		var s thunderdome.Story
+		var finalized sql.NullTime
+		var points sql.NullString
+		if err := rows.Scan(&s.Id, &s.VoteStartTime, &s.VoteEndTime, &finalized, &points, &s.Active); err != nil {
+			d.Logger.Error("get poker story voting durations scan error", zap.Error(err))
+			continue
+		}
+		s.FinalizedTime = finalized.Time
+		s.Points = points.String
+		stories = append(stories, &s)
+	}
+
+	return calculateStoryVotingDurations(stories, Until), nil
+}
+
+// calculateStoryVotingDurations calculates the time from voting activation until the story was finalized or voting ended,
+// stories finalized before finalized times were recorded fall back to when voting ended and stories whose end
+// is before voting started (e.g. reactivated after ending) are left out
+func calculateStoryVotingDurations(Stories []*thunderdome.Story, Until string) map[string]time.Duration {
+	durations := make(map[string]time.Duration)
+
+	for _, s := range Stories {
+		if s.Points == "" || s.Active || s.VoteStartTime.IsZero() {
+			continue
+		}
+
+		end := s.VoteEndTime
+		if Until == thunderdome.VotingDurationUntilFinalized && !s.FinalizedTime.IsZero() {
+			end = s.FinalizedTime
+		}
+		if end.Before(s.VoteStartTime) {
+			continue
+		}
+
+		durations[s.Id] = end.Sub(s.VoteStartTime)
+	}
+
+	return durations
+}
+
+// GetVoteTimingStats gets how long after voting started each finalized story got its first vote and its full turnout
+// from the games vote events, along with the medians across the game to highlight slow to decide stories
+func (d *Service) GetVoteTimingStats(PokerID string) (*thunderdome.TimingStats, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
+	stories, events, err := d.getVoteTimings(PokerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return calculateVoteTiming(PokerID, stories, events), nil
+}
+
+// getVoteTimings gets the games finalized stories with their votes and the vote events
+// for timing how quickly each story was voted on
+func (d *Service) getVoteTimings(PokerID string) ([]*thunderdome.Story, []*thunderdome.PokerEvent, error) {
+	storyRows, err := d.DB.Query(
+		`SELECT id, name, votestart_time, voteend_time, COALESCE(finalized_date, voteend_time), votes
+		FROM thunderdome.poker_story
+		WHERE poker_id = $1 AND active = false AND skipped = false AND COALESCE(points, '') != ''
+		ORDER BY position, created_date;`,
+		PokerID,
+	)
+	if err != nil {
+		d.Logger.Error("get poker vote timing stories query error", zap.Error(err))
+		return nil, nil, fmt.Errorf("unable to get vote timings: %w", err)
+	}
+	defer storyRows.Close()
+
+	stories := make([]*thunderdome.Story, 0)
+	for storyRows.Next() {
+		var s thunderdome.Story
+		var v string
+		if err := storyRows.Scan(&s.Id, &s.Name, &s.VoteStartTime, &s.VoteEndTime, &s.FinalizedTime, &v); err != nil {
+			d.Logger.Error("get poker vote timing stories scan error", zap.Error(err))
+			continue
+		}
+		if s.Votes, err = decodeStoryVotes(v); err != nil {
+			d.Logger.Error("get poker vote timing story corrupt votes error", zap.String("story_id", s.Id), zap.Error(err))
+			continue
+		}
+		stories = append(stories, &s)
+	}
+
+	eventRows, err := d.DB.Query(
+		`SELECT story_id::text, user_id::text, created_date FROM thunderdome.poker_event
+		WHERE poker_id = $1 AND event_type = 'vote_set' AND story_id IS NOT NULL AND user_id IS NOT NULL
+		ORDER BY id;`,
+		PokerID,
+	)
+	if err != nil {
+		d.Logger.Error("get poker vote timing events query error", zap.Error(err))
+		return nil, nil, fmt.Errorf("unable to get vote timings: %w", err)
+	}
+	defer eventRows.Close()
+
+	events := make([]*thunderdome.PokerEvent, 0)
+	for eventRows.Next() {
+		var e thunderdome.PokerEvent
+		if err := eventRows.Scan(&e.StoryID, &e.UserID, &e.CreatedDate); err != nil {
+			d.Logger.Error("get poker vote timing events scan error", zap.Error(err))
+			continue
+		}
+		events = append(events, &e)
+	}
+
+	return stories, events, nil
+}
+
+// calculateVoteTiming times each story from voting starting to its first vote and to the last of its voters
+// first voting, only vote events between voting starting and the story being finalized count so earlier rounds
+// before a revote are ignored, stories with a voter missing a vote event (e.g. from before the event log) are left out
+func calculateVoteTiming(PokerID string, Stories []*thunderdome.Story, Events []*thunderdome.PokerEvent) *thunderdome.TimingStats {
+	stats := &thunderdome.TimingStats{
+		PokerID: PokerID,
+		Stories: make([]*thunderdome.StoryVoteTiming, 0),
+	}
+
+	storyEvents := make(map[string][]*thunderdome.PokerEvent)
+	for _, e := range Events {
+		storyEvents[e.StoryID] = append(storyEvents[e.StoryID], e)
+	}
+
+	firstVotes := make([]time.Duration, 0)
+	turnouts := make([]time.Duration, 0)
+	for _, s := range Stories {
+		firstVoted := firstVoteTimes(s, storyEvents[s.Id])
+
+		var first time.Time
+		var last time.Time
+		complete := len(s.Votes) > 0
+		for _, v := range s.Votes {
+			t, ok := firstVoted[v.UserId]
+			if !ok {
+				complete = false
+				break
+			}
+			if first.IsZero() || t.Before(first) {
+				first = t
+			}
+			if t.After(last) {
+				last = t
+			}
+		}
+		if !complete {
+			continue
+		}
+
+		timing := &thunderdome.StoryVoteTiming{
+			StoryID:           s.Id,
+			Name:              s.Name,
+			TimeToFirstVote:   first.Sub(s.VoteStartTime),
+			TimeToFullTurnout: last.Sub(s.VoteStartTime),
+		}
+		stats.Stories = append(stats.Stories, timing)
+		firstVotes = append(firstVotes, timing.TimeToFirstVote)
+		turnouts = append(turnouts, timing.TimeToFullTurnout)
+	}
+
+	stats.StoriesTimed = len(stats.Stories)
+	stats.MedianTimeToFirstVote = medianDuration(firstVotes)
+	stats.MedianTimeToFullTurnout = medianDuration(turnouts)
+
+	return stats
+}
+
+// firstVoteTimes gets when each user first voted on the story from its vote events, only events between voting
+// starting and the story being finalized count so earlier rounds before a revote are ignored
+func firstVoteTimes(Story *thunderdome.Story, Events []*thunderdome.PokerEvent) map[string]time.Time {
+	end := Story.FinalizedTime
+	if end.IsZero() {
+		end = Story.VoteEndTime
+	}
+
+	firstVoted := make(map[string]time.Time)
+	for _, e := range Events {
+		if e.CreatedDate.Before(Story.VoteStartTime) || (!end.IsZero() && e.CreatedDate.After(end)) {
+			continue
+		}
+		if t, ok := firstVoted[e.UserID]; !ok || e.CreatedDate.Before(t) {
+			firstVoted[e.UserID] = e.CreatedDate
+		}
+	}
+
+	return firstVoted
+}
+
+// medianDuration gets the median of the durations averaging the middle two for an even count, zero when empty
+func medianDuration(Durations []time.Duration) time.Duration {
+	if len(Durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), Durations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+
+	return sorted[middle]
+}
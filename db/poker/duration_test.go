@@ -0,0 +1,198 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestGetGameDuration calls GetGameDuration for an archived game, a game with a finished round and a game without one
+// and makes sure each is measured from creation to its explicit end rather than its last activity
+func TestGetGameDuration(t *testing.T) {
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	created := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	d, f := newTestService(t)
+	var end driver.Value
+	f.Query("FROM thunderdome.poker p WHERE p.id = $1", []string{"created_date", "end_date"}, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{created, end}}, nil
+	})
+
+	end = created.Add(45 * time.Minute)
+	if duration, err := d.GetGameDuration(PokerID); err != nil || duration != 45*time.Minute {
+		t.Fatalf(`expected the game to last 45m until its end got %v (%v)`, duration, err)
+	}
+
+	end = nil
+	if duration, err := d.GetGameDuration(PokerID); err != nil || duration != 0 {
+		t.Fatalf(`expected no duration for a game without an end got %v (%v)`, duration, err)
+	}
+
+	end = created.Add(-time.Minute)
+	if duration, err := d.GetGameDuration(PokerID); err != nil || duration != 0 {
+		t.Fatalf(`expected no duration for an end before creation got %v (%v)`, duration, err)
+	}
+
+	if _, err := d.GetGameDuration("not-a-uuid"); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected validation error for an invalid game ID got %v`, err)
+	}
+}
+
+// TestCalculateStoryVotingDurations calls calculateStoryVotingDurations with controlled timestamps
+// and makes sure only finalized stories are included measured until they were finalized
+func TestCalculateStoryVotingDurations(t *testing.T) {
+	start := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	stories := []*thunderdome.Story{
+		{Id: "finalized", Points: "3", VoteStartTime: start, VoteEndTime: start.Add(time.Minute), FinalizedTime: start.Add(3 * time.Minute)},
+		{Id: "legacy", Points: "5", VoteStartTime: start, VoteEndTime: start.Add(2 * time.Minute)},
+		{Id: "active", Active: true, VoteStartTime: start},
+		{Id: "unestimated", VoteStartTime: start, VoteEndTime: start.Add(time.Minute)},
+	}
+
+	durations := calculateStoryVotingDurations(stories, thunderdome.VotingDurationUntilFinalized)
+
+	if len(durations) != 2 {
+		t.Fatalf(`expected 2 durations got %d`, len(durations))
+	}
+	if durations["finalized"] != 3*time.Minute {
+		t.Fatalf(`expected finalized duration: 3m got %v`, durations["finalized"])
+	}
+	if durations["legacy"] != 2*time.Minute {
+		t.Fatalf(`expected legacy duration: 2m got %v`, durations["legacy"])
+	}
+}
+
+// TestCalculateStoryVotingDurationsUntilVoteEnd calls calculateStoryVotingDurations with controlled activation and end times
+// and makes sure only finalized stories are included measured until voting ended
+func TestCalculateStoryVotingDurationsUntilVoteEnd(t *testing.T) {
+	start := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	stories := []*thunderdome.Story{
+		{Id: "finalized", Points: "3", VoteStartTime: start, VoteEndTime: start.Add(time.Minute), FinalizedTime: start.Add(3 * time.Minute)},
+		{Id: "slow", Points: "8", VoteStartTime: start, VoteEndTime: start.Add(25 * time.Minute)},
+		{Id: "active", Points: "5", Active: true, VoteStartTime: start, VoteEndTime: start.Add(time.Minute)},
+		{Id: "unestimated", VoteStartTime: start, VoteEndTime: start.Add(time.Minute)},
+		{Id: "reactivated", Points: "2", VoteStartTime: start.Add(time.Hour), VoteEndTime: start},
+	}
+
+	durations := calculateStoryVotingDurations(stories, thunderdome.VotingDurationUntilVoteEnd)
+
+	if len(durations) != 2 {
+		t.Fatalf(`expected 2 durations got %d`, len(durations))
+	}
+	if durations["finalized"] != time.Minute {
+		t.Fatalf(`expected finalized duration until voting ended: 1m got %v`, durations["finalized"])
+	}
+	if durations["slow"] != 25*time.Minute {
+		t.Fatalf(`expected slow duration: 25m got %v`, durations["slow"])
+	}
+}
+
+// TestGetStoryVotingDurations calls GetStoryVotingDurations against stories with controlled activation, end and finalized times
+// and makes sure each finalized story is keyed by its ID with the time until the requested end
+func TestGetStoryVotingDurations(t *testing.T) {
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	start := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	d, f := newTestService(t)
+	f.Rows("FROM thunderdome.poker_story", []string{"id", "votestart_time", "voteend_time", "finalized_date", "points", "active"},
+		[]driver.Value{"story-1", start, start.Add(90 * time.Second), start.Add(4 * time.Minute), "3", false},
+		[]driver.Value{"story-2", start, start.Add(10 * time.Minute), nil, nil, false},
+		[]driver.Value{"story-3", start, start.Add(time.Minute), nil, "5", true},
+	)
+
+	durations, err := d.GetStoryVotingDurations(PokerID, thunderdome.VotingDurationUntilVoteEnd)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if len(durations) != 1 || durations["story-1"] != 90*time.Second {
+		t.Fatalf(`expected only story-1 lasting 1m30s got %v`, durations)
+	}
+
+	durations, err = d.GetStoryVotingDurations(PokerID, thunderdome.VotingDurationUntilFinalized)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if len(durations) != 1 || durations["story-1"] != 4*time.Minute {
+		t.Fatalf(`expected only story-1 lasting 4m until finalized got %v`, durations)
+	}
+
+	if _, err := d.GetStoryVotingDurations("not-a-uuid", thunderdome.VotingDurationUntilVoteEnd); err == nil {
+		t.Fatalf(`expected error for an invalid game ID`)
+	}
+	if _, err := d.GetStoryVotingDurations(PokerID, "last-active"); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected validation error for an unknown end got %v`, err)
+	}
+}
+
+// TestCalculateVoteTiming calls calculateVoteTiming with controlled vote event timestamps
+// and makes sure each stories first vote and full turnout times and the medians across stories are correct
+func TestCalculateVoteTiming(t *testing.T) {
+	start := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	votes := func(UserIDs ...string) []*thunderdome.Vote {
+		vs := make([]*thunderdome.Vote, 0, len(UserIDs))
+		for _, id := range UserIDs {
+			vs = append(vs, &thunderdome.Vote{UserId: id, VoteValue: "3"})
+		}
+		return vs
+	}
+	stories := []*thunderdome.Story{
+		{Id: "quick", Name: "Quick", VoteStartTime: start, FinalizedTime: start.Add(5 * time.Minute), Votes: votes("a", "b")},
+		{Id: "slow", Name: "Slow", VoteStartTime: start.Add(10 * time.Minute), FinalizedTime: start.Add(30 * time.Minute), Votes: votes("a", "b")},
+		{Id: "revoted", Name: "Revoted", VoteStartTime: start.Add(40 * time.Minute), FinalizedTime: start.Add(50 * time.Minute), Votes: votes("a")},
+		{Id: "legacy", Name: "Legacy", VoteStartTime: start, FinalizedTime: start.Add(time.Hour), Votes: votes("a")},
+	}
+	event := func(StoryID string, UserID string, at time.Duration) *thunderdome.PokerEvent {
+		return &thunderdome.PokerEvent{StoryID: StoryID, UserID: UserID, Type: "vote_set", CreatedDate: start.Add(at)}
+	}
+	events := []*thunderdome.PokerEvent{
+		event("quick", "a", 30*time.Second),
+		event("quick", "b", time.Minute),
+		// a changed vote doesn't move their first vote
+		event("quick", "a", 2*time.Minute),
+		event("slow", "b", 14*time.Minute),
+		event("slow", "a", 22*time.Minute),
+		// a vote from before the revote reset voting start is ignored
+		event("revoted", "a", 35*time.Minute),
+		event("revoted", "a", 43*time.Minute),
+	}
+
+	stats := calculateVoteTiming("game", stories, events)
+
+	if stats.StoriesTimed != 3 {
+		t.Fatalf(`expected 3 timed stories got %d`, stats.StoriesTimed)
+	}
+	expected := map[string][2]time.Duration{
+		"quick":   {30 * time.Second, time.Minute},
+		"slow":    {4 * time.Minute, 12 * time.Minute},
+		"revoted": {3 * time.Minute, 3 * time.Minute},
+	}
+	for _, s := range stats.Stories {
+		want, ok := expected[s.StoryID]
+		if !ok {
+			t.Fatalf(`unexpected timed story %s`, s.StoryID)
+		}
+		if s.TimeToFirstVote != want[0] || s.TimeToFullTurnout != want[1] {
+			t.Fatalf(`expected %s timings %v and %v got %v and %v`, s.StoryID, want[0], want[1], s.TimeToFirstVote, s.TimeToFullTurnout)
+		}
+	}
+	if stats.MedianTimeToFirstVote != 3*time.Minute {
+		t.Fatalf(`expected median time to first vote: 3m got %v`, stats.MedianTimeToFirstVote)
+	}
+	if stats.MedianTimeToFullTurnout != 3*time.Minute {
+		t.Fatalf(`expected median time to full turnout: 3m got %v`, stats.MedianTimeToFullTurnout)
+	}
+}
+
+// TestMedianDuration calls medianDuration with odd, even, and empty counts
+func TestMedianDuration(t *testing.T) {
+	if m := medianDuration([]time.Duration{3, 1, 2}); m != 2 {
+		t.Fatalf(`expected median 2 got %v`, m)
+	}
+	if m := medianDuration([]time.Duration{4 * time.Minute, time.Minute, 2 * time.Minute, 3 * time.Minute}); m != 150*time.Second {
+		t.Fatalf(`expected median of the middle two to be 2m30s got %v`, m)
+	}
+	if m := medianDuration(nil); m != 0 {
+		t.Fatalf(`expected no median got %v`, m)
+	}
+}
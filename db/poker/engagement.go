@@ -0,0 +1,97 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// GetUserEngagement gets the share of the games finalized stories each active non-spectator user voted on,
+// in games that hide voter identity the users who haven't opted into revealing their identity
+// are combined into a single entry with an empty user ID
+func (d *Service) GetUserEngagement(PokerID string) (map[string]float64, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
+	var HideVoterIdentity bool
+	if err := d.DB.QueryRow(
+		`SELECT hide_voter_identity FROM thunderdome.poker WHERE id = $1;`,
+		PokerID,
+	).Scan(&HideVoterIdentity); err != nil {
+		d.Logger.Error("get poker hide_voter_identity error", zap.Error(err))
+		return nil, errors.New("not found")
+	}
+
+	rows, err := d.DB.Query(
+		`SELECT id, votes FROM thunderdome.poker_story
+		WHERE poker_id = $1 AND active = false AND skipped = false AND COALESCE(points, '') != '';`,
+		PokerID,
+	)
+	if err != nil {
+		d.Logger.Error("get poker user engagement query error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get user engagement: %w", err)
+	}
+	defer rows.Close()
+
+	stories := make([][]*thunderdome.Vote, 0)
+	for rows.Next() {
+		var StoryID string
+		var v string
+		if err := rows.Scan(&StoryID, &v); err != nil {
+			d.Logger.Error("get poker user engagement scan error", zap.Error(err))
+			continue
+		}
+		Votes, err := decodeStoryVotes(v)
+		if err != nil {
+			d.Logger.Error("get poker user engagement corrupt votes error", zap.String("story_id", StoryID), zap.Error(err))
+			continue
+		}
+		stories = append(stories, Votes)
+	}
+
+	return calculateEngagement(d.getActiveUsers(d.DB, PokerID), stories, HideVoterIdentity), nil
+}
+
+// calculateEngagement divides the number of stories each active non-spectator user voted on by the number of stories,
+// when HideVoterIdentity is set users who haven't opted into revealing their identity share the empty user ID entry
+// which is their combined votes divided by the stories they could have voted on
+func calculateEngagement(Users []*thunderdome.PokerUser, Stories [][]*thunderdome.Vote, HideVoterIdentity bool) map[string]float64 {
+	voted := make(map[string]int)
+	for _, Votes := range Stories {
+		for _, vote := range Votes {
+			if vote.VoteValue != "" {
+				voted[vote.UserId]++
+			}
+		}
+	}
+
+	engagement := make(map[string]float64)
+	anonymousVotes, anonymousUsers := 0, 0
+	for _, u := range Users {
+		if u.Spectator {
+			continue
+		}
+		if HideVoterIdentity && !u.RevealIdentity {
+			anonymousVotes += voted[u.Id]
+			anonymousUsers++
+			continue
+		}
+		engagement[u.Id] = 0
+		if len(Stories) > 0 {
+			engagement[u.Id] = float64(voted[u.Id]) / float64(len(Stories))
+		}
+	}
+	if anonymousUsers > 0 {
+		engagement[""] = 0
+		if len(Stories) > 0 {
+			engagement[""] = float64(anonymousVotes) / float64(anonymousUsers*len(Stories))
+		}
+	}
+
+	return engagement
+}
@@ -0,0 +1,61 @@
+package poker
+
+import (
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestCalculateEngagement calls calculateEngagement with users who voted on some but not all finalized stories
+// and makes sure each gets the share of stories they voted on, spectators are left out
+func TestCalculateEngagement(t *testing.T) {
+	users := []*thunderdome.PokerUser{
+		{Id: "u1"},
+		{Id: "u2"},
+		{Id: "u3"},
+		{Id: "u4", Spectator: true},
+	}
+	stories := [][]*thunderdome.Vote{
+		{{UserId: "u1", VoteValue: "3"}, {UserId: "u2", VoteValue: "5"}},
+		{{UserId: "u1", VoteValue: "8"}, {UserId: "u2", VoteValue: ""}},
+		{{UserId: "u1", VoteValue: "1"}, {UserId: "u2", VoteValue: "1"}, {UserId: "u4", VoteValue: "2"}},
+		{{UserId: "u1", VoteValue: "2"}},
+	}
+
+	engagement := calculateEngagement(users, stories, false)
+	expected := map[string]float64{"u1": 1, "u2": 0.5, "u3": 0}
+	if len(engagement) != len(expected) {
+		t.Fatalf(`expected engagement for %v got %v`, expected, engagement)
+	}
+	for UserID, ratio := range expected {
+		if got, ok := engagement[UserID]; !ok || got != ratio {
+			t.Fatalf(`expected %s engagement %v got %v`, UserID, ratio, engagement)
+		}
+	}
+
+	if engagement := calculateEngagement(users, nil, false); engagement["u1"] != 0 {
+		t.Fatalf(`expected no engagement without finalized stories got %v`, engagement)
+	}
+}
+
+// TestCalculateEngagementHideVoterIdentity calls calculateEngagement for a game hiding voter identity
+// and makes sure only users who revealed their identity are listed with the others combined anonymously
+func TestCalculateEngagementHideVoterIdentity(t *testing.T) {
+	users := []*thunderdome.PokerUser{
+		{Id: "u1", RevealIdentity: true},
+		{Id: "u2"},
+		{Id: "u3"},
+	}
+	stories := [][]*thunderdome.Vote{
+		{{UserId: "u1", VoteValue: "3"}, {UserId: "u2", VoteValue: "5"}},
+		{{UserId: "u2", VoteValue: "8"}, {UserId: "u3", VoteValue: "8"}},
+	}
+
+	engagement := calculateEngagement(users, stories, true)
+	if len(engagement) != 2 || engagement["u1"] != 0.5 || engagement[""] != 0.75 {
+		t.Fatalf(`expected u1 at 0.5 and the anonymous users at 0.75 got %v`, engagement)
+	}
+	if _, ok := engagement["u2"]; ok {
+		t.Fatalf(`expected u2 identity to be omitted got %v`, engagement)
+	}
+}
@@ -0,0 +1,64 @@
+package poker
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// GetLastEstimateForReference gets the most recent finalized estimate for stories with the ReferenceID across games
+func (d *Service) GetLastEstimateForReference(ReferenceID string) (*thunderdome.StoryEstimate, error) {
+	if ReferenceID == "" {
+		return nil, errors.New("reference id required")
+	}
+
+	var estimates = make([]*thunderdome.StoryEstimate, 0)
+	rows, err := d.DB.Query(
+		`SELECT id, poker_id, points, points_numeric, COALESCE(finalized_date, voteend_time)
+		FROM thunderdome.poker_story
+		WHERE reference_id = $1 AND points != '' AND skipped = false;`,
+		ReferenceID,
+	)
+	if err != nil {
+		d.Logger.Error("get last estimate for reference query error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get last estimate: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var PointsNumeric sql.NullFloat64
+		var e thunderdome.StoryEstimate
+		if err := rows.Scan(&e.StoryID, &e.PokerID, &e.Points, &PointsNumeric, &e.FinalizedDate); err != nil {
+			d.Logger.Error("get last estimate for reference scan error", zap.Error(err))
+			continue
+		}
+		if PointsNumeric.Valid {
+			e.PointsNumeric = &PointsNumeric.Float64
+		}
+		estimates = append(estimates, &e)
+	}
+
+	latest := latestStoryEstimate(estimates)
+	if latest == nil {
+		return nil, errors.New("not found")
+	}
+
+	return latest, nil
+}
+
+// latestStoryEstimate returns the most recently finalized estimate or nil when there are none
+func latestStoryEstimate(Estimates []*thunderdome.StoryEstimate) *thunderdome.StoryEstimate {
+	var latest *thunderdome.StoryEstimate
+
+	for _, e := range Estimates {
+		if latest == nil || e.FinalizedDate.After(latest.FinalizedDate) {
+			latest = e
+		}
+	}
+
+	return latest
+}
@@ -0,0 +1,27 @@
+package poker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestLatestStoryEstimate calls latestStoryEstimate with the same reference finalized in two games
+// and makes sure the most recent estimate is returned
+func TestLatestStoryEstimate(t *testing.T) {
+	finalized := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	estimates := []*thunderdome.StoryEstimate{
+		{StoryID: "newer", PokerID: "game2", Points: "8", FinalizedDate: finalized.Add(48 * time.Hour)},
+		{StoryID: "older", PokerID: "game1", Points: "5", FinalizedDate: finalized},
+	}
+
+	latest := latestStoryEstimate(estimates)
+
+	if latest == nil || latest.StoryID != "newer" || latest.Points != "8" {
+		t.Fatalf(`expected newer estimate of 8 points got %+v`, latest)
+	}
+	if latestStoryEstimate(nil) != nil {
+		t.Fatalf(`expected no estimate for no stories`)
+	}
+}
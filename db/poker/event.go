@@ -0,0 +1,83 @@
+package poker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// RecordGameEvent appends an action to the games event log, StoryID is optional
+func (d *Service) RecordGameEvent(PokerID string, StoryID string, UserID string, EventType string, Value string) error {
+	return d.recordGameEvent(context.Background(), d.DB, PokerID, StoryID, UserID, EventType, Value)
+}
+
+// RecordGameEventTx appends an action to the games event log within the transaction
+// so the event is only logged when the action it records commits
+func (d *Service) RecordGameEventTx(ctx context.Context, tx *sql.Tx, PokerID string, StoryID string, UserID string, EventType string, Value string) error {
+	return d.recordGameEvent(ctx, tx, PokerID, StoryID, UserID, EventType, Value)
+}
+
+// recordGameEvent inserts the event log entry using the database handle or transaction
+func (d *Service) recordGameEvent(ctx context.Context, q execer, PokerID string, StoryID string, UserID string, EventType string, Value string) error {
+	if err := db.ValidateUUID(PokerID, UserID); err != nil {
+		return err
+	}
+	if StoryID != "" {
+		if err := db.ValidateUUID(StoryID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := q.ExecContext(ctx,
+		`INSERT INTO thunderdome.poker_event (poker_id, story_id, user_id, event_type, value)
+		VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5);`,
+		PokerID, StoryID, UserID, EventType, Value,
+	); err != nil {
+		d.Logger.Error("insert poker event error", zap.Error(err))
+		return fmt.Errorf("unable to record poker event: %w", err)
+	}
+
+	return nil
+}
+
+// GetGameEventLog gets the games event log in the order the actions happened
+func (d *Service) GetGameEventLog(PokerID string) ([]*thunderdome.PokerEvent, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
+	var events = make([]*thunderdome.PokerEvent, 0)
+	rows, err := d.DB.Query(
+		`SELECT id, poker_id, COALESCE(story_id::text, ''), COALESCE(user_id::text, ''), event_type, value, created_date
+		FROM thunderdome.poker_event
+		WHERE poker_id = $1
+		ORDER BY id;`,
+		PokerID,
+	)
+	if err != nil {
+		d.Logger.Error("get poker event log query error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get poker event log: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e thunderdome.PokerEvent
+		if err := rows.Scan(&e.Id, &e.PokerID, &e.StoryID, &e.UserID, &e.Type, &e.Value, &e.CreatedDate); err != nil {
+			d.Logger.Error("get poker event log scan error", zap.Error(err))
+			continue
+		}
+		events = append(events, &e)
+	}
+
+	return events, nil
+}
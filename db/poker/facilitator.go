@@ -0,0 +1,57 @@
+package poker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// lockFacilitatorUser confirms the facilitator user exists and locks their row for the rest of the transaction
+// so a game can't be created referencing a user that doesn't exist or is deleted mid creation,
+// returning the facilitator as an active game user
+func (d *Service) lockFacilitatorUser(ctx context.Context, tx *sql.Tx, FacilitatorID string) (*thunderdome.PokerUser, error) {
+	var w thunderdome.PokerUser
+	var Email string
+	err := tx.QueryRowContext(ctx,
+		`SELECT id, name, type, avatar, COALESCE(email, '') FROM thunderdome.users WHERE id = $1 FOR SHARE;`, FacilitatorID,
+	).Scan(&w.Id, &w.Name, &w.Type, &w.Avatar, &Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		d.Logger.Error("poker create facilitator lookup error", zap.Error(err))
+	}
+	if err := facilitatorLookupError(err); err != nil {
+		return nil, err
+	}
+
+	return activeFacilitatorUser(w, Email), nil
+}
+
+// activeFacilitatorUser returns the facilitator as an active voting game user the way GetActiveUsers would
+func activeFacilitatorUser(w thunderdome.PokerUser, Email string) *thunderdome.PokerUser {
+	w.Active = true
+	w.Abandoned = false
+	w.Spectator = false
+	if Email != "" {
+		w.GravatarHash = db.CreateGravatarHash(Email)
+	} else {
+		w.GravatarHash = db.CreateGravatarHash(w.Id)
+	}
+
+	return &w
+}
+
+// facilitatorLookupError maps the facilitator user lookup result to the error returned from game creation
+func facilitatorLookupError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return thunderdome.ErrUserNotFound
+	default:
+		return errors.New("error creating poker")
+	}
+}
@@ -0,0 +1,117 @@
+package poker
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestFacilitatorLookupError calls facilitatorLookupError with the result of looking up an existing
+// and a nonexistent facilitator and makes sure only the nonexistent one returns ErrUserNotFound
+func TestFacilitatorLookupError(t *testing.T) {
+	if err := facilitatorLookupError(nil); err != nil {
+		t.Fatalf(`expected existing facilitator to be allowed got %v`, err)
+	}
+
+	if err := facilitatorLookupError(sql.ErrNoRows); !errors.Is(err, thunderdome.ErrUserNotFound) {
+		t.Fatalf(`expected nonexistent facilitator to return ErrUserNotFound got %v`, err)
+	}
+
+	if err := facilitatorLookupError(errors.New("connection reset")); err == nil || errors.Is(err, thunderdome.ErrUserNotFound) {
+		t.Fatalf(`expected lookup failure to return a generic error got %v`, err)
+	}
+}
+
+// TestCreateGameInvalidFacilitator calls CreateGame with a malformed facilitator ID
+// and makes sure it fails validation before querying the database
+func TestCreateGameInvalidFacilitator(t *testing.T) {
+	d := &Service{}
+	if _, err := d.CreateGame(context.Background(), "not-a-user", "game", nil, nil, true, "ceil", "", "", false, "points", nil, thunderdome.PokerSettings{}, ""); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected invalid facilitator ID to fail validation got %v`, err)
+	}
+}
+
+// TestActiveFacilitatorUser calls activeFacilitatorUser for a newly created games facilitator
+// and makes sure they're listed as an active voting user like GetActiveUsers would return
+func TestActiveFacilitatorUser(t *testing.T) {
+	w := activeFacilitatorUser(thunderdome.PokerUser{Id: "leader", Name: "Thor", Type: "REGISTERED"}, "thor@asgard.com")
+	if !w.Active || w.Abandoned || w.Spectator {
+		t.Fatalf(`expected facilitator to be an active voting user got %+v`, w)
+	}
+	if w.Id != "leader" || w.Name != "Thor" {
+		t.Fatalf(`expected facilitator details kept got %+v`, w)
+	}
+	if w.GravatarHash == "" || w.GravatarHash == "thor@asgard.com" {
+		t.Fatalf(`expected gravatar hash of the email got %q`, w.GravatarHash)
+	}
+}
+
+// TestAddFacilitatorSpectator calls AddFacilitator for a spectator and for a voting user
+// and makes sure only the voting user is made a facilitator
+func TestAddFacilitatorSpectator(t *testing.T) {
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	SpectatorID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	VoterID := "9c2e4d6f-8a1b-4c3d-9e5f-1a2b3c4d5e6f"
+	spectators := map[string]bool{SpectatorID: true}
+	facilitators := make([]string, 0)
+
+	d, f := newTestService(t)
+	// the facilitator insert only adds users that aren't spectators like its NOT EXISTS guard
+	f.Exec("spectator = true", func(args []driver.Value) (int64, error) {
+		UserID := args[1].(string)
+		if spectators[UserID] {
+			return 0, nil
+		}
+		facilitators = append(facilitators, UserID)
+		return 1, nil
+	})
+	f.Query("SELECT user_id FROM thunderdome.poker_facilitator WHERE poker_id = $1;", []string{"user_id"}, func(args []driver.Value) ([][]driver.Value, error) {
+		values := make([][]driver.Value, 0)
+		for _, UserID := range facilitators {
+			values = append(values, []driver.Value{UserID})
+		}
+		return values, nil
+	})
+
+	if _, err := d.AddFacilitator(PokerID, SpectatorID); !errors.Is(err, thunderdome.ErrSpectatorFacilitator) {
+		t.Fatalf(`expected spectator to be rejected got %v`, err)
+	}
+
+	leaders, err := d.AddFacilitator(PokerID, VoterID)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if len(leaders) != 1 || leaders[0] != VoterID {
+		t.Fatalf(`expected only the voting user as facilitator got %v`, leaders)
+	}
+}
+
+// TestIsFacilitator calls IsFacilitator for a facilitator, a non facilitator, and a game that doesn't exist
+// and makes sure only the facilitator is confirmed and the missing game returns an error
+func TestIsFacilitator(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	MissingPokerID := "5f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+	FacilitatorID := "1e2d3c4b-5a69-4788-9a6b-5c4d3e2f1a0b"
+	UserID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	f.Query("SELECT EXISTS (", []string{"exists"}, func(args []driver.Value) ([][]driver.Value, error) {
+		if args[0] != PokerID {
+			return nil, nil
+		}
+		return [][]driver.Value{{args[1] == FacilitatorID}}, nil
+	})
+
+	if is, err := svc.IsFacilitator(PokerID, FacilitatorID); err != nil || !is {
+		t.Fatalf(`expected the facilitator to be confirmed got %v %v`, is, err)
+	}
+	if is, err := svc.IsFacilitator(PokerID, UserID); err != nil || is {
+		t.Fatalf(`expected the non facilitator not to be confirmed got %v %v`, is, err)
+	}
+	if _, err := svc.IsFacilitator(MissingPokerID, FacilitatorID); err == nil {
+		t.Fatalf(`expected error for a missing game`)
+	}
+}
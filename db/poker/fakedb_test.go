@@ -0,0 +1,53 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/dbtest"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+)
+
+// voteColumns are the columns of the vote mode query SetVote reads before writing a vote
+var voteColumns = []string{"vote_mode", "custom_scale", "votes", "point_values_allowed", "vote_aliases"}
+
+// gameColumns stand in for the columns of the GetGame query
+var gameColumns = make([]string, 27)
+
+// newTestService returns a service backed by a fake database answering the guards of an open, unpaused points game
+// whose users are registered facilitators, tests register the statements they exercise on top
+func newTestService(t *testing.T) (*Service, *dbtest.DB) {
+	f := dbtest.New()
+	f.Rows("COALESCE(archived, false)", []string{"archived"}, []driver.Value{false})
+	f.Rows("COALESCE(archived, false), paused", []string{"archived", "paused"}, []driver.Value{false, false})
+	f.Rows("SELECT type FROM thunderdome.users", []string{"type"}, []driver.Value{"REGISTERED"})
+	f.Query("FROM thunderdome.poker_facilitator WHERE poker_id = $1 AND user_id = $2", []string{"user_id"}, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{args[1]}}, nil
+	})
+	f.Rows("SELECT locked", []string{"locked"}, []driver.Value{false})
+	f.Rows("SELECT parallel_voting", []string{"parallel_voting"}, []driver.Value{false})
+	f.Rows("p.require_named_users", []string{"require_named_users", "name"}, []driver.Value{false, ""})
+	f.Rows("COALESCE(p.vote_mode, 'points')", voteColumns, pointsVoteRow("[]"))
+	f.Rows("p.min_voters_to_finalize", []string{"min_voters_to_finalize", "votes"}, []driver.Value{int64(0), "[]"})
+	f.Affected("p.parallel_voting = true", 0)
+
+	return &Service{DB: f.Open(t), Logger: otelzap.New(zap.NewNop()), HTMLSanitizerPolicy: bluemonday.UGCPolicy()}, f
+}
+
+// pointsVoteRow is the vote mode row of a points game with the stories votes
+func pointsVoteRow(Votes string) []driver.Value {
+	return []driver.Value{"points", "[]", Votes, `["1","2","3","5","8"]`, "{}"}
+}
+
+// gameRow is a GetGame row for a points game with the facilitators json
+func gameRow(PokerID driver.Value, Facilitators string) []driver.Value {
+	now := time.Now()
+	return []driver.Value{
+		PokerID, "Game", true, "", `["1","2","3"]`, true, "ceil", false, "", "", "", now, now, "points",
+		false, "[]", "points", "round-up", int64(0), int64(0), "", int64(1), false, false, false, "{}", Facilitators,
+	}
+}
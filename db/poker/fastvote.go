@@ -0,0 +1,78 @@
+package poker
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// GetFastVoters flags the users whose first vote came sooner than Threshold after voting started
+// on at least MinStories of the games finalized stories e.g. a bot or someone voting without reading,
+// only a facilitator can get the flags, they're advisory and votes are still counted
+func (d *Service) GetFastVoters(PokerID string, FacilitatorID string, Threshold time.Duration, MinStories int) ([]*thunderdome.FastVoter, error) {
+	if err := db.ValidateUUID(PokerID, FacilitatorID); err != nil {
+		return nil, err
+	}
+	if Threshold <= 0 {
+		return nil, fmt.Errorf("%w: threshold must be positive", thunderdome.ErrValidation)
+	}
+	if MinStories < 1 {
+		return nil, fmt.Errorf("%w: min stories must be at least 1", thunderdome.ErrValidation)
+	}
+	if err := d.ConfirmFacilitator(PokerID, FacilitatorID); err != nil {
+		return nil, err
+	}
+
+	stories, events, err := d.getVoteTimings(PokerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return detectFastVoters(stories, events, Threshold, MinStories), nil
+}
+
+// detectFastVoters times each voters first vote on each story from voting starting, flagging the voters
+// with at least MinStories votes faster than Threshold, most fast votes first
+func detectFastVoters(Stories []*thunderdome.Story, Events []*thunderdome.PokerEvent, Threshold time.Duration, MinStories int) []*thunderdome.FastVoter {
+	storyEvents := make(map[string][]*thunderdome.PokerEvent)
+	for _, e := range Events {
+		storyEvents[e.StoryID] = append(storyEvents[e.StoryID], e)
+	}
+
+	voters := make(map[string]*thunderdome.FastVoter)
+	for _, s := range Stories {
+		for UserID, t := range firstVoteTimes(s, storyEvents[s.Id]) {
+			v, ok := voters[UserID]
+			if !ok {
+				v = &thunderdome.FastVoter{UserID: UserID, FastestVote: -1}
+				voters[UserID] = v
+			}
+			elapsed := t.Sub(s.VoteStartTime)
+			v.StoriesVoted++
+			if elapsed < Threshold {
+				v.FastVotes++
+			}
+			if v.FastestVote < 0 || elapsed < v.FastestVote {
+				v.FastestVote = elapsed
+			}
+		}
+	}
+
+	flagged := make([]*thunderdome.FastVoter, 0)
+	for _, v := range voters {
+		if v.FastVotes >= MinStories {
+			flagged = append(flagged, v)
+		}
+	}
+	sort.Slice(flagged, func(i, j int) bool {
+		if flagged[i].FastVotes != flagged[j].FastVotes {
+			return flagged[i].FastVotes > flagged[j].FastVotes
+		}
+		return flagged[i].UserID < flagged[j].UserID
+	})
+
+	return flagged
+}
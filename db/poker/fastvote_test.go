@@ -0,0 +1,50 @@
+package poker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestDetectFastVoters calls detectFastVoters with a voter always voting within seconds of voting starting,
+// one fast only once and one taking their time, making sure only the consistently fast voter is flagged
+func TestDetectFastVoters(t *testing.T) {
+	start := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	stories := make([]*thunderdome.Story, 0)
+	events := make([]*thunderdome.PokerEvent, 0)
+	event := func(StoryID string, UserID string, at time.Time) *thunderdome.PokerEvent {
+		return &thunderdome.PokerEvent{StoryID: StoryID, UserID: UserID, Type: "vote_set", CreatedDate: at}
+	}
+	for i, id := range []string{"one", "two", "three"} {
+		voteStart := start.Add(time.Duration(i) * 10 * time.Minute)
+		stories = append(stories, &thunderdome.Story{Id: id, VoteStartTime: voteStart, FinalizedTime: voteStart.Add(5 * time.Minute)})
+		events = append(events,
+			event(id, "bot", voteStart.Add(time.Second)),
+			event(id, "reader", voteStart.Add(90*time.Second)),
+		)
+		// a quick change of vote after a considered first vote isn't a fast vote
+		events = append(events, event(id, "reader", voteStart.Add(91*time.Second)))
+	}
+	events = append(events,
+		event("one", "skimmer", stories[0].VoteStartTime.Add(2*time.Second)),
+		event("two", "skimmer", stories[1].VoteStartTime.Add(time.Minute)),
+		// a vote from an earlier round before a revote doesn't count
+		event("three", "skimmer", stories[2].VoteStartTime.Add(-time.Second)),
+		event("three", "skimmer", stories[2].VoteStartTime.Add(2*time.Minute)),
+	)
+
+	flagged := detectFastVoters(stories, events, 5*time.Second, 2)
+
+	if len(flagged) != 1 || flagged[0].UserID != "bot" {
+		t.Fatalf(`expected only bot to be flagged got %+v`, flagged)
+	}
+	if flagged[0].StoriesVoted != 3 || flagged[0].FastVotes != 3 || flagged[0].FastestVote != time.Second {
+		t.Fatalf(`expected bot with 3 fast votes on 3 stories fastest 1s got %+v`, flagged[0])
+	}
+
+	flagged = detectFastVoters(stories, events, 5*time.Second, 1)
+	if len(flagged) != 2 || flagged[0].UserID != "bot" || flagged[1].UserID != "skimmer" || flagged[1].FastVotes != 1 {
+		t.Fatalf(`expected bot then skimmer flagged with a lower min stories got %+v`, flagged)
+	}
+}
@@ -0,0 +1,170 @@
+package poker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// FinalizeStoriesBulk sets the same points on each of the stories in a single transaction,
+// the points must be one of the games allowed point values and every story must belong to the game
+// otherwise none of the stories are finalized, as they aren't when any of the stories are locked
+func (d *Service) FinalizeStoriesBulk(PokerID string, FacilitatorID string, StoryIDs []string, Points string) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID, FacilitatorID); err != nil {
+		return nil, err
+	}
+	StoryIDs = uniqueStoryIDs(StoryIDs)
+	if len(StoryIDs) == 0 {
+		return nil, fmt.Errorf("%w: at least one story required", thunderdome.ErrValidation)
+	}
+	if err := db.ValidateUUID(StoryIDs...); err != nil {
+		return nil, err
+	}
+	if err := validateStoryPoints(Points); err != nil {
+		return nil, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+	if err := d.ConfirmFacilitator(PokerID, FacilitatorID); err != nil {
+		return nil, err
+	}
+
+	tx, err := d.DB.Begin()
+	if err != nil {
+		d.Logger.Error("poker bulk finalize begin error", zap.Error(err))
+		return nil, fmt.Errorf("unable to finalize stories: %w", err)
+	}
+	defer tx.Rollback()
+
+	var pv string
+	var PointValuesAllowed = make([]string, 0)
+	if err := tx.QueryRow(
+		`SELECT point_values_allowed FROM thunderdome.poker WHERE id = $1 FOR UPDATE;`, PokerID,
+	).Scan(&pv); err != nil {
+		d.Logger.Error("poker bulk finalize point values error", zap.Error(err))
+		return nil, errors.New("not found")
+	}
+	_ = json.Unmarshal([]byte(pv), &PointValuesAllowed)
+	if err := validateAllowedPoints(PointValuesAllowed, Points); err != nil {
+		return nil, err
+	}
+
+	var Locked bool
+	if err := tx.QueryRow(
+		`SELECT EXISTS (
+			SELECT 1 FROM thunderdome.poker_story WHERE poker_id = $1 AND id = ANY($2) AND locked = true
+		);`,
+		PokerID, StoryIDs,
+	).Scan(&Locked); err != nil {
+		d.Logger.Error("poker bulk finalize locked stories error", zap.Error(err))
+		return nil, fmt.Errorf("unable to finalize stories: %w", err)
+	}
+	if Locked {
+		return nil, thunderdome.ErrStoryLocked
+	}
+
+	result, err := tx.Exec(
+		`UPDATE thunderdome.poker_story SET updated_date = NOW(), active = false, skipped = false, points = $3,
+			points_numeric = thunderdome.poker_points_to_numeric($3), finalized_date = NOW()
+		WHERE poker_id = $1 AND id = ANY($2);`,
+		PokerID, StoryIDs, Points,
+	)
+	if err != nil {
+		d.Logger.Error("poker bulk finalize stories error", zap.Error(err))
+		return nil, fmt.Errorf("unable to finalize stories: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows != int64(len(StoryIDs)) {
+		return nil, thunderdome.ErrStoryNotFound
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE thunderdome.poker SET updated_date = NOW(), last_active = NOW(),
+			active_story_id = CASE WHEN active_story_id = ANY($2) THEN null ELSE active_story_id END
+		WHERE id = $1;`,
+		PokerID, StoryIDs,
+	); err != nil {
+		d.Logger.Error("poker bulk finalize active story reset error", zap.Error(err))
+		return nil, fmt.Errorf("unable to finalize stories: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		d.Logger.Error("poker bulk finalize commit error", zap.Error(err))
+		return nil, fmt.Errorf("unable to finalize stories: %w", err)
+	}
+	d.syncParallelVoting(PokerID)
+
+	plans := d.getStories(d.DB, PokerID, "")
+	d.publishStoriesFinalized(PokerID, StoryIDs, plans)
+
+	return plans, nil
+}
+
+// FinalizeActiveStory finalizes whichever story is the games active story with the points so the facilitator
+// doesn't pass a story ID that could be stale, the points must be one of the games allowed point values,
+// the finalized story ID is returned along with the stories
+func (d *Service) FinalizeActiveStory(PokerID string, FacilitatorID string, Points string) ([]*thunderdome.Story, string, error) {
+	if err := db.ValidateUUID(PokerID, FacilitatorID); err != nil {
+		return nil, "", err
+	}
+	if err := validateStoryPoints(Points); err != nil {
+		return nil, "", err
+	}
+	if err := d.ConfirmFacilitator(PokerID, FacilitatorID); err != nil {
+		return nil, "", err
+	}
+
+	var StoryID string
+	var pv string
+	var PointValuesAllowed = make([]string, 0)
+	if err := d.DB.QueryRow(
+		`SELECT COALESCE(active_story_id::text, ''), point_values_allowed FROM thunderdome.poker WHERE id = $1;`,
+		PokerID,
+	).Scan(&StoryID, &pv); err != nil {
+		d.Logger.Error("get poker active story error", zap.Error(err))
+		return nil, "", errors.New("not found")
+	}
+	if StoryID == "" {
+		return nil, "", thunderdome.ErrNoActiveStory
+	}
+	_ = json.Unmarshal([]byte(pv), &PointValuesAllowed)
+	if err := validateAllowedPoints(PointValuesAllowed, Points); err != nil {
+		return nil, "", err
+	}
+
+	plans, err := d.FinalizeStory(PokerID, StoryID, Points, false)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return plans, StoryID, nil
+}
+
+// validateAllowedPoints checks the points are one of the games allowed point values
+func validateAllowedPoints(PointValuesAllowed []string, Points string) error {
+	if !db.Contains(PointValuesAllowed, Points) {
+		return fmt.Errorf("%w: points %q not in the games point values", thunderdome.ErrValidation, Points)
+	}
+
+	return nil
+}
+
+// uniqueStoryIDs removes duplicate story IDs keeping their order so the updated row count can be checked
+func uniqueStoryIDs(StoryIDs []string) []string {
+	seen := make(map[string]bool, len(StoryIDs))
+	unique := make([]string, 0, len(StoryIDs))
+
+	for _, id := range StoryIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return unique
+}
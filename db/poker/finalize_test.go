@@ -0,0 +1,110 @@
+package poker
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestValidateAllowedPoints calls validateAllowedPoints with points on and off the games scale
+// and makes sure only points off the scale return a validation error
+func TestValidateAllowedPoints(t *testing.T) {
+	scale := []string{"1", "2", "3", "5", "8"}
+
+	if err := validateAllowedPoints(scale, "3"); err != nil {
+		t.Fatalf(`expected no error for points on the scale got %v`, err)
+	}
+	if err := validateAllowedPoints(scale, "4"); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected validation error for points off the scale got %v`, err)
+	}
+}
+
+// TestUniqueStoryIDs calls uniqueStoryIDs with repeated IDs
+// and makes sure duplicates are removed in order so each story counts once toward the updated rows
+func TestUniqueStoryIDs(t *testing.T) {
+	ids := uniqueStoryIDs([]string{"a", "b", "a", "c", "b"})
+
+	if len(ids) != 3 || ids[0] != "a" || ids[1] != "b" || ids[2] != "c" {
+		t.Fatalf(`expected a, b, c got %v`, ids)
+	}
+}
+
+// TestFinalizeStoriesBulkValidatesBeforeWriting calls FinalizeStoriesBulk with invalid input against a closed database
+// and makes sure validation fails before any database call is attempted
+func TestFinalizeStoriesBulkValidatesBeforeWriting(t *testing.T) {
+	closedDB, err := sql.Open("pgx", "postgres://localhost/thunderdome")
+	if err != nil {
+		t.Fatalf(`unexpected error opening database: %v`, err)
+	}
+	_ = closedDB.Close()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	svc := &Service{DB: closedDB, Logger: otelzap.New(zap.New(core))}
+	pokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	storyID := "5b2f4a1e-7c1d-4e3b-8a6f-2d9c0b1e4f77"
+
+	if _, err := svc.FinalizeStoriesBulk(pokerID, pokerID, nil, "3"); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected validation error for no stories got %v`, err)
+	}
+	if _, err := svc.FinalizeStoriesBulk(pokerID, pokerID, []string{storyID, "not-a-uuid"}, "3"); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected validation error for invalid story id got %v`, err)
+	}
+	if _, err := svc.FinalizeStoriesBulk(pokerID, pokerID, []string{storyID}, strings.Repeat("9", storyPointsMaxLength+1)); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected validation error for over-length points got %v`, err)
+	}
+	if logs.Len() != 0 {
+		t.Fatalf(`expected no database calls got %d log entries`, logs.Len())
+	}
+}
+
+// TestFinalizeActiveStory calls FinalizeActiveStory with the games active story and points on and off its scale,
+// then again once no story is active, making sure only the active story is finalized with points on the scale
+func TestFinalizeActiveStory(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	FacilitatorID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	StoryID := "3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b"
+	activeStoryID, finalizedStory, finalizedWith := StoryID, "", ""
+	f.Exec("CALL thunderdome.poker_story_finalize", func(args []driver.Value) (int64, error) {
+		finalizedStory, finalizedWith, activeStoryID = args[1].(string), args[2].(string), ""
+		return 1, nil
+	})
+	f.Query("active_story_id::text", []string{"active_story_id", "point_values_allowed"}, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{activeStoryID, `["1","2","3","5","8"]`}}, nil
+	})
+
+	if _, _, err := svc.FinalizeActiveStory(PokerID, FacilitatorID, "4"); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected validation error for points off the scale got %v`, err)
+	}
+	if finalizedStory != "" {
+		t.Fatalf(`expected no story finalized with points off the scale got %s`, finalizedStory)
+	}
+
+	_, FinalizedID, err := svc.FinalizeActiveStory(PokerID, FacilitatorID, "5")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if FinalizedID != StoryID {
+		t.Fatalf(`expected the finalized story ID %s returned got %s`, StoryID, FinalizedID)
+	}
+	if finalizedStory != StoryID || finalizedWith != "5" {
+		t.Fatalf(`expected active story %s finalized with 5 got %s with %q`, StoryID, finalizedStory, finalizedWith)
+	}
+
+	finalizedStory = ""
+	if _, _, err := svc.FinalizeActiveStory(PokerID, FacilitatorID, "5"); !errors.Is(err, thunderdome.ErrNoActiveStory) {
+		t.Fatalf(`expected ErrNoActiveStory once no story is active got %v`, err)
+	}
+	if finalizedStory != "" {
+		t.Fatalf(`expected no story finalized without an active story got %s`, finalizedStory)
+	}
+}
@@ -0,0 +1,56 @@
+package poker
+
+import (
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// GetVoteFrequency counts how often each vote value was cast across the games finalized stories,
+// stories still being voted on or skipped are left out as are stories whose votes can't be read
+func (d *Service) GetVoteFrequency(PokerID string) (map[string]int, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
+	rows, err := d.DB.Query(
+		`SELECT id, votes FROM thunderdome.poker_story
+		WHERE poker_id = $1 AND active = false AND skipped = false AND COALESCE(points, '') != '';`,
+		PokerID,
+	)
+	if err != nil {
+		d.Logger.Error("get poker vote frequency query error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get vote frequency: %w", err)
+	}
+	defer rows.Close()
+
+	frequency := make(map[string]int)
+	for rows.Next() {
+		var StoryID string
+		var v string
+		if err := rows.Scan(&StoryID, &v); err != nil {
+			d.Logger.Error("get poker vote frequency scan error", zap.Error(err))
+			continue
+		}
+		Votes, err := decodeStoryVotes(v)
+		if err != nil {
+			d.Logger.Error("get poker vote frequency corrupt votes error", zap.String("story_id", StoryID), zap.Error(err))
+			continue
+		}
+		countVoteFrequency(frequency, Votes)
+	}
+
+	return frequency, nil
+}
+
+// countVoteFrequency adds each cast vote value to the frequency
+func countVoteFrequency(Frequency map[string]int, Votes []*thunderdome.Vote) {
+	for _, vote := range Votes {
+		if vote.VoteValue != "" {
+			Frequency[vote.VoteValue]++
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+// frequencyStory is a poker_story row seeded into the fake database
+type frequencyStory struct {
+	id      string
+	points  string
+	active  bool
+	skipped bool
+	votes   string
+}
+
+// TestGetVoteFrequency calls GetVoteFrequency for a game with finalized, active, skipped and corrupt stories
+// and makes sure only the votes cast on the readable finalized stories are counted
+func TestGetVoteFrequency(t *testing.T) {
+	svc, f := newTestService(t)
+	stories := []frequencyStory{
+		{id: "a", points: "3", votes: `[{"warriorId":"thor","vote":"3"},{"warriorId":"loki","vote":"5"},{"warriorId":"sif","vote":"3"}]`},
+		{id: "b", points: "8", votes: `[{"warriorId":"thor","vote":"8"},{"warriorId":"loki","vote":"3"},{"warriorId":"sif","vote":"?"}]`},
+		{id: "c", points: "", active: true, votes: `[{"warriorId":"thor","vote":"13"}]`},
+		{id: "d", points: "", skipped: true, votes: `[{"warriorId":"thor","vote":"1"}]`},
+		{id: "e", points: "5", votes: `not json`},
+	}
+	// the vote frequency query only reads the finalized stories
+	f.Query("active = false AND skipped = false AND COALESCE(points, '') != ''", []string{"id", "votes"}, func(args []driver.Value) ([][]driver.Value, error) {
+		values := make([][]driver.Value, 0)
+		for _, story := range stories {
+			if !story.active && !story.skipped && story.points != "" {
+				values = append(values, []driver.Value{story.id, story.votes})
+			}
+		}
+		return values, nil
+	})
+
+	frequency, err := svc.GetVoteFrequency("0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+
+	expected := map[string]int{"3": 3, "5": 1, "8": 1, "?": 1}
+	if len(frequency) != len(expected) {
+		t.Fatalf(`expected frequency %v got %v`, expected, frequency)
+	}
+	for value, count := range expected {
+		if frequency[value] != count {
+			t.Fatalf(`expected frequency %v got %v`, expected, frequency)
+		}
+	}
+}
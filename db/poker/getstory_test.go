@@ -0,0 +1,76 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// storyColumns are the columns of the single story query
+var storyColumns = []string{
+	"id", "name", "type", "reference_id", "link", "description", "acceptance_criteria", "priority", "points",
+	"active", "skipped", "votestart_time", "voteend_time", "votes", "finalized_date", "updated_date",
+	"points_numeric", "position", "votes_revealed", "locked", "group_id", "vote_reveal_threshold",
+}
+
+// storyRow builds a poker_story row with the votes
+func storyRow(StoryID string, Active bool, Votes string) []driver.Value {
+	now := time.Now()
+	return []driver.Value{
+		StoryID, "Login", "Story", nil, nil, nil, nil, int64(99), "",
+		Active, false, now, now, Votes, nil, now,
+		nil, int64(1), false, false, "", int64(0),
+	}
+}
+
+// TestGetStory calls GetStory for an active story, a finished story, and a story that doesn't exist
+// and makes sure others votes are only hidden on the active story and the missing story returns ErrStoryNotFound
+func TestGetStory(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	votes := `[{"warriorId":"voter","vote":"3"},{"warriorId":"other","vote":"8"}]`
+	stories := map[string][]driver.Value{
+		"3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b": storyRow("3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b", true, votes),
+		"7a6b5c4d-3e2f-4a1b-9c8d-7e6f5a4b3c2d": storyRow("7a6b5c4d-3e2f-4a1b-9c8d-7e6f5a4b3c2d", false, votes),
+	}
+	f.Query("WHERE poker_id = $1 AND id = $2", storyColumns, func(args []driver.Value) ([][]driver.Value, error) {
+		if story, ok := stories[args[1].(string)]; ok {
+			return [][]driver.Value{story}, nil
+		}
+		return nil, nil
+	})
+
+	active, err := svc.GetStory(PokerID, "3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b", "voter")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if !active.Active || len(active.Votes) != 2 {
+		t.Fatalf(`expected active story with 2 votes got %+v`, active)
+	}
+	if active.Votes[0].VoteValue != "3" || active.Votes[1].VoteValue != "" {
+		t.Fatalf(`expected only the users own vote to be visible got %+v %+v`, *active.Votes[0], *active.Votes[1])
+	}
+
+	finished, err := svc.GetStory(PokerID, "7a6b5c4d-3e2f-4a1b-9c8d-7e6f5a4b3c2d", "voter")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if finished.Votes[1].VoteValue != "8" {
+		t.Fatalf(`expected others votes visible once voting is over got %+v`, *finished.Votes[1])
+	}
+
+	if _, err := svc.GetStory(PokerID, "1b2c3d4e-5f6a-4b7c-8d9e-0f1a2b3c4d5e", "voter"); !errors.Is(err, thunderdome.ErrStoryNotFound) {
+		t.Fatalf(`expected ErrStoryNotFound got %v`, err)
+	}
+}
+
+// TestGetStoryInvalidID calls GetStory with a malformed story ID and makes sure it fails validation
+func TestGetStoryInvalidID(t *testing.T) {
+	svc := &Service{}
+	if _, err := svc.GetStory("0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11", "nope", ""); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected validation error got %v`, err)
+	}
+}
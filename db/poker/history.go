@@ -0,0 +1,119 @@
+package poker
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// GetStoriesWithHistory gets the games finalized stories in order with each stories event log entries,
+// loading them together in one query rather than the event log per story, votes are masked like the games
+// other stories and in games that hide voter identity only voters who opted into revealing it are named
+func (d *Service) GetStoriesWithHistory(PokerID string) ([]*thunderdome.StoryWithHistory, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
+	rows, err := d.reader().Query(
+		`SELECT ps.id, ps.name, ps.type, COALESCE(ps.reference_id, ''), COALESCE(ps.link, ''), ps.position,
+			ps.points, ps.points_numeric, ps.votes, ps.finalized_date,
+			e.id, COALESCE(e.user_id::text, ''), e.event_type, e.value, e.created_date,
+			p.hide_voter_identity, p.vote_reveal_threshold
+		FROM thunderdome.poker_story ps
+		JOIN thunderdome.poker p ON p.id = ps.poker_id
+		LEFT JOIN thunderdome.poker_event e ON e.poker_id = ps.poker_id AND e.story_id = ps.id
+		WHERE ps.poker_id = $1 AND ps.active = false AND ps.points <> ''
+		ORDER BY ps.position, ps.created_date, ps.id, e.id;`,
+		PokerID,
+	)
+	if err != nil {
+		d.Logger.Error("get poker stories with history query error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get stories with history: %w", err)
+	}
+	defer rows.Close()
+
+	stories := make([]*thunderdome.StoryWithHistory, 0)
+	var current *thunderdome.StoryWithHistory
+	var HideVoterIdentity bool
+	var RevealThreshold int
+	for rows.Next() {
+		var s thunderdome.Story
+		var v string
+		var PointsNumeric sql.NullFloat64
+		var FinalizedDate sql.NullTime
+		var EventID sql.NullInt64
+		var EventUserID string
+		var EventType sql.NullString
+		var EventValue sql.NullString
+		var EventDate sql.NullTime
+		if err := rows.Scan(
+			&s.Id, &s.Name, &s.Type, &s.ReferenceId, &s.Link, &s.Position,
+			&s.Points, &PointsNumeric, &v, &FinalizedDate,
+			&EventID, &EventUserID, &EventType, &EventValue, &EventDate,
+			&HideVoterIdentity, &RevealThreshold,
+		); err != nil {
+			d.Logger.Error("get poker stories with history scan error", zap.Error(err))
+			return nil, fmt.Errorf("unable to get stories with history: %w", err)
+		}
+
+		// rows are ordered by story so a new story starts once the ID changes
+		if current == nil || current.Story.Id != s.Id {
+			s.FinalizedTime = FinalizedDate.Time
+			if PointsNumeric.Valid {
+				s.PointsNumeric = &PointsNumeric.Float64
+			}
+			if s.Votes, err = decodeStoryVotes(v); err != nil {
+				d.Logger.Error("get poker stories with history corrupt votes error", zap.String("story_id", s.Id), zap.Error(err))
+				s.VotesCorrupt = true
+			}
+			current = &thunderdome.StoryWithHistory{Story: &s, History: make([]*thunderdome.PokerEvent, 0)}
+			stories = append(stories, current)
+		}
+
+		if EventID.Valid {
+			current.History = append(current.History, &thunderdome.PokerEvent{
+				Id:          EventID.Int64,
+				PokerID:     PokerID,
+				StoryID:     s.Id,
+				UserID:      EventUserID,
+				Type:        EventType.String,
+				Value:       EventValue.String,
+				CreatedDate: EventDate.Time,
+			})
+		}
+	}
+
+	revealed := make(map[string]bool)
+	if HideVoterIdentity {
+		for _, u := range d.getUsers(d.reader(), PokerID) {
+			revealed[u.Id] = u.RevealIdentity
+		}
+	}
+	for _, s := range stories {
+		maskStoryVotes(s.Story, "", RevealThreshold)
+		if HideVoterIdentity {
+			hideStoryVoters(s, revealed)
+		}
+	}
+
+	return stories, nil
+}
+
+// hideStoryVoters clears the user of the stories votes and vote events
+// unless the voter opted into revealing their identity
+func hideStoryVoters(Story *thunderdome.StoryWithHistory, Revealed map[string]bool) {
+	for _, v := range Story.Story.Votes {
+		if !Revealed[v.UserId] {
+			v.UserId = ""
+		}
+	}
+	for _, e := range Story.History {
+		if e.Type == "vote_set" && !Revealed[e.UserID] {
+			e.UserID = ""
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+)
+
+// TestGetStoriesWithHistory calls GetStoriesWithHistory with two finalized stories, one with events and one without
+// and makes sure each story comes back once in order with only its own event log entries
+func TestGetStoriesWithHistory(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	now := time.Now()
+
+	story := func(ID string, Position int64, Votes string) []driver.Value {
+		return []driver.Value{ID, "Story " + ID, "Story", "", "", Position, "3", 3.0, Votes, now}
+	}
+	event := func(ID int64, Type string) []driver.Value {
+		return []driver.Value{ID, "u1", Type, "", now, false, int64(0)}
+	}
+	// the stories joined to their event log and game, a story without events has null event columns
+	f.Rows("LEFT JOIN thunderdome.poker_event e", make([]string, 17),
+		append(story("a", 1, `[{"warriorId":"u1","vote":"3"}]`), event(1, "plan_activated")...),
+		append(story("a", 1, `[{"warriorId":"u1","vote":"3"}]`), event(2, "vote_set")...),
+		append(story("a", 1, `[{"warriorId":"u1","vote":"3"}]`), event(5, "plan_finalized")...),
+		append(story("b", 2, `[]`), nil, "", nil, nil, nil, false, int64(0)),
+	)
+
+	stories, err := svc.GetStoriesWithHistory(PokerID)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if len(stories) != 2 || stories[0].Story.Id != "a" || stories[1].Story.Id != "b" {
+		t.Fatalf(`expected stories a and b once each in order got %v`, stories)
+	}
+	if len(stories[0].Story.Votes) != 1 || stories[0].Story.PointsNumeric == nil {
+		t.Fatalf(`expected story a to keep its votes and numeric points got %+v`, stories[0].Story)
+	}
+
+	h := stories[0].History
+	if len(h) != 3 || h[0].Type != "plan_activated" || h[1].Type != "vote_set" || h[2].Type != "plan_finalized" {
+		t.Fatalf(`expected story a to have its 3 events in order got %v`, h)
+	}
+	for _, e := range h {
+		if e.StoryID != "a" || e.PokerID != PokerID {
+			t.Fatalf(`expected event to belong to story a got %+v`, e)
+		}
+	}
+	if stories[1].History == nil || len(stories[1].History) != 0 {
+		t.Fatalf(`expected story b to have an empty history got %v`, stories[1].History)
+	}
+}
+
+// TestGetStoriesWithHistoryHiddenVoters calls GetStoriesWithHistory in a game hiding voter identity with a reveal threshold
+// and makes sure only voters who opted into revealing their identity are named and stories below the threshold show no votes
+func TestGetStoriesWithHistoryHiddenVoters(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	now := time.Now()
+
+	row := func(ID string, Votes string, EventID int64, EventUserID string, EventType string) []driver.Value {
+		return []driver.Value{
+			ID, "Story " + ID, "Story", "", "", int64(1), "3", 3.0, Votes, now,
+			EventID, EventUserID, EventType, "", now, true, int64(2),
+		}
+	}
+	f.Rows("LEFT JOIN thunderdome.poker_event e", make([]string, 17),
+		row("a", `[{"warriorId":"u1","vote":"3"},{"warriorId":"u2","vote":"5"}]`, 1, "u1", "vote_set"),
+		row("a", `[{"warriorId":"u1","vote":"3"},{"warriorId":"u2","vote":"5"}]`, 2, "u2", "vote_set"),
+		row("a", `[{"warriorId":"u1","vote":"3"},{"warriorId":"u2","vote":"5"}]`, 3, "u3", "plan_finalized"),
+		row("b", `[{"warriorId":"u1","vote":"8"}]`, 4, "u3", "plan_finalized"),
+	)
+	f.Rows("FROM thunderdome.poker_user pu", []string{"id", "name", "type", "avatar", "active", "spectator", "email", "reveal_identity"},
+		[]driver.Value{"u1", "Ada", "REGISTERED", "", true, false, "", false},
+		[]driver.Value{"u2", "Grace", "REGISTERED", "", true, false, "", true},
+	)
+
+	stories, err := svc.GetStoriesWithHistory(PokerID)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if len(stories) != 2 {
+		t.Fatalf(`expected 2 stories got %d`, len(stories))
+	}
+
+	votes := stories[0].Story.Votes
+	if len(votes) != 2 || votes[0].UserId != "" || votes[0].VoteValue != "3" || votes[1].UserId != "u2" {
+		t.Fatalf(`expected only u2's vote to be named got %+v %+v`, votes[0], votes[1])
+	}
+	h := stories[0].History
+	if h[0].UserID != "" || h[1].UserID != "u2" || h[2].UserID != "u3" {
+		t.Fatalf(`expected only the hidden voters vote event to lose its user got %q %q %q`, h[0].UserID, h[1].UserID, h[2].UserID)
+	}
+	if len(stories[1].Story.Votes) != 0 {
+		t.Fatalf(`expected story b below the reveal threshold to show no votes got %d`, len(stories[1].Story.Votes))
+	}
+}
@@ -0,0 +1,101 @@
+package poker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// idempotencyKeyTTL is how long a creation idempotency key replays its original result,
+// an expired key is replaced by the next creation using it
+const idempotencyKeyTTL = "24 hours"
+
+// idempotency keys are scoped to the facilitator creating games and to the game stories are added to
+const (
+	idempotencyScopeGame  = "game"
+	idempotencyScopeStory = "story"
+)
+
+// errIdempotencyKeyUsed is returned from a creation transaction to roll it back when the key
+// already created something, including a concurrent retry claiming the key first
+var errIdempotencyKeyUsed = errors.New("IDEMPOTENCY_KEY_USED")
+
+// validateIdempotencyKey checks the optional idempotency key fits the idempotency_key column
+func validateIdempotencyKey(Key string) error {
+	if len(Key) > 128 {
+		return fmt.Errorf("%w: idempotency key must be 128 characters or less", thunderdome.ErrValidation)
+	}
+
+	return nil
+}
+
+// lookupIdempotencyKey gets the game and story created with the unexpired key, found is false for a new or expired key
+func (d *Service) lookupIdempotencyKey(ctx context.Context, tx *sql.Tx, Scope string, ScopeID string, Key string) (PokerID string, StoryID string, found bool, err error) {
+	if Key == "" {
+		return "", "", false, nil
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`SELECT poker_id, COALESCE(story_id::text, '') FROM thunderdome.poker_idempotency_key
+		WHERE scope = $1 AND scope_id = $2 AND idempotency_key = $3 AND created_date > NOW() - $4::interval;`,
+		Scope, ScopeID, Key, idempotencyKeyTTL,
+	).Scan(&PokerID, &StoryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", false, nil
+	}
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("get poker idempotency key query error", zap.Error(err))
+		return "", "", false, fmt.Errorf("unable to check idempotency key: %w", err)
+	}
+
+	return PokerID, StoryID, true, nil
+}
+
+// claimIdempotencyKey stores what the key created replacing an expired key,
+// errIdempotencyKeyUsed is returned when an unexpired key was claimed by a concurrent retry
+func (d *Service) claimIdempotencyKey(ctx context.Context, tx *sql.Tx, Scope string, ScopeID string, Key string, PokerID string, StoryID string) error {
+	if Key == "" {
+		return nil
+	}
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO thunderdome.poker_idempotency_key (scope, scope_id, idempotency_key, poker_id, story_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::uuid)
+		ON CONFLICT (scope, scope_id, idempotency_key) DO UPDATE
+		SET poker_id = EXCLUDED.poker_id, story_id = EXCLUDED.story_id, created_date = NOW()
+		WHERE thunderdome.poker_idempotency_key.created_date <= NOW() - $6::interval;`,
+		Scope, ScopeID, Key, PokerID, StoryID, idempotencyKeyTTL,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("insert poker idempotency key error", zap.Error(err))
+		return fmt.Errorf("unable to store idempotency key: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+		return errIdempotencyKeyUsed
+	}
+
+	return nil
+}
+
+// replayCreatedGame gets the game originally created by the facilitator with the idempotency key
+func (d *Service) replayCreatedGame(ctx context.Context, FacilitatorID string, Key string) (*thunderdome.Poker, error) {
+	var PokerID string
+	if err := d.WithTx(ctx, func(tx *sql.Tx) error {
+		var found bool
+		var err error
+		PokerID, _, found, err = d.lookupIdempotencyKey(ctx, tx, idempotencyScopeGame, FacilitatorID, Key)
+		if err == nil && !found {
+			err = errors.New("error creating poker")
+		}
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return d.GetGame(PokerID, FacilitatorID)
+}
@@ -0,0 +1,166 @@
+package poker
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// idempotencyDB is a fake database standing in for a games stories and the idempotency keys
+// used creating them, keys are stored by scope, scope ID and key and never expire
+type idempotencyDB struct {
+	stories int
+	keys    map[string][2]string
+}
+
+func idempotencyKeyOf(args []driver.Value) string {
+	return fmt.Sprintf("%v/%v/%v", args[0], args[1], args[2])
+}
+
+func newIdempotencyService(t *testing.T) (*Service, *sql.DB, *idempotencyDB) {
+	svc, f := newTestService(t)
+	d := &idempotencyDB{keys: make(map[string][2]string)}
+	f.Exec("INSERT INTO thunderdome.poker_idempotency_key", func(args []driver.Value) (int64, error) {
+		key := idempotencyKeyOf(args)
+		if _, ok := d.keys[key]; ok {
+			return 0, nil
+		}
+		d.keys[key] = [2]string{args[3].(string), args[4].(string)}
+		return 1, nil
+	})
+	f.Query("FROM thunderdome.poker_idempotency_key", []string{"poker_id", "story_id"}, func(args []driver.Value) ([][]driver.Value, error) {
+		if ids, ok := d.keys[idempotencyKeyOf(args)]; ok {
+			return [][]driver.Value{{ids[0], ids[1]}}, nil
+		}
+		return nil, nil
+	})
+	f.Query("INSERT INTO thunderdome.poker_story", []string{"id"}, func(args []driver.Value) ([][]driver.Value, error) {
+		d.stories++
+		return [][]driver.Value{{fmt.Sprintf("story-%d", d.stories)}}, nil
+	})
+
+	return svc, svc.DB, d
+}
+
+// TestCreateStoryIdempotencyKey calls CreateStory repeating and changing the idempotency key
+// and makes sure a repeated key doesn't add the story again while a fresh or empty key does
+func TestCreateStoryIdempotencyKey(t *testing.T) {
+	svc, _, idempotency := newIdempotencyService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+
+	for _, c := range []struct {
+		key     string
+		stories int
+	}{
+		{"retry-1", 1},
+		{"retry-1", 1},
+		{"retry-2", 2},
+		{"", 3},
+		{"", 4},
+	} {
+		if _, err := svc.CreateStory(PokerID, "story", "Story", "", "", "", "", 0, c.key); err != nil {
+			t.Fatalf(`unexpected error creating story with key %q %v`, c.key, err)
+		}
+		if idempotency.stories != c.stories {
+			t.Fatalf(`expected %d stories created after key %q got %d`, c.stories, c.key, idempotency.stories)
+		}
+	}
+	if ids := idempotency.keys["story/"+PokerID+"/retry-1"]; ids != [2]string{PokerID, "story-1"} {
+		t.Fatalf(`expected retry-1 to be stored with the first story got %v`, ids)
+	}
+}
+
+// TestCreateStoryInsertError calls CreateStory when the story insert fails
+// and makes sure the error is returned, the transaction rolled back and the idempotency key left unclaimed
+func TestCreateStoryInsertError(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	f.Rows("FROM thunderdome.poker_idempotency_key", []string{"poker_id", "story_id"})
+	f.Query("INSERT INTO thunderdome.poker_story", []string{"id"}, func(args []driver.Value) ([][]driver.Value, error) {
+		return nil, errors.New("connection reset")
+	})
+
+	if _, err := svc.CreateStory(PokerID, "story", "Story", "", "", "", "", 0, "retry-1"); err == nil {
+		t.Fatalf(`expected error when the story insert fails`)
+	}
+	if f.Commits() != 0 || f.Rollbacks() != 1 {
+		t.Fatalf(`expected the story transaction to roll back got %d commits %d rollbacks`, f.Commits(), f.Rollbacks())
+	}
+	if calls := f.Calls("INSERT INTO thunderdome.poker_idempotency_key"); calls != 0 {
+		t.Fatalf(`expected the idempotency key not to be claimed got %d claims`, calls)
+	}
+}
+
+// TestCreateStoryRetriesDroppedConnection calls CreateStory when the first story insert fails with a dropped connection
+// and makes sure the transaction is retried and the story committed once
+func TestCreateStoryRetriesDroppedConnection(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	f.Rows("FROM thunderdome.poker_idempotency_key", []string{"poker_id", "story_id"})
+	f.Affected("INSERT INTO thunderdome.poker_idempotency_key", 1)
+	inserts := 0
+	f.Query("INSERT INTO thunderdome.poker_story", []string{"id"}, func(args []driver.Value) ([][]driver.Value, error) {
+		inserts++
+		if inserts == 1 {
+			return nil, &pgconn.PgError{Code: "57P01", Message: "terminating connection due to administrator command"}
+		}
+		return [][]driver.Value{{"7c9e6679-7425-40de-944b-e07fc1f90ae7"}}, nil
+	})
+
+	if _, err := svc.CreateStory(PokerID, "story", "Story", "", "", "", "", 0, "retry-1"); err != nil {
+		t.Fatalf(`expected the story to be created after retrying got %v`, err)
+	}
+	if inserts != 2 {
+		t.Fatalf(`expected the story insert to be retried once got %d inserts`, inserts)
+	}
+	if f.Commits() != 1 || f.Rollbacks() != 1 {
+		t.Fatalf(`expected 1 commit and 1 rollback got %d commits %d rollbacks`, f.Commits(), f.Rollbacks())
+	}
+}
+
+// TestGameIdempotencyKey claims a game idempotency key and looks it up again
+// making sure the repeated key finds the original game, a fresh key finds nothing and the claimed key can't be reclaimed
+func TestGameIdempotencyKey(t *testing.T) {
+	svc, DB, _ := newIdempotencyService(t)
+	ctx := context.Background()
+	FacilitatorID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+
+	tx, _ := DB.Begin()
+	defer tx.Rollback()
+
+	if _, _, found, err := svc.lookupIdempotencyKey(ctx, tx, idempotencyScopeGame, FacilitatorID, "retry-1"); err != nil || found {
+		t.Fatalf(`expected a new key not to be found got %v (%v)`, found, err)
+	}
+	if err := svc.claimIdempotencyKey(ctx, tx, idempotencyScopeGame, FacilitatorID, "retry-1", PokerID, ""); err != nil {
+		t.Fatalf(`unexpected error claiming key %v`, err)
+	}
+	if id, _, found, err := svc.lookupIdempotencyKey(ctx, tx, idempotencyScopeGame, FacilitatorID, "retry-1"); err != nil || !found || id != PokerID {
+		t.Fatalf(`expected the repeated key to find game %s got %s %v (%v)`, PokerID, id, found, err)
+	}
+	if err := svc.claimIdempotencyKey(ctx, tx, idempotencyScopeGame, FacilitatorID, "retry-1", "another-game", ""); !errors.Is(err, errIdempotencyKeyUsed) {
+		t.Fatalf(`expected errIdempotencyKeyUsed claiming a used key got %v`, err)
+	}
+	if _, _, found, err := svc.lookupIdempotencyKey(ctx, tx, idempotencyScopeGame, FacilitatorID, "retry-2"); err != nil || found {
+		t.Fatalf(`expected a fresh key not to be found got %v (%v)`, found, err)
+	}
+	if _, _, found, _ := svc.lookupIdempotencyKey(ctx, tx, idempotencyScopeStory, FacilitatorID, "retry-1"); found {
+		t.Fatalf(`expected game keys not to be shared with story keys`)
+	}
+}
+
+// TestValidateIdempotencyKey calls validateIdempotencyKey making sure keys over 128 characters are rejected
+func TestValidateIdempotencyKey(t *testing.T) {
+	if err := validateIdempotencyKey(""); err != nil {
+		t.Fatalf(`expected empty key to be allowed got %v`, err)
+	}
+	if err := validateIdempotencyKey(strings.Repeat("k", 129)); err == nil {
+		t.Fatalf(`expected a 129 character key to be rejected`)
+	}
+}
@@ -0,0 +1,97 @@
+package poker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// storyNameMaxLength is the length of the poker_story name column
+const storyNameMaxLength = 256
+
+// CreateStoriesBulk adds the imported stories to the game, stories with names longer than the name column
+// either reject the whole import listing the offending line numbers or when TruncateNames is set are truncated
+// and their line numbers reported in the result
+func (d *Service) CreateStoriesBulk(PokerID string, Stories []*thunderdome.Story, TruncateNames bool) (*thunderdome.StoryImportResult, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+
+	Truncated, err := prepareStoryImport(Stories, TruncateNames)
+	if err != nil {
+		return nil, err
+	}
+
+	// the stories are added in one transaction so a failed insert doesn't leave a partial import
+	if err := d.WithTx(context.Background(), func(tx *sql.Tx) error {
+		for _, s := range Stories {
+			// default priority should be 99 for sort order purposes
+			if s.Priority == 0 {
+				s.Priority = 99
+			}
+			if _, err := tx.Exec(
+				`INSERT INTO thunderdome.poker_story (poker_id, name, type, reference_id, link, description, acceptance_criteria, priority)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`,
+				PokerID, s.Name, s.Type, s.ReferenceId, s.Link,
+				d.HTMLSanitizerPolicy.Sanitize(s.Description), d.HTMLSanitizerPolicy.Sanitize(s.AcceptanceCriteria), s.Priority,
+			); err != nil {
+				d.Logger.Error("error bulk creating poker story", zap.Error(err))
+				return err
+			}
+		}
+
+		positions, err := d.lockStoryPositions(tx, PokerID)
+		if err != nil {
+			return err
+		}
+
+		return d.updateStoryPositions(tx, compactStoryPositions(positions))
+	}); err != nil {
+		return nil, errors.New("unable to import stories")
+	}
+
+	return &thunderdome.StoryImportResult{
+		Stories:       d.getStories(d.DB, PokerID, ""),
+		TruncatedRows: Truncated,
+	}, nil
+}
+
+// prepareStoryImport finds the 1-based line numbers of stories whose names exceed the name column,
+// truncating them when TruncateNames is set otherwise returning a validation error listing them
+func prepareStoryImport(Stories []*thunderdome.Story, TruncateNames bool) ([]int, error) {
+	overlong := make([]int, 0)
+
+	for i, s := range Stories {
+		if utf8.RuneCountInString(s.Name) <= storyNameMaxLength {
+			continue
+		}
+		overlong = append(overlong, i+1)
+		if TruncateNames {
+			s.Name = string([]rune(s.Name)[:storyNameMaxLength])
+		}
+	}
+
+	if len(overlong) > 0 && !TruncateNames {
+		lines := make([]string, 0, len(overlong))
+		for _, line := range overlong {
+			lines = append(lines, fmt.Sprint(line))
+		}
+		return nil, fmt.Errorf(
+			"%w: story names exceed %d characters on lines %s",
+			thunderdome.ErrValidation, storyNameMaxLength, strings.Join(lines, ", "),
+		)
+	}
+
+	return overlong, nil
+}
@@ -0,0 +1,95 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestPrepareStoryImportReject calls prepareStoryImport without truncation
+// and makes sure the whole batch is rejected with the overlong line numbers
+func TestPrepareStoryImportReject(t *testing.T) {
+	stories := []*thunderdome.Story{
+		{Name: "short"},
+		{Name: strings.Repeat("a", storyNameMaxLength+1)},
+		{Name: strings.Repeat("é", storyNameMaxLength)},
+		{Name: strings.Repeat("b", storyNameMaxLength+10)},
+	}
+
+	_, err := prepareStoryImport(stories, false)
+	if !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected validation error got %v`, err)
+	}
+	if !strings.Contains(err.Error(), "lines 2, 4") {
+		t.Fatalf(`expected lines 2, 4 in error got %v`, err)
+	}
+	if len(stories[1].Name) != storyNameMaxLength+1 {
+		t.Fatalf(`expected rejected names to be left as is`)
+	}
+}
+
+// TestPrepareStoryImportTruncate calls prepareStoryImport with truncation
+// and makes sure overlong names are truncated by character and reported
+func TestPrepareStoryImportTruncate(t *testing.T) {
+	stories := []*thunderdome.Story{
+		{Name: "short"},
+		{Name: strings.Repeat("é", storyNameMaxLength+5)},
+	}
+
+	truncated, err := prepareStoryImport(stories, true)
+	if err != nil {
+		t.Fatalf(`expected no error got %v`, err)
+	}
+	if len(truncated) != 1 || truncated[0] != 2 {
+		t.Fatalf(`expected truncated rows [2] got %v`, truncated)
+	}
+	if stories[1].Name != strings.Repeat("é", storyNameMaxLength) {
+		t.Fatalf(`expected name truncated to %d characters`, storyNameMaxLength)
+	}
+	if stories[0].Name != "short" {
+		t.Fatalf(`expected short name unchanged got %s`, stories[0].Name)
+	}
+}
+
+// TestCreateStoriesBulkTransaction imports stories where one fails to insert and then where all insert
+// and makes sure the failed import is rolled back as a whole while the successful one is committed
+func TestCreateStoriesBulkTransaction(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	f.Exec("INSERT INTO thunderdome.poker_story", func(args []driver.Value) (int64, error) {
+		if args[1] == "Broken" {
+			return 0, errors.New("insert failed")
+		}
+		return 1, nil
+	})
+	f.Affected("FOR UPDATE", 1)
+	f.Rows("SELECT id, position", []string{"id", "position", "estimated"})
+	stories := func(Names ...string) []*thunderdome.Story {
+		s := make([]*thunderdome.Story, 0, len(Names))
+		for _, name := range Names {
+			s = append(s, &thunderdome.Story{Name: name, Type: "Story"})
+		}
+		return s
+	}
+
+	if _, err := svc.CreateStoriesBulk(PokerID, stories("Login", "Broken", "Logout"), false); err == nil {
+		t.Fatalf(`expected error when a story fails to insert`)
+	}
+	if f.Rollbacks() != 1 || f.Commits() != 0 {
+		t.Fatalf(`expected the failed import to be rolled back got %d rollbacks and %d commits`, f.Rollbacks(), f.Commits())
+	}
+	if inserts := f.Calls("INSERT INTO thunderdome.poker_story"); inserts != 2 {
+		t.Fatalf(`expected the import to stop at the failed story got %d inserts`, inserts)
+	}
+
+	result, err := svc.CreateStoriesBulk(PokerID, stories("Login", "Logout"), false)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if result == nil || f.Commits() != 1 {
+		t.Fatalf(`expected the import to be committed got %d commits`, f.Commits())
+	}
+}
@@ -0,0 +1,74 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+
+	"go.uber.org/zap"
+)
+
+// CreateGameInvite creates a revocable invite token for the game that expires at ExpireDate,
+// only the hashed token is stored so the plain token is only available on creation
+func (d *Service) CreateGameInvite(PokerID string, FacilitatorID string, ExpireDate time.Time) (string, error) {
+	if err := db.ValidateUUID(PokerID, FacilitatorID); err != nil {
+		return "", err
+	}
+	if err := d.ConfirmFacilitator(PokerID, FacilitatorID); err != nil {
+		return "", err
+	}
+
+	token, tokenErr := db.RandomBase64String(32)
+	if tokenErr != nil {
+		d.Logger.Error("error generating poker invite token", zap.Error(tokenErr))
+		return "", fmt.Errorf("unable to create poker invite: %w", tokenErr)
+	}
+
+	if _, err := d.DB.Exec(
+		`INSERT INTO thunderdome.poker_invite (id, poker_id, created_by, expire_date) VALUES ($1, $2, $3, $4);`,
+		db.HashString(token), PokerID, FacilitatorID, ExpireDate,
+	); err != nil {
+		d.Logger.Error("insert poker invite error", zap.Error(err))
+		return "", fmt.Errorf("unable to create poker invite: %w", err)
+	}
+
+	return token, nil
+}
+
+// RedeemGameInvite returns the game ID for the invite token if it exists and has not expired
+func (d *Service) RedeemGameInvite(InviteToken string) (string, error) {
+	var PokerID string
+
+	err := d.DB.QueryRow(
+		`SELECT poker_id FROM thunderdome.poker_invite WHERE id = $1 AND expire_date > NOW();`,
+		db.HashString(InviteToken),
+	).Scan(&PokerID)
+	if err != nil {
+		d.Logger.Error("get poker invite error", zap.Error(err))
+		return "", errors.New("INVALID_INVITE")
+	}
+
+	return PokerID, nil
+}
+
+// RevokeGameInvite deletes the invite token from the game by a facilitator so it can no longer be redeemed
+func (d *Service) RevokeGameInvite(PokerID string, FacilitatorID string, InviteToken string) error {
+	if err := db.ValidateUUID(PokerID, FacilitatorID); err != nil {
+		return err
+	}
+	if err := d.ConfirmFacilitator(PokerID, FacilitatorID); err != nil {
+		return err
+	}
+
+	if _, err := d.DB.Exec(
+		`DELETE FROM thunderdome.poker_invite WHERE id = $1 AND poker_id = $2;`,
+		db.HashString(InviteToken), PokerID,
+	); err != nil {
+		d.Logger.Error("delete poker invite error", zap.Error(err))
+		return fmt.Errorf("unable to revoke poker invite: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/dbtest"
+)
+
+// inviteDB answers the invite statements from an in memory poker_invite table keyed by hashed token,
+// treating invites past their expire date as missing like the redeem query does
+func inviteDB(f *dbtest.DB) {
+	type invite struct {
+		PokerID    driver.Value
+		ExpireDate time.Time
+	}
+	invites := make(map[driver.Value]invite)
+
+	f.Exec("INSERT INTO thunderdome.poker_invite", func(args []driver.Value) (int64, error) {
+		invites[args[0]] = invite{PokerID: args[1], ExpireDate: args[3].(time.Time)}
+		return 1, nil
+	})
+	f.Query("FROM thunderdome.poker_invite", []string{"poker_id"}, func(args []driver.Value) ([][]driver.Value, error) {
+		i, ok := invites[args[0]]
+		if !ok || !i.ExpireDate.After(time.Now()) {
+			return nil, nil
+		}
+		return [][]driver.Value{{i.PokerID}}, nil
+	})
+	f.Exec("DELETE FROM thunderdome.poker_invite", func(args []driver.Value) (int64, error) {
+		i, ok := invites[args[0]]
+		if !ok || i.PokerID != args[1] {
+			return 0, nil
+		}
+		delete(invites, args[0])
+		return 1, nil
+	})
+}
+
+// TestRedeemGameInvite creates an invite and makes sure redeeming it returns the game
+// while an unknown token is rejected
+func TestRedeemGameInvite(t *testing.T) {
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	FacilitatorID := "8c3a1f0e-2b4d-4e6f-9a1b-3c5d7e9f1a2b"
+	d, f := newTestService(t)
+	inviteDB(f)
+
+	token, err := d.CreateGameInvite(PokerID, FacilitatorID, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+
+	redeemed, err := d.RedeemGameInvite(token)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if redeemed != PokerID {
+		t.Fatalf(`expected redeemed game %s got %s`, PokerID, redeemed)
+	}
+
+	if _, err := d.RedeemGameInvite("unknown-token"); err == nil || err.Error() != "INVALID_INVITE" {
+		t.Fatalf(`expected INVALID_INVITE for an unknown token got %v`, err)
+	}
+}
+
+// TestRedeemGameInviteExpired creates an already expired invite
+// and makes sure it can't be redeemed
+func TestRedeemGameInviteExpired(t *testing.T) {
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	FacilitatorID := "8c3a1f0e-2b4d-4e6f-9a1b-3c5d7e9f1a2b"
+	d, f := newTestService(t)
+	inviteDB(f)
+
+	token, err := d.CreateGameInvite(PokerID, FacilitatorID, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+
+	if _, err := d.RedeemGameInvite(token); err == nil || err.Error() != "INVALID_INVITE" {
+		t.Fatalf(`expected INVALID_INVITE for an expired token got %v`, err)
+	}
+}
+
+// TestRevokeGameInvite revokes an invite and makes sure it can no longer be redeemed,
+// and that revoking through another game leaves the invite in place
+func TestRevokeGameInvite(t *testing.T) {
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	OtherPokerID := "5f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+	FacilitatorID := "8c3a1f0e-2b4d-4e6f-9a1b-3c5d7e9f1a2b"
+	d, f := newTestService(t)
+	inviteDB(f)
+
+	token, err := d.CreateGameInvite(PokerID, FacilitatorID, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+
+	if err := d.RevokeGameInvite(OtherPokerID, FacilitatorID, token); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if _, err := d.RedeemGameInvite(token); err != nil {
+		t.Fatalf(`expected another games revoke to leave the invite redeemable got %v`, err)
+	}
+
+	if err := d.RevokeGameInvite(PokerID, FacilitatorID, token); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if _, err := d.RedeemGameInvite(token); err == nil || err.Error() != "INVALID_INVITE" {
+		t.Fatalf(`expected INVALID_INVITE for a revoked token got %v`, err)
+	}
+}
+
+// TestRevokeGameInviteNotFacilitator revokes an invite as a user who isn't a facilitator of the game
+// and makes sure the revoke is rejected leaving the invite redeemable
+func TestRevokeGameInviteNotFacilitator(t *testing.T) {
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	FacilitatorID := "8c3a1f0e-2b4d-4e6f-9a1b-3c5d7e9f1a2b"
+	UserID := "3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b"
+	d, f := newTestService(t)
+	inviteDB(f)
+	f.Query("FROM thunderdome.poker_facilitator WHERE poker_id = $1 AND user_id = $2", []string{"user_id"}, func(args []driver.Value) ([][]driver.Value, error) {
+		if args[1] != FacilitatorID {
+			return nil, nil
+		}
+		return [][]driver.Value{{args[1]}}, nil
+	})
+
+	token, err := d.CreateGameInvite(PokerID, FacilitatorID, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if _, err := d.CreateGameInvite(PokerID, UserID, time.Now().Add(time.Hour)); err == nil {
+		t.Fatalf(`expected creating an invite as a non facilitator to fail`)
+	}
+
+	if err := d.RevokeGameInvite(PokerID, UserID, token); err == nil {
+		t.Fatalf(`expected revoking as a non facilitator to fail`)
+	}
+	if _, err := d.RedeemGameInvite(token); err != nil {
+		t.Fatalf(`expected the invite to still be redeemable got %v`, err)
+	}
+}
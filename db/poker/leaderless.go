@@ -0,0 +1,78 @@
+package poker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// PromoteFirstActiveUser makes the most recently seen active non-spectator user the facilitator of a leaderless game
+// e.g. after its facilitators were deleted or merged away, facilitators whose user no longer exists are removed
+// and a game that still has a facilitator is left as is
+func (d *Service) PromoteFirstActiveUser(PokerID string) (*thunderdome.Poker, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
+	var FacilitatorID string
+	err := d.WithTx(context.Background(), func(tx *sql.Tx) error {
+		if _, err := tx.Exec(
+			`DELETE FROM thunderdome.poker_facilitator f
+			WHERE f.poker_id = $1 AND NOT EXISTS (SELECT 1 FROM thunderdome.users u WHERE u.id = f.user_id);`,
+			PokerID,
+		); err != nil {
+			d.Logger.Error("delete poker orphaned facilitators error", zap.Error(err))
+			return err
+		}
+
+		var Facilitators int
+		if err := tx.QueryRow(
+			`SELECT COUNT(*) FROM thunderdome.poker_facilitator WHERE poker_id = $1;`,
+			PokerID,
+		).Scan(&Facilitators); err != nil {
+			d.Logger.Error("get poker facilitator count error", zap.Error(err))
+			return err
+		}
+		if Facilitators > 0 {
+			return nil
+		}
+
+		if err := tx.QueryRow(
+			`INSERT INTO thunderdome.poker_facilitator (poker_id, user_id)
+			SELECT pu.poker_id, pu.user_id FROM thunderdome.poker_user pu
+			JOIN thunderdome.users u ON u.id = pu.user_id
+			WHERE pu.poker_id = $1 AND pu.active = true AND pu.spectator = false
+			ORDER BY pu.last_seen DESC NULLS LAST, u.name, u.id
+			LIMIT 1
+			RETURNING user_id;`,
+			PokerID,
+		).Scan(&FacilitatorID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return thunderdome.ErrNoActiveUsers
+			}
+			d.Logger.Error("promote poker active user error", zap.Error(err))
+			return err
+		}
+
+		return nil
+	})
+	if errors.Is(err, thunderdome.ErrNoActiveUsers) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.New("unable to promote active user")
+	}
+
+	b, err := d.getGame(d.DB, PokerID, FacilitatorID)
+	if err != nil {
+		return nil, err
+	}
+	b.ActiveStoryIDs = activeStoryIDs(b.Stories)
+
+	return b, nil
+}
@@ -0,0 +1,117 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// leaderlessDB is a fake database standing in for a games facilitators and active users,
+// facilitators missing from users stand in for facilitators whose user was deleted
+type leaderlessDB struct {
+	users        map[string]bool
+	facilitators []string
+	active       []string
+}
+
+// existingFacilitators are the facilitators whose user still exists
+func (d *leaderlessDB) existingFacilitators() []string {
+	existing := make([]string, 0)
+	for _, UserID := range d.facilitators {
+		if d.users[UserID] {
+			existing = append(existing, UserID)
+		}
+	}
+	return existing
+}
+
+func newLeaderlessService(t *testing.T) (*Service, *leaderlessDB) {
+	svc, f := newTestService(t)
+	d := &leaderlessDB{}
+	f.Exec("DELETE FROM thunderdome.poker_facilitator f", func(args []driver.Value) (int64, error) {
+		d.facilitators = d.existingFacilitators()
+		return 1, nil
+	})
+	f.Query("SELECT COUNT(*) FROM thunderdome.poker_facilitator", []string{"count"}, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{int64(len(d.facilitators))}}, nil
+	})
+	f.Query("INSERT INTO thunderdome.poker_facilitator", []string{"user_id"}, func(args []driver.Value) ([][]driver.Value, error) {
+		values := make([][]driver.Value, 0)
+		if len(d.active) > 0 {
+			d.facilitators = append(d.facilitators, d.active[0])
+			values = append(values, []driver.Value{d.active[0]})
+		}
+		return values, nil
+	})
+	f.Query("FROM thunderdome.poker b", gameColumns, func(args []driver.Value) ([][]driver.Value, error) {
+		facilitators, _ := json.Marshal(d.existingFacilitators())
+		return [][]driver.Value{gameRow(args[0], string(facilitators))}, nil
+	})
+
+	return svc, d
+}
+
+// TestGetGameLeaderless calls GetGame for games with and without a facilitator whose user still exists
+// and makes sure only the game whose facilitator was deleted is flagged leaderless
+func TestGetGameLeaderless(t *testing.T) {
+	svc, leaderless := newLeaderlessService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	FacilitatorID := "1e2d3c4b-5a69-4788-9a6b-5c4d3e2f1a0b"
+
+	leaderless.users = map[string]bool{FacilitatorID: true}
+	leaderless.facilitators = []string{FacilitatorID}
+	b, err := svc.GetGame(PokerID, "")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if b.Leaderless || len(b.Facilitators) != 1 {
+		t.Fatalf(`expected game with a facilitator not to be leaderless got %v %v`, b.Leaderless, b.Facilitators)
+	}
+
+	delete(leaderless.users, FacilitatorID)
+	if b, err = svc.GetGame(PokerID, ""); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if !b.Leaderless || len(b.Facilitators) != 0 {
+		t.Fatalf(`expected game whose facilitator was deleted to be leaderless got %v %v`, b.Leaderless, b.Facilitators)
+	}
+}
+
+// TestPromoteFirstActiveUser calls PromoteFirstActiveUser on leaderless games with and without active users
+// and on a game that still has a facilitator
+func TestPromoteFirstActiveUser(t *testing.T) {
+	svc, leaderless := newLeaderlessService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	DeletedID := "1e2d3c4b-5a69-4788-9a6b-5c4d3e2f1a0b"
+	UserID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	OtherID := "7a6b5c4d-3e2f-4a1b-9c8d-7e6f5a4b3c2d"
+
+	leaderless.users = map[string]bool{UserID: true, OtherID: true}
+	leaderless.facilitators = []string{DeletedID}
+	leaderless.active = nil
+	if _, err := svc.PromoteFirstActiveUser(PokerID); !errors.Is(err, thunderdome.ErrNoActiveUsers) {
+		t.Fatalf(`expected ErrNoActiveUsers without active users got %v`, err)
+	}
+	if len(leaderless.facilitators) != 0 {
+		t.Fatalf(`expected the deleted facilitator to be removed got %v`, leaderless.facilitators)
+	}
+
+	leaderless.active = []string{UserID, OtherID}
+	b, err := svc.PromoteFirstActiveUser(PokerID)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if b.Leaderless || len(b.Facilitators) != 1 || b.Facilitators[0] != UserID {
+		t.Fatalf(`expected the first active user to be promoted got %v %v`, b.Leaderless, b.Facilitators)
+	}
+
+	if b, err = svc.PromoteFirstActiveUser(PokerID); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if len(b.Facilitators) != 1 || b.Facilitators[0] != UserID {
+		t.Fatalf(`expected a game with a facilitator to be left as is got %v`, b.Facilitators)
+	}
+}
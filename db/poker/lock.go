@@ -0,0 +1,88 @@
+package poker
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// LockStory locks the finalized story so it can't be revised, re-pointed, reactivated, skipped or deleted
+// until the facilitator unlocks it, e.g. once its estimate has been agreed and exported
+func (d *Service) LockStory(PokerID string, StoryID string, FacilitatorID string) ([]*thunderdome.Story, error) {
+	return d.setStoryLocked(PokerID, StoryID, FacilitatorID, true)
+}
+
+// UnlockStory unlocks the story so it can be changed again
+func (d *Service) UnlockStory(PokerID string, StoryID string, FacilitatorID string) ([]*thunderdome.Story, error) {
+	return d.setStoryLocked(PokerID, StoryID, FacilitatorID, false)
+}
+
+// setStoryLocked sets whether the story is locked, only finalized stories can be locked
+func (d *Service) setStoryLocked(PokerID string, StoryID string, FacilitatorID string, Locked bool) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID, StoryID, FacilitatorID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+	if err := d.ConfirmFacilitator(PokerID, FacilitatorID); err != nil {
+		return nil, err
+	}
+
+	var Points string
+	var Skipped bool
+	err := d.DB.QueryRow(
+		`SELECT points, skipped FROM thunderdome.poker_story WHERE poker_id = $1 AND id = $2;`,
+		PokerID, StoryID,
+	).Scan(&Points, &Skipped)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, thunderdome.ErrStoryNotFound
+	}
+	if err != nil {
+		d.Logger.Error("get poker story lock error", zap.Error(err))
+		return nil, fmt.Errorf("unable to lock story: %w", err)
+	}
+	if Locked && (Points == "" || Skipped) {
+		return nil, fmt.Errorf("%w: only finalized stories can be locked", thunderdome.ErrValidation)
+	}
+
+	if _, err := d.DB.Exec(
+		`UPDATE thunderdome.poker_story SET locked = $3, updated_date = NOW() WHERE poker_id = $1 AND id = $2;`,
+		PokerID, StoryID, Locked,
+	); err != nil {
+		d.Logger.Error("update poker story locked error", zap.Error(err))
+		return nil, fmt.Errorf("unable to lock story: %w", err)
+	}
+
+	plans := d.getStories(d.DB, PokerID, "")
+
+	return plans, nil
+}
+
+// ensureStoryUnlocked returns ErrStoryLocked when the story is locked,
+// a story that doesn't exist is left for the change to handle
+func (d *Service) ensureStoryUnlocked(PokerID string, StoryID string) error {
+	var Locked bool
+	err := d.DB.QueryRow(
+		`SELECT locked FROM thunderdome.poker_story WHERE poker_id = $1 AND id = $2;`,
+		PokerID, StoryID,
+	).Scan(&Locked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		d.Logger.Error("get poker story locked error", zap.Error(err))
+		return fmt.Errorf("unable to get story: %w", err)
+	}
+
+	if Locked {
+		return thunderdome.ErrStoryLocked
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/dbtest"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// lockDB is a fake database standing in for a games finalized story and its facilitator,
+// counting the writes made to the story other than locking it
+type lockDB struct {
+	*dbtest.DB
+	locked    bool
+	mutations int
+}
+
+func newLockService(t *testing.T, Points string) (*Service, *lockDB) {
+	svc, f := newTestService(t)
+	lock := &lockDB{DB: f}
+	f.Exec("", func(args []driver.Value) (int64, error) {
+		lock.mutations++
+		return 1, nil
+	})
+	f.Exec("SET locked = $3", func(args []driver.Value) (int64, error) {
+		lock.locked = args[2].(bool)
+		return 1, nil
+	})
+	f.Rows("SELECT points, skipped", []string{"points", "skipped"}, []driver.Value{Points, false})
+	f.Query("SELECT locked", []string{"locked"}, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{lock.locked}}, nil
+	})
+
+	return svc, lock
+}
+
+// TestLockStory locks a finalized story and makes sure revising, re-pointing, reactivating, skipping and deleting it
+// are rejected with ErrStoryLocked without changing it, then allowed again once it's unlocked
+func TestLockStory(t *testing.T) {
+	svc, f := newLockService(t, "5")
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	StoryID := "3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b"
+	FacilitatorID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+
+	mutations := map[string]func() error{
+		"revise": func() error {
+			_, err := svc.UpdateStory(PokerID, StoryID, "renamed", "Story", "", "", "", "", 0)
+			return err
+		},
+		"finalize": func() error {
+			_, err := svc.FinalizeStory(PokerID, StoryID, "8", true)
+			return err
+		},
+		"activate": func() error {
+			_, err := svc.ActivateStoryVoting(PokerID, StoryID)
+			return err
+		},
+		"skip": func() error {
+			_, err := svc.SkipStory(PokerID, StoryID)
+			return err
+		},
+		"delete": func() error {
+			_, err := svc.DeleteStory(PokerID, StoryID)
+			return err
+		},
+	}
+
+	if _, err := svc.LockStory(PokerID, StoryID, FacilitatorID); err != nil || !f.locked {
+		t.Fatalf(`expected the story to be locked got %v`, err)
+	}
+	f.mutations = 0
+	for name, mutate := range mutations {
+		if err := mutate(); !errors.Is(err, thunderdome.ErrStoryLocked) {
+			t.Fatalf(`%s: expected ErrStoryLocked got %v`, name, err)
+		}
+	}
+	if f.mutations != 0 {
+		t.Fatalf(`expected the locked story not to be changed got %d writes`, f.mutations)
+	}
+
+	if _, err := svc.UnlockStory(PokerID, StoryID, FacilitatorID); err != nil || f.locked {
+		t.Fatalf(`expected the story to be unlocked got %v`, err)
+	}
+	for name, mutate := range mutations {
+		if err := mutate(); err != nil {
+			t.Fatalf(`%s: expected the unlocked story to be changed got %v`, name, err)
+		}
+	}
+	if f.mutations == 0 {
+		t.Fatalf(`expected the unlocked story to be changed`)
+	}
+}
+
+// TestLockStoryNotFinalized makes sure a story without an estimate can't be locked
+func TestLockStoryNotFinalized(t *testing.T) {
+	svc, f := newLockService(t, "")
+
+	_, err := svc.LockStory("0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11", "3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b", "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f")
+	if !errors.Is(err, thunderdome.ErrValidation) || f.locked {
+		t.Fatalf(`expected ErrValidation locking an unfinalized story got %v`, err)
+	}
+}
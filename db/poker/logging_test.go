@@ -0,0 +1,41 @@
+package poker
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestServiceLogsErrorsAtErrorLevel calls GetGameDuration against a closed database
+// and makes sure the failure is logged at error level with the error attached
+func TestServiceLogsErrorsAtErrorLevel(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	sdb, err := sql.Open("pgx", "postgres://localhost/thunderdome")
+	if err != nil {
+		t.Fatalf(`unexpected error opening database: %v`, err)
+	}
+	_ = sdb.Close()
+
+	d := &Service{DB: sdb, Logger: otelzap.New(zap.New(core))}
+
+	if _, err := d.GetGameDuration("0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"); err == nil {
+		t.Fatalf(`expected error getting game duration from closed database`)
+	}
+
+	entries := logs.FilterMessage("get poker duration error").All()
+	if len(entries) != 1 {
+		t.Fatalf(`expected 1 logged error got %d`, len(entries))
+	}
+	if entries[0].Level != zapcore.ErrorLevel {
+		t.Fatalf(`expected error level got %v`, entries[0].Level)
+	}
+	if _, ok := entries[0].ContextMap()["error"]; !ok {
+		t.Fatalf(`expected error field to be logged`)
+	}
+}
@@ -0,0 +1,165 @@
+package poker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// MergeUsers moves a duplicate users poker games, facilitator roles and story votes onto the primary user
+// and then deletes the duplicate user, when both users voted on the same story the primary users vote is kept
+func (d *Service) MergeUsers(PrimaryUserID string, DuplicateUserID string) error {
+	if err := db.ValidateUUID(PrimaryUserID, DuplicateUserID); err != nil {
+		return err
+	}
+	if PrimaryUserID == DuplicateUserID {
+		return fmt.Errorf("%w: can't merge a user into itself", thunderdome.ErrValidation)
+	}
+
+	tx, err := d.DB.Begin()
+	if err != nil {
+		d.Logger.Error("poker merge users begin error", zap.Error(err))
+		return fmt.Errorf("unable to merge users: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow(
+		`SELECT COUNT(*) FROM thunderdome.users WHERE id = ANY($1);`,
+		[]string{PrimaryUserID, DuplicateUserID},
+	).Scan(&exists); err != nil {
+		d.Logger.Error("poker merge users lookup error", zap.Error(err))
+		return fmt.Errorf("unable to merge users: %w", err)
+	}
+	if exists != 2 {
+		return errors.New("USER_NOT_FOUND")
+	}
+
+	rows, err := tx.Query(
+		`SELECT id, votes FROM thunderdome.poker_story
+		WHERE votes @> jsonb_build_array(jsonb_build_object('warriorId', $1::text))
+		FOR UPDATE;`,
+		DuplicateUserID,
+	)
+	if err != nil {
+		d.Logger.Error("poker merge users story votes query error", zap.Error(err))
+		return fmt.Errorf("unable to merge users: %w", err)
+	}
+	storyVotes := make(map[string][]*thunderdome.Vote)
+	for rows.Next() {
+		var StoryID string
+		var vs string
+		if err := rows.Scan(&StoryID, &vs); err != nil {
+			rows.Close()
+			d.Logger.Error("poker merge users story votes scan error", zap.Error(err))
+			return fmt.Errorf("unable to merge users: %w", err)
+		}
+		votes, err := decodeStoryVotes(vs)
+		if err != nil {
+			rows.Close()
+			d.Logger.Error("poker merge users story corrupt votes error", zap.String("story_id", StoryID), zap.Error(err))
+			return err
+		}
+		storyVotes[StoryID] = mergeUserVotes(votes, PrimaryUserID, DuplicateUserID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		d.Logger.Error("poker merge users story votes rows error", zap.Error(err))
+		return fmt.Errorf("unable to merge users: %w", err)
+	}
+
+	for StoryID, votes := range storyVotes {
+		votesJSON, _ := json.Marshal(votes)
+		if _, err := tx.Exec(
+			`UPDATE thunderdome.poker_story SET votes = $2, updated_date = NOW() WHERE id = $1;`,
+			StoryID, string(votesJSON),
+		); err != nil {
+			d.Logger.Error("poker merge users story votes update error", zap.Error(err))
+			return fmt.Errorf("unable to merge users: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE thunderdome.poker_user du SET user_id = $1
+		WHERE du.user_id = $2 AND NOT EXISTS (
+			SELECT 1 FROM thunderdome.poker_user pu WHERE pu.poker_id = du.poker_id AND pu.user_id = $1
+		);`,
+		PrimaryUserID, DuplicateUserID,
+	); err != nil {
+		d.Logger.Error("poker merge users poker_user update error", zap.Error(err))
+		return fmt.Errorf("unable to merge users: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE thunderdome.poker_facilitator df SET user_id = $1
+		WHERE df.user_id = $2 AND NOT EXISTS (
+			SELECT 1 FROM thunderdome.poker_facilitator pf WHERE pf.poker_id = df.poker_id AND pf.user_id = $1
+		);`,
+		PrimaryUserID, DuplicateUserID,
+	); err != nil {
+		d.Logger.Error("poker merge users poker_facilitator update error", zap.Error(err))
+		return fmt.Errorf("unable to merge users: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE thunderdome.poker SET owner_id = $1 WHERE owner_id = $2;`,
+		PrimaryUserID, DuplicateUserID,
+	); err != nil {
+		d.Logger.Error("poker merge users poker owner update error", zap.Error(err))
+		return fmt.Errorf("unable to merge users: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE thunderdome.poker_event SET user_id = $1 WHERE user_id = $2;`,
+		PrimaryUserID, DuplicateUserID,
+	); err != nil {
+		d.Logger.Error("poker merge users poker_event update error", zap.Error(err))
+		return fmt.Errorf("unable to merge users: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		`DELETE FROM thunderdome.users WHERE id = $1;`,
+		DuplicateUserID,
+	); err != nil {
+		d.Logger.Error("poker merge users delete duplicate error", zap.Error(err))
+		return fmt.Errorf("unable to merge users: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		d.Logger.Error("poker merge users commit error", zap.Error(err))
+		return fmt.Errorf("unable to merge users: %w", err)
+	}
+
+	return nil
+}
+
+// mergeUserVotes reassigns the duplicate users vote to the primary user,
+// dropping the duplicate users vote when the primary user already voted
+func mergeUserVotes(Votes []*thunderdome.Vote, PrimaryUserID string, DuplicateUserID string) []*thunderdome.Vote {
+	primaryVoted := false
+	for _, v := range Votes {
+		if v.UserId == PrimaryUserID {
+			primaryVoted = true
+			break
+		}
+	}
+
+	merged := make([]*thunderdome.Vote, 0, len(Votes))
+	for _, v := range Votes {
+		if v.UserId == DuplicateUserID {
+			if primaryVoted {
+				continue
+			}
+			v.UserId = PrimaryUserID
+			primaryVoted = true
+		}
+		merged = append(merged, v)
+	}
+
+	return merged
+}
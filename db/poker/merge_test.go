@@ -0,0 +1,54 @@
+package poker
+
+import (
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestMergeUserVotesReassign calls mergeUserVotes where only the duplicate user voted
+// and makes sure the vote is moved to the primary user keeping its value
+func TestMergeUserVotesReassign(t *testing.T) {
+	votes := []*thunderdome.Vote{
+		{UserId: "other", VoteValue: "5"},
+		{UserId: "dup", VoteValue: "3"},
+	}
+
+	merged := mergeUserVotes(votes, "primary", "dup")
+	if len(merged) != 2 {
+		t.Fatalf(`expected 2 votes got %d`, len(merged))
+	}
+	if merged[1].UserId != "primary" || merged[1].VoteValue != "3" {
+		t.Fatalf(`expected duplicate vote of 3 reassigned to primary got %s %s`, merged[1].UserId, merged[1].VoteValue)
+	}
+	if merged[0].UserId != "other" || merged[0].VoteValue != "5" {
+		t.Fatalf(`expected other users vote untouched got %s %s`, merged[0].UserId, merged[0].VoteValue)
+	}
+}
+
+// TestMergeUserVotesConflict calls mergeUserVotes where both users voted on the same story
+// and makes sure only the primary users vote is kept
+func TestMergeUserVotesConflict(t *testing.T) {
+	for _, votes := range [][]*thunderdome.Vote{
+		{{UserId: "primary", VoteValue: "8"}, {UserId: "dup", VoteValue: "3"}},
+		{{UserId: "dup", VoteValue: "3"}, {UserId: "primary", VoteValue: "8"}},
+	} {
+		merged := mergeUserVotes(votes, "primary", "dup")
+		if len(merged) != 1 {
+			t.Fatalf(`expected 1 vote got %d`, len(merged))
+		}
+		if merged[0].UserId != "primary" || merged[0].VoteValue != "8" {
+			t.Fatalf(`expected primary vote of 8 kept got %s %s`, merged[0].UserId, merged[0].VoteValue)
+		}
+	}
+}
+
+// TestMergeUsersValidation calls MergeUsers with the same user twice
+// and makes sure it fails validation before touching the database
+func TestMergeUsersValidation(t *testing.T) {
+	d := &Service{}
+	id := "4c7d3e2a-1a2b-4c3d-8e9f-0a1b2c3d4e5f"
+	if err := d.MergeUsers(id, id); err == nil {
+		t.Fatalf(`expected merging a user into itself to fail`)
+	}
+}
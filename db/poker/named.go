@@ -0,0 +1,42 @@
+package poker
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/user"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// ensureNamedVoter returns thunderdome.ErrUserNameRequired when the game requires named users
+// and the user hasn't set a name of their own
+func (d *Service) ensureNamedVoter(PokerID string, UserID string) error {
+	var RequireNamedUsers bool
+	var Name string
+
+	if err := d.DB.QueryRow(
+		`SELECT p.require_named_users, COALESCE(u.name, '')
+		FROM thunderdome.poker p
+		LEFT JOIN thunderdome.users u ON u.id = $2
+		WHERE p.id = $1;`,
+		PokerID, UserID,
+	).Scan(&RequireNamedUsers, &Name); err != nil {
+		d.Logger.Error("get poker require_named_users error", zap.Error(err))
+		return errors.New("not found")
+	}
+
+	if RequireNamedUsers && !isNamedUser(Name) {
+		return thunderdome.ErrUserNameRequired
+	}
+
+	return nil
+}
+
+// isNamedUser checks the name is one the user chose rather than empty or generated for them as a guest
+func isNamedUser(Name string) bool {
+	Name = strings.TrimSpace(Name)
+
+	return Name != "" && !user.IsGeneratedGuestName(Name)
+}
@@ -0,0 +1,49 @@
+package poker
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+)
+
+// TestCleanOrphanedUsers seeds game users whose user or game was deleted alongside valid game users
+// and makes sure only the orphaned rows are removed and counted
+func TestCleanOrphanedUsers(t *testing.T) {
+	svc, f := newTestService(t)
+	users := map[string]bool{"thor": true, "sif": true}
+	games := map[string]bool{"asgard": true}
+	gameUsers := [][2]string{
+		{"asgard", "thor"},
+		{"asgard", "sif"},
+		{"asgard", "loki"},
+		{"jotunheim", "thor"},
+		{"jotunheim", "ymir"},
+	}
+	// the orphaned game users delete keeps only the game users whose game and user still exist
+	f.Exec("DELETE FROM thunderdome.poker_user pu", func(args []driver.Value) (int64, error) {
+		kept := make([][2]string, 0)
+		for _, gu := range gameUsers {
+			if games[gu[0]] && users[gu[1]] {
+				kept = append(kept, gu)
+			}
+		}
+		removed := len(gameUsers) - len(kept)
+		gameUsers = kept
+		return int64(removed), nil
+	})
+
+	removed, err := svc.CleanOrphanedUsers(context.Background())
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if removed != 3 {
+		t.Fatalf(`expected 3 orphaned game users removed got %d`, removed)
+	}
+	if len(gameUsers) != 2 || gameUsers[0] != [2]string{"asgard", "thor"} || gameUsers[1] != [2]string{"asgard", "sif"} {
+		t.Fatalf(`expected only the valid game users kept got %v`, gameUsers)
+	}
+
+	if removed, err := svc.CleanOrphanedUsers(context.Background()); err != nil || removed != 0 {
+		t.Fatalf(`expected nothing left to clean got %d %v`, removed, err)
+	}
+}
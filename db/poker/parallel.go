@@ -0,0 +1,205 @@
+package poker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// isParallelVoting gets whether the game lets several stories be voted on at once
+func (d *Service) isParallelVoting(PokerID string) (bool, error) {
+	var ParallelVoting bool
+	if err := d.DB.QueryRow(
+		`SELECT parallel_voting FROM thunderdome.poker WHERE id = $1;`,
+		PokerID,
+	).Scan(&ParallelVoting); err != nil {
+		d.Logger.Error("get poker parallel_voting error", zap.Error(err))
+		return false, errors.New("not found")
+	}
+
+	return ParallelVoting, nil
+}
+
+// activateParallelStory starts voting on the story like poker_story_activate without ending voting on the other
+// active stories, except one active in the same group as only one story per group can be voted on at a time,
+// the story becomes the games active story
+func (d *Service) activateParallelStory(PokerID string, StoryID string) error {
+	if err := d.WithTx(context.Background(), func(tx *sql.Tx) error {
+		if _, err := tx.Exec(
+			`UPDATE thunderdome.poker_story ps SET updated_date = NOW(), active = false
+			FROM thunderdome.poker_story s
+			WHERE s.poker_id = $1 AND s.id = $2 AND s.group_id IS NOT NULL
+				AND ps.poker_id = $1 AND ps.id <> $2 AND ps.active = true AND ps.group_id = s.group_id;`,
+			PokerID, StoryID,
+		); err != nil {
+			d.Logger.Error("poker parallel story group deactivate error", zap.Error(err))
+			return err
+		}
+
+		if _, err := tx.Exec(
+			`WITH activated AS (
+				UPDATE thunderdome.poker_story SET updated_date = NOW(), active = true, skipped = false, points = '',
+					points_numeric = null, votestart_time = NOW(), finalized_date = null, votes = '[]'::jsonb, votes_revealed = false
+				WHERE poker_id = $1 AND id = $2
+				RETURNING id
+			)
+			UPDATE thunderdome.poker SET last_active = NOW(), updated_date = NOW(), voting_locked = false, active_story_id = $2
+			WHERE id = $1 AND EXISTS (SELECT 1 FROM activated);`,
+			PokerID, StoryID,
+		); err != nil {
+			d.Logger.Error("poker parallel story activate error", zap.Error(err))
+			return err
+		}
+
+		return nil
+	}); err != nil {
+		return errors.New("unable to activate story")
+	}
+
+	return nil
+}
+
+// syncParallelVoting keeps a parallel voting game open while any story is still active after voting on one ends,
+// moving the active story to the current or latest activated story still being voted on, games without
+// parallel voting are left as the story procedures set them
+func (d *Service) syncParallelVoting(PokerID string) {
+	if _, err := d.DB.Exec(
+		`UPDATE thunderdome.poker p SET
+			active_story_id = COALESCE((
+				SELECT ps.id FROM thunderdome.poker_story ps WHERE ps.poker_id = p.id AND ps.active = true
+				ORDER BY ps.id IS NOT DISTINCT FROM p.active_story_id DESC, ps.votestart_time DESC LIMIT 1
+			), p.active_story_id),
+			voting_locked = NOT EXISTS (
+				SELECT 1 FROM thunderdome.poker_story ps WHERE ps.poker_id = p.id AND ps.active = true
+			)
+		WHERE p.id = $1 AND p.parallel_voting = true;`,
+		PokerID,
+	); err != nil {
+		d.Logger.Error("poker parallel voting sync error", zap.Error(err))
+	}
+}
+
+// GetActiveStoriesTurnout gets who has voted and who is outstanding on each of the games active stories
+// in the order they were activated, for games with parallel voting
+func (d *Service) GetActiveStoriesTurnout(PokerID string) ([]*thunderdome.StoryTurnout, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
+	var u string
+	if err := d.DB.QueryRow(
+		`SELECT COALESCE(json_agg(pu.user_id ORDER BY u.name), '[]')
+		FROM thunderdome.poker_user pu
+		JOIN thunderdome.users u ON u.id = pu.user_id
+		WHERE pu.poker_id = $1 AND pu.active = true AND pu.spectator = false;`,
+		PokerID,
+	).Scan(&u); err != nil {
+		d.Logger.Error("get poker active stories turnout users query error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get active stories turnout: %w", err)
+	}
+	var EligibleUserIDs []string
+	if err := json.Unmarshal([]byte(u), &EligibleUserIDs); err != nil {
+		d.Logger.Error("get poker active stories turnout users error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get active stories turnout: %w", err)
+	}
+
+	rows, err := d.DB.Query(
+		`SELECT id, votes FROM thunderdome.poker_story
+		WHERE poker_id = $1 AND active = true ORDER BY votestart_time, position;`,
+		PokerID,
+	)
+	if err != nil {
+		d.Logger.Error("get poker active stories turnout query error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get active stories turnout: %w", err)
+	}
+	defer rows.Close()
+
+	turnouts := make([]*thunderdome.StoryTurnout, 0)
+	for rows.Next() {
+		var StoryID string
+		var v string
+		if err := rows.Scan(&StoryID, &v); err != nil {
+			d.Logger.Error("get poker active stories turnout scan error", zap.Error(err))
+			return nil, fmt.Errorf("unable to get active stories turnout: %w", err)
+		}
+		Votes, err := decodeStoryVotes(v)
+		if err != nil {
+			d.Logger.Error("get poker active stories turnout corrupt votes error", zap.String("story_id", StoryID), zap.Error(err))
+			return nil, err
+		}
+		turnouts = append(turnouts, calculateTurnout(StoryID, Votes, EligibleUserIDs))
+	}
+
+	return turnouts, nil
+}
+
+// activeStoryIDs lists the stories being voted on keeping their order
+func activeStoryIDs(Stories []*thunderdome.Story) []string {
+	ids := make([]string, 0)
+	for _, s := range Stories {
+		if s.Active {
+			ids = append(ids, s.Id)
+		}
+	}
+
+	return ids
+}
+
+// storyGroupMaxLength is the longest group id a story can be given, matching the group_id column
+const storyGroupMaxLength = 64
+
+// SetStoryGroup moves the story into the parallel voting group, an empty group removes it from its group,
+// an active story can't join a group that already has an active story
+func (d *Service) SetStoryGroup(PokerID string, StoryID string, GroupID string) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+	GroupID = strings.TrimSpace(GroupID)
+	if len(GroupID) > storyGroupMaxLength {
+		return nil, fmt.Errorf("%w: group must be %d characters or less", thunderdome.ErrValidation, storyGroupMaxLength)
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureStoryUnlocked(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+
+	var Active bool
+	if err := d.DB.QueryRow(
+		`SELECT active FROM thunderdome.poker_story WHERE poker_id = $1 AND id = $2;`,
+		PokerID, StoryID,
+	).Scan(&Active); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, thunderdome.ErrStoryNotFound
+		}
+		d.Logger.Error("get poker story active error", zap.Error(err))
+		return nil, fmt.Errorf("unable to set story group: %w", err)
+	}
+
+	res, err := d.DB.Exec(
+		`UPDATE thunderdome.poker_story SET group_id = NULLIF($3, ''), updated_date = NOW()
+		WHERE poker_id = $1 AND id = $2 AND NOT (active AND EXISTS (
+			SELECT 1 FROM thunderdome.poker_story o
+			WHERE o.poker_id = $1 AND o.id <> $2 AND o.active = true AND o.group_id = NULLIF($3, '')
+		));`,
+		PokerID, StoryID, GroupID,
+	)
+	if err != nil {
+		d.Logger.Error("update poker story group_id error", zap.Error(err))
+		return nil, fmt.Errorf("unable to set story group: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err == nil && affected == 0 && Active {
+		return nil, fmt.Errorf("%w: group already has an active story", thunderdome.ErrValidation)
+	}
+
+	return d.getStories(d.DB, PokerID, ""), nil
+}
@@ -0,0 +1,164 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// parallelDB is a fake database standing in for a games stories,
+// activation without parallel voting ends voting on the other stories like poker_story_activate
+// and with parallel voting only ends voting on the other story in the same group
+type parallelDB struct {
+	parallel bool
+	active   map[string]bool
+	votes    map[string]int
+	groups   map[string]string
+}
+
+func newParallelService(t *testing.T) (*Service, *parallelDB) {
+	svc, f := newTestService(t)
+	d := &parallelDB{}
+	f.Exec("CALL thunderdome.poker_story_activate", func(args []driver.Value) (int64, error) {
+		d.active = map[string]bool{args[1].(string): true}
+		return 1, nil
+	})
+	f.Exec("ps.group_id = s.group_id", func(args []driver.Value) (int64, error) {
+		if group := d.groups[args[1].(string)]; group != "" {
+			for StoryID := range d.active {
+				if StoryID != args[1].(string) && d.groups[StoryID] == group {
+					delete(d.active, StoryID)
+				}
+			}
+		}
+		return 1, nil
+	})
+	f.Exec("WITH activated AS", func(args []driver.Value) (int64, error) {
+		d.active[args[1].(string)] = true
+		return 1, nil
+	})
+	f.Exec("p1.active = true", func(args []driver.Value) (int64, error) {
+		if !d.active[args[0].(string)] {
+			return 0, nil
+		}
+		d.votes[args[0].(string)]++
+		return 1, nil
+	})
+	f.Query("SELECT parallel_voting", []string{"parallel_voting"}, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{d.parallel}}, nil
+	})
+
+	return svc, d
+}
+
+// TestParallelVoting activates two stories in a game with and without parallel voting and votes on both,
+// making sure both votes count only with parallel voting
+func TestParallelVoting(t *testing.T) {
+	svc, parallel := newParallelService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	UserID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	first := "3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b"
+	second := "7a6b5c4d-3e2f-4a1b-9c8d-7e6f5a4b3c2d"
+
+	for _, enabled := range []bool{true, false} {
+		parallel.parallel = enabled
+		parallel.active = make(map[string]bool)
+		parallel.votes = make(map[string]int)
+
+		for _, StoryID := range []string{first, second} {
+			if _, err := svc.ActivateStoryVoting(PokerID, StoryID); err != nil {
+				t.Fatalf(`unexpected error activating %s %v`, StoryID, err)
+			}
+		}
+		if parallel.active[first] != enabled || !parallel.active[second] {
+			t.Fatalf(`parallel voting %v: expected first active %v and second active got %v`, enabled, enabled, parallel.active)
+		}
+
+		_, _, firstErr := svc.SetVote(PokerID, UserID, first, "3", "")
+		if _, _, err := svc.SetVote(PokerID, UserID, second, "5", ""); err != nil {
+			t.Fatalf(`parallel voting %v: unexpected error voting on the second story %v`, enabled, err)
+		}
+
+		if enabled {
+			if firstErr != nil || parallel.votes[first] != 1 || parallel.votes[second] != 1 {
+				t.Fatalf(`expected a vote on each active story got %v (%v)`, parallel.votes, firstErr)
+			}
+		} else if !errors.Is(firstErr, thunderdome.ErrVotingClosed) || parallel.votes[first] != 0 {
+			t.Fatalf(`expected the first story to be closed once the second was activated got %v (%v)`, parallel.votes, firstErr)
+		}
+	}
+}
+
+// TestParallelVotingGroups activates stories across two groups in a parallel voting game
+// and makes sure each group keeps only its latest activated story active
+func TestParallelVotingGroups(t *testing.T) {
+	svc, parallel := newParallelService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	frontend1 := "3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b"
+	frontend2 := "7a6b5c4d-3e2f-4a1b-9c8d-7e6f5a4b3c2d"
+	backend1 := "9c8b7a6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+	backend2 := "1e2d3c4b-5a69-4788-9a6b-5c4d3e2f1a0b"
+
+	parallel.parallel = true
+	parallel.active = make(map[string]bool)
+	parallel.votes = make(map[string]int)
+	parallel.groups = map[string]string{frontend1: "frontend", frontend2: "frontend", backend1: "backend", backend2: "backend"}
+
+	for _, StoryID := range []string{frontend1, backend1} {
+		if _, err := svc.ActivateStoryVoting(PokerID, StoryID); err != nil {
+			t.Fatalf(`unexpected error activating %s %v`, StoryID, err)
+		}
+	}
+	if !parallel.active[frontend1] || !parallel.active[backend1] {
+		t.Fatalf(`expected a story active in each group got %v`, parallel.active)
+	}
+
+	if _, err := svc.ActivateStoryVoting(PokerID, frontend2); err != nil {
+		t.Fatalf(`unexpected error activating %s %v`, frontend2, err)
+	}
+	if parallel.active[frontend1] || !parallel.active[frontend2] || !parallel.active[backend1] {
+		t.Fatalf(`expected frontend voting to move to the second story leaving backend active got %v`, parallel.active)
+	}
+
+	if _, err := svc.ActivateStoryVoting(PokerID, backend2); err != nil {
+		t.Fatalf(`unexpected error activating %s %v`, backend2, err)
+	}
+	if count := len(parallel.active); count != 2 || !parallel.active[frontend2] || !parallel.active[backend2] {
+		t.Fatalf(`expected one active story per group got %v`, parallel.active)
+	}
+}
+
+// TestApplySafeGameStateParallel calls applySafeGameState with two active stories
+// and makes sure both are only kept active when the game has parallel voting
+func TestApplySafeGameStateParallel(t *testing.T) {
+	start := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+	game := func(Parallel bool) *thunderdome.Poker {
+		return &thunderdome.Poker{
+			ActiveStoryID:  "b",
+			ParallelVoting: Parallel,
+			Stories: []*thunderdome.Story{
+				{Id: "a", Active: true, VoteStartTime: start},
+				{Id: "b", Active: true, VoteStartTime: start.Add(time.Minute)},
+			},
+		}
+	}
+
+	g := game(true)
+	if applySafeGameState(g) {
+		t.Fatalf(`expected parallel active stories to be consistent`)
+	}
+	if ids := activeStoryIDs(g.Stories); len(ids) != 2 {
+		t.Fatalf(`expected 2 active stories got %v`, ids)
+	}
+
+	g = game(false)
+	if !applySafeGameState(g) {
+		t.Fatalf(`expected multiple active stories without parallel voting to need repair`)
+	}
+	if ids := activeStoryIDs(g.Stories); len(ids) != 1 || ids[0] != "b" {
+		t.Fatalf(`expected only the active story b to stay active got %v`, ids)
+	}
+}
@@ -0,0 +1,149 @@
+package poker
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// participationAttendance is a user that joined one of the team's games
+type participationAttendance struct {
+	HideVoterIdentity bool
+	UserID            string
+	UserName          string
+}
+
+// participationStory is a story from one of the team's games
+type participationStory struct {
+	HideVoterIdentity bool
+	Story             *thunderdome.Story
+}
+
+// GetTeamParticipationReport gets each user's participation across the team's games created between From and To,
+// participation in games that hide voter identity is reported without the user's identity
+func (d *Service) GetTeamParticipationReport(TeamID string, From time.Time, To time.Time) ([]*thunderdome.WarriorParticipation, error) {
+	if err := db.ValidateUUID(TeamID); err != nil {
+		return nil, err
+	}
+
+	attendance := make([]participationAttendance, 0)
+	stories := make([]participationStory, 0)
+
+	userRows, err := d.DB.Query(
+		`SELECT p.hide_voter_identity, u.id, u.name
+		FROM thunderdome.poker_user pu
+		JOIN thunderdome.poker p ON p.id = pu.poker_id
+		JOIN thunderdome.users u ON u.id = pu.user_id
+		WHERE p.team_id = $1 AND p.created_date BETWEEN $2 AND $3;`,
+		TeamID, From, To,
+	)
+	if err != nil {
+		d.Logger.Error("get team poker participation users error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get team participation report: %w", err)
+	}
+	defer userRows.Close()
+
+	for userRows.Next() {
+		var a participationAttendance
+		if err := userRows.Scan(&a.HideVoterIdentity, &a.UserID, &a.UserName); err != nil {
+			d.Logger.Error("get team poker participation users scan error", zap.Error(err))
+			continue
+		}
+		attendance = append(attendance, a)
+	}
+
+	storyRows, err := d.DB.Query(
+		`SELECT p.hide_voter_identity, ps.points, ps.skipped, ps.votes
+		FROM thunderdome.poker_story ps
+		JOIN thunderdome.poker p ON p.id = ps.poker_id
+		WHERE p.team_id = $1 AND p.created_date BETWEEN $2 AND $3;`,
+		TeamID, From, To,
+	)
+	if err != nil {
+		d.Logger.Error("get team poker participation stories error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get team participation report: %w", err)
+	}
+	defer storyRows.Close()
+
+	for storyRows.Next() {
+		var v string
+		var ps = participationStory{
+			Story: &thunderdome.Story{
+				Votes: make([]*thunderdome.Vote, 0),
+			},
+		}
+		if err := storyRows.Scan(&ps.HideVoterIdentity, &ps.Story.Points, &ps.Story.Skipped, &v); err != nil {
+			d.Logger.Error("get team poker participation stories scan error", zap.Error(err))
+			continue
+		}
+		var err error
+		if ps.Story.Votes, err = decodeStoryVotes(v); err != nil {
+			// leave the story out rather than count it as having no votes
+			d.Logger.Error("get team poker participation corrupt votes error", zap.Error(err))
+			continue
+		}
+		stories = append(stories, ps)
+	}
+
+	return calculateTeamParticipation(attendance, stories), nil
+}
+
+// calculateTeamParticipation totals games attended, stories voted, and votes matching the final points per user,
+// participation in games that hide voter identity is combined into a single entry without a user ID or name
+func calculateTeamParticipation(Attendance []participationAttendance, Stories []participationStory) []*thunderdome.WarriorParticipation {
+	participants := make(map[string]*thunderdome.WarriorParticipation)
+	anonymous := &thunderdome.WarriorParticipation{}
+
+	participant := func(HideVoterIdentity bool, UserID string) *thunderdome.WarriorParticipation {
+		if HideVoterIdentity {
+			return anonymous
+		}
+		if _, ok := participants[UserID]; !ok {
+			participants[UserID] = &thunderdome.WarriorParticipation{UserID: UserID}
+		}
+		return participants[UserID]
+	}
+
+	for _, a := range Attendance {
+		p := participant(a.HideVoterIdentity, a.UserID)
+		if !a.HideVoterIdentity {
+			p.UserName = a.UserName
+		}
+		p.GamesAttended++
+	}
+
+	for _, s := range Stories {
+		finalized := s.Story.Points != "" && !s.Story.Skipped
+		for _, v := range s.Story.Votes {
+			if v.VoteValue == "" {
+				continue
+			}
+			p := participant(s.HideVoterIdentity, v.UserId)
+			p.StoriesVoted++
+			if finalized && pointValuesEqual(v.VoteValue, s.Story.Points) {
+				p.ConsensusMatches++
+			}
+		}
+	}
+
+	report := make([]*thunderdome.WarriorParticipation, 0, len(participants)+1)
+	for _, p := range participants {
+		report = append(report, p)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].UserName == report[j].UserName {
+			return report[i].UserID < report[j].UserID
+		}
+		return report[i].UserName < report[j].UserName
+	})
+	if anonymous.GamesAttended > 0 || anonymous.StoriesVoted > 0 {
+		report = append(report, anonymous)
+	}
+
+	return report
+}
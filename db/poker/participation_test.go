@@ -0,0 +1,42 @@
+package poker
+
+import (
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestCalculateTeamParticipation calls calculateTeamParticipation with known attendance and votes
+// and makes sure the per user counts match and anonymous games don't reveal identity
+func TestCalculateTeamParticipation(t *testing.T) {
+	attendance := []participationAttendance{
+		{UserID: "a", UserName: "Alice"},
+		{UserID: "b", UserName: "Bob"},
+		{UserID: "a", UserName: "Alice"},
+		{HideVoterIdentity: true, UserID: "a", UserName: "Alice"},
+		{HideVoterIdentity: true, UserID: "b", UserName: "Bob"},
+	}
+	stories := []participationStory{
+		{Story: &thunderdome.Story{Points: "3", Votes: []*thunderdome.Vote{{UserId: "a", VoteValue: "3"}, {UserId: "b", VoteValue: "5"}}}},
+		{Story: &thunderdome.Story{Points: "½", Votes: []*thunderdome.Vote{{UserId: "a", VoteValue: "0.5"}, {UserId: "b", VoteValue: "1/2"}}}},
+		{Story: &thunderdome.Story{Skipped: true, Votes: []*thunderdome.Vote{{UserId: "a", VoteValue: "8"}}}},
+		{HideVoterIdentity: true, Story: &thunderdome.Story{Points: "2", Votes: []*thunderdome.Vote{{UserId: "a", VoteValue: "2"}, {UserId: "b", VoteValue: "2"}}}},
+	}
+
+	report := calculateTeamParticipation(attendance, stories)
+
+	if len(report) != 3 {
+		t.Fatalf(`expected 3 participants got %d`, len(report))
+	}
+
+	alice, bob, anonymous := report[0], report[1], report[2]
+	if alice.UserID != "a" || alice.GamesAttended != 2 || alice.StoriesVoted != 3 || alice.ConsensusMatches != 2 {
+		t.Fatalf(`expected alice 2 games, 3 voted, 2 matches got %+v`, alice)
+	}
+	if bob.UserID != "b" || bob.GamesAttended != 1 || bob.StoriesVoted != 2 || bob.ConsensusMatches != 1 {
+		t.Fatalf(`expected bob 1 game, 2 voted, 1 match got %+v`, bob)
+	}
+	if anonymous.UserID != "" || anonymous.UserName != "" || anonymous.GamesAttended != 2 || anonymous.StoriesVoted != 2 || anonymous.ConsensusMatches != 2 {
+		t.Fatalf(`expected anonymous 2 games, 2 voted, 2 matches without identity got %+v`, anonymous)
+	}
+}
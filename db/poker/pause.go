@@ -0,0 +1,68 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// PauseGame pauses the game e.g. for a break, votes and story changes are rejected until it's resumed
+func (d *Service) PauseGame(PokerID string, FacilitatorID string) error {
+	return d.setGamePaused(PokerID, FacilitatorID, true)
+}
+
+// ResumeGame resumes the paused game so voting and story changes can continue
+func (d *Service) ResumeGame(PokerID string, FacilitatorID string) error {
+	return d.setGamePaused(PokerID, FacilitatorID, false)
+}
+
+// setGamePaused sets whether the game is paused, archived games can't be paused or resumed
+func (d *Service) setGamePaused(PokerID string, FacilitatorID string, Paused bool) error {
+	if err := db.ValidateUUID(PokerID, FacilitatorID); err != nil {
+		return err
+	}
+	if err := d.ensureNotArchived(PokerID); err != nil {
+		return err
+	}
+	if err := d.ConfirmFacilitator(PokerID, FacilitatorID); err != nil {
+		return err
+	}
+
+	if _, err := d.DB.Exec(
+		`UPDATE thunderdome.poker SET paused = $2, updated_date = NOW() WHERE id = $1;`,
+		PokerID, Paused,
+	); err != nil {
+		d.Logger.Error("update poker paused error", zap.Error(err))
+		return fmt.Errorf("unable to pause poker: %w", err)
+	}
+
+	return nil
+}
+
+// ensureGameOpen returns thunderdome.ErrGameArchived when the game has been archived
+// or thunderdome.ErrGamePaused while the game is paused
+func (d *Service) ensureGameOpen(PokerID string) error {
+	var Archived bool
+	var Paused bool
+
+	if err := d.DB.QueryRow(
+		`SELECT COALESCE(archived, false), paused FROM thunderdome.poker WHERE id = $1;`,
+		PokerID,
+	).Scan(&Archived, &Paused); err != nil {
+		d.Logger.Error("get poker archived error", zap.Error(err))
+		return errors.New("not found")
+	}
+
+	if Archived {
+		return thunderdome.ErrGameArchived
+	}
+	if Paused {
+		return thunderdome.ErrGamePaused
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestPauseGame pauses a game and makes sure votes and story changes are rejected with ErrGamePaused
+// until the game is resumed
+func TestPauseGame(t *testing.T) {
+	svc, f := newTestService(t)
+	var paused bool
+	votes := 0
+	f.Exec("SET paused = $2", func(args []driver.Value) (int64, error) {
+		paused = args[1].(bool)
+		return 1, nil
+	})
+	f.Exec("UPDATE thunderdome.poker_story p1", func(args []driver.Value) (int64, error) {
+		votes++
+		return 1, nil
+	})
+	f.Query("COALESCE(archived, false), paused", []string{"archived", "paused"}, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{false, paused}}, nil
+	})
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	FacilitatorID := "1e2d3c4b-5a69-4788-9a6b-5c4d3e2f1a0b"
+	UserID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	StoryID := "9c8b7a6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+
+	if err := svc.PauseGame(PokerID, FacilitatorID); err != nil {
+		t.Fatalf(`unexpected error pausing %v`, err)
+	}
+	if !paused {
+		t.Fatalf(`expected the game to be paused`)
+	}
+
+	if _, _, err := svc.SetVote(PokerID, UserID, StoryID, "3", ""); !errors.Is(err, thunderdome.ErrGamePaused) {
+		t.Fatalf(`expected vote while paused to return ErrGamePaused got %v`, err)
+	}
+	if _, err := svc.ActivateStoryVoting(PokerID, StoryID); !errors.Is(err, thunderdome.ErrGamePaused) {
+		t.Fatalf(`expected activating a story while paused to return ErrGamePaused got %v`, err)
+	}
+	if _, err := svc.SkipStory(PokerID, StoryID); !errors.Is(err, thunderdome.ErrGamePaused) {
+		t.Fatalf(`expected skipping a story while paused to return ErrGamePaused got %v`, err)
+	}
+	if _, err := svc.DeleteStory(PokerID, StoryID); !errors.Is(err, thunderdome.ErrGamePaused) {
+		t.Fatalf(`expected deleting a story while paused to return ErrGamePaused got %v`, err)
+	}
+	if votes != 0 {
+		t.Fatalf(`expected no votes written while paused got %d`, votes)
+	}
+
+	if err := svc.ResumeGame(PokerID, FacilitatorID); err != nil {
+		t.Fatalf(`unexpected error resuming %v`, err)
+	}
+	if paused {
+		t.Fatalf(`expected the game to be resumed`)
+	}
+	if _, _, err := svc.SetVote(PokerID, UserID, StoryID, "3", ""); err != nil {
+		t.Fatalf(`expected vote once resumed to be counted got %v`, err)
+	}
+	if votes != 1 {
+		t.Fatalf(`expected 1 vote written once resumed got %d`, votes)
+	}
+}
@@ -23,10 +23,36 @@ type Service struct {
 	Logger              *otelzap.Logger
 	AESHashKey          string
 	HTMLSanitizerPolicy *bluemonday.Policy
+	// ReadDB is an optional read replica used by GetGame, GetStories and GetActiveUsers, reads use DB when it's nil
+	ReadDB *sql.DB
+	// Publisher optionally publishes finalized stories to a message queue
+	Publisher thunderdome.PokerEventPublisher
 }
 
-// CreateGame creates a new story pointing session
-func (d *Service) CreateGame(ctx context.Context, FacilitatorID string, Name string, PointValuesAllowed []string, Stories []*thunderdome.Story, AutoFinishVoting bool, PointAverageRounding string, JoinCode string, FacilitatorCode string, HideVoterIdentity bool) (*thunderdome.Poker, error) {
+// reader is the database handle for read only queries, the read replica when configured otherwise the primary
+func (d *Service) reader() *sql.DB {
+	if d.ReadDB != nil {
+		return d.ReadDB
+	}
+
+	return d.DB
+}
+
+// CreateGame creates a new story pointing session, repeating the call with the same non-empty
+// IdempotencyKey within its TTL returns the game originally created instead of a new one
+func (d *Service) CreateGame(ctx context.Context, FacilitatorID string, Name string, PointValuesAllowed []string, Stories []*thunderdome.Story, AutoFinishVoting bool, PointAverageRounding string, JoinCode string, FacilitatorCode string, HideVoterIdentity bool, VoteMode string, CustomScale []thunderdome.ScaleValue, Settings thunderdome.PokerSettings, IdempotencyKey string) (*thunderdome.Poker, error) {
+	if err := db.ValidateUUID(FacilitatorID); err != nil {
+		return nil, err
+	}
+	if err := validateIdempotencyKey(IdempotencyKey); err != nil {
+		return nil, err
+	}
+	settingsColumns, settingsArgs, err := createSettingsUpdate(CustomScale, Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	VoteMode, PointValuesAllowed = normalizeVoteMode(VoteMode, PointValuesAllowed)
 	var pointValuesJSON, _ = json.Marshal(PointValuesAllowed)
 	var encryptedJoinCode string
 	var encryptedLeaderCode string
@@ -59,50 +85,90 @@ func (d *Service) CreateGame(ctx context.Context, FacilitatorID string, Name str
 		Facilitators:         make([]string, 0),
 		JoinCode:             JoinCode,
 		FacilitatorCode:      FacilitatorCode,
+		VoteMode:             VoteMode,
+		EstimationUnit:       thunderdome.EstimationUnitPoints,
+		TieBreakStrategy:     thunderdome.TieBreakRoundUp,
 	}
 	b.Facilitators = append(b.Facilitators, FacilitatorID)
 
-	e := d.DB.QueryRowContext(ctx,
-		`SELECT pokerid FROM thunderdome.poker_create($1, $2, $3, $4, $5, $6, $7, $8, null);`,
-		FacilitatorID,
-		Name,
-		string(pointValuesJSON),
-		AutoFinishVoting,
-		PointAverageRounding,
-		HideVoterIdentity,
-		encryptedJoinCode,
-		encryptedLeaderCode,
-	).Scan(&b.Id)
-	if e != nil {
-		d.Logger.Error("poker_create query error", zap.Error(e))
-		return nil, errors.New("error creating poker")
-	}
+	if err := d.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, _, found, err := d.lookupIdempotencyKey(ctx, tx, idempotencyScopeGame, FacilitatorID, IdempotencyKey); err != nil {
+			return err
+		} else if found {
+			return errIdempotencyKeyUsed
+		}
 
-	for _, plan := range Stories {
-		plan.Votes = make([]*thunderdome.Vote, 0)
+		facilitator, err := d.lockFacilitatorUser(ctx, tx, FacilitatorID)
+		if err != nil {
+			return err
+		}
 
-		e := d.DB.QueryRowContext(ctx,
-			`INSERT INTO thunderdome.poker_story (poker_id, name, type, reference_id, link, description, acceptance_criteria) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
-			b.Id,
-			plan.Name,
-			plan.Type,
-			plan.ReferenceId,
-			plan.Link,
-			plan.Description,
-			plan.AcceptanceCriteria,
-		).Scan(&plan.Id)
-		if e != nil {
-			d.Logger.Error("insert stories error", zap.Error(e))
+		if err := tx.QueryRowContext(ctx,
+			`SELECT pokerid FROM thunderdome.poker_create($1, $2, $3, $4, $5, $6, $7, $8, null, $9);`,
+			FacilitatorID,
+			Name,
+			string(pointValuesJSON),
+			AutoFinishVoting,
+			PointAverageRounding,
+			HideVoterIdentity,
+			encryptedJoinCode,
+			encryptedLeaderCode,
+			VoteMode,
+		).Scan(&b.Id); err != nil {
+			d.Logger.Error("poker_create query error", zap.Error(err))
+			return fmt.Errorf("error creating poker: %w", err)
 		}
+
+		// the facilitator is present as they're creating the game
+		if _, err := d.AddUserTx(ctx, tx, b.Id, FacilitatorID); err != nil {
+			return errors.New("error creating poker")
+		}
+		b.Users = append(b.Users, facilitator)
+
+		for _, plan := range Stories {
+			if err := d.CreateStoryTx(ctx, tx, b.Id, plan); err != nil {
+				return errors.New("error creating poker")
+			}
+		}
+
+		// the settings are part of the creation so a failure doesn't leave a half configured game
+		if err := d.updateGameColumnsTx(ctx, tx, b.Id, settingsColumns, settingsArgs); err != nil {
+			return errors.New("error creating poker")
+		}
+
+		return d.claimIdempotencyKey(ctx, tx, idempotencyScopeGame, FacilitatorID, IdempotencyKey, b.Id, "")
+	}); errors.Is(err, errIdempotencyKeyUsed) {
+		return d.replayCreatedGame(ctx, FacilitatorID, IdempotencyKey)
+	} else if err != nil {
+		return nil, err
 	}
 
 	b.Stories = Stories
+	applyCreateSettings(b, CustomScale, Settings)
+
+	if ShortCode, err := d.assignShortCode(ctx, b.Id); err != nil {
+		d.Logger.Error("poker create short code error", zap.String("poker_id", b.Id), zap.Error(err))
+	} else {
+		b.ShortCode = ShortCode
+	}
 
 	return b, nil
 }
 
-// TeamCreateGame creates a new story pointing session associated to a team
-func (d *Service) TeamCreateGame(ctx context.Context, TeamID string, FacilitatorID string, Name string, PointValuesAllowed []string, Stories []*thunderdome.Story, AutoFinishVoting bool, PointAverageRounding string, JoinCode string, FacilitatorCode string, HideVoterIdentity bool) (*thunderdome.Poker, error) {
+// TeamCreateGame creates a new story pointing session associated to a team, IdempotencyKey works as with CreateGame
+func (d *Service) TeamCreateGame(ctx context.Context, TeamID string, FacilitatorID string, Name string, PointValuesAllowed []string, Stories []*thunderdome.Story, AutoFinishVoting bool, PointAverageRounding string, JoinCode string, FacilitatorCode string, HideVoterIdentity bool, VoteMode string, CustomScale []thunderdome.ScaleValue, Settings thunderdome.PokerSettings, IdempotencyKey string) (*thunderdome.Poker, error) {
+	if err := db.ValidateUUID(TeamID, FacilitatorID); err != nil {
+		return nil, err
+	}
+	if err := validateIdempotencyKey(IdempotencyKey); err != nil {
+		return nil, err
+	}
+	settingsColumns, settingsArgs, err := createSettingsUpdate(CustomScale, Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	VoteMode, PointValuesAllowed = normalizeVoteMode(VoteMode, PointValuesAllowed)
 	var pointValuesJSON, _ = json.Marshal(PointValuesAllowed)
 	var encryptedJoinCode string
 	var encryptedLeaderCode string
@@ -136,51 +202,98 @@ func (d *Service) TeamCreateGame(ctx context.Context, TeamID string, Facilitator
 		JoinCode:             JoinCode,
 		FacilitatorCode:      FacilitatorCode,
 		TeamID:               TeamID,
+		VoteMode:             VoteMode,
+		EstimationUnit:       thunderdome.EstimationUnitPoints,
+		TieBreakStrategy:     thunderdome.TieBreakRoundUp,
 	}
 	b.Facilitators = append(b.Facilitators, FacilitatorID)
 
-	e := d.DB.QueryRowContext(ctx,
-		`SELECT pokerid FROM thunderdome.poker_create($1, $2, $3, $4, $5, $6, $7, $8, $9);`,
-		FacilitatorID,
-		Name,
-		string(pointValuesJSON),
-		AutoFinishVoting,
-		PointAverageRounding,
-		HideVoterIdentity,
-		encryptedJoinCode,
-		encryptedLeaderCode,
-		TeamID,
-	).Scan(&b.Id)
-	if e != nil {
-		d.Logger.Error("team_create_poker query error", zap.Error(e))
-		return nil, errors.New("error creating poker")
-	}
+	if err := d.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, _, found, err := d.lookupIdempotencyKey(ctx, tx, idempotencyScopeGame, FacilitatorID, IdempotencyKey); err != nil {
+			return err
+		} else if found {
+			return errIdempotencyKeyUsed
+		}
+
+		facilitator, err := d.lockFacilitatorUser(ctx, tx, FacilitatorID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.QueryRowContext(ctx,
+			`SELECT pokerid FROM thunderdome.poker_create($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);`,
+			FacilitatorID,
+			Name,
+			string(pointValuesJSON),
+			AutoFinishVoting,
+			PointAverageRounding,
+			HideVoterIdentity,
+			encryptedJoinCode,
+			encryptedLeaderCode,
+			TeamID,
+			VoteMode,
+		).Scan(&b.Id); err != nil {
+			d.Logger.Error("team_create_poker query error", zap.Error(err))
+			return fmt.Errorf("error creating poker: %w", err)
+		}
+
+		// the facilitator is present as they're creating the game
+		if _, err := d.AddUserTx(ctx, tx, b.Id, FacilitatorID); err != nil {
+			return errors.New("error creating poker")
+		}
+		b.Users = append(b.Users, facilitator)
 
-	for _, plan := range Stories {
-		plan.Votes = make([]*thunderdome.Vote, 0)
+		for _, plan := range Stories {
+			if err := d.CreateStoryTx(ctx, tx, b.Id, plan); err != nil {
+				return errors.New("error creating poker")
+			}
+		}
 
-		e := d.DB.QueryRowContext(ctx,
-			`INSERT INTO thunderdome.poker_story (poker_id, name, type, reference_id, link, description, acceptance_criteria) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
-			b.Id,
-			plan.Name,
-			plan.Type,
-			plan.ReferenceId,
-			plan.Link,
-			plan.Description,
-			plan.AcceptanceCriteria,
-		).Scan(&plan.Id)
-		if e != nil {
-			d.Logger.Error("insert stories error", zap.Error(e))
+		// the settings are part of the creation so a failure doesn't leave a half configured game
+		if err := d.updateGameColumnsTx(ctx, tx, b.Id, settingsColumns, settingsArgs); err != nil {
+			return errors.New("error creating poker")
 		}
+
+		return d.claimIdempotencyKey(ctx, tx, idempotencyScopeGame, FacilitatorID, IdempotencyKey, b.Id, "")
+	}); errors.Is(err, errIdempotencyKeyUsed) {
+		return d.replayCreatedGame(ctx, FacilitatorID, IdempotencyKey)
+	} else if err != nil {
+		return nil, err
 	}
 
 	b.Stories = Stories
+	applyCreateSettings(b, CustomScale, Settings)
+
+	if ShortCode, err := d.assignShortCode(ctx, b.Id); err != nil {
+		d.Logger.Error("poker create short code error", zap.String("poker_id", b.Id), zap.Error(err))
+	} else {
+		b.ShortCode = ShortCode
+	}
 
 	return b, nil
 }
 
 // UpdateGame updates the game by ID
-func (d *Service) UpdateGame(PokerID string, Name string, PointValuesAllowed []string, AutoFinishVoting bool, PointAverageRounding string, HideVoterIdentity bool, JoinCode string, FacilitatorCode string, TeamID string) error {
+func (d *Service) UpdateGame(PokerID string, Name string, PointValuesAllowed []string, AutoFinishVoting bool, PointAverageRounding string, HideVoterIdentity bool, JoinCode string, FacilitatorCode string, TeamID string, VoteMode string) error {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return err
+	}
+	if err := d.ensureNotArchived(PokerID); err != nil {
+		return err
+	}
+
+	// keep the stored vote mode when none is given so clients unaware of it don't reset it to points
+	if VoteMode == "" {
+		if err := d.DB.QueryRow(
+			`SELECT COALESCE(vote_mode, 'points') FROM thunderdome.poker WHERE id = $1;`,
+			PokerID,
+		).Scan(&VoteMode); err != nil {
+			d.Logger.Error("get poker vote_mode error", zap.Error(err))
+			return fmt.Errorf("unable to revise poker: %w", err)
+		}
+	}
+
+	VoteMode, PointValuesAllowed = normalizeVoteMode(VoteMode, PointValuesAllowed)
 	var pointValuesJSON, _ = json.Marshal(PointValuesAllowed)
 	var encryptedJoinCode string
 	var encryptedLeaderCode string
@@ -204,13 +317,14 @@ func (d *Service) UpdateGame(PokerID string, Name string, PointValuesAllowed []s
 	if _, err := d.DB.Exec(`
 		UPDATE thunderdome.poker
 		SET name = $2, point_values_allowed = $3, auto_finish_voting = $4, point_average_rounding = $5,
-		 hide_voter_identity = $6, join_code = $7, leader_code = $8, updated_date = NOW(), team_id = NULLIF($9, '')::uuid
+		 hide_voter_identity = $6, join_code = $7, leader_code = $8, updated_date = NOW(), team_id = NULLIF($9, '')::uuid,
+		 vote_mode = $10
 		WHERE id = $1`,
 		PokerID, Name, string(pointValuesJSON), AutoFinishVoting, PointAverageRounding,
-		HideVoterIdentity, encryptedJoinCode, encryptedLeaderCode, TeamID,
+		HideVoterIdentity, encryptedJoinCode, encryptedLeaderCode, TeamID, VoteMode,
 	); err != nil {
 		d.Logger.Error("update poker error", zap.Error(err))
-		return errors.New("unable to revise poker")
+		return fmt.Errorf("unable to revise poker: %w", err)
 	}
 
 	return nil
@@ -218,6 +332,10 @@ func (d *Service) UpdateGame(PokerID string, Name string, PointValuesAllowed []s
 
 // GetFacilitatorCode retrieve the game leader_code
 func (d *Service) GetFacilitatorCode(PokerID string) (string, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return "", err
+	}
+
 	var EncryptedLeaderCode string
 
 	if err := d.DB.QueryRow(`
@@ -226,7 +344,7 @@ func (d *Service) GetFacilitatorCode(PokerID string) (string, error) {
 		PokerID,
 	).Scan(&EncryptedLeaderCode); err != nil {
 		d.Logger.Error("get poker leadercode error", zap.Error(err))
-		return "", errors.New("unable to retrieve poker leader_code")
+		return "", fmt.Errorf("unable to retrieve poker leader_code: %w", err)
 	}
 
 	if EncryptedLeaderCode == "" {
@@ -240,31 +358,72 @@ func (d *Service) GetFacilitatorCode(PokerID string) (string, error) {
 	return DecryptedCode, nil
 }
 
-// GetGame gets a game by ID
+// GetGame gets a game by ID from the read replica when configured
 func (d *Service) GetGame(PokerID string, UserID string) (*thunderdome.Poker, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
+	b, err := d.getGame(d.reader(), PokerID, UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// a lagging replica can look inconsistent so the game is read again from the primary before repairing it
+	if d.reader() != d.DB {
+		if _, _, inconsistent := reconcileGameState(b.ActiveStoryID, b.VotingLocked, b.ParallelVoting, b.Stories); inconsistent {
+			if b, err = d.getGame(d.DB, PokerID, UserID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// self-heal a stale active story or voting lock e.g. from a story deleted mid vote,
+	// the reconciled state is returned even when the repair can't be saved
+	if applySafeGameState(b) {
+		if err := d.applyGameState(PokerID, b.ActiveStoryID, b.VotingLocked, b.ParallelVoting); err == nil {
+			b.Stories = d.getStories(d.DB, PokerID, UserID)
+			if Version, err := d.getGameVersion(PokerID); err == nil {
+				b.Version = Version
+			}
+		}
+	}
+	b.ActiveStoryIDs = activeStoryIDs(b.Stories)
+
+	return b, nil
+}
+
+// getGame gets a game by ID with its users and stories using the database handle
+func (d *Service) getGame(q *sql.DB, PokerID string, UserID string) (*thunderdome.Poker, error) {
 	var b = &thunderdome.Poker{
 		Id:                 PokerID,
 		Users:              make([]*thunderdome.PokerUser, 0),
 		Stories:            make([]*thunderdome.Story, 0),
 		VotingLocked:       true,
 		PointValuesAllowed: make([]string, 0),
+		CustomScale:        make([]thunderdome.ScaleValue, 0),
+		VoteAliases:        make(map[string]string),
 		AutoFinishVoting:   true,
 		Facilitators:       make([]string, 0),
 	}
 
 	// get game
 	var pv string
+	var cs string
+	var va string
 	var facilitators string
 	var JoinCode string
 	var FacilitatorCode string
-	e := d.DB.QueryRow(
+	e := q.QueryRow(
 		`
 		SELECT b.id, b.name, b.voting_locked, COALESCE(b.active_story_id::text, ''), b.point_values_allowed, b.auto_finish_voting, 
 		b.point_average_rounding, b.hide_voter_identity, COALESCE(b.join_code, ''), COALESCE(b.leader_code, ''),
-		 COALESCE(b.team_id::text, ''), b.created_date, b.updated_date,
+		 COALESCE(b.team_id::text, ''), b.created_date, b.updated_date, COALESCE(b.vote_mode, 'points'),
+		 COALESCE(b.archived, false), COALESCE(b.custom_scale, '[]'::jsonb), b.estimation_unit, b.tie_break_strategy, b.min_voters_to_finalize, b.vote_reveal_threshold, COALESCE(b.short_code, ''), b.version, b.parallel_voting, b.require_named_users, b.paused, COALESCE(b.vote_aliases, '{}'::jsonb),
 		CASE WHEN COUNT(bl) = 0 THEN '[]'::json ELSE array_to_json(array_agg(bl.user_id)) END AS leaders
 		FROM thunderdome.poker b
 		LEFT JOIN thunderdome.poker_facilitator bl ON b.id = bl.poker_id
+			AND EXISTS (SELECT 1 FROM thunderdome.users fu WHERE fu.id = bl.user_id)
 		WHERE b.id = $1
 		GROUP BY b.id`,
 		PokerID,
@@ -282,6 +441,19 @@ func (d *Service) GetGame(PokerID string, UserID string) (*thunderdome.Poker, er
 		&b.TeamID,
 		&b.CreatedDate,
 		&b.UpdatedDate,
+		&b.VoteMode,
+		&b.Archived,
+		&cs,
+		&b.EstimationUnit,
+		&b.TieBreakStrategy,
+		&b.MinVotersToFinalize,
+		&b.VoteRevealThreshold,
+		&b.ShortCode,
+		&b.Version,
+		&b.ParallelVoting,
+		&b.RequireNamedUsers,
+		&b.Paused,
+		&va,
 		&facilitators,
 	)
 	if e != nil {
@@ -291,6 +463,10 @@ func (d *Service) GetGame(PokerID string, UserID string) (*thunderdome.Poker, er
 
 	_ = json.Unmarshal([]byte(facilitators), &b.Facilitators)
 	_ = json.Unmarshal([]byte(pv), &b.PointValuesAllowed)
+	_ = json.Unmarshal([]byte(cs), &b.CustomScale)
+	_ = json.Unmarshal([]byte(va), &b.VoteAliases)
+	// facilitators whose user was deleted or merged away aren't listed, leaving nobody able to run the game
+	b.Leaderless = len(b.Facilitators) == 0
 
 	isFacilitator := db.Contains(b.Facilitators, UserID)
 
@@ -310,14 +486,63 @@ func (d *Service) GetGame(PokerID string, UserID string) (*thunderdome.Poker, er
 		b.FacilitatorCode = DecryptedCode
 	}
 
-	b.Users = d.GetUsers(PokerID)
-	b.Stories = d.GetStories(PokerID, UserID)
+	b.Users = d.getUsers(q, PokerID)
+	b.Stories = d.getStories(q, PokerID, UserID)
 
 	return b, nil
 }
 
+// GetGameIfChanged gets the game when its version differs from the KnownVersion,
+// returning false without loading the game when it hasn't changed
+func (d *Service) GetGameIfChanged(PokerID string, UserID string, KnownVersion int64) (*thunderdome.Poker, bool, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, false, err
+	}
+
+	Version, err := d.getGameVersion(PokerID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !gameVersionChanged(Version, KnownVersion) {
+		return nil, false, nil
+	}
+
+	b, err := d.GetGame(PokerID, UserID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return b, true, nil
+}
+
+// getGameVersion gets the games current version which is bumped on every change to the game
+func (d *Service) getGameVersion(PokerID string) (int64, error) {
+	var Version int64
+
+	err := d.DB.QueryRow(
+		`SELECT version FROM thunderdome.poker WHERE id = $1;`,
+		PokerID,
+	).Scan(&Version)
+	if err != nil {
+		d.Logger.Error("get poker version error", zap.Error(err))
+		return 0, errors.New("not found")
+	}
+
+	return Version, nil
+}
+
+// gameVersionChanged compares versions for inequality rather than ordering
+// so a client holding a version from a restored database still refetches
+func gameVersionChanged(CurrentVersion int64, KnownVersion int64) bool {
+	return CurrentVersion != KnownVersion
+}
+
 // GetGamesByUser gets a list of games by UserID
 func (d *Service) GetGamesByUser(UserID string, Limit int, Offset int) ([]*thunderdome.Poker, int, error) {
+	if err := db.ValidateUUID(UserID); err != nil {
+		return nil, 0, err
+	}
+
 	var Count int
 	var games = make([]*thunderdome.Poker, 0)
 
@@ -390,12 +615,16 @@ func (d *Service) GetGamesByUser(UserID string, Limit int, Offset int) ([]*thund
 
 // ConfirmFacilitator confirms the user is a facilitator of the game
 func (d *Service) ConfirmFacilitator(PokerID string, UserID string) error {
+	if err := db.ValidateUUID(PokerID, UserID); err != nil {
+		return err
+	}
+
 	var facilitatorID string
 	var role string
 	err := d.DB.QueryRow("SELECT type FROM thunderdome.users WHERE id = $1", UserID).Scan(&role)
 	if err != nil {
 		d.Logger.Error("error getting user role", zap.Error(err))
-		return errors.New("unable to get user role")
+		return fmt.Errorf("unable to get user role: %w", err)
 	}
 
 	e := d.DB.QueryRow("SELECT user_id FROM thunderdome.poker_facilitator WHERE poker_id = $1 AND user_id = $2", PokerID, UserID).Scan(&facilitatorID)
@@ -407,8 +636,33 @@ func (d *Service) ConfirmFacilitator(PokerID string, UserID string) error {
 	return nil
 }
 
+// IsFacilitator checks whether the user is a facilitator of the game without loading the game
+func (d *Service) IsFacilitator(PokerID string, UserID string) (bool, error) {
+	if err := db.ValidateUUID(PokerID, UserID); err != nil {
+		return false, err
+	}
+
+	var isFacilitator bool
+	err := d.DB.QueryRow(
+		`SELECT EXISTS (
+			SELECT 1 FROM thunderdome.poker_facilitator pf WHERE pf.poker_id = p.id AND pf.user_id = $2
+		) FROM thunderdome.poker p WHERE p.id = $1;`,
+		PokerID, UserID,
+	).Scan(&isFacilitator)
+	if err != nil {
+		d.Logger.Error("is poker facilitator query error", zap.Error(err))
+		return false, errors.New("not found")
+	}
+
+	return isFacilitator, nil
+}
+
 // GetUserActiveStatus checks game active status of User
 func (d *Service) GetUserActiveStatus(PokerID string, UserID string) error {
+	if err := db.ValidateUUID(PokerID, UserID); err != nil {
+		return err
+	}
+
 	var active bool
 
 	e := d.DB.QueryRow(`
@@ -431,12 +685,21 @@ func (d *Service) GetUserActiveStatus(PokerID string, UserID string) error {
 	return nil
 }
 
-// GetUsers retrieves the users for a given game
+// GetUsers retrieves the users for a given game, an invalid game ID gets none
 func (d *Service) GetUsers(PokerID string) []*thunderdome.PokerUser {
+	return d.getUsers(d.DB, PokerID)
+}
+
+// getUsers retrieves the users for given game using the database handle, an invalid game ID gets none
+func (d *Service) getUsers(q *sql.DB, PokerID string) []*thunderdome.PokerUser {
 	var users = make([]*thunderdome.PokerUser, 0)
-	rows, err := d.DB.Query(
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return users
+	}
+
+	rows, err := q.Query(
 		`SELECT
-			u.id, u.name, u.type, u.avatar, pu.active, pu.spectator, COALESCE(u.email, '')
+			u.id, u.name, u.type, u.avatar, pu.active, pu.spectator, COALESCE(u.email, ''), pu.reveal_identity
 		FROM thunderdome.poker_user pu
 		LEFT JOIN thunderdome.users u ON pu.user_id = u.id
 		WHERE pu.poker_id = $1
@@ -447,7 +710,7 @@ func (d *Service) GetUsers(PokerID string) []*thunderdome.PokerUser {
 		defer rows.Close()
 		for rows.Next() {
 			var w thunderdome.PokerUser
-			if err := rows.Scan(&w.Id, &w.Name, &w.Type, &w.Avatar, &w.Active, &w.Spectator, &w.GravatarHash); err != nil {
+			if err := rows.Scan(&w.Id, &w.Name, &w.Type, &w.Avatar, &w.Active, &w.Spectator, &w.GravatarHash, &w.RevealIdentity); err != nil {
 				d.Logger.Error("error getting poker users", zap.Error(err))
 			} else {
 				if w.GravatarHash != "" {
@@ -463,12 +726,21 @@ func (d *Service) GetUsers(PokerID string) []*thunderdome.PokerUser {
 	return users
 }
 
-// GetActiveUsers retrieves the active users for a given game
+// GetActiveUsers retrieves the active users for a given game from the read replica when configured, an invalid game ID gets none
 func (d *Service) GetActiveUsers(PokerID string) []*thunderdome.PokerUser {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return make([]*thunderdome.PokerUser, 0)
+	}
+
+	return d.getActiveUsers(d.reader(), PokerID)
+}
+
+// getActiveUsers retrieves the active users for given game using the database handle
+func (d *Service) getActiveUsers(q *sql.DB, PokerID string) []*thunderdome.PokerUser {
 	var users = make([]*thunderdome.PokerUser, 0)
-	rows, err := d.DB.Query(
+	rows, err := q.Query(
 		`SELECT
-			w.id, w.name, w.type, w.avatar, bw.active, bw.spectator, COALESCE(w.email, '')
+			w.id, w.name, w.type, w.avatar, bw.active, bw.spectator, COALESCE(w.email, ''), bw.reveal_identity
 		FROM thunderdome.poker_user bw
 		LEFT JOIN thunderdome.users w ON bw.user_id = w.id
 		WHERE bw.poker_id = $1 AND bw.active = true
@@ -479,7 +751,7 @@ func (d *Service) GetActiveUsers(PokerID string) []*thunderdome.PokerUser {
 		defer rows.Close()
 		for rows.Next() {
 			var w thunderdome.PokerUser
-			if err := rows.Scan(&w.Id, &w.Name, &w.Type, &w.Avatar, &w.Active, &w.Spectator, &w.GravatarHash); err != nil {
+			if err := rows.Scan(&w.Id, &w.Name, &w.Type, &w.Avatar, &w.Active, &w.Spectator, &w.GravatarHash, &w.RevealIdentity); err != nil {
 				d.Logger.Error("error getting active poker users", zap.Error(err))
 			} else {
 				if w.GravatarHash != "" {
@@ -495,25 +767,44 @@ func (d *Service) GetActiveUsers(PokerID string) []*thunderdome.PokerUser {
 	return users
 }
 
-// AddUser adds a user by ID to the game by ID
-func (d *Service) AddUser(PokerID string, UserID string) ([]*thunderdome.PokerUser, error) {
-	if _, err := d.DB.Exec(
-		`INSERT INTO thunderdome.poker_user (poker_id, user_id, active)
-		VALUES ($1, $2, true)
-		ON CONFLICT (poker_id, user_id) DO UPDATE SET active = true, abandoned = false`,
+// CountActiveUsers counts the active non spectator users in the game, the number expected to vote
+func (d *Service) CountActiveUsers(PokerID string) (int, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := d.DB.QueryRow(
+		`SELECT COUNT(*) FROM thunderdome.poker_user WHERE poker_id = $1 AND active = true AND spectator = false;`,
 		PokerID,
-		UserID,
-	); err != nil {
-		d.Logger.Error("error adding user to poker", zap.Error(err))
+	).Scan(&count); err != nil {
+		d.Logger.Error("count active poker users error", zap.Error(err))
+		return 0, fmt.Errorf("unable to count active poker users: %w", err)
 	}
 
-	users := d.GetUsers(PokerID)
+	return count, nil
+}
 
-	return users, nil
+// AddUser adds a user by ID to the game by ID, reporting whether they are new to the game or rejoining
+func (d *Service) AddUser(PokerID string, UserID string) ([]*thunderdome.PokerUser, bool, error) {
+	if err := db.ValidateUUID(PokerID, UserID); err != nil {
+		return nil, false, err
+	}
+
+	// a failed insert is logged and the current users still returned
+	isNew, _ := d.addUser(context.Background(), d.DB, PokerID, UserID)
+
+	users := d.getUsers(d.DB, PokerID)
+
+	return users, isNew, nil
 }
 
-// RetreatUser removes a user from the current game by ID
+// RetreatUser removes a user from the current game by ID, invalid IDs leave the game alone and get no users
 func (d *Service) RetreatUser(PokerID string, UserID string) []*thunderdome.PokerUser {
+	if err := db.ValidateUUID(PokerID, UserID); err != nil {
+		return make([]*thunderdome.PokerUser, 0)
+	}
+
 	if _, err := d.DB.Exec(
 		`UPDATE thunderdome.poker_user SET active = false WHERE poker_id = $1 AND user_id = $2`, PokerID, UserID); err != nil {
 		d.Logger.Error("error updating poker user to active false", zap.Error(err))
@@ -524,13 +815,17 @@ func (d *Service) RetreatUser(PokerID string, UserID string) []*thunderdome.Poke
 		d.Logger.Error("error updating user last active timestamp", zap.Error(err))
 	}
 
-	users := d.GetUsers(PokerID)
+	users := d.getUsers(d.DB, PokerID)
 
 	return users
 }
 
 // AbandonGame removes a user from the current game by ID and sets abandoned true
 func (d *Service) AbandonGame(PokerID string, UserID string) ([]*thunderdome.PokerUser, error) {
+	if err := db.ValidateUUID(PokerID, UserID); err != nil {
+		return nil, err
+	}
+
 	if _, err := d.DB.Exec(
 		`UPDATE thunderdome.poker_user SET active = false, abandoned = true WHERE poker_id = $1 AND user_id = $2`, PokerID, UserID); err != nil {
 		d.Logger.Error("error updating game user to abandoned", zap.Error(err))
@@ -543,20 +838,31 @@ func (d *Service) AbandonGame(PokerID string, UserID string) ([]*thunderdome.Pok
 		return nil, err
 	}
 
-	users := d.GetUsers(PokerID)
+	users := d.getUsers(d.DB, PokerID)
 
 	return users, nil
 }
 
-// AddFacilitator makes a user a facilitator of the game
+// AddFacilitator makes a user a facilitator of the game, spectators must stop spectating before they can facilitate
 func (d *Service) AddFacilitator(PokerID string, UserID string) ([]string, error) {
+	if err := db.ValidateUUID(PokerID, UserID); err != nil {
+		return nil, err
+	}
+
 	facilitators := make([]string, 0)
 
-	if _, err := d.DB.Exec(
-		`INSERT INTO thunderdome.poker_facilitator (poker_id, user_id) VALUES ($1, $2);`,
-		PokerID, UserID); err != nil {
+	result, err := d.DB.Exec(
+		`INSERT INTO thunderdome.poker_facilitator (poker_id, user_id)
+		SELECT $1, $2 WHERE NOT EXISTS (
+			SELECT 1 FROM thunderdome.poker_user WHERE poker_id = $1 AND user_id = $2 AND spectator = true
+		);`,
+		PokerID, UserID)
+	if err != nil {
 		d.Logger.Error("set poker facilitator query error", zap.Error(err))
-		return nil, errors.New("unable to make facilitator")
+		return nil, fmt.Errorf("unable to make facilitator: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, thunderdome.ErrSpectatorFacilitator
 	}
 
 	rows, facilitatorErr := d.DB.Query(`
@@ -583,13 +889,17 @@ func (d *Service) AddFacilitator(PokerID string, UserID string) ([]string, error
 
 // RemoveFacilitator removes a user from game facilitators
 func (d *Service) RemoveFacilitator(PokerID string, UserID string) ([]string, error) {
+	if err := db.ValidateUUID(PokerID, UserID); err != nil {
+		return nil, err
+	}
+
 	facilitators := make([]string, 0)
 
 	if _, err := d.DB.Exec(
 		`DELETE FROM thunderdome.poker_facilitator WHERE poker_id = $1 AND user_id = $2;`,
 		PokerID, UserID); err != nil {
 		d.Logger.Error("delete poker_facilitator query error", zap.Error(err))
-		return nil, errors.New("unable to delete facilitator")
+		return nil, fmt.Errorf("unable to delete facilitator: %w", err)
 	}
 
 	rows, facilitatorErr := d.DB.Query(`
@@ -616,6 +926,10 @@ func (d *Service) RemoveFacilitator(PokerID string, UserID string) ([]string, er
 
 // ToggleSpectator changes a game users spectator status
 func (d *Service) ToggleSpectator(PokerID string, UserID string, Spectator bool) ([]*thunderdome.PokerUser, error) {
+	if err := db.ValidateUUID(PokerID, UserID); err != nil {
+		return nil, err
+	}
+
 	if _, err := d.DB.Exec(
 		`UPDATE thunderdome.poker_user SET spectator = $3 WHERE poker_id = $1 AND user_id = $2`, PokerID, UserID, Spectator); err != nil {
 		d.Logger.Error("update poker user spectator error", zap.Error(err))
@@ -627,13 +941,34 @@ func (d *Service) ToggleSpectator(PokerID string, UserID string, Spectator bool)
 		d.Logger.Error("error updating user last active timestamp", zap.Error(err))
 	}
 
-	users := d.GetUsers(PokerID)
+	users := d.getUsers(d.DB, PokerID)
+
+	return users, nil
+}
+
+// SetUserRevealIdentity sets whether the user shows who they are with their votes in a game that hides voter identity
+func (d *Service) SetUserRevealIdentity(PokerID string, UserID string, RevealIdentity bool) ([]*thunderdome.PokerUser, error) {
+	if err := db.ValidateUUID(PokerID, UserID); err != nil {
+		return nil, err
+	}
+
+	if _, err := d.DB.Exec(
+		`UPDATE thunderdome.poker_user SET reveal_identity = $3 WHERE poker_id = $1 AND user_id = $2`, PokerID, UserID, RevealIdentity); err != nil {
+		d.Logger.Error("update poker user reveal_identity error", zap.Error(err))
+		return nil, err
+	}
+
+	users := d.getUsers(d.DB, PokerID)
 
 	return users, nil
 }
 
 // DeleteGame removes all game associations and the game itself by PokerID
 func (d *Service) DeleteGame(PokerID string) error {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return err
+	}
+
 	if _, err := d.DB.Exec(
 		`DELETE FROM thunderdome.poker WHERE id = $1;`, PokerID); err != nil {
 		d.Logger.Error("delete poker error", zap.Error(err))
@@ -645,6 +980,10 @@ func (d *Service) DeleteGame(PokerID string) error {
 
 // AddFacilitatorsByEmail adds additional game facilitators by email
 func (d *Service) AddFacilitatorsByEmail(ctx context.Context, PokerID string, FacilitatorEmails []string) ([]string, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
 	var facilitators string
 	var newFacilitators []string
 
@@ -659,7 +998,7 @@ func (d *Service) AddFacilitatorsByEmail(ctx context.Context, PokerID string, Fa
 	).Scan(&facilitators)
 	if e != nil {
 		d.Logger.Error("poker_facilitator_add_by_email query error", zap.Error(e))
-		return nil, errors.New("error adding poker facilitator by email")
+		return nil, fmt.Errorf("error adding poker facilitator by email: %w", e)
 	}
 
 	_ = json.Unmarshal([]byte(facilitators), &newFacilitators)
@@ -805,3 +1144,22 @@ func (d *Service) PurgeOldGames(ctx context.Context, DaysOld int) error {
 
 	return nil
 }
+
+// CleanOrphanedUsers deletes game user rows whose user or game no longer exists e.g. after a restore or merge,
+// returning how many were removed
+func (d *Service) CleanOrphanedUsers(ctx context.Context) (int, error) {
+	res, err := d.DB.ExecContext(ctx,
+		`DELETE FROM thunderdome.poker_user pu
+		WHERE NOT EXISTS (SELECT 1 FROM thunderdome.users u WHERE u.id = pu.user_id)
+			OR NOT EXISTS (SELECT 1 FROM thunderdome.poker p WHERE p.id = pu.poker_id);`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error attempting to clean orphaned poker users: %v", err)
+	}
+	removed, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error attempting to clean orphaned poker users: %v", err)
+	}
+
+	return int(removed), nil
+}
@@ -0,0 +1,188 @@
+package poker
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestUpdateGameKeepsVoteMode revises a fist-of-five game without a vote mode like the game page does
+// and makes sure the stored vote mode and its values are kept, while a given vote mode still replaces it
+func TestUpdateGameKeepsVoteMode(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	storedMode := thunderdome.PokerVoteModeFistOfFive
+	var savedMode, savedPoints string
+	f.Query("SELECT COALESCE(vote_mode, 'points') FROM thunderdome.poker", []string{"vote_mode"}, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{storedMode}}, nil
+	})
+	f.Exec("SET name = $2, point_values_allowed = $3", func(args []driver.Value) (int64, error) {
+		savedPoints, savedMode = args[2].(string), args[9].(string)
+		return 1, nil
+	})
+
+	for _, mode := range []string{thunderdome.PokerVoteModeFistOfFive, thunderdome.PokerVoteModeEffortComplexity} {
+		storedMode = mode
+		if err := svc.UpdateGame(PokerID, "Revised", []string{"1", "2", "3"}, false, "ceil", false, "", "", "", ""); err != nil {
+			t.Fatalf(`unexpected error %v`, err)
+		}
+		if savedMode != mode {
+			t.Fatalf(`expected the stored vote mode %s to be kept got %s`, mode, savedMode)
+		}
+	}
+	storedMode = thunderdome.PokerVoteModeFistOfFive
+	if err := svc.UpdateGame(PokerID, "Revised", []string{"1", "2", "3"}, false, "ceil", false, "", "", "", ""); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if savedPoints != `["0","1","2","3","4","5"]` {
+		t.Fatalf(`expected the fist-of-five values to be kept got %s`, savedPoints)
+	}
+
+	if err := svc.UpdateGame(PokerID, "Revised", []string{"1", "2", "3"}, false, "ceil", false, "", "", "", thunderdome.PokerVoteModePoints); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if savedMode != thunderdome.PokerVoteModePoints || savedPoints != `["1","2","3"]` {
+		t.Fatalf(`expected the given points vote mode to replace the stored mode got %s %s`, savedMode, savedPoints)
+	}
+}
+
+// TestAddUserIsNew adds a user to a game twice and makes sure the first join is reported as new
+// and the rejoin as returning, with the user listed in the game both times
+func TestAddUserIsNew(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	UserID := "8c3a1f0e-2b4d-4e6f-9a1b-3c5d7e9f1a2b"
+	members := make(map[driver.Value]bool)
+	f.Query("INSERT INTO thunderdome.poker_user", []string{"inserted"}, func(args []driver.Value) ([][]driver.Value, error) {
+		inserted := !members[args[1]]
+		members[args[1]] = true
+		return [][]driver.Value{{inserted}}, nil
+	})
+	f.Query("FROM thunderdome.poker_user pu", []string{"id", "name", "type", "avatar", "active", "spectator", "email", "reveal_identity"}, func(args []driver.Value) ([][]driver.Value, error) {
+		values := make([][]driver.Value, 0)
+		for id := range members {
+			values = append(values, []driver.Value{id, "Thor", "REGISTERED", "identicon", true, false, "", false})
+		}
+		return values, nil
+	})
+
+	users, isNew, err := svc.AddUser(PokerID, UserID)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if !isNew {
+		t.Fatalf(`expected the first join to be new`)
+	}
+	if len(users) != 1 || users[0].Id != UserID {
+		t.Fatalf(`expected the joined user to be listed got %v`, users)
+	}
+
+	users, isNew, err = svc.AddUser(PokerID, UserID)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if isNew {
+		t.Fatalf(`expected the rejoin not to be new`)
+	}
+	if len(users) != 1 {
+		t.Fatalf(`expected the rejoining user to be listed once got %d users`, len(users))
+	}
+}
+
+// TestCreateGameSettings creates a game with a custom scale and settings, retries it with the same idempotency key,
+// then creates another whose settings fail to save, making sure the settings are saved once within the creation
+// transaction, the retry doesn't reapply them and a failure rolls the whole game back
+func TestCreateGameSettings(t *testing.T) {
+	svc, f := newTestService(t)
+	ctx := context.Background()
+	FacilitatorID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	keys := make(map[driver.Value]driver.Value)
+	var settingsErr error
+	f.Query("FROM thunderdome.poker_idempotency_key", []string{"poker_id", "story_id"}, func(args []driver.Value) ([][]driver.Value, error) {
+		if id, ok := keys[args[2]]; ok {
+			return [][]driver.Value{{id, ""}}, nil
+		}
+		return nil, nil
+	})
+	f.Exec("INSERT INTO thunderdome.poker_idempotency_key", func(args []driver.Value) (int64, error) {
+		keys[args[2]] = args[3]
+		return 1, nil
+	})
+	f.Rows("FROM thunderdome.users WHERE id = $1 FOR SHARE", []string{"id", "name", "type", "avatar", "email"}, []driver.Value{FacilitatorID, "Thor", "REGISTERED", "identicon", ""})
+	f.Rows("thunderdome.poker_create", []string{"pokerid"}, []driver.Value{PokerID})
+	f.Rows("INSERT INTO thunderdome.poker_user", []string{"inserted"}, []driver.Value{true})
+	f.Affected("SET short_code = $2", 1)
+	f.Exec("SET custom_scale = $2, point_values_allowed = $3, estimation_unit = $4, min_voters_to_finalize = $5", func(args []driver.Value) (int64, error) {
+		return 1, settingsErr
+	})
+	f.Query("FROM thunderdome.poker b", gameColumns, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{gameRow(args[0], "[]")}, nil
+	})
+	scale := []thunderdome.ScaleValue{{Label: "S"}, {Label: "M"}, {Label: "L"}}
+	unit, minVoters := thunderdome.EstimationUnitHours, 2
+	settings := thunderdome.PokerSettings{EstimationUnit: &unit, MinVotersToFinalize: &minVoters}
+	create := func(Key string) (*thunderdome.Poker, error) {
+		return svc.CreateGame(ctx, FacilitatorID, "game", []string{"1", "2"}, nil, true, "ceil", "", "", false, "points", scale, settings, Key)
+	}
+
+	b, err := create("retry-1")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if f.Calls("SET custom_scale") != 1 || f.Commits() != 1 {
+		t.Fatalf(`expected the settings saved once in the committed creation got %d saves and %d commits`, f.Calls("SET custom_scale"), f.Commits())
+	}
+	if b.EstimationUnit != unit || b.MinVotersToFinalize != minVoters || len(b.PointValuesAllowed) != 3 || b.PointValuesAllowed[0] != "S" {
+		t.Fatalf(`expected the created game to have its settings got %s %d %v`, b.EstimationUnit, b.MinVotersToFinalize, b.PointValuesAllowed)
+	}
+
+	if _, err := create("retry-1"); err != nil {
+		t.Fatalf(`unexpected error replaying %v`, err)
+	}
+	if f.Calls("SET custom_scale") != 1 {
+		t.Fatalf(`expected the replayed creation not to reapply the settings got %d saves`, f.Calls("SET custom_scale"))
+	}
+
+	settingsErr = errors.New("settings update failed")
+	if _, err := create("retry-2"); err == nil {
+		t.Fatalf(`expected error when the settings fail to save`)
+	}
+	if _, claimed := keys["retry-2"]; claimed || f.Rollbacks() == 0 {
+		t.Fatalf(`expected the game creation to be rolled back when its settings fail to save`)
+	}
+
+	unit = "weeks"
+	if _, err := create("retry-3"); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected ErrValidation for an invalid estimation unit got %v`, err)
+	}
+}
+
+// TestInvalidIDsSkipQueries calls GetStories, GetUsers, GetActiveUsers and RetreatUser with IDs that aren't UUIDs
+// and makes sure nothing is returned without the database being queried
+func TestInvalidIDsSkipQueries(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+
+	if stories := svc.GetStories("not-a-uuid", ""); len(stories) != 0 {
+		t.Fatalf(`expected no stories for an invalid game ID got %d`, len(stories))
+	}
+	if users := svc.GetUsers("not-a-uuid"); len(users) != 0 {
+		t.Fatalf(`expected no users for an invalid game ID got %d`, len(users))
+	}
+	if users := svc.GetActiveUsers("not-a-uuid"); len(users) != 0 {
+		t.Fatalf(`expected no active users for an invalid game ID got %d`, len(users))
+	}
+	if users := svc.RetreatUser(PokerID, "not-a-uuid"); len(users) != 0 {
+		t.Fatalf(`expected no users for an invalid user ID got %d`, len(users))
+	}
+	if users := svc.RetreatUser("not-a-uuid", PokerID); len(users) != 0 {
+		t.Fatalf(`expected no users for an invalid game ID got %d`, len(users))
+	}
+	if calls := f.Calls(""); calls != 0 {
+		t.Fatalf(`expected no queries for invalid IDs got %d`, calls)
+	}
+}
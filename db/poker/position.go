@@ -0,0 +1,142 @@
+package poker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// storyPosition is a story's current position in the game
+type storyPosition struct {
+	StoryID   string
+	Position  int32
+	Estimated bool
+}
+
+// CompactStoryPositions renumbers the games story positions 1..N in their current order
+func (d *Service) CompactStoryPositions(PokerID string) error {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return err
+	}
+
+	if err := d.WithTx(context.Background(), func(tx *sql.Tx) error {
+		positions, err := d.lockStoryPositions(tx, PokerID)
+		if err != nil {
+			return err
+		}
+
+		return d.updateStoryPositions(tx, compactStoryPositions(positions))
+	}); err != nil {
+		return errors.New("unable to compact story positions")
+	}
+
+	return nil
+}
+
+// RequeueUnestimated moves the games unestimated and skipped stories after the estimated ones keeping
+// their relative order so the team can do a cleanup pass, returning the reordered stories
+func (d *Service) RequeueUnestimated(PokerID string) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+
+	if err := d.WithTx(context.Background(), func(tx *sql.Tx) error {
+		positions, err := d.lockStoryPositions(tx, PokerID)
+		if err != nil {
+			return err
+		}
+
+		return d.updateStoryPositions(tx, compactStoryPositions(requeueUnestimatedPositions(positions)))
+	}); err != nil {
+		return nil, errors.New("unable to requeue stories")
+	}
+
+	return d.getStories(d.DB, PokerID, ""), nil
+}
+
+// lockStoryPositions locks the game row for the transaction so concurrent reorders and inserts don't interleave,
+// returning the games stories in their current order
+func (d *Service) lockStoryPositions(tx *sql.Tx, PokerID string) ([]storyPosition, error) {
+	if _, err := tx.Exec(`SELECT id FROM thunderdome.poker WHERE id = $1 FOR UPDATE;`, PokerID); err != nil {
+		d.Logger.Error("poker story positions lock error", zap.Error(err))
+		return nil, err
+	}
+
+	rows, err := tx.Query(
+		`SELECT id, position, (NOT skipped AND COALESCE(points, '') != '')
+		FROM thunderdome.poker_story WHERE poker_id = $1 ORDER BY position, created_date;`,
+		PokerID,
+	)
+	if err != nil {
+		d.Logger.Error("poker story positions query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var positions []storyPosition
+	for rows.Next() {
+		var sp storyPosition
+		if err := rows.Scan(&sp.StoryID, &sp.Position, &sp.Estimated); err != nil {
+			d.Logger.Error("poker story positions scan error", zap.Error(err))
+			return nil, err
+		}
+		positions = append(positions, sp)
+	}
+
+	return positions, rows.Err()
+}
+
+// updateStoryPositions saves the changed story positions
+func (d *Service) updateStoryPositions(tx *sql.Tx, Positions []storyPosition) error {
+	for _, sp := range Positions {
+		if _, err := tx.Exec(
+			`UPDATE thunderdome.poker_story SET position = $2, updated_date = NOW() WHERE id = $1;`,
+			sp.StoryID, sp.Position,
+		); err != nil {
+			d.Logger.Error("poker story positions update error", zap.Error(err))
+			return err
+		}
+	}
+
+	return nil
+}
+
+// requeueUnestimatedPositions orders the estimated stories first followed by the
+// unestimated and skipped stories, each keeping their relative order
+func requeueUnestimatedPositions(Positions []storyPosition) []storyPosition {
+	requeued := make([]storyPosition, 0, len(Positions))
+	for _, sp := range Positions {
+		if sp.Estimated {
+			requeued = append(requeued, sp)
+		}
+	}
+	for _, sp := range Positions {
+		if !sp.Estimated {
+			requeued = append(requeued, sp)
+		}
+	}
+
+	return requeued
+}
+
+// compactStoryPositions numbers the ordered stories 1..N returning only those whose position changed
+func compactStoryPositions(Positions []storyPosition) []storyPosition {
+	changed := make([]storyPosition, 0)
+
+	for i, sp := range Positions {
+		position := int32(i + 1)
+		if sp.Position != position {
+			changed = append(changed, storyPosition{StoryID: sp.StoryID, Position: position})
+		}
+	}
+
+	return changed
+}
@@ -0,0 +1,68 @@
+package poker
+
+import "testing"
+
+// TestCompactStoryPositions calls compactStoryPositions with gaps from deleted stories
+// and makes sure positions become contiguous with only the moved stories updated
+func TestCompactStoryPositions(t *testing.T) {
+	positions := []storyPosition{
+		{StoryID: "a", Position: 1},
+		{StoryID: "b", Position: 2},
+		{StoryID: "c", Position: 5},
+		{StoryID: "d", Position: 9},
+	}
+
+	changed := compactStoryPositions(positions)
+
+	if len(changed) != 2 {
+		t.Fatalf(`expected 2 changed positions got %d`, len(changed))
+	}
+	if changed[0].StoryID != "c" || changed[0].Position != 3 {
+		t.Fatalf(`expected c at position 3 got %s at %d`, changed[0].StoryID, changed[0].Position)
+	}
+	if changed[1].StoryID != "d" || changed[1].Position != 4 {
+		t.Fatalf(`expected d at position 4 got %s at %d`, changed[1].StoryID, changed[1].Position)
+	}
+	if len(compactStoryPositions([]storyPosition{{StoryID: "a", Position: 1}})) != 0 {
+		t.Fatalf(`expected contiguous positions to be unchanged`)
+	}
+}
+
+// TestRequeueUnestimatedPositions calls requeueUnestimatedPositions with estimated stories mixed between
+// unestimated and skipped ones and makes sure estimated stories keep their place while the rest move to the tail in order
+func TestRequeueUnestimatedPositions(t *testing.T) {
+	positions := []storyPosition{
+		{StoryID: "a", Position: 1, Estimated: true},
+		{StoryID: "b", Position: 2},
+		{StoryID: "c", Position: 3, Estimated: true},
+		{StoryID: "d", Position: 4},
+		{StoryID: "e", Position: 5, Estimated: true},
+	}
+
+	requeued := requeueUnestimatedPositions(positions)
+
+	order := ""
+	for _, sp := range requeued {
+		order += sp.StoryID
+	}
+	if order != "acebd" {
+		t.Fatalf(`expected order acebd got %s`, order)
+	}
+
+	changed := compactStoryPositions(requeued)
+	moved := make(map[string]int32)
+	for _, sp := range changed {
+		moved[sp.StoryID] = sp.Position
+	}
+	if _, ok := moved["a"]; ok {
+		t.Fatalf(`expected first estimated story to stay put`)
+	}
+	if moved["c"] != 2 || moved["e"] != 3 || moved["b"] != 4 || moved["d"] != 5 {
+		t.Fatalf(`expected c:2 e:3 b:4 d:5 got %v`, moved)
+	}
+
+	allEstimated := []storyPosition{{StoryID: "a", Position: 1, Estimated: true}, {StoryID: "b", Position: 2, Estimated: true}}
+	if len(compactStoryPositions(requeueUnestimatedPositions(allEstimated))) != 0 {
+		t.Fatalf(`expected no changes when every story is estimated`)
+	}
+}
@@ -0,0 +1,26 @@
+package poker
+
+import (
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+
+	"go.uber.org/zap"
+)
+
+// UserHeartbeat marks the game user as seen now, it's called often so is a single update without any reads
+func (d *Service) UserHeartbeat(PokerID string, UserID string) error {
+	if err := db.ValidateUUID(PokerID, UserID); err != nil {
+		return err
+	}
+
+	if _, err := d.DB.Exec(
+		`UPDATE thunderdome.poker_user SET last_seen = NOW() WHERE poker_id = $1 AND user_id = $2;`,
+		PokerID, UserID,
+	); err != nil {
+		d.Logger.Error("poker user heartbeat error", zap.Error(err))
+		return fmt.Errorf("unable to update poker user last seen: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+)
+
+// TestUserHeartbeat calls UserHeartbeat twice
+// and makes sure last_seen advances each time using a single update without any reads
+func TestUserHeartbeat(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	UserID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	var lastSeen time.Time
+	f.Exec("thunderdome.poker_user SET last_seen = NOW()", func(args []driver.Value) (int64, error) {
+		lastSeen = time.Now()
+		return 1, nil
+	})
+
+	if err := svc.UserHeartbeat(PokerID, UserID); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	first := lastSeen
+	if first.IsZero() {
+		t.Fatalf(`expected last_seen to be set`)
+	}
+
+	time.Sleep(time.Millisecond)
+	if err := svc.UserHeartbeat(PokerID, UserID); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if !lastSeen.After(first) {
+		t.Fatalf(`expected last_seen to advance past %v got %v`, first, lastSeen)
+	}
+	if calls := f.Calls(""); calls != 2 {
+		t.Fatalf(`expected only the 2 updates got %d statements`, calls)
+	}
+}
+
+// TestUserHeartbeatInvalidID calls UserHeartbeat with an invalid user ID
+// and makes sure it's rejected before touching the database
+func TestUserHeartbeatInvalidID(t *testing.T) {
+	svc := &Service{Logger: otelzap.New(zap.NewNop())}
+
+	if err := svc.UserHeartbeat("0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11", "not-a-uuid"); err == nil {
+		t.Fatalf(`expected invalid ID error`)
+	}
+}
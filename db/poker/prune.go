@@ -0,0 +1,97 @@
+package poker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+
+	"go.uber.org/zap"
+)
+
+// pruneCandidate is a game user considered for pruning
+type pruneCandidate struct {
+	UserID      string
+	Active      bool
+	Facilitator bool
+}
+
+// PruneInactiveUsers removes the users no longer in the game from its participants, returning how many were removed,
+// facilitators are kept even when inactive so they can still manage the game
+func (d *Service) PruneInactiveUsers(PokerID string, FacilitatorID string) (int, error) {
+	if err := db.ValidateUUID(PokerID, FacilitatorID); err != nil {
+		return 0, err
+	}
+	if err := d.ensureNotArchived(PokerID); err != nil {
+		return 0, err
+	}
+	if err := d.ConfirmFacilitator(PokerID, FacilitatorID); err != nil {
+		return 0, err
+	}
+
+	var pruned int
+	if err := d.WithTx(context.Background(), func(tx *sql.Tx) error {
+		rows, err := tx.Query(
+			`SELECT pu.user_id, pu.active, EXISTS (
+				SELECT 1 FROM thunderdome.poker_facilitator pf WHERE pf.poker_id = pu.poker_id AND pf.user_id = pu.user_id
+			)
+			FROM thunderdome.poker_user pu WHERE pu.poker_id = $1
+			FOR UPDATE OF pu;`,
+			PokerID,
+		)
+		if err != nil {
+			d.Logger.Error("poker prune users query error", zap.Error(err))
+			return err
+		}
+		var candidates []pruneCandidate
+		for rows.Next() {
+			var c pruneCandidate
+			if err := rows.Scan(&c.UserID, &c.Active, &c.Facilitator); err != nil {
+				rows.Close()
+				d.Logger.Error("poker prune users scan error", zap.Error(err))
+				return err
+			}
+			candidates = append(candidates, c)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			d.Logger.Error("poker prune users rows error", zap.Error(err))
+			return err
+		}
+
+		UserIDs := inactiveUsersToPrune(candidates)
+		if len(UserIDs) == 0 {
+			return nil
+		}
+
+		result, err := tx.Exec(
+			`DELETE FROM thunderdome.poker_user WHERE poker_id = $1 AND user_id = ANY($2);`,
+			PokerID, UserIDs,
+		)
+		if err != nil {
+			d.Logger.Error("poker prune users delete error", zap.Error(err))
+			return err
+		}
+		deleted, _ := result.RowsAffected()
+		pruned = int(deleted)
+
+		return nil
+	}); err != nil {
+		return 0, errors.New("unable to prune inactive users")
+	}
+
+	return pruned, nil
+}
+
+// inactiveUsersToPrune picks the inactive users that aren't facilitators
+func inactiveUsersToPrune(Candidates []pruneCandidate) []string {
+	UserIDs := make([]string, 0)
+	for _, c := range Candidates {
+		if !c.Active && !c.Facilitator {
+			UserIDs = append(UserIDs, c.UserID)
+		}
+	}
+
+	return UserIDs
+}
@@ -0,0 +1,27 @@
+package poker
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestInactiveUsersToPrune calls inactiveUsersToPrune with active and inactive users
+// and makes sure only the inactive users who aren't facilitators are pruned
+func TestInactiveUsersToPrune(t *testing.T) {
+	candidates := []pruneCandidate{
+		{UserID: "thor", Active: true},
+		{UserID: "loki", Active: false},
+		{UserID: "odin", Active: false, Facilitator: true},
+		{UserID: "sif", Active: true, Facilitator: true},
+		{UserID: "heimdall", Active: false},
+	}
+
+	pruned := inactiveUsersToPrune(candidates)
+
+	if strings.Join(pruned, ",") != "loki,heimdall" {
+		t.Fatalf(`expected loki and heimdall pruned got %v`, pruned)
+	}
+	if len(inactiveUsersToPrune([]pruneCandidate{{UserID: "thor", Active: true}})) != 0 {
+		t.Fatalf(`expected no users pruned when everyone is active`)
+	}
+}
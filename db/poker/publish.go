@@ -0,0 +1,69 @@
+package poker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// StoryFinalizedTopic is the topic finalized story events are published to
+const StoryFinalizedTopic = "poker.story.finalized"
+
+// publishTimeout bounds how long publishing a single event can take
+const publishTimeout = 10 * time.Second
+
+// publishStoriesFinalized publishes an event for each of the finalized stories in the background when a publisher
+// is configured, failures are only logged so downstream consumers never hold up or fail finalizing
+func (d *Service) publishStoriesFinalized(PokerID string, StoryIDs []string, Stories []*thunderdome.Story) {
+	if d.Publisher == nil {
+		return
+	}
+
+	messages := storyFinalizedMessages(PokerID, StoryIDs, Stories)
+	go func() {
+		for _, m := range messages {
+			ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+			if err := d.Publisher.Publish(ctx, StoryFinalizedTopic, m); err != nil {
+				d.Logger.Error("poker story finalized publish error", zap.String("poker_id", PokerID), zap.Error(err))
+			}
+			cancel()
+		}
+	}()
+}
+
+// storyFinalizedMessages builds the finalized event messages for the stories with the given IDs
+func storyFinalizedMessages(PokerID string, StoryIDs []string, Stories []*thunderdome.Story) [][]byte {
+	finalized := make(map[string]bool, len(StoryIDs))
+	for _, id := range StoryIDs {
+		finalized[id] = true
+	}
+
+	messages := make([][]byte, 0, len(StoryIDs))
+	for _, s := range Stories {
+		if !finalized[s.Id] {
+			continue
+		}
+		m, err := json.Marshal(&thunderdome.StoryFinalizedEvent{
+			PokerID:       PokerID,
+			StoryID:       s.Id,
+			Name:          s.Name,
+			Type:          s.Type,
+			ReferenceID:   s.ReferenceId,
+			Link:          s.Link,
+			Points:        s.Points,
+			PointsNumeric: s.PointsNumeric,
+			VoteCount:     len(s.Votes),
+			FinalizedTime: s.FinalizedTime,
+		})
+		if err != nil {
+			continue
+		}
+		messages = append(messages, m)
+	}
+
+	return messages
+}
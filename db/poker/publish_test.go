@@ -0,0 +1,89 @@
+package poker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+)
+
+// fakePublisher sends each published message to messages, failing the publish when err is set
+type fakePublisher struct {
+	messages chan []byte
+	topics   chan string
+	err      error
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, Topic string, Message []byte) error {
+	p.topics <- Topic
+	p.messages <- Message
+	return p.err
+}
+
+// TestPublishStoriesFinalized calls publishStoriesFinalized for one of a games stories
+// and makes sure a single message describing the finalized story is published to the topic
+func TestPublishStoriesFinalized(t *testing.T) {
+	publisher := &fakePublisher{messages: make(chan []byte, 2), topics: make(chan string, 2)}
+	svc := &Service{Logger: otelzap.New(zap.NewNop()), Publisher: publisher}
+	points := 5.0
+	finalizedTime := time.Date(2023, 8, 28, 10, 0, 0, 0, time.UTC)
+	stories := []*thunderdome.Story{
+		{Id: "a", Name: "Login", Type: "Story", ReferenceId: "TD-1", Link: "https://example.com/TD-1", Points: "5",
+			PointsNumeric: &points, FinalizedTime: finalizedTime, Votes: []*thunderdome.Vote{{UserId: "thor", VoteValue: "5"}, {UserId: "loki", VoteValue: "3"}}},
+		{Id: "b", Name: "Logout", Points: "3"},
+	}
+
+	svc.publishStoriesFinalized("game", []string{"a"}, stories)
+
+	select {
+	case topic := <-publisher.topics:
+		if topic != StoryFinalizedTopic {
+			t.Fatalf(`expected topic %s got %s`, StoryFinalizedTopic, topic)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf(`expected a finalized story message to be published`)
+	}
+	var event thunderdome.StoryFinalizedEvent
+	if err := json.Unmarshal(<-publisher.messages, &event); err != nil {
+		t.Fatalf(`unexpected error decoding message %v`, err)
+	}
+	if event.PokerID != "game" || event.StoryID != "a" || event.Name != "Login" || event.ReferenceID != "TD-1" ||
+		event.Points != "5" || event.PointsNumeric == nil || *event.PointsNumeric != 5 || event.VoteCount != 2 ||
+		!event.FinalizedTime.Equal(finalizedTime) {
+		t.Fatalf(`unexpected message %+v`, event)
+	}
+
+	select {
+	case <-publisher.topics:
+		t.Fatalf(`expected only the finalized story to be published`)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestPublishStoriesFinalizedFailure calls publishStoriesFinalized with a failing publisher and without one
+// and makes sure neither blocks the caller
+func TestPublishStoriesFinalizedFailure(t *testing.T) {
+	stories := []*thunderdome.Story{{Id: "a", Points: "1"}}
+
+	(&Service{Logger: otelzap.New(zap.NewNop())}).publishStoriesFinalized("game", []string{"a"}, stories)
+
+	publisher := &fakePublisher{messages: make(chan []byte), topics: make(chan string), err: errors.New("queue unavailable")}
+	done := make(chan struct{})
+	go func() {
+		(&Service{Logger: otelzap.New(zap.NewNop()), Publisher: publisher}).publishStoriesFinalized("game", []string{"a"}, stories)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf(`expected publishing to not block finalizing`)
+	}
+	<-publisher.topics
+	<-publisher.messages
+}
@@ -0,0 +1,84 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// SetGameMinVotersToFinalize sets how many active users must vote before a story can be finalized, 0 disables the quorum
+func (d *Service) SetGameMinVotersToFinalize(PokerID string, MinVoters int) error {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return err
+	}
+	if MinVoters < 0 {
+		return fmt.Errorf("%w: min voters to finalize can't be negative", thunderdome.ErrValidation)
+	}
+	if err := d.ensureNotArchived(PokerID); err != nil {
+		return err
+	}
+
+	if _, err := d.DB.Exec(
+		`UPDATE thunderdome.poker SET min_voters_to_finalize = $2, updated_date = NOW() WHERE id = $1;`,
+		PokerID, MinVoters,
+	); err != nil {
+		d.Logger.Error("update poker min_voters_to_finalize error", zap.Error(err))
+		return fmt.Errorf("unable to update poker min voters to finalize: %w", err)
+	}
+
+	return nil
+}
+
+// ensureQuorum checks enough active users voted on the story to meet the games min voters to finalize
+func (d *Service) ensureQuorum(PokerID string, StoryID string) error {
+	var MinVoters int
+	var v string
+
+	if err := d.DB.QueryRow(
+		`SELECT p.min_voters_to_finalize, ps.votes
+		FROM thunderdome.poker p
+		JOIN thunderdome.poker_story ps ON ps.poker_id = p.id
+		WHERE p.id = $1 AND ps.id = $2;`,
+		PokerID, StoryID,
+	).Scan(&MinVoters, &v); err != nil {
+		d.Logger.Error("get poker quorum error", zap.Error(err))
+		return errors.New("not found")
+	}
+	if MinVoters <= 0 {
+		return nil
+	}
+	Votes, err := decodeStoryVotes(v)
+	if err != nil {
+		d.Logger.Error("get poker quorum corrupt votes error", zap.String("story_id", StoryID), zap.Error(err))
+		return err
+	}
+
+	return checkQuorum(MinVoters, Votes, d.getActiveUsers(d.DB, PokerID))
+}
+
+// checkQuorum counts the votes cast by active non-spectator users and errors when fewer than MinVoters voted
+func checkQuorum(MinVoters int, Votes []*thunderdome.Vote, ActiveUsers []*thunderdome.PokerUser) error {
+	voters := make(map[string]bool, len(ActiveUsers))
+	for _, u := range ActiveUsers {
+		if !u.Spectator {
+			voters[u.Id] = true
+		}
+	}
+
+	var count int
+	for _, v := range Votes {
+		if v.VoteValue != "" && voters[v.UserId] {
+			count++
+		}
+	}
+
+	if count < MinVoters {
+		return fmt.Errorf("%w: %d of %d required voters", thunderdome.ErrQuorumNotMet, count, MinVoters)
+	}
+
+	return nil
+}
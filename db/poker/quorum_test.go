@@ -0,0 +1,32 @@
+package poker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestCheckQuorum calls checkQuorum with votes from active, spectating, and departed users
+// and makes sure only active voters count toward the quorum
+func TestCheckQuorum(t *testing.T) {
+	activeUsers := []*thunderdome.PokerUser{
+		{Id: "a"},
+		{Id: "b"},
+		{Id: "s", Spectator: true},
+	}
+	votes := []*thunderdome.Vote{
+		{UserId: "a", VoteValue: "3"},
+		{UserId: "s", VoteValue: "5"},
+		{UserId: "gone", VoteValue: "8"},
+	}
+
+	if err := checkQuorum(2, votes, activeUsers); !errors.Is(err, thunderdome.ErrQuorumNotMet) {
+		t.Fatalf(`expected quorum not met with 1 of 2 voters got %v`, err)
+	}
+
+	votes = append(votes, &thunderdome.Vote{UserId: "b", VoteValue: "3"})
+	if err := checkQuorum(2, votes, activeUsers); err != nil {
+		t.Fatalf(`expected quorum met with 2 of 2 voters got %v`, err)
+	}
+}
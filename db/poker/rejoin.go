@@ -0,0 +1,95 @@
+package poker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// AddUsers adds or reactivates a group of users rejoining the game in one statement and returns the active users,
+// when the game has a join code only users already part of the game are reactivated as new users must join with the code
+func (d *Service) AddUsers(PokerID string, UserIDs []string) ([]*thunderdome.PokerUser, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+	if err := db.ValidateUUID(UserIDs...); err != nil {
+		return nil, err
+	}
+
+	if err := d.WithTx(context.Background(), func(tx *sql.Tx) error {
+		var JoinLocked bool
+		if err := tx.QueryRow(
+			`SELECT COALESCE(join_code, '') <> '' FROM thunderdome.poker WHERE id = $1 FOR SHARE;`,
+			PokerID,
+		).Scan(&JoinLocked); err != nil {
+			d.Logger.Error("poker add users join code query error", zap.Error(err))
+			return err
+		}
+
+		rows, err := tx.Query(
+			`SELECT user_id FROM thunderdome.poker_user WHERE poker_id = $1 AND user_id = ANY($2);`,
+			PokerID, UserIDs,
+		)
+		if err != nil {
+			d.Logger.Error("poker add users members query error", zap.Error(err))
+			return err
+		}
+		Members := make(map[string]bool)
+		for rows.Next() {
+			var UserID string
+			if err := rows.Scan(&UserID); err != nil {
+				rows.Close()
+				d.Logger.Error("poker add users members scan error", zap.Error(err))
+				return err
+			}
+			Members[UserID] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			d.Logger.Error("poker add users members rows error", zap.Error(err))
+			return err
+		}
+
+		Joining := joiningUsers(UserIDs, Members, JoinLocked)
+		if len(Joining) == 0 {
+			return nil
+		}
+
+		if _, err := tx.Exec(
+			`INSERT INTO thunderdome.poker_user (poker_id, user_id, active)
+			SELECT $1, unnest($2::uuid[]), true
+			ON CONFLICT (poker_id, user_id) DO UPDATE SET active = true, abandoned = false;`,
+			PokerID, Joining,
+		); err != nil {
+			d.Logger.Error("poker add users error", zap.Error(err))
+			return err
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.New("unable to add users")
+	}
+
+	return d.getActiveUsers(d.DB, PokerID), nil
+}
+
+// joiningUsers gets the users to add without duplicates,
+// leaving out users who aren't already members when the game is locked by a join code
+func joiningUsers(UserIDs []string, Members map[string]bool, JoinLocked bool) []string {
+	joining := make([]string, 0, len(UserIDs))
+	seen := make(map[string]bool)
+	for _, UserID := range UserIDs {
+		if seen[UserID] || (JoinLocked && !Members[UserID]) {
+			continue
+		}
+		seen[UserID] = true
+		joining = append(joining, UserID)
+	}
+
+	return joining
+}
@@ -0,0 +1,105 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// rejoinDB is a fake database standing in for a games users, members maps each user who is part of the game
+// to whether they're active and the add users upsert activates the users it's given
+type rejoinDB struct {
+	joinLocked bool
+	members    map[string]bool
+	upserts    int
+}
+
+func newRejoinService(t *testing.T) (*Service, *rejoinDB) {
+	svc, f := newTestService(t)
+	d := &rejoinDB{}
+	f.Exec("unnest($2::uuid[])", func(args []driver.Value) (int64, error) {
+		d.upserts++
+		for _, UserID := range args[1].([]string) {
+			d.members[UserID] = true
+		}
+		return int64(len(args[1].([]string))), nil
+	})
+	f.Query("COALESCE(join_code, '') <> ''", []string{"join_locked"}, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{d.joinLocked}}, nil
+	})
+	f.Query("user_id = ANY($2)", []string{"user_id"}, func(args []driver.Value) ([][]driver.Value, error) {
+		values := make([][]driver.Value, 0)
+		for _, UserID := range args[1].([]string) {
+			if _, ok := d.members[UserID]; ok {
+				values = append(values, []driver.Value{UserID})
+			}
+		}
+		return values, nil
+	})
+	f.Query("bw.active = true", []string{"id", "name", "type", "avatar", "active", "spectator", "email", "reveal_identity"}, func(args []driver.Value) ([][]driver.Value, error) {
+		ids := make([]string, 0)
+		for UserID, active := range d.members {
+			if active {
+				ids = append(ids, UserID)
+			}
+		}
+		sort.Strings(ids)
+		values := make([][]driver.Value, 0)
+		for _, UserID := range ids {
+			values = append(values, []driver.Value{UserID, UserID, "REGISTERED", "identicon", true, false, "", false})
+		}
+		return values, nil
+	})
+
+	return svc, d
+}
+
+// TestAddUsers calls AddUsers with a batch of returning and new users and makes sure they're all made active
+// with a single upsert, and with a join code only the returning users are reactivated
+func TestAddUsers(t *testing.T) {
+	svc, rejoin := newRejoinService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	returning := "1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"
+	present := "2b3c4d5e-6f7a-4b8c-9d0e-1f2a3b4c5d6e"
+	newcomer := "3c4d5e6f-7a8b-4c9d-8e1f-2a3b4c5d6e7f"
+	batch := []string{returning, newcomer, present, returning}
+
+	for _, locked := range []bool{false, true} {
+		rejoin.joinLocked, rejoin.upserts = locked, 0
+		rejoin.members = map[string]bool{returning: false, present: true}
+
+		users, err := svc.AddUsers(PokerID, batch)
+		if err != nil {
+			t.Fatalf(`join locked %v: unexpected error %v`, locked, err)
+		}
+		if rejoin.upserts != 1 {
+			t.Fatalf(`join locked %v: expected 1 upsert got %d`, locked, rejoin.upserts)
+		}
+
+		active := make([]string, 0)
+		for _, u := range users {
+			active = append(active, u.Id)
+		}
+		expected := []string{returning, present, newcomer}
+		if locked {
+			expected = []string{returning, present}
+		}
+		if strings.Join(active, ",") != strings.Join(expected, ",") {
+			t.Fatalf(`join locked %v: expected active users %v got %v`, locked, expected, active)
+		}
+	}
+}
+
+// TestJoiningUsers calls joiningUsers and makes sure duplicates are dropped
+// and only members are kept when the game is locked by a join code
+func TestJoiningUsers(t *testing.T) {
+	members := map[string]bool{"thor": true}
+
+	if joining := joiningUsers([]string{"thor", "loki", "thor"}, members, false); strings.Join(joining, ",") != "thor,loki" {
+		t.Fatalf(`expected thor and loki to join got %v`, joining)
+	}
+	if joining := joiningUsers([]string{"thor", "loki"}, members, true); strings.Join(joining, ",") != "thor" {
+		t.Fatalf(`expected only thor to rejoin a join code locked game got %v`, joining)
+	}
+}
@@ -0,0 +1,51 @@
+package poker
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/dbtest"
+
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+)
+
+// newReadDB returns a fake database standing in for either the primary or the read replica,
+// returning a game without users or stories
+func newReadDB(t *testing.T) (*dbtest.DB, *sql.DB) {
+	f := dbtest.New()
+	f.Rows("", []string{"id"})
+	f.Query("FROM thunderdome.poker b", gameColumns, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{gameRow(args[0], "[]")}, nil
+	})
+
+	return f, f.Open(t)
+}
+
+// TestReadReplica calls GetGame, GetStories and GetActiveUsers with and without a read replica
+// and makes sure they're served by the replica when one is configured and the primary otherwise
+func TestReadReplica(t *testing.T) {
+	readPrimary, Primary := newReadDB(t)
+	readReplica, Replica := newReadDB(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+
+	reads := func(svc *Service) {
+		if _, err := svc.GetGame(PokerID, ""); err != nil {
+			t.Fatalf(`unexpected error getting game %v`, err)
+		}
+		svc.GetStories(PokerID, "")
+		svc.GetActiveUsers(PokerID)
+	}
+
+	reads(&Service{DB: Primary, ReadDB: Replica, Logger: otelzap.New(zap.NewNop())})
+	if primary, replica := readPrimary.Calls(""), readReplica.Calls(""); primary != 0 || replica == 0 {
+		t.Fatalf(`expected reads on the replica got %d primary and %d replica queries`, primary, replica)
+	}
+
+	replicaReads := readReplica.Calls("")
+	reads(&Service{DB: Primary, Logger: otelzap.New(zap.NewNop())})
+	if primary, replica := readPrimary.Calls(""), readReplica.Calls("")-replicaReads; primary == 0 || replica != 0 {
+		t.Fatalf(`expected reads on the primary without a replica got %d primary and %d replica queries`, primary, replica)
+	}
+}
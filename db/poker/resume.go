@@ -0,0 +1,69 @@
+package poker
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// GetResumableGames gets the non-archived games the user is part of that still have a story to estimate,
+// most recently active first, each games stories only include their id, name, points and skipped status
+func (d *Service) GetResumableGames(UserID string) ([]*thunderdome.Poker, error) {
+	if err := db.ValidateUUID(UserID); err != nil {
+		return nil, err
+	}
+
+	rows, err := d.DB.Query(`
+		SELECT p.id, p.name, p.voting_locked, COALESCE(p.active_story_id::text, ''), p.created_date, p.updated_date,
+		COALESCE((
+			SELECT json_agg(json_build_object('id', ps.id, 'name', ps.name, 'points', ps.points, 'skipped', ps.skipped) ORDER BY ps.position)
+			FROM thunderdome.poker_story ps WHERE ps.poker_id = p.id
+		), '[]'::json) AS stories
+		FROM thunderdome.poker p
+		JOIN thunderdome.poker_user pu ON pu.poker_id = p.id
+		WHERE pu.user_id = $1 AND pu.abandoned = false AND COALESCE(p.archived, false) = false
+		ORDER BY p.last_active DESC;
+	`, UserID)
+	if err != nil {
+		d.Logger.Error("get resumable poker games query error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get resumable games: %w", err)
+	}
+	defer rows.Close()
+
+	games := make([]*thunderdome.Poker, 0)
+	for rows.Next() {
+		var stories string
+		var g = &thunderdome.Poker{
+			Users:   make([]*thunderdome.PokerUser, 0),
+			Stories: make([]*thunderdome.Story, 0),
+		}
+		if err := rows.Scan(&g.Id, &g.Name, &g.VotingLocked, &g.ActiveStoryID, &g.CreatedDate, &g.UpdatedDate, &stories); err != nil {
+			d.Logger.Error("get resumable poker games scan error", zap.Error(err))
+			continue
+		}
+		if err := json.Unmarshal([]byte(stories), &g.Stories); err != nil {
+			d.Logger.Error("get resumable poker games stories error", zap.String("poker_id", g.Id), zap.Error(err))
+			continue
+		}
+		if hasUnestimatedStory(g.Stories) {
+			games = append(games, g)
+		}
+	}
+
+	return games, nil
+}
+
+// hasUnestimatedStory checks whether any story has neither been given points nor skipped
+func hasUnestimatedStory(Stories []*thunderdome.Story) bool {
+	for _, s := range Stories {
+		if s.Points == "" && !s.Skipped {
+			return true
+		}
+	}
+
+	return false
+}
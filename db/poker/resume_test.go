@@ -0,0 +1,45 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestGetResumableGames calls GetResumableGames for a user with a fully estimated, a partially estimated
+// and a storyless game and makes sure only the partially estimated game is returned
+func TestGetResumableGames(t *testing.T) {
+	svc, f := newTestService(t)
+	stories := map[string]string{
+		"full":    `[{"id":"a","name":"a","points":"3","skipped":false},{"id":"b","name":"b","points":"","skipped":true}]`,
+		"partial": `[{"id":"c","name":"c","points":"5","skipped":false},{"id":"d","name":"d","points":"","skipped":false}]`,
+		"empty":   `[]`,
+	}
+	now := time.Now()
+	values := make([][]driver.Value, 0)
+	for _, id := range []string{"full", "partial", "empty"} {
+		values = append(values, []driver.Value{id, id, true, "", now, now, stories[id]})
+	}
+	f.Rows("COALESCE(p.archived, false) = false",
+		[]string{"id", "name", "voting_locked", "active_story_id", "created_date", "updated_date", "stories"}, values...)
+
+	games, err := svc.GetResumableGames("5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if len(games) != 1 || games[0].Id != "partial" || len(games[0].Stories) != 2 {
+		t.Fatalf(`expected only the partially estimated game got %v`, games)
+	}
+}
+
+// TestHasUnestimatedStory calls hasUnestimatedStory and makes sure pointed and skipped stories count as estimated
+func TestHasUnestimatedStory(t *testing.T) {
+	if hasUnestimatedStory([]*thunderdome.Story{{Points: "1"}, {Skipped: true}}) {
+		t.Fatalf(`expected pointed and skipped stories to be estimated`)
+	}
+	if !hasUnestimatedStory([]*thunderdome.Story{{Points: "1"}, {}}) {
+		t.Fatalf(`expected a story without points to be unestimated`)
+	}
+}
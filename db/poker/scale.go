@@ -0,0 +1,140 @@
+package poker
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// SetGameCustomScale sets the games custom vote scale, the labels become the allowed point values
+// while the ordinals are used for numeric operations such as the median, an empty scale clears it
+func (d *Service) SetGameCustomScale(PokerID string, CustomScale []thunderdome.ScaleValue) error {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return err
+	}
+	if err := validateCustomScale(CustomScale); err != nil {
+		return err
+	}
+	if err := d.ensureNotArchived(PokerID); err != nil {
+		return err
+	}
+
+	if CustomScale == nil {
+		CustomScale = make([]thunderdome.ScaleValue, 0)
+	}
+	var scaleJSON, _ = json.Marshal(CustomScale)
+
+	query := `UPDATE thunderdome.poker SET custom_scale = $2, updated_date = NOW() WHERE id = $1;`
+	args := []interface{}{PokerID, string(scaleJSON)}
+	if len(CustomScale) > 0 {
+		var pointValuesJSON, _ = json.Marshal(scaleLabels(CustomScale))
+		query = `UPDATE thunderdome.poker SET custom_scale = $2, point_values_allowed = $3, updated_date = NOW() WHERE id = $1;`
+		args = append(args, string(pointValuesJSON))
+	}
+
+	if _, err := d.DB.Exec(query, args...); err != nil {
+		d.Logger.Error("update poker custom_scale error", zap.Error(err))
+		return fmt.Errorf("unable to update poker custom scale: %w", err)
+	}
+
+	return nil
+}
+
+// validateCustomScale checks the custom scale labels are present, unique and short enough to be story points
+func validateCustomScale(CustomScale []thunderdome.ScaleValue) error {
+	labels := make(map[string]bool)
+
+	for _, sv := range CustomScale {
+		if sv.Label == "" {
+			return fmt.Errorf("%w: custom scale label required", thunderdome.ErrValidation)
+		}
+		if err := validateStoryPoints(sv.Label); err != nil {
+			return err
+		}
+		if labels[sv.Label] {
+			return fmt.Errorf("%w: duplicate custom scale label %q", thunderdome.ErrValidation, sv.Label)
+		}
+		labels[sv.Label] = true
+	}
+
+	return nil
+}
+
+// UpdateGameScale changes the games allowed point values, clearing any votes on the active story
+// that are not in the new scale, finalized stories points are left as is
+func (d *Service) UpdateGameScale(PokerID string, FacilitatorID string, NewScale []string) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID, FacilitatorID); err != nil {
+		return nil, err
+	}
+	if len(NewScale) == 0 {
+		return nil, fmt.Errorf("%w: point scale required", thunderdome.ErrValidation)
+	}
+	if err := d.ensureNotArchived(PokerID); err != nil {
+		return nil, err
+	}
+	isFacilitator, err := d.IsFacilitator(PokerID, FacilitatorID)
+	if err != nil {
+		return nil, err
+	}
+	if !isFacilitator {
+		return nil, errors.New("REQUIRES_FACILITATOR")
+	}
+
+	var pointValuesJSON, _ = json.Marshal(NewScale)
+	if _, err := d.DB.Exec(
+		`UPDATE thunderdome.poker SET point_values_allowed = $2, updated_date = NOW() WHERE id = $1;`,
+		PokerID, string(pointValuesJSON),
+	); err != nil {
+		d.Logger.Error("update poker point_values_allowed error", zap.Error(err))
+		return nil, fmt.Errorf("unable to update poker scale: %w", err)
+	}
+
+	var StoryID string
+	var v string
+	var Votes = make([]*thunderdome.Vote, 0)
+	err = d.DB.QueryRow(
+		`SELECT ps.id, ps.votes FROM thunderdome.poker_story ps
+		JOIN thunderdome.poker p ON p.active_story_id = ps.id
+		WHERE p.id = $1;`,
+		PokerID,
+	).Scan(&StoryID, &v)
+	if err == nil {
+		// corrupt votes are left in place for investigation rather than overwritten
+		if Votes, err = decodeStoryVotes(v); err != nil {
+			d.Logger.Error("get poker active story corrupt votes error", zap.String("story_id", StoryID), zap.Error(err))
+		} else if kept, cleared := filterVotesByScale(Votes, NewScale); cleared > 0 {
+			var votesJSON, _ = json.Marshal(kept)
+			if _, err := d.DB.Exec(
+				`UPDATE thunderdome.poker_story SET votes = $2, updated_date = NOW() WHERE id = $1;`,
+				StoryID, string(votesJSON),
+			); err != nil {
+				d.Logger.Error("clear poker story invalid votes error", zap.Error(err))
+			}
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		d.Logger.Error("get poker active story votes error", zap.Error(err))
+	}
+
+	plans := d.getStories(d.DB, PokerID, "")
+
+	return plans, nil
+}
+
+// filterVotesByScale keeps only the votes whose value is in the scale, returning how many were cleared
+func filterVotesByScale(Votes []*thunderdome.Vote, Scale []string) ([]*thunderdome.Vote, int) {
+	kept := make([]*thunderdome.Vote, 0, len(Votes))
+
+	for _, vote := range Votes {
+		if db.Contains(Scale, vote.VoteValue) {
+			kept = append(kept, vote)
+		}
+	}
+
+	return kept, len(Votes) - len(kept)
+}
@@ -0,0 +1,27 @@
+package poker
+
+import (
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestFilterVotesByScale calls filterVotesByScale with a new scale
+// and makes sure votes in the scale survive while the rest are cleared
+func TestFilterVotesByScale(t *testing.T) {
+	votes := []*thunderdome.Vote{
+		{UserId: "a", VoteValue: "1"},
+		{UserId: "b", VoteValue: "13"},
+		{UserId: "c", VoteValue: "3"},
+		{UserId: "d", VoteValue: "?"},
+	}
+
+	kept, cleared := filterVotesByScale(votes, []string{"1", "2", "3", "5", "8"})
+
+	if cleared != 2 {
+		t.Fatalf(`expected 2 cleared votes got %d`, cleared)
+	}
+	if len(kept) != 2 || kept[0].UserId != "a" || kept[1].UserId != "c" {
+		t.Fatalf(`expected votes from a and c to survive got %v`, kept)
+	}
+}
@@ -0,0 +1,45 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/dbtest"
+)
+
+// TestServicesUseTheirOwnDependencies constructs two services against separate databases
+// and makes sure each reads and fails only through its own database
+func TestServicesUseTheirOwnDependencies(t *testing.T) {
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	asgard, asgardDB := newTestService(t)
+	midgard, midgardDB := newTestService(t)
+	named := func(f *dbtest.DB, Name string) {
+		f.Query("FROM thunderdome.poker b", gameColumns, func(args []driver.Value) ([][]driver.Value, error) {
+			row := gameRow(args[0], "[]")
+			row[1] = Name
+			return [][]driver.Value{row}, nil
+		})
+	}
+	named(asgardDB, "Asgard")
+	named(midgardDB, "Midgard")
+
+	a, err := asgard.GetGame(PokerID, "")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	m, err := midgard.GetGame(PokerID, "")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if a.Name != "Asgard" || m.Name != "Midgard" {
+		t.Fatalf(`expected each service to read its own game got %q and %q`, a.Name, m.Name)
+	}
+
+	asgardCalls, midgardCalls := asgardDB.Calls(""), midgardDB.Calls("")
+	if _, err := asgard.GetVoteFrequency(PokerID); err == nil {
+		t.Fatalf(`expected error from the service without the vote frequency query`)
+	}
+	if asgardDB.Calls("") == asgardCalls || midgardDB.Calls("") != midgardCalls {
+		t.Fatalf(`expected the failing query to only reach its own services database`)
+	}
+}
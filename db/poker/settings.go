@@ -0,0 +1,220 @@
+package poker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// UpdateGameSettings applies the set game settings in a single update so they change together,
+// only a facilitator can update the settings
+func (d *Service) UpdateGameSettings(PokerID string, FacilitatorID string, Settings thunderdome.PokerSettings) (*thunderdome.Poker, error) {
+	if err := db.ValidateUUID(PokerID, FacilitatorID); err != nil {
+		return nil, err
+	}
+	columns, args, err := gameSettingsUpdate(Settings)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.ensureNotArchived(PokerID); err != nil {
+		return nil, err
+	}
+	if err := d.ConfirmFacilitator(PokerID, FacilitatorID); err != nil {
+		return nil, err
+	}
+
+	if _, err := d.DB.Exec(
+		gameColumnsUpdateQuery(columns),
+		append([]interface{}{PokerID}, args...)...,
+	); err != nil {
+		d.Logger.Error("update poker settings error", zap.Error(err))
+		return nil, fmt.Errorf("unable to update poker settings: %w", err)
+	}
+
+	return d.GetGame(PokerID, FacilitatorID)
+}
+
+// gameColumnsUpdateQuery builds the update of the games columns with the game ID as $1 followed by the column values
+func gameColumnsUpdateQuery(columns []string) string {
+	sets := make([]string, 0, len(columns)+1)
+	for i, column := range columns {
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, i+2))
+	}
+	sets = append(sets, "updated_date = NOW()")
+
+	return `UPDATE thunderdome.poker SET ` + strings.Join(sets, ", ") + ` WHERE id = $1;`
+}
+
+// updateGameColumnsTx sets the games columns returned by createSettingsUpdate within the transaction,
+// nothing is updated without columns
+func (d *Service) updateGameColumnsTx(ctx context.Context, tx *sql.Tx, PokerID string, columns []string, args []interface{}) error {
+	if len(columns) == 0 {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		gameColumnsUpdateQuery(columns),
+		append([]interface{}{PokerID}, args...)...,
+	); err != nil {
+		d.Logger.Ctx(ctx).Error("update poker create settings error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// createSettingsUpdate validates the custom scale and settings a game is created with returning the columns
+// to set with their values like gameSettingsUpdate, no columns are returned when neither is given
+func createSettingsUpdate(CustomScale []thunderdome.ScaleValue, Settings thunderdome.PokerSettings) ([]string, []interface{}, error) {
+	columns := make([]string, 0)
+	args := make([]interface{}, 0)
+
+	if len(CustomScale) > 0 {
+		if err := validateCustomScale(CustomScale); err != nil {
+			return nil, nil, err
+		}
+		scaleJSON, _ := json.Marshal(CustomScale)
+		pointValuesJSON, _ := json.Marshal(scaleLabels(CustomScale))
+		columns = append(columns, "custom_scale", "point_values_allowed")
+		args = append(args, string(scaleJSON), string(pointValuesJSON))
+	}
+	if !emptyGameSettings(Settings) {
+		settingsColumns, settingsArgs, err := gameSettingsUpdate(Settings)
+		if err != nil {
+			return nil, nil, err
+		}
+		columns = append(columns, settingsColumns...)
+		args = append(args, settingsArgs...)
+	}
+
+	return columns, args, nil
+}
+
+// emptyGameSettings checks none of the settings are set
+func emptyGameSettings(Settings thunderdome.PokerSettings) bool {
+	return Settings.AutoFinishVoting == nil && Settings.PointAverageRounding == nil && Settings.HideVoterIdentity == nil &&
+		Settings.EstimationUnit == nil && Settings.TieBreakStrategy == nil && Settings.MinVotersToFinalize == nil &&
+		Settings.VoteRevealThreshold == nil && Settings.ParallelVoting == nil && Settings.RequireNamedUsers == nil &&
+		Settings.VoteAliases == nil
+}
+
+// applyCreateSettings updates the created game with the custom scale and settings set by createSettingsUpdate
+func applyCreateSettings(Game *thunderdome.Poker, CustomScale []thunderdome.ScaleValue, Settings thunderdome.PokerSettings) {
+	if len(CustomScale) > 0 {
+		Game.CustomScale = CustomScale
+		Game.PointValuesAllowed = scaleLabels(CustomScale)
+	}
+	if Settings.AutoFinishVoting != nil {
+		Game.AutoFinishVoting = *Settings.AutoFinishVoting
+	}
+	if Settings.PointAverageRounding != nil {
+		Game.PointAverageRounding = *Settings.PointAverageRounding
+	}
+	if Settings.HideVoterIdentity != nil {
+		Game.HideVoterIdentity = *Settings.HideVoterIdentity
+	}
+	if Settings.EstimationUnit != nil {
+		Game.EstimationUnit, _ = normalizeEstimationUnit(*Settings.EstimationUnit)
+	}
+	if Settings.TieBreakStrategy != nil {
+		Game.TieBreakStrategy, _ = normalizeTieBreakStrategy(*Settings.TieBreakStrategy)
+	}
+	if Settings.MinVotersToFinalize != nil {
+		Game.MinVotersToFinalize = *Settings.MinVotersToFinalize
+	}
+	if Settings.VoteRevealThreshold != nil {
+		Game.VoteRevealThreshold = *Settings.VoteRevealThreshold
+	}
+	if Settings.ParallelVoting != nil {
+		Game.ParallelVoting = *Settings.ParallelVoting
+	}
+	if Settings.RequireNamedUsers != nil {
+		Game.RequireNamedUsers = *Settings.RequireNamedUsers
+	}
+	if Settings.VoteAliases != nil {
+		Game.VoteAliases = Settings.VoteAliases
+	}
+}
+
+// gameSettingsUpdate validates the set settings returning the columns to update with their values,
+// column names only ever come from this function never from input
+func gameSettingsUpdate(Settings thunderdome.PokerSettings) ([]string, []interface{}, error) {
+	columns := make([]string, 0)
+	args := make([]interface{}, 0)
+
+	if Settings.AutoFinishVoting != nil {
+		columns = append(columns, "auto_finish_voting")
+		args = append(args, *Settings.AutoFinishVoting)
+	}
+	if Settings.PointAverageRounding != nil {
+		if !db.Contains([]string{"ceil", "round", "floor"}, *Settings.PointAverageRounding) {
+			return nil, nil, fmt.Errorf("%w: invalid point average rounding %q", thunderdome.ErrValidation, *Settings.PointAverageRounding)
+		}
+		columns = append(columns, "point_average_rounding")
+		args = append(args, *Settings.PointAverageRounding)
+	}
+	if Settings.HideVoterIdentity != nil {
+		columns = append(columns, "hide_voter_identity")
+		args = append(args, *Settings.HideVoterIdentity)
+	}
+	if Settings.EstimationUnit != nil {
+		unit, err := normalizeEstimationUnit(*Settings.EstimationUnit)
+		if err != nil {
+			return nil, nil, err
+		}
+		columns = append(columns, "estimation_unit")
+		args = append(args, unit)
+	}
+	if Settings.TieBreakStrategy != nil {
+		strategy, err := normalizeTieBreakStrategy(*Settings.TieBreakStrategy)
+		if err != nil {
+			return nil, nil, err
+		}
+		columns = append(columns, "tie_break_strategy")
+		args = append(args, strategy)
+	}
+	if Settings.MinVotersToFinalize != nil {
+		if *Settings.MinVotersToFinalize < 0 {
+			return nil, nil, fmt.Errorf("%w: min voters to finalize can't be negative", thunderdome.ErrValidation)
+		}
+		columns = append(columns, "min_voters_to_finalize")
+		args = append(args, *Settings.MinVotersToFinalize)
+	}
+	if Settings.VoteRevealThreshold != nil {
+		if *Settings.VoteRevealThreshold < 0 {
+			return nil, nil, fmt.Errorf("%w: vote reveal threshold can't be negative", thunderdome.ErrValidation)
+		}
+		columns = append(columns, "vote_reveal_threshold")
+		args = append(args, *Settings.VoteRevealThreshold)
+	}
+	// turning parallel voting off leaves only the games current active story open, see reconcileGameState
+	if Settings.ParallelVoting != nil {
+		columns = append(columns, "parallel_voting")
+		args = append(args, *Settings.ParallelVoting)
+	}
+	if Settings.RequireNamedUsers != nil {
+		columns = append(columns, "require_named_users")
+		args = append(args, *Settings.RequireNamedUsers)
+	}
+	if Settings.VoteAliases != nil {
+		if err := validateVoteAliases(Settings.VoteAliases); err != nil {
+			return nil, nil, err
+		}
+		aliases, _ := json.Marshal(Settings.VoteAliases)
+		columns = append(columns, "vote_aliases")
+		args = append(args, string(aliases))
+	}
+
+	if len(columns) == 0 {
+		return nil, nil, fmt.Errorf("%w: no settings to update", thunderdome.ErrValidation)
+	}
+
+	return columns, args, nil
+}
@@ -0,0 +1,75 @@
+package poker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestGameSettingsUpdateSubset calls gameSettingsUpdate with only some settings set, including zero values,
+// and makes sure only the set settings are updated with zero values kept distinct from unset
+func TestGameSettingsUpdateSubset(t *testing.T) {
+	hide := false
+	minVoters := 0
+	unit := thunderdome.EstimationUnitHours
+
+	columns, args, err := gameSettingsUpdate(thunderdome.PokerSettings{
+		HideVoterIdentity:   &hide,
+		EstimationUnit:      &unit,
+		MinVotersToFinalize: &minVoters,
+	})
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+
+	want := map[string]interface{}{
+		"hide_voter_identity":    false,
+		"estimation_unit":        thunderdome.EstimationUnitHours,
+		"min_voters_to_finalize": 0,
+	}
+	if len(columns) != len(want) || len(args) != len(want) {
+		t.Fatalf(`expected %d settings updated got %v`, len(want), columns)
+	}
+	for i, column := range columns {
+		value, ok := want[column]
+		if !ok {
+			t.Fatalf(`expected unset setting %s to be left unchanged`, column)
+		}
+		if args[i] != value {
+			t.Fatalf(`expected %s set to %v got %v`, column, value, args[i])
+		}
+	}
+}
+
+// TestGameSettingsUpdateValidation calls gameSettingsUpdate with no settings and invalid settings
+// and makes sure nothing is updated when any setting is invalid
+func TestGameSettingsUpdateValidation(t *testing.T) {
+	if _, _, err := gameSettingsUpdate(thunderdome.PokerSettings{}); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected no settings to be invalid got %v`, err)
+	}
+
+	autoFinish := true
+	rounding := "nearest"
+	if _, _, err := gameSettingsUpdate(thunderdome.PokerSettings{
+		AutoFinishVoting:     &autoFinish,
+		PointAverageRounding: &rounding,
+	}); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected invalid rounding to fail the whole update got %v`, err)
+	}
+
+	threshold := -1
+	if _, _, err := gameSettingsUpdate(thunderdome.PokerSettings{VoteRevealThreshold: &threshold}); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected negative reveal threshold to be invalid got %v`, err)
+	}
+
+	if _, _, err := gameSettingsUpdate(thunderdome.PokerSettings{VoteAliases: map[string]string{"big": ""}}); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected empty vote alias value to be invalid got %v`, err)
+	}
+
+	strategy := ""
+	columns, args, err := gameSettingsUpdate(thunderdome.PokerSettings{TieBreakStrategy: &strategy})
+	if err != nil || len(columns) != 1 || args[0] != thunderdome.TieBreakRoundUp {
+		t.Fatalf(`expected empty tie break strategy to reset to round-up got %v %v %v`, columns, args, err)
+	}
+}
@@ -0,0 +1,172 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// votingDB is a fake database standing in for a games active story,
+// when finalizeOnRead is set the story is finalized right after SetVote reads it like a facilitator racing the vote
+type votingDB struct {
+	active         bool
+	finalizeOnRead bool
+	votes          int
+	requireNamed   bool
+	userName       string
+}
+
+func newVotingService(t *testing.T) (*Service, *votingDB) {
+	svc, f := newTestService(t)
+	d := &votingDB{}
+	f.Exec("UPDATE thunderdome.poker_story p1", func(args []driver.Value) (int64, error) {
+		d.votes++
+		return 1, nil
+	})
+	f.Exec("p1.active = true", func(args []driver.Value) (int64, error) {
+		if !d.active {
+			return 0, nil
+		}
+		d.votes++
+		return 1, nil
+	})
+	f.Query("p.require_named_users", []string{"require_named_users", "name"}, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{d.requireNamed, d.userName}}, nil
+	})
+	f.Query("COALESCE(p.vote_mode, 'points')", voteColumns, func(args []driver.Value) ([][]driver.Value, error) {
+		if d.finalizeOnRead {
+			d.active = false
+		}
+		return [][]driver.Value{pointsVoteRow("[]")}, nil
+	})
+
+	return svc, d
+}
+
+// TestSetVoteFinalizeRace calls SetVote while the story is finalized between the vote being read and written
+// and makes sure the vote is rejected with ErrVotingClosed rather than landing on the finalized story
+func TestSetVoteFinalizeRace(t *testing.T) {
+	svc, voting := newVotingService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	UserID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	StoryID := "9c8b7a6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+
+	voting.active, voting.finalizeOnRead = true, false
+	if _, _, err := svc.SetVote(PokerID, UserID, StoryID, "3", ""); err != nil {
+		t.Fatalf(`expected vote on an active story to be counted got %v`, err)
+	}
+	if voting.votes != 1 {
+		t.Fatalf(`expected 1 vote written got %d`, voting.votes)
+	}
+
+	voting.active, voting.finalizeOnRead = true, true
+	if _, _, err := svc.SetVote(PokerID, UserID, StoryID, "5", ""); !errors.Is(err, thunderdome.ErrVotingClosed) {
+		t.Fatalf(`expected vote racing finalize to return ErrVotingClosed got %v`, err)
+	}
+	if voting.votes != 1 {
+		t.Fatalf(`expected vote racing finalize not to be written got %d votes`, voting.votes)
+	}
+}
+
+// TestSetVoteRequireNamedUsers calls SetVote in a game requiring named users
+// and makes sure only users with a name of their own can vote
+func TestSetVoteRequireNamedUsers(t *testing.T) {
+	svc, voting := newVotingService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	UserID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	StoryID := "9c8b7a6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+
+	voting.active, voting.finalizeOnRead, voting.votes = true, false, 0
+	voting.requireNamed = true
+	for _, name := range []string{"", "  ", "Rusty Gladiator"} {
+		voting.userName = name
+		if _, _, err := svc.SetVote(PokerID, UserID, StoryID, "3", ""); !errors.Is(err, thunderdome.ErrUserNameRequired) {
+			t.Fatalf(`expected vote from user named %q to return ErrUserNameRequired got %v`, name, err)
+		}
+	}
+	if voting.votes != 0 {
+		t.Fatalf(`expected votes from unnamed users not to be written got %d votes`, voting.votes)
+	}
+
+	voting.userName = "Ada"
+	if _, _, err := svc.SetVote(PokerID, UserID, StoryID, "3", ""); err != nil {
+		t.Fatalf(`expected vote from a named user to be counted got %v`, err)
+	}
+
+	voting.requireNamed, voting.userName = false, ""
+	if _, _, err := svc.SetVote(PokerID, UserID, StoryID, "5", ""); err != nil {
+		t.Fatalf(`expected vote from an unnamed user to be counted when names aren't required got %v`, err)
+	}
+	if voting.votes != 2 {
+		t.Fatalf(`expected 2 votes written got %d`, voting.votes)
+	}
+}
+
+// TestVotingClosedError calls votingClosedError with the result of a vote update
+// and makes sure only an update that matched no active story returns ErrVotingClosed
+func TestVotingClosedError(t *testing.T) {
+	if err := votingClosedError(driver.RowsAffected(1)); err != nil {
+		t.Fatalf(`expected counted vote to be allowed got %v`, err)
+	}
+	if err := votingClosedError(driver.RowsAffected(0)); !errors.Is(err, thunderdome.ErrVotingClosed) {
+		t.Fatalf(`expected ErrVotingClosed got %v`, err)
+	}
+}
+
+// TestGetStoryVoteCount calls SetVote for several users, including one changing their vote,
+// and makes sure the story vote count matches the number of users who voted out of the active users
+func TestGetStoryVoteCount(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	StoryID := "9c8b7a6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+	UserIDs := []string{
+		"5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f",
+		"1e2d3c4b-5a69-4788-9a6b-5c4d3e2f1a0b",
+		"8c3a1f0e-2b4d-4e6f-9a1b-3c5d7e9f1a2b",
+		"5f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d",
+	}
+	votes := make(map[string]string)
+	votesJSON := func() string {
+		vs := make([]*thunderdome.Vote, 0, len(votes))
+		for UserID, VoteValue := range votes {
+			vs = append(vs, &thunderdome.Vote{UserId: UserID, VoteValue: VoteValue})
+		}
+		b, _ := json.Marshal(vs)
+		return string(b)
+	}
+	f.Query("COALESCE(p.vote_mode, 'points')", voteColumns, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{pointsVoteRow(votesJSON())}, nil
+	})
+	f.Exec("UPDATE thunderdome.poker_story p1", func(args []driver.Value) (int64, error) {
+		votes[args[1].(string)] = args[2].(string)
+		return 1, nil
+	})
+	f.Query("jsonb_array_length(votes)", []string{"count"}, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{int64(len(votes))}}, nil
+	})
+	f.Rows("active = true AND spectator = false", []string{"count"}, []driver.Value{int64(len(UserIDs))})
+
+	for i, vote := range []string{"3", "5", "3", "8"} {
+		if _, _, err := svc.SetVote(PokerID, UserIDs[i%3], StoryID, vote, ""); err != nil {
+			t.Fatalf(`unexpected error %v`, err)
+		}
+	}
+
+	count, err := svc.GetStoryVoteCount(StoryID)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if count != 3 {
+		t.Fatalf(`expected 3 votes after a user changed their vote got %d`, count)
+	}
+	active, err := svc.CountActiveUsers(PokerID)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if active != len(UserIDs) {
+		t.Fatalf(`expected %d active users got %d`, len(UserIDs), active)
+	}
+}
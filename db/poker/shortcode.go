@@ -0,0 +1,108 @@
+package poker
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// shortCodeAlphabet is the RFC 4648 base32 alphabet which avoids easily confused characters like 0/O and 1/I
+const shortCodeAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
+
+const shortCodeLength = 6
+
+// shortCodeMaxAttempts is how many codes are tried before giving up on a run of collisions
+const shortCodeMaxAttempts = 5
+
+// GetGameByShortCode retrieves the poker game by its short join code
+func (d *Service) GetGameByShortCode(ShortCode string, UserID string) (*thunderdome.Poker, error) {
+	ShortCode, err := normalizeShortCode(ShortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	var PokerID string
+	if err := d.DB.QueryRow(
+		`SELECT id FROM thunderdome.poker WHERE short_code = $1;`, ShortCode,
+	).Scan(&PokerID); err != nil {
+		return nil, errors.New("not found")
+	}
+
+	return d.GetGame(PokerID, UserID)
+}
+
+// assignShortCode generates a unique short code for the poker game, regenerating on collisions
+func (d *Service) assignShortCode(ctx context.Context, PokerID string) (string, error) {
+	code, err := retryShortCode(generateShortCode, func(code string) (bool, error) {
+		_, err := d.DB.ExecContext(ctx,
+			`UPDATE thunderdome.poker SET short_code = $2 WHERE id = $1;`,
+			PokerID, code,
+		)
+		if db.IsUniqueViolation(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		d.Logger.Error("poker short_code assign error", zap.Error(err))
+		return "", fmt.Errorf("unable to assign poker short code: %w", err)
+	}
+
+	return code, nil
+}
+
+// retryShortCode generates codes until assign succeeds without the code already being taken
+func retryShortCode(generate func() (string, error), assign func(code string) (bool, error)) (string, error) {
+	for i := 0; i < shortCodeMaxAttempts; i++ {
+		code, err := generate()
+		if err != nil {
+			return "", err
+		}
+		taken, err := assign(code)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return code, nil
+		}
+	}
+
+	return "", errors.New("SHORT_CODE_COLLISION")
+}
+
+// generateShortCode returns a random secure base32 short code
+func generateShortCode() (string, error) {
+	code := make([]byte, shortCodeLength)
+	for i := range code {
+		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(shortCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = shortCodeAlphabet[num.Int64()]
+	}
+
+	return string(code), nil
+}
+
+// normalizeShortCode uppercases the short code ignoring spaces and dashes people add when reading it out
+func normalizeShortCode(ShortCode string) (string, error) {
+	ShortCode = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(ShortCode))
+	if len(ShortCode) != shortCodeLength {
+		return "", fmt.Errorf("%w: short code must be %d characters", thunderdome.ErrValidation, shortCodeLength)
+	}
+	for _, c := range ShortCode {
+		if !strings.ContainsRune(shortCodeAlphabet, c) {
+			return "", fmt.Errorf("%w: invalid short code", thunderdome.ErrValidation)
+		}
+	}
+
+	return ShortCode, nil
+}
@@ -0,0 +1,95 @@
+package poker
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestGenerateShortCode calls generateShortCode repeatedly and makes sure each code
+// is the expected length using only base32 characters and codes aren't repeated
+func TestGenerateShortCode(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 200; i++ {
+		code, err := generateShortCode()
+		if err != nil {
+			t.Fatalf(`unexpected error generating short code %v`, err)
+		}
+		if len(code) != shortCodeLength {
+			t.Fatalf(`expected short code length %d got %q`, shortCodeLength, code)
+		}
+		for _, c := range code {
+			if !strings.ContainsRune(shortCodeAlphabet, c) {
+				t.Fatalf(`expected base32 short code got %q`, code)
+			}
+		}
+		if seen[code] {
+			t.Fatalf(`expected unique short codes got %q twice`, code)
+		}
+		seen[code] = true
+	}
+}
+
+// TestRetryShortCode calls retryShortCode with codes that are already taken
+// and makes sure it regenerates until a free code is found or gives up after max attempts
+func TestRetryShortCode(t *testing.T) {
+	codes := []string{"AAAAAA", "BBBBBB", "CCCCCC"}
+	next := 0
+	generate := func() (string, error) {
+		code := codes[next%len(codes)]
+		next++
+		return code, nil
+	}
+	taken := map[string]bool{"AAAAAA": true, "BBBBBB": true}
+
+	code, err := retryShortCode(generate, func(code string) (bool, error) {
+		return taken[code], nil
+	})
+	if err != nil || code != "CCCCCC" {
+		t.Fatalf(`expected collisions to regenerate to CCCCCC got %q %v`, code, err)
+	}
+
+	next = 0
+	attempts := 0
+	if _, err := retryShortCode(generate, func(code string) (bool, error) {
+		attempts++
+		return true, nil
+	}); err == nil || attempts != shortCodeMaxAttempts {
+		t.Fatalf(`expected to give up after %d attempts got %d %v`, shortCodeMaxAttempts, attempts, err)
+	}
+
+	assignErr := errors.New("db down")
+	if _, err := retryShortCode(generate, func(code string) (bool, error) {
+		return false, assignErr
+	}); !errors.Is(err, assignErr) {
+		t.Fatalf(`expected assign error to be returned got %v`, err)
+	}
+}
+
+// TestNormalizeShortCode calls normalizeShortCode with codes as people might type them
+// and makes sure they match the stored code while malformed codes fail validation
+func TestNormalizeShortCode(t *testing.T) {
+	for _, input := range []string{"ab2c7z", "AB2-C7Z", " ab2 c7z "} {
+		code, err := normalizeShortCode(input)
+		if err != nil || code != "AB2C7Z" {
+			t.Fatalf(`expected %q to normalize to AB2C7Z got %q %v`, input, code, err)
+		}
+	}
+
+	for _, input := range []string{"", "AB2C7", "AB2C7ZZ", "AB0C1Z", "AB2C7!"} {
+		if _, err := normalizeShortCode(input); !errors.Is(err, thunderdome.ErrValidation) {
+			t.Fatalf(`expected %q to be invalid got %v`, input, err)
+		}
+	}
+}
+
+// TestGetGameByShortCodeValidation calls GetGameByShortCode with a malformed code
+// and makes sure it fails validation before querying the database
+func TestGetGameByShortCodeValidation(t *testing.T) {
+	d := &Service{}
+	if _, err := d.GetGameByShortCode("not-a-code", ""); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected invalid short code to fail validation got %v`, err)
+	}
+}
@@ -0,0 +1,275 @@
+package poker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// gameSnapshotVersion is the snapshot format version, bumped when the format changes incompatibly
+const gameSnapshotVersion = 1
+
+// gameSnapshot is a games settings, users, facilitators, and stories with their votes and points,
+// join and facilitator codes aren't included so a snapshot can be shared without them
+type gameSnapshot struct {
+	Version              int                      `json:"version"`
+	Name                 string                   `json:"name"`
+	OwnerID              string                   `json:"ownerId"`
+	VotingLocked         bool                     `json:"votingLocked"`
+	PointValuesAllowed   []string                 `json:"pointValuesAllowed"`
+	AutoFinishVoting     bool                     `json:"autoFinishVoting"`
+	PointAverageRounding string                   `json:"pointAverageRounding"`
+	HideVoterIdentity    bool                     `json:"hideVoterIdentity"`
+	VoteMode             string                   `json:"voteMode"`
+	CustomScale          []thunderdome.ScaleValue `json:"customScale"`
+	EstimationUnit       string                   `json:"estimationUnit"`
+	TieBreakStrategy     string                   `json:"tieBreakStrategy"`
+	MinVotersToFinalize  int                      `json:"minVotersToFinalize"`
+	VoteRevealThreshold  int                      `json:"voteRevealThreshold"`
+	Facilitators         []string                 `json:"facilitators"`
+	Users                []gameSnapshotUser       `json:"users"`
+	Stories              []gameSnapshotStory      `json:"stories"`
+	ParallelVoting       bool                     `json:"parallelVoting"`
+}
+
+// gameSnapshotUser is a users association with the game
+type gameSnapshotUser struct {
+	UserID    string `json:"userId"`
+	Abandoned bool   `json:"abandoned"`
+	Spectator bool   `json:"spectator"`
+}
+
+// gameSnapshotStory is a story in the game, the ID is only used to restore the active story
+type gameSnapshotStory struct {
+	Id                 string              `json:"id"`
+	Name               string              `json:"name"`
+	Type               string              `json:"type"`
+	ReferenceId        string              `json:"referenceId"`
+	Link               string              `json:"link"`
+	Description        string              `json:"description"`
+	AcceptanceCriteria string              `json:"acceptanceCriteria"`
+	Priority           int32               `json:"priority"`
+	Position           int32               `json:"position"`
+	Points             string              `json:"points"`
+	Active             bool                `json:"active"`
+	Skipped            bool                `json:"skipped"`
+	VotesRevealed      bool                `json:"votesRevealed"`
+	Votes              []*thunderdome.Vote `json:"votes"`
+	FinalizedTime      *time.Time          `json:"finalizedTime,omitempty"`
+}
+
+// SnapshotGame captures the games full state including every vote so it can be restored later e.g. for demos and QA
+func (d *Service) SnapshotGame(PokerID string) ([]byte, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
+	s := &gameSnapshot{Version: gameSnapshotVersion}
+	var pv string
+	var cs string
+	var facilitators string
+	if err := d.DB.QueryRow(
+		`SELECT p.name, p.owner_id, p.voting_locked, p.point_values_allowed, p.auto_finish_voting, p.point_average_rounding,
+			p.hide_voter_identity, COALESCE(p.vote_mode, 'points'), COALESCE(p.custom_scale, '[]'::jsonb), p.estimation_unit,
+			p.tie_break_strategy, p.min_voters_to_finalize, p.vote_reveal_threshold, p.parallel_voting,
+			COALESCE((SELECT json_agg(pf.user_id) FROM thunderdome.poker_facilitator pf WHERE pf.poker_id = p.id), '[]')
+		FROM thunderdome.poker p WHERE p.id = $1;`,
+		PokerID,
+	).Scan(
+		&s.Name, &s.OwnerID, &s.VotingLocked, &pv, &s.AutoFinishVoting, &s.PointAverageRounding,
+		&s.HideVoterIdentity, &s.VoteMode, &cs, &s.EstimationUnit,
+		&s.TieBreakStrategy, &s.MinVotersToFinalize, &s.VoteRevealThreshold, &s.ParallelVoting,
+		&facilitators,
+	); err != nil {
+		d.Logger.Error("poker snapshot game query error", zap.Error(err))
+		return nil, errors.New("not found")
+	}
+	_ = json.Unmarshal([]byte(pv), &s.PointValuesAllowed)
+	_ = json.Unmarshal([]byte(cs), &s.CustomScale)
+	_ = json.Unmarshal([]byte(facilitators), &s.Facilitators)
+
+	userRows, err := d.DB.Query(
+		`SELECT user_id, abandoned, spectator FROM thunderdome.poker_user WHERE poker_id = $1;`,
+		PokerID,
+	)
+	if err != nil {
+		d.Logger.Error("poker snapshot users query error", zap.Error(err))
+		return nil, fmt.Errorf("unable to snapshot poker: %w", err)
+	}
+	defer userRows.Close()
+	for userRows.Next() {
+		var u gameSnapshotUser
+		if err := userRows.Scan(&u.UserID, &u.Abandoned, &u.Spectator); err != nil {
+			d.Logger.Error("poker snapshot users scan error", zap.Error(err))
+			return nil, fmt.Errorf("unable to snapshot poker: %w", err)
+		}
+		s.Users = append(s.Users, u)
+	}
+
+	storyRows, err := d.DB.Query(
+		`SELECT id, name, type, COALESCE(reference_id, ''), COALESCE(link, ''), COALESCE(description, ''),
+			COALESCE(acceptance_criteria, ''), priority, position, points, active, skipped, votes_revealed, votes, finalized_date
+		FROM thunderdome.poker_story WHERE poker_id = $1 ORDER BY position, created_date;`,
+		PokerID,
+	)
+	if err != nil {
+		d.Logger.Error("poker snapshot stories query error", zap.Error(err))
+		return nil, fmt.Errorf("unable to snapshot poker: %w", err)
+	}
+	defer storyRows.Close()
+	for storyRows.Next() {
+		var st gameSnapshotStory
+		var v string
+		var FinalizedDate sql.NullTime
+		if err := storyRows.Scan(
+			&st.Id, &st.Name, &st.Type, &st.ReferenceId, &st.Link, &st.Description,
+			&st.AcceptanceCriteria, &st.Priority, &st.Position, &st.Points, &st.Active, &st.Skipped, &st.VotesRevealed, &v, &FinalizedDate,
+		); err != nil {
+			d.Logger.Error("poker snapshot stories scan error", zap.Error(err))
+			return nil, fmt.Errorf("unable to snapshot poker: %w", err)
+		}
+		if st.Votes, err = decodeStoryVotes(v); err != nil {
+			d.Logger.Error("poker snapshot story corrupt votes error", zap.String("story_id", st.Id), zap.Error(err))
+			return nil, err
+		}
+		if FinalizedDate.Valid {
+			st.FinalizedTime = &FinalizedDate.Time
+		}
+		s.Stories = append(s.Stories, st)
+	}
+
+	return json.Marshal(s)
+}
+
+// RestoreGame creates a new game from a snapshot with new game and story IDs so it doesn't collide with the original,
+// users are associated with the restored game as inactive and users deleted since the snapshot are left out
+func (d *Service) RestoreGame(Data []byte) (*thunderdome.Poker, error) {
+	s, err := decodeGameSnapshot(Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var PokerID string
+	ctx := context.Background()
+	if err := d.WithTx(ctx, func(tx *sql.Tx) error {
+		pv, _ := json.Marshal(s.PointValuesAllowed)
+		cs, _ := json.Marshal(s.CustomScale)
+		if err := tx.QueryRow(
+			`INSERT INTO thunderdome.poker (name, owner_id, voting_locked, point_values_allowed, auto_finish_voting,
+				point_average_rounding, hide_voter_identity, vote_mode, custom_scale, estimation_unit, tie_break_strategy,
+				min_voters_to_finalize, vote_reveal_threshold, parallel_voting)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id;`,
+			s.Name, s.OwnerID, s.VotingLocked, string(pv), s.AutoFinishVoting,
+			s.PointAverageRounding, s.HideVoterIdentity, s.VoteMode, string(cs), s.EstimationUnit, s.TieBreakStrategy,
+			s.MinVotersToFinalize, s.VoteRevealThreshold, s.ParallelVoting,
+		).Scan(&PokerID); err != nil {
+			d.Logger.Error("poker restore game insert error", zap.Error(err))
+			return err
+		}
+
+		for _, u := range s.Users {
+			if _, err := tx.Exec(
+				`INSERT INTO thunderdome.poker_user (poker_id, user_id, active, abandoned, spectator)
+				SELECT $1, id, false, $3, $4 FROM thunderdome.users WHERE id = $2;`,
+				PokerID, u.UserID, u.Abandoned, u.Spectator,
+			); err != nil {
+				d.Logger.Error("poker restore user insert error", zap.Error(err))
+				return err
+			}
+		}
+		for _, FacilitatorID := range s.Facilitators {
+			if _, err := tx.Exec(
+				`INSERT INTO thunderdome.poker_facilitator (poker_id, user_id)
+				SELECT $1, id FROM thunderdome.users WHERE id = $2;`,
+				PokerID, FacilitatorID,
+			); err != nil {
+				d.Logger.Error("poker restore facilitator insert error", zap.Error(err))
+				return err
+			}
+		}
+
+		for _, st := range s.Stories {
+			votes, _ := json.Marshal(st.Votes)
+			var StoryID string
+			if err := tx.QueryRow(
+				`INSERT INTO thunderdome.poker_story (poker_id, name, type, reference_id, link, description, acceptance_criteria,
+					priority, position, points, points_numeric, active, skipped, votes_revealed, votes, finalized_date)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, thunderdome.poker_points_to_numeric($10), $11, $12, $13, $14, $15)
+				RETURNING id;`,
+				PokerID, st.Name, st.Type, st.ReferenceId, st.Link, st.Description, st.AcceptanceCriteria,
+				st.Priority, st.Position, st.Points, st.Active, st.Skipped, st.VotesRevealed, string(votes), st.FinalizedTime,
+			).Scan(&StoryID); err != nil {
+				d.Logger.Error("poker restore story insert error", zap.Error(err))
+				return err
+			}
+			if st.Active {
+				if _, err := tx.Exec(
+					`UPDATE thunderdome.poker SET active_story_id = $2 WHERE id = $1;`, PokerID, StoryID,
+				); err != nil {
+					d.Logger.Error("poker restore active story error", zap.Error(err))
+					return err
+				}
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.New("unable to restore poker")
+	}
+
+	if _, err := d.assignShortCode(ctx, PokerID); err != nil {
+		d.Logger.Error("poker restore short code error", zap.Error(err))
+	}
+
+	return d.GetGame(PokerID, "")
+}
+
+// decodeGameSnapshot reads the snapshot checking its version and that the IDs and votes it references are usable
+func decodeGameSnapshot(Data []byte) (*gameSnapshot, error) {
+	var s gameSnapshot
+	if err := json.Unmarshal(Data, &s); err != nil {
+		return nil, fmt.Errorf("%w: invalid snapshot: %v", thunderdome.ErrValidation, err)
+	}
+	if s.Version != gameSnapshotVersion {
+		return nil, fmt.Errorf("%w: unsupported snapshot version %d", thunderdome.ErrValidation, s.Version)
+	}
+	if s.Name == "" {
+		return nil, fmt.Errorf("%w: snapshot game name is required", thunderdome.ErrValidation)
+	}
+
+	UserIDs := []string{s.OwnerID}
+	UserIDs = append(UserIDs, s.Facilitators...)
+	for _, u := range s.Users {
+		UserIDs = append(UserIDs, u.UserID)
+	}
+	active := 0
+	for i := range s.Stories {
+		if s.Stories[i].Active {
+			active++
+		}
+		if s.Stories[i].Votes == nil {
+			s.Stories[i].Votes = make([]*thunderdome.Vote, 0)
+		}
+		for _, v := range s.Stories[i].Votes {
+			if v == nil || v.UserId == "" {
+				return nil, thunderdome.ErrCorruptVotes
+			}
+		}
+	}
+	if active > 1 && !s.ParallelVoting {
+		return nil, fmt.Errorf("%w: snapshot has %d active stories", thunderdome.ErrValidation, active)
+	}
+	if err := db.ValidateUUID(UserIDs...); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
@@ -0,0 +1,99 @@
+package poker
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestGameSnapshotRoundTrip calls decodeGameSnapshot with an encoded snapshot
+// and makes sure the stories votes and points come back intact
+func TestGameSnapshotRoundTrip(t *testing.T) {
+	owner := "6f5b2a3e-7d1c-4e8f-9a0b-1c2d3e4f5a6b"
+	voter := "0b1c2d3e-4f5a-4b6c-8d7e-9f0a1b2c3d4e"
+	snapshot := &gameSnapshot{
+		Version:            gameSnapshotVersion,
+		Name:               "Sprint 12",
+		OwnerID:            owner,
+		PointValuesAllowed: []string{"1", "2", "3", "5", "8"},
+		Facilitators:       []string{owner},
+		Users: []gameSnapshotUser{
+			{UserID: owner},
+			{UserID: voter},
+		},
+		Stories: []gameSnapshotStory{
+			{
+				Id: "finalized", Name: "Login", Points: "5", Position: 1,
+				Votes: []*thunderdome.Vote{
+					{UserId: owner, VoteValue: "5"},
+					{UserId: voter, VoteValue: "3", ComplexityValue: "8"},
+				},
+			},
+			{
+				Id: "voting", Name: "Logout", Active: true, Position: 2,
+				Votes: []*thunderdome.Vote{{UserId: voter, VoteValue: "2"}},
+			},
+			{Id: "unvoted", Name: "Signup", Position: 3},
+		},
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf(`expected snapshot to encode got %v`, err)
+	}
+
+	restored, err := decodeGameSnapshot(data)
+	if err != nil {
+		t.Fatalf(`expected snapshot to decode got %v`, err)
+	}
+
+	if len(restored.Stories) != len(snapshot.Stories) {
+		t.Fatalf(`expected %d stories got %d`, len(snapshot.Stories), len(restored.Stories))
+	}
+	for i, st := range snapshot.Stories {
+		rs := restored.Stories[i]
+		if rs.Points != st.Points || rs.Active != st.Active {
+			t.Fatalf(`expected story %s points %q active %v got %q %v`, st.Id, st.Points, st.Active, rs.Points, rs.Active)
+		}
+		if len(rs.Votes) != len(st.Votes) {
+			t.Fatalf(`expected story %s to have %d votes got %d`, st.Id, len(st.Votes), len(rs.Votes))
+		}
+		for j, v := range st.Votes {
+			if *rs.Votes[j] != *v {
+				t.Fatalf(`expected story %s vote %d: %+v got %+v`, st.Id, j, *v, *rs.Votes[j])
+			}
+		}
+	}
+	if restored.Stories[2].Votes == nil {
+		t.Fatalf(`expected a story without votes to restore an empty vote list`)
+	}
+}
+
+// TestDecodeGameSnapshotInvalid calls decodeGameSnapshot with unusable snapshots and makes sure they're rejected
+func TestDecodeGameSnapshotInvalid(t *testing.T) {
+	owner := "6f5b2a3e-7d1c-4e8f-9a0b-1c2d3e4f5a6b"
+	cases := map[string]struct {
+		data     string
+		expected error
+	}{
+		"version":   {`{"version":2,"name":"a","ownerId":"` + owner + `"}`, thunderdome.ErrValidation},
+		"name":      {`{"version":1,"ownerId":"` + owner + `"}`, thunderdome.ErrValidation},
+		"owner":     {`{"version":1,"name":"a","ownerId":"nope"}`, thunderdome.ErrValidation},
+		"malformed": {`{"version":`, thunderdome.ErrValidation},
+		"active": {
+			`{"version":1,"name":"a","ownerId":"` + owner + `","stories":[{"active":true},{"active":true}]}`,
+			thunderdome.ErrValidation,
+		},
+		"votes": {
+			`{"version":1,"name":"a","ownerId":"` + owner + `","stories":[{"votes":[{"vote":"3"}]}]}`,
+			thunderdome.ErrCorruptVotes,
+		},
+	}
+
+	for name, c := range cases {
+		if _, err := decodeGameSnapshot([]byte(c.data)); !errors.Is(err, c.expected) {
+			t.Fatalf(`expected %s snapshot to fail with %v got %v`, name, c.expected, err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package poker
+
+import (
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// GetSprintReadyStories retrieves the games finalized stories with numeric points in position order,
+// leaving out skipped, unestimated, and non-numeric (e.g. ?) stories so they can be handed off to a sprint
+func (d *Service) GetSprintReadyStories(PokerID string) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
+	stories, err := d.queryStories(d.DB, "",
+		`SELECT
+			id, name, type, reference_id, link, description, acceptance_criteria, priority, points, active, skipped, votestart_time, voteend_time, votes, finalized_date, updated_date, points_numeric, position, votes_revealed, locked, COALESCE(group_id, ''),
+			(SELECT vote_reveal_threshold FROM thunderdome.poker WHERE id = $1)
+			FROM thunderdome.poker_story
+			WHERE poker_id = $1 AND skipped = false AND COALESCE(points, '') != ''
+			ORDER BY position, created_date
+		`,
+		PokerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return sprintReadyStories(stories), nil
+}
+
+// sprintReadyStories keeps the finalized stories with numeric points preserving their order
+func sprintReadyStories(Stories []*thunderdome.Story) []*thunderdome.Story {
+	ready := make([]*thunderdome.Story, 0, len(Stories))
+	for _, s := range Stories {
+		if s.Skipped || s.Active || s.Points == "" {
+			continue
+		}
+		if _, ok := storyPointsToFloat(s); !ok {
+			continue
+		}
+		ready = append(ready, s)
+	}
+
+	return ready
+}
@@ -0,0 +1,37 @@
+package poker
+
+import (
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestSprintReadyStories calls sprintReadyStories with a mix of estimated, skipped, and unestimated stories
+// and makes sure only the finalized stories with numeric points are kept in their order
+func TestSprintReadyStories(t *testing.T) {
+	eight := 8.0
+	stories := []*thunderdome.Story{
+		{Id: "estimated", Points: "3", Position: 1},
+		{Id: "skipped", Points: "5", Skipped: true, Position: 2},
+		{Id: "unestimated", Position: 3},
+		{Id: "unsure", Points: "?", Position: 4},
+		{Id: "half", Points: "½", Position: 5},
+		{Id: "voting", Points: "2", Active: true, Position: 6},
+		{Id: "numeric", Points: "8", PointsNumeric: &eight, Position: 7},
+	}
+
+	ready := sprintReadyStories(stories)
+
+	expected := []string{"estimated", "half", "numeric"}
+	if len(ready) != len(expected) {
+		t.Fatalf(`expected %d sprint ready stories got %d`, len(expected), len(ready))
+	}
+	for i, id := range expected {
+		if ready[i].Id != id {
+			t.Fatalf(`expected story %d: %s got %s`, i, id, ready[i].Id)
+		}
+	}
+	if len(sprintReadyStories(nil)) != 0 {
+		t.Fatalf(`expected no sprint ready stories for a game without stories`)
+	}
+}
@@ -0,0 +1,111 @@
+package poker
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// RepairGameState reconciles the games active_story_id and voting_locked with its stories,
+// e.g. when active_story_id references a deleted story or more than one story is active without parallel voting
+func (d *Service) RepairGameState(PokerID string) error {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return err
+	}
+
+	var ActiveStoryID string
+	var VotingLocked bool
+	var ParallelVoting bool
+
+	if err := d.DB.QueryRow(
+		`SELECT COALESCE(active_story_id::text, ''), voting_locked, parallel_voting FROM thunderdome.poker WHERE id = $1;`,
+		PokerID,
+	).Scan(&ActiveStoryID, &VotingLocked, &ParallelVoting); err != nil {
+		d.Logger.Error("get poker state error", zap.Error(err))
+		return errors.New("not found")
+	}
+
+	stories := d.getStories(d.DB, PokerID, "")
+	if activeStoryID, votingLocked, inconsistent := reconcileGameState(ActiveStoryID, VotingLocked, ParallelVoting, stories); inconsistent {
+		return d.applyGameState(PokerID, activeStoryID, votingLocked, ParallelVoting)
+	}
+
+	return nil
+}
+
+// applyGameState sets the games active story and voting lock, deactivating any other active stories
+// unless the game has parallel voting
+func (d *Service) applyGameState(PokerID string, ActiveStoryID string, VotingLocked bool, ParallelVoting bool) error {
+	if _, err := d.DB.Exec(
+		`WITH deactivated AS (
+			UPDATE thunderdome.poker_story SET active = false, updated_date = NOW()
+			WHERE poker_id = $1 AND active = true AND id IS DISTINCT FROM NULLIF($2, '')::uuid AND NOT $4::boolean
+		)
+		UPDATE thunderdome.poker SET active_story_id = NULLIF($2, '')::uuid, voting_locked = $3, updated_date = NOW()
+		WHERE id = $1;`,
+		PokerID, ActiveStoryID, VotingLocked, ParallelVoting,
+	); err != nil {
+		d.Logger.Error("repair poker state error", zap.Error(err))
+		return fmt.Errorf("unable to repair poker state: %w", err)
+	}
+
+	return nil
+}
+
+// reconcileGameState determines what the games active story and voting lock should be based on its stories
+// and whether the current state is inconsistent. An active story means voting is open, otherwise the active story
+// may only remain when it exists and hasn't been finalized or skipped (voting ended awaiting points),
+// with parallel voting several stories may be active and the active story is the current or latest activated one
+func reconcileGameState(ActiveStoryID string, VotingLocked bool, ParallelVoting bool, Stories []*thunderdome.Story) (string, bool, bool) {
+	var activeStory *thunderdome.Story
+	var currentStory *thunderdome.Story
+	activeCount := 0
+
+	for _, s := range Stories {
+		if s.Id == ActiveStoryID {
+			currentStory = s
+		}
+		if !s.Active {
+			continue
+		}
+		activeCount++
+		if activeStory == nil || s.Id == ActiveStoryID ||
+			(activeStory.Id != ActiveStoryID && s.VoteStartTime.After(activeStory.VoteStartTime)) {
+			activeStory = s
+		}
+	}
+
+	targetID := ""
+	targetLocked := true
+	if activeStory != nil {
+		targetID = activeStory.Id
+		targetLocked = false
+	} else if currentStory != nil && currentStory.Points == "" && !currentStory.Skipped {
+		targetID = currentStory.Id
+	}
+
+	inconsistent := (activeCount > 1 && !ParallelVoting) || targetID != ActiveStoryID || targetLocked != VotingLocked
+
+	return targetID, targetLocked, inconsistent
+}
+
+// applySafeGameState replaces the games active story and voting lock with the reconciled state so a finalized,
+// skipped, or deleted story is never reported as active, returning whether the stored state needs repair
+func applySafeGameState(Game *thunderdome.Poker) bool {
+	ActiveStoryID, VotingLocked, inconsistent := reconcileGameState(Game.ActiveStoryID, Game.VotingLocked, Game.ParallelVoting, Game.Stories)
+	if !inconsistent {
+		return false
+	}
+
+	Game.ActiveStoryID = ActiveStoryID
+	Game.VotingLocked = VotingLocked
+	for _, s := range Game.Stories {
+		s.Active = (s.Id == ActiveStoryID || (Game.ParallelVoting && s.Active)) && !VotingLocked
+	}
+
+	return true
+}
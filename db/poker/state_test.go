@@ -0,0 +1,167 @@
+package poker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestReconcileGameState calls reconcileGameState with deliberately inconsistent game states
+// and makes sure the expected active story and voting lock are returned
+func TestReconcileGameState(t *testing.T) {
+	start := time.Date(2023, 8, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		activeStoryID string
+		votingLocked  bool
+		parallel      bool
+		stories       []*thunderdome.Story
+		wantID        string
+		wantLocked    bool
+		wantRepair    bool
+	}{
+		{
+			name:          "deleted active story",
+			activeStoryID: "deleted",
+			votingLocked:  false,
+			stories:       []*thunderdome.Story{{Id: "a"}},
+			wantID:        "",
+			wantLocked:    true,
+			wantRepair:    true,
+		},
+		{
+			name:          "active story not referenced",
+			activeStoryID: "",
+			votingLocked:  true,
+			stories:       []*thunderdome.Story{{Id: "a"}, {Id: "b", Active: true}},
+			wantID:        "b",
+			wantLocked:    false,
+			wantRepair:    true,
+		},
+		{
+			name:          "multiple active stories",
+			activeStoryID: "",
+			votingLocked:  true,
+			stories: []*thunderdome.Story{
+				{Id: "a", Active: true, VoteStartTime: start},
+				{Id: "b", Active: true, VoteStartTime: start.Add(time.Minute)},
+			},
+			wantID:     "b",
+			wantLocked: false,
+			wantRepair: true,
+		},
+		{
+			name:          "finalized story still referenced",
+			activeStoryID: "a",
+			votingLocked:  true,
+			stories:       []*thunderdome.Story{{Id: "a", Points: "5"}},
+			wantID:        "",
+			wantLocked:    true,
+			wantRepair:    true,
+		},
+		{
+			name:          "voting ended awaiting points",
+			activeStoryID: "a",
+			votingLocked:  true,
+			stories:       []*thunderdome.Story{{Id: "a"}},
+			wantID:        "a",
+			wantLocked:    true,
+			wantRepair:    false,
+		},
+		{
+			name:          "voting open",
+			activeStoryID: "a",
+			votingLocked:  false,
+			stories:       []*thunderdome.Story{{Id: "a", Active: true}},
+			wantID:        "a",
+			wantLocked:    false,
+			wantRepair:    false,
+		},
+		{
+			name:          "parallel active stories",
+			activeStoryID: "a",
+			votingLocked:  false,
+			parallel:      true,
+			stories: []*thunderdome.Story{
+				{Id: "a", Active: true, VoteStartTime: start},
+				{Id: "b", Active: true, VoteStartTime: start.Add(time.Minute)},
+			},
+			wantID:     "a",
+			wantLocked: false,
+			wantRepair: false,
+		},
+		{
+			name:          "parallel voting ended on the active story",
+			activeStoryID: "a",
+			votingLocked:  false,
+			parallel:      true,
+			stories: []*thunderdome.Story{
+				{Id: "a", VoteStartTime: start},
+				{Id: "b", Active: true, VoteStartTime: start.Add(time.Minute)},
+			},
+			wantID:     "b",
+			wantLocked: false,
+			wantRepair: true,
+		},
+	}
+
+	for _, tt := range tests {
+		id, locked, repair := reconcileGameState(tt.activeStoryID, tt.votingLocked, tt.parallel, tt.stories)
+		if id != tt.wantID || locked != tt.wantLocked || repair != tt.wantRepair {
+			t.Fatalf(`%s: expected (%q, %v, %v) got (%q, %v, %v)`,
+				tt.name, tt.wantID, tt.wantLocked, tt.wantRepair, id, locked, repair)
+		}
+	}
+}
+
+// TestGameVersionChanged calls gameVersionChanged and makes sure only a matching version short-circuits
+func TestGameVersionChanged(t *testing.T) {
+	if gameVersionChanged(3, 3) {
+		t.Fatalf(`expected matching version to be unchanged`)
+	}
+	if !gameVersionChanged(4, 3) {
+		t.Fatalf(`expected bumped version to be changed`)
+	}
+	if !gameVersionChanged(1, 3) {
+		t.Fatalf(`expected lower version to be changed`)
+	}
+}
+
+// TestApplySafeGameStateFinalizedStory calls applySafeGameState with a finalized story still referenced
+// as the active story with voting unlocked and makes sure it's no longer reported as active
+func TestApplySafeGameStateFinalizedStory(t *testing.T) {
+	game := &thunderdome.Poker{
+		ActiveStoryID: "a",
+		VotingLocked:  false,
+		Stories: []*thunderdome.Story{
+			{Id: "a", Active: false, Points: "5"},
+			{Id: "b"},
+		},
+	}
+
+	if !applySafeGameState(game) {
+		t.Fatalf(`expected stale active story to need repair`)
+	}
+	if game.ActiveStoryID != "" || !game.VotingLocked {
+		t.Fatalf(`expected no active story with voting locked got %q locked: %v`, game.ActiveStoryID, game.VotingLocked)
+	}
+}
+
+// TestApplySafeGameStateAwaitingPoints calls applySafeGameState with a story whose voting ended awaiting points
+// and makes sure it's still reported so the facilitator can finalize it
+func TestApplySafeGameStateAwaitingPoints(t *testing.T) {
+	game := &thunderdome.Poker{
+		ActiveStoryID: "a",
+		VotingLocked:  true,
+		Stories:       []*thunderdome.Story{{Id: "a"}},
+	}
+
+	if applySafeGameState(game) {
+		t.Fatalf(`expected consistent state to be left alone`)
+	}
+	if game.ActiveStoryID != "a" {
+		t.Fatalf(`expected active story a got %q`, game.ActiveStoryID)
+	}
+}
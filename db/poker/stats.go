@@ -0,0 +1,192 @@
+package poker
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// GetTeamEstimationStats gets aggregate estimation stats for the team's games created between From and To
+func (d *Service) GetTeamEstimationStats(TeamID string, From time.Time, To time.Time) (*thunderdome.TeamEstimationStats, error) {
+	if err := db.ValidateUUID(TeamID); err != nil {
+		return nil, err
+	}
+
+	var GameCount int
+	err := d.DB.QueryRow(
+		`SELECT COUNT(*) FROM thunderdome.poker WHERE team_id = $1 AND created_date BETWEEN $2 AND $3;`,
+		TeamID, From, To,
+	).Scan(&GameCount)
+	if err != nil {
+		d.Logger.Error("get team poker count error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get team estimation stats: %w", err)
+	}
+
+	stories, err := d.queryEstimationStories(
+		`SELECT ps.points, ps.points_numeric, ps.skipped, ps.votes
+		FROM thunderdome.poker_story ps
+		JOIN thunderdome.poker p ON p.id = ps.poker_id
+		WHERE p.team_id = $1 AND p.created_date BETWEEN $2 AND $3;`,
+		TeamID, From, To,
+	)
+	if err != nil {
+		return nil, errors.New("unable to get team estimation stats")
+	}
+
+	return calculateEstimationStats(GameCount, stories), nil
+}
+
+// GetFacilitatorEstimationStats gets aggregate estimation stats for the games created between From and To
+// that the user facilitates, e.g. for reporting across a program managers games
+func (d *Service) GetFacilitatorEstimationStats(FacilitatorID string, From time.Time, To time.Time) (*thunderdome.TeamEstimationStats, error) {
+	if err := db.ValidateUUID(FacilitatorID); err != nil {
+		return nil, err
+	}
+
+	var GameCount int
+	err := d.DB.QueryRow(
+		`SELECT COUNT(*) FROM thunderdome.poker p
+		JOIN thunderdome.poker_facilitator pf ON pf.poker_id = p.id
+		WHERE pf.user_id = $1 AND p.created_date BETWEEN $2 AND $3;`,
+		FacilitatorID, From, To,
+	).Scan(&GameCount)
+	if err != nil {
+		d.Logger.Error("get facilitator poker count error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get facilitator estimation stats: %w", err)
+	}
+
+	stories, err := d.queryEstimationStories(
+		`SELECT ps.points, ps.points_numeric, ps.skipped, ps.votes
+		FROM thunderdome.poker_story ps
+		JOIN thunderdome.poker p ON p.id = ps.poker_id
+		JOIN thunderdome.poker_facilitator pf ON pf.poker_id = p.id
+		WHERE pf.user_id = $1 AND p.created_date BETWEEN $2 AND $3;`,
+		FacilitatorID, From, To,
+	)
+	if err != nil {
+		return nil, errors.New("unable to get facilitator estimation stats")
+	}
+
+	return calculateEstimationStats(GameCount, stories), nil
+}
+
+// queryEstimationStories runs the stories query selecting points, points_numeric, skipped and votes,
+// stories whose votes can't be read are left out rather than counted as having no votes
+func (d *Service) queryEstimationStories(query string, args ...interface{}) ([]*thunderdome.Story, error) {
+	stories := make([]*thunderdome.Story, 0)
+	rows, err := d.DB.Query(query, args...)
+	if err != nil {
+		d.Logger.Error("get estimation stories error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v string
+		var PointsNumeric sql.NullFloat64
+		var s = &thunderdome.Story{
+			Votes: make([]*thunderdome.Vote, 0),
+		}
+		if err := rows.Scan(&s.Points, &PointsNumeric, &s.Skipped, &v); err != nil {
+			d.Logger.Error("get estimation stories scan error", zap.Error(err))
+			continue
+		}
+		var err error
+		if s.Votes, err = decodeStoryVotes(v); err != nil {
+			d.Logger.Error("get estimation stories corrupt votes error", zap.Error(err))
+			continue
+		}
+		if PointsNumeric.Valid {
+			s.PointsNumeric = &PointsNumeric.Float64
+		}
+		stories = append(stories, s)
+	}
+
+	return stories, nil
+}
+
+// calculateEstimationStats aggregates the finalized (non-skipped) stories into estimation stats,
+// a story reached consensus when every vote cast matches the final points
+func calculateEstimationStats(GameCount int, Stories []*thunderdome.Story) *thunderdome.TeamEstimationStats {
+	stats := &thunderdome.TeamEstimationStats{
+		GameCount: GameCount,
+	}
+
+	for _, s := range Stories {
+		if s.Points == "" || s.Skipped {
+			continue
+		}
+		stats.StoriesEstimated++
+
+		if points, ok := storyPointsToFloat(s); ok {
+			stats.TotalPoints += points
+		} else {
+			stats.NonNumericEstimates++
+		}
+
+		consensus := len(s.Votes) > 0
+		for _, v := range s.Votes {
+			if !pointValuesEqual(v.VoteValue, s.Points) {
+				consensus = false
+				break
+			}
+		}
+		if consensus {
+			stats.ConsensusCount++
+		}
+	}
+
+	if stats.GameCount > 0 {
+		stats.AvgStoriesPerGame = float64(stats.StoriesEstimated) / float64(stats.GameCount)
+	}
+	if stats.StoriesEstimated > 0 {
+		stats.ConsensusRate = float64(stats.ConsensusCount) / float64(stats.StoriesEstimated)
+	}
+
+	return stats
+}
+
+// pointValueToFloat converts a point value to its numeric value e.g. "1/2" to 0.5,
+// returns false for non-numeric values such as "?" or "XL"
+func pointValueToFloat(PointValue string) (float64, bool) {
+	pv := strings.TrimSpace(PointValue)
+	if pv == "½" || pv == "1/2" {
+		return 0.5, true
+	}
+
+	f, err := strconv.ParseFloat(pv, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return f, true
+}
+
+// storyPointsToFloat gets the stories numeric points, falling back to parsing the points
+// for stories finalized before numeric points were stored
+func storyPointsToFloat(Story *thunderdome.Story) (float64, bool) {
+	if Story.PointsNumeric != nil {
+		return *Story.PointsNumeric, true
+	}
+
+	return pointValueToFloat(Story.Points)
+}
+
+// pointValuesEqual compares point values numerically when both are numeric so "0.5" and "½" match
+func pointValuesEqual(a string, b string) bool {
+	af, aok := pointValueToFloat(a)
+	bf, bok := pointValueToFloat(b)
+	if aok && bok {
+		return af == bf
+	}
+
+	return a == b
+}
@@ -0,0 +1,138 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestCalculateEstimationStats calls calculateEstimationStats with a mix of finalized, skipped, and unestimated stories
+// and makes sure the totals, averages, and consensus rate match
+func TestCalculateEstimationStats(t *testing.T) {
+	stories := []*thunderdome.Story{
+		{Points: "5", Votes: []*thunderdome.Vote{{UserId: "a", VoteValue: "5"}, {UserId: "b", VoteValue: "5"}}},
+		{Points: "8", Votes: []*thunderdome.Vote{{UserId: "a", VoteValue: "5"}, {UserId: "b", VoteValue: "8"}}},
+		{Points: "1/2", Votes: []*thunderdome.Vote{{UserId: "a", VoteValue: "1/2"}}},
+		{Points: "?", Votes: []*thunderdome.Vote{{UserId: "a", VoteValue: "?"}}},
+		{Points: "", Skipped: true},
+		{Points: ""},
+	}
+
+	stats := calculateEstimationStats(2, stories)
+
+	if stats.StoriesEstimated != 4 {
+		t.Fatalf(`expected StoriesEstimated: 4 got %d`, stats.StoriesEstimated)
+	}
+	if stats.TotalPoints != 13.5 {
+		t.Fatalf(`expected TotalPoints: 13.5 got %v`, stats.TotalPoints)
+	}
+	if stats.NonNumericEstimates != 1 {
+		t.Fatalf(`expected NonNumericEstimates: 1 got %d`, stats.NonNumericEstimates)
+	}
+	if stats.AvgStoriesPerGame != 2 {
+		t.Fatalf(`expected AvgStoriesPerGame: 2 got %v`, stats.AvgStoriesPerGame)
+	}
+	if stats.ConsensusCount != 3 || stats.ConsensusRate != 0.75 {
+		t.Fatalf(`expected ConsensusCount: 3 and ConsensusRate: 0.75 got %d and %v`, stats.ConsensusCount, stats.ConsensusRate)
+	}
+}
+
+// TestCalculateEstimationStatsNoGames makes sure no games doesn't divide by zero
+func TestCalculateEstimationStatsNoGames(t *testing.T) {
+	stats := calculateEstimationStats(0, []*thunderdome.Story{})
+
+	if stats.AvgStoriesPerGame != 0 || stats.ConsensusRate != 0 {
+		t.Fatalf(`expected zero averages got %v and %v`, stats.AvgStoriesPerGame, stats.ConsensusRate)
+	}
+}
+
+// TestCalculateEstimationStatsNumericPoints makes sure stored numeric points are preferred
+// and half point votes in different formats still count as consensus
+func TestCalculateEstimationStatsNumericPoints(t *testing.T) {
+	half := 0.5
+	stories := []*thunderdome.Story{
+		{Points: "½", PointsNumeric: &half, Votes: []*thunderdome.Vote{{UserId: "a", VoteValue: "0.5"}, {UserId: "b", VoteValue: "1/2"}}},
+		{Points: "2.5", Votes: []*thunderdome.Vote{{UserId: "a", VoteValue: "2.5"}}},
+	}
+
+	stats := calculateEstimationStats(1, stories)
+
+	if stats.TotalPoints != 3 {
+		t.Fatalf(`expected TotalPoints: 3 got %v`, stats.TotalPoints)
+	}
+	if stats.NonNumericEstimates != 0 {
+		t.Fatalf(`expected NonNumericEstimates: 0 got %d`, stats.NonNumericEstimates)
+	}
+	if stats.ConsensusCount != 2 {
+		t.Fatalf(`expected ConsensusCount: 2 got %d`, stats.ConsensusCount)
+	}
+}
+
+// estimationGame is a poker game seeded into the fake database with its stories as points and votes
+type estimationGame struct {
+	facilitator string
+	created     time.Time
+	stories     [][2]string
+}
+
+// TestGetFacilitatorEstimationStats calls GetFacilitatorEstimationStats with games inside and outside the date range
+// and games of another facilitator, making sure only the facilitators games in range are aggregated
+func TestGetFacilitatorEstimationStats(t *testing.T) {
+	svc, f := newTestService(t)
+	FacilitatorID := "1e2d3c4b-5a69-4788-9a6b-5c4d3e2f1a0b"
+	OtherID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	from := time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 8, 31, 0, 0, 0, 0, time.UTC)
+
+	games := []estimationGame{
+		{facilitator: FacilitatorID, created: from.Add(24 * time.Hour), stories: [][2]string{
+			{"3", `[{"warriorId":"a","vote":"3"},{"warriorId":"b","vote":"3"}]`},
+			{"5", `[{"warriorId":"a","vote":"5"},{"warriorId":"b","vote":"8"}]`},
+		}},
+		{facilitator: FacilitatorID, created: to.Add(-24 * time.Hour), stories: [][2]string{
+			{"8", `[{"warriorId":"a","vote":"8"}]`},
+			{"", `[]`},
+		}},
+		{facilitator: FacilitatorID, created: from.Add(-24 * time.Hour), stories: [][2]string{
+			{"13", `[{"warriorId":"a","vote":"13"}]`},
+		}},
+		{facilitator: OtherID, created: from.Add(48 * time.Hour), stories: [][2]string{
+			{"1", `[{"warriorId":"a","vote":"1"}]`},
+		}},
+	}
+	// filtered applies the facilitator and created date filters of the stats queries
+	filtered := func(args []driver.Value) []estimationGame {
+		matched := make([]estimationGame, 0)
+		for _, g := range games {
+			if g.facilitator == args[0].(string) && !g.created.Before(args[1].(time.Time)) && !g.created.After(args[2].(time.Time)) {
+				matched = append(matched, g)
+			}
+		}
+		return matched
+	}
+	f.Query("pf.user_id = $1 AND p.created_date BETWEEN $2 AND $3", []string{"points", "points_numeric", "skipped", "votes"}, func(args []driver.Value) ([][]driver.Value, error) {
+		values := make([][]driver.Value, 0)
+		for _, g := range filtered(args) {
+			for _, story := range g.stories {
+				values = append(values, []driver.Value{story[0], nil, false, story[1]})
+			}
+		}
+		return values, nil
+	})
+	f.Query("SELECT COUNT(*)", []string{"count"}, func(args []driver.Value) ([][]driver.Value, error) {
+		return [][]driver.Value{{int64(len(filtered(args)))}}, nil
+	})
+
+	stats, err := svc.GetFacilitatorEstimationStats(FacilitatorID, from, to)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if stats.GameCount != 2 || stats.StoriesEstimated != 3 || stats.TotalPoints != 16 {
+		t.Fatalf(`expected 2 games with 3 stories totalling 16 points got %+v`, stats)
+	}
+	if stats.ConsensusCount != 2 || stats.ConsensusRate != 2.0/3.0 || stats.AvgStoriesPerGame != 1.5 {
+		t.Fatalf(`expected 2 of 3 stories to reach consensus got %+v`, stats)
+	}
+}
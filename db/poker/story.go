@@ -1,24 +1,96 @@
 package poker
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
 	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 
 	"go.uber.org/zap"
 )
 
-// GetStories retrieves stories for given poker game
+// GetStories retrieves stories for given poker game from the read replica when configured, an invalid game ID gets none
 func (d *Service) GetStories(PokerID string, UserID string) []*thunderdome.Story {
-	var plans = make([]*thunderdome.Story, 0)
-	planRows, plansErr := d.DB.Query(
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return make([]*thunderdome.Story, 0)
+	}
+
+	return d.getStories(d.reader(), PokerID, UserID)
+}
+
+// getStories retrieves stories for given poker game using the database handle,
+// stories returned right after a change are read from the primary as a replica may lag behind
+func (d *Service) getStories(q *sql.DB, PokerID string, UserID string) []*thunderdome.Story {
+	// query errors are logged by queryStories, callers get no stories
+	stories, _ := d.queryStories(q, UserID,
 		`SELECT
-			id, name, type, reference_id, link, description, acceptance_criteria, priority, points, active, skipped, votestart_time, voteend_time, votes
-			FROM thunderdome.poker_story WHERE poker_id = $1 ORDER BY created_date
+			id, name, type, reference_id, link, description, acceptance_criteria, priority, points, active, skipped, votestart_time, voteend_time, votes, finalized_date, updated_date, points_numeric, position, votes_revealed, locked, COALESCE(group_id, ''),
+			(SELECT vote_reveal_threshold FROM thunderdome.poker WHERE id = $1)
+			FROM thunderdome.poker_story WHERE poker_id = $1 ORDER BY position, created_date
 		`,
 		PokerID,
 	)
+
+	return stories
+}
+
+// GetStoriesUpdatedSince retrieves only the stories for given poker game that changed after Since
+func (d *Service) GetStoriesUpdatedSince(PokerID string, UserID string, Since time.Time) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
+	return d.queryStories(d.DB, UserID,
+		`SELECT
+			id, name, type, reference_id, link, description, acceptance_criteria, priority, points, active, skipped, votestart_time, voteend_time, votes, finalized_date, updated_date, points_numeric, position, votes_revealed, locked, COALESCE(group_id, ''),
+			(SELECT vote_reveal_threshold FROM thunderdome.poker WHERE id = $1)
+			FROM thunderdome.poker_story WHERE poker_id = $1 AND updated_date > $2 ORDER BY position, created_date
+		`,
+		PokerID, Since,
+	)
+}
+
+// GetStory retrieves a single story of the game with the same vote hiding as GetStories
+func (d *Service) GetStory(PokerID string, StoryID string, UserID string) (*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+
+	stories, err := d.queryStories(d.DB, UserID,
+		`SELECT
+			id, name, type, reference_id, link, description, acceptance_criteria, priority, points, active, skipped, votestart_time, voteend_time, votes, finalized_date, updated_date, points_numeric, position, votes_revealed, locked, COALESCE(group_id, ''),
+			(SELECT vote_reveal_threshold FROM thunderdome.poker WHERE id = $1)
+			FROM thunderdome.poker_story WHERE poker_id = $1 AND id = $2
+		`,
+		PokerID, StoryID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return storyFound(stories)
+}
+
+// storyFound returns the only story of a single story query or ErrStoryNotFound when there's none
+func storyFound(Stories []*thunderdome.Story) (*thunderdome.Story, error) {
+	if len(Stories) == 0 {
+		return nil, thunderdome.ErrStoryNotFound
+	}
+
+	return Stories[0], nil
+}
+
+// queryStories runs the stories query hiding other users votes on active stories and stories below the games
+// vote reveal threshold, the query must select the games vote_reveal_threshold last with the game ID as $1
+func (d *Service) queryStories(q *sql.DB, UserID string, query string, args ...interface{}) ([]*thunderdome.Story, error) {
+	var plans = make([]*thunderdome.Story, 0)
+	planRows, plansErr := q.Query(query, args...)
 	if plansErr == nil {
 		defer planRows.Close()
 		for planRows.Next() {
@@ -27,13 +99,16 @@ func (d *Service) GetStories(PokerID string, UserID string) []*thunderdome.Story
 			var Link sql.NullString
 			var Description sql.NullString
 			var AcceptanceCriteria sql.NullString
+			var FinalizedDate sql.NullTime
+			var PointsNumeric sql.NullFloat64
+			var RevealThreshold int
 			var p = &thunderdome.Story{
 				Votes:   make([]*thunderdome.Vote, 0),
 				Active:  false,
 				Skipped: false,
 			}
 			if err := planRows.Scan(
-				&p.Id, &p.Name, &p.Type, &ReferenceID, &Link, &Description, &AcceptanceCriteria, &p.Priority, &p.Points, &p.Active, &p.Skipped, &p.VoteStartTime, &p.VoteEndTime, &v,
+				&p.Id, &p.Name, &p.Type, &ReferenceID, &Link, &Description, &AcceptanceCriteria, &p.Priority, &p.Points, &p.Active, &p.Skipped, &p.VoteStartTime, &p.VoteEndTime, &v, &FinalizedDate, &p.UpdatedDate, &PointsNumeric, &p.Position, &p.VotesRevealed, &p.Locked, &p.GroupID, &RevealThreshold,
 			); err != nil {
 				d.Logger.Error("get poker stories query error", zap.Error(err))
 			} else {
@@ -41,82 +116,243 @@ func (d *Service) GetStories(PokerID string, UserID string) []*thunderdome.Story
 				p.Link = Link.String
 				p.Description = Description.String
 				p.AcceptanceCriteria = AcceptanceCriteria.String
-				err = json.Unmarshal([]byte(v), &p.Votes)
-				if err != nil {
-					d.Logger.Error("get poker stories query scan error", zap.Error(err))
+				p.FinalizedTime = FinalizedDate.Time
+				if PointsNumeric.Valid {
+					p.PointsNumeric = &PointsNumeric.Float64
 				}
-
-				// don't send others vote values to client, prevent sneaky devs from peaking at votes
-				for i := range p.Votes {
-					if p.Active && p.Votes[i].UserId != UserID {
-						p.Votes[i].VoteValue = ""
-					}
+				if p.Votes, err = decodeStoryVotes(v); err != nil {
+					d.Logger.Error("get poker stories corrupt votes error", zap.String("story_id", p.Id), zap.Error(err))
+					p.VotesCorrupt = true
 				}
 
+				maskStoryVotes(p, UserID, RevealThreshold)
+
 				plans = append(plans, p)
 			}
 		}
+	} else {
+		d.Logger.Error("get poker stories query error", zap.Error(plansErr))
+		return plans, fmt.Errorf("unable to get stories: %w", plansErr)
 	}
 
-	return plans
+	return plans, nil
 }
 
-// CreateStory adds a new story to the game
-func (d *Service) CreateStory(PokerID string, Name string, Type string, ReferenceID string, Link string, Description string, AcceptanceCriteria string, Priority int32) ([]*thunderdome.Story, error) {
+// CreateStory adds a new story to the game, repeating the call with the same non-empty IdempotencyKey
+// within its TTL returns the games stories without adding the story again
+func (d *Service) CreateStory(PokerID string, Name string, Type string, ReferenceID string, Link string, Description string, AcceptanceCriteria string, Priority int32, IdempotencyKey string) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+	if err := validateIdempotencyKey(IdempotencyKey); err != nil {
+		return nil, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+
 	SanitizedDescription := d.HTMLSanitizerPolicy.Sanitize(Description)
 	SanitizedAcceptanceCriteria := d.HTMLSanitizerPolicy.Sanitize(AcceptanceCriteria)
 	// default priority should be 99 for sort order purposes
 	if Priority == 0 {
 		Priority = 99
 	}
-	if _, err := d.DB.Exec(
-		`INSERT INTO thunderdome.poker_story (poker_id, name, type, reference_id, link, description, acceptance_criteria, priority)
-    VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`,
-		PokerID, Name, Type, ReferenceID, Link, SanitizedDescription, SanitizedAcceptanceCriteria, Priority,
-	); err != nil {
-		d.Logger.Error("error creating poker story", zap.Error(err))
+	ctx := context.Background()
+	if err := d.WithTx(ctx, func(tx *sql.Tx) error {
+		if _, _, found, err := d.lookupIdempotencyKey(ctx, tx, idempotencyScopeStory, PokerID, IdempotencyKey); err != nil {
+			return err
+		} else if found {
+			return errIdempotencyKeyUsed
+		}
+
+		var StoryID string
+		if err := tx.QueryRowContext(ctx,
+			`INSERT INTO thunderdome.poker_story (poker_id, name, type, reference_id, link, description, acceptance_criteria, priority)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id;`,
+			PokerID, Name, Type, ReferenceID, Link, SanitizedDescription, SanitizedAcceptanceCriteria, Priority,
+		).Scan(&StoryID); err != nil {
+			d.Logger.Error("error creating poker story", zap.Error(err))
+			return fmt.Errorf("unable to create poker story: %w", err)
+		}
+
+		return d.claimIdempotencyKey(ctx, tx, idempotencyScopeStory, PokerID, IdempotencyKey, PokerID, StoryID)
+	}); err != nil && !errors.Is(err, errIdempotencyKeyUsed) {
+		return nil, err
 	}
 
-	plans := d.GetStories(PokerID, "")
+	plans := d.getStories(d.DB, PokerID, "")
 
 	return plans, nil
 }
 
 // ActivateStoryVoting sets the story by ID to active, wipes any previous votes/points, and disables votingLock
 func (d *Service) ActivateStoryVoting(PokerID string, StoryID string) ([]*thunderdome.Story, error) {
-	if _, err := d.DB.Exec(
+	if err := db.ValidateUUID(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureStoryUnlocked(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+
+	ParallelVoting, err := d.isParallelVoting(PokerID)
+	if err != nil {
+		return nil, err
+	}
+	if ParallelVoting {
+		if err := d.activateParallelStory(PokerID, StoryID); err != nil {
+			return nil, err
+		}
+	} else if _, err := d.DB.Exec(
 		`CALL thunderdome.poker_story_activate($1, $2);`, PokerID, StoryID,
 	); err != nil {
 		d.Logger.Error("CALL thunderdome.poker_story_activate error", zap.Error(err))
 	}
 
-	plans := d.GetStories(PokerID, "")
+	plans := d.getStories(d.DB, PokerID, "")
 
 	return plans, nil
 }
 
-// SetVote sets a users vote for the story
-func (d *Service) SetVote(PokerID string, UserID string, StoryID string, VoteValue string) (Stories []*thunderdome.Story, AllUsersVoted bool) {
-	if _, err := d.DB.Exec(
+// GetStoryVoteCount gets the number of votes cast for the story without loading the votes
+func (d *Service) GetStoryVoteCount(StoryID string) (int, error) {
+	if err := db.ValidateUUID(StoryID); err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := d.DB.QueryRow(
+		`SELECT COALESCE(jsonb_array_length(votes), 0) FROM thunderdome.poker_story WHERE id = $1;`,
+		StoryID,
+	).Scan(&count); err != nil {
+		d.Logger.Error("get poker story vote count error", zap.Error(err))
+		return 0, errors.New("not found")
+	}
+
+	return count, nil
+}
+
+// CountStoriesByStatus counts the games stories that are unestimated, estimated, and skipped
+func (d *Service) CountStoriesByStatus(PokerID string) (map[string]int, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
+	var Unestimated, Estimated, Skipped int
+	if err := d.DB.QueryRow(
+		`SELECT
+			COUNT(*) FILTER (WHERE NOT skipped AND COALESCE(points, '') = ''),
+			COUNT(*) FILTER (WHERE NOT skipped AND COALESCE(points, '') != ''),
+			COUNT(*) FILTER (WHERE skipped)
+		FROM thunderdome.poker_story WHERE poker_id = $1;`,
+		PokerID,
+	).Scan(&Unestimated, &Estimated, &Skipped); err != nil {
+		d.Logger.Error("count poker stories by status error", zap.Error(err))
+		return nil, fmt.Errorf("unable to count poker stories: %w", err)
+	}
+
+	return map[string]int{
+		thunderdome.StoryStatusUnestimated: Unestimated,
+		thunderdome.StoryStatusEstimated:   Estimated,
+		thunderdome.StoryStatusSkipped:     Skipped,
+	}, nil
+}
+
+// SetVote sets a users vote for the story, rejecting votes not allowed by the games vote mode,
+// the ComplexityValue is required in effort-complexity vote mode and must be empty otherwise
+func (d *Service) SetVote(PokerID string, UserID string, StoryID string, VoteValue string, ComplexityValue string) (Stories []*thunderdome.Story, AllUsersVoted bool, err error) {
+	if err := db.ValidateUUID(PokerID, UserID, StoryID); err != nil {
+		return nil, false, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, false, err
+	}
+	if err := d.ensureNamedVoter(PokerID, UserID); err != nil {
+		return nil, false, err
+	}
+
+	var VoteMode string
+	var cs string
+	var v string
+	var pv string
+	var va string
+	var CustomScale = make([]thunderdome.ScaleValue, 0)
+	var Votes = make([]*thunderdome.Vote, 0)
+	var PointValuesAllowed = make([]string, 0)
+	var VoteAliases = make(map[string]string)
+	if err := d.DB.QueryRow(
+		`SELECT COALESCE(p.vote_mode, 'points'), COALESCE(p.custom_scale, '[]'::jsonb), ps.votes,
+			p.point_values_allowed, COALESCE(p.vote_aliases, '{}'::jsonb)
+		FROM thunderdome.poker p
+		JOIN thunderdome.poker_story ps ON ps.poker_id = p.id
+		WHERE p.id = $1 AND ps.id = $2;`, PokerID, StoryID,
+	).Scan(&VoteMode, &cs, &v, &pv, &va); err != nil {
+		d.Logger.Error("get poker vote_mode error", zap.Error(err))
+		return nil, false, errors.New("not found")
+	}
+	_ = json.Unmarshal([]byte(cs), &CustomScale)
+	_ = json.Unmarshal([]byte(pv), &PointValuesAllowed)
+	_ = json.Unmarshal([]byte(va), &VoteAliases)
+	// refuse to vote on top of corrupt votes as the write would replace them
+	if Votes, err = decodeStoryVotes(v); err != nil {
+		d.Logger.Error("set poker vote corrupt votes error", zap.String("story_id", StoryID), zap.Error(err))
+		return nil, false, err
+	}
+	// variants like "xl" or a games alias e.g. "Extra Large" are counted as the scale value they stand for
+	scale := voteScaleValues(VoteMode, CustomScale, PointValuesAllowed)
+	if VoteValue, err = resolveVoteValue(VoteAliases, scale, VoteValue); err != nil {
+		return nil, false, err
+	}
+	if ComplexityValue, err = resolveVoteValue(VoteAliases, scale, ComplexityValue); err != nil {
+		return nil, false, err
+	}
+	if err := validateStoryPoints(VoteValue); err != nil {
+		return nil, false, err
+	}
+	if err := validateStoryPoints(ComplexityValue); err != nil {
+		return nil, false, err
+	}
+	if err := validateVoteValue(VoteMode, CustomScale, VoteValue); err != nil {
+		return nil, false, err
+	}
+	if err := validateComplexityValue(VoteMode, CustomScale, ComplexityValue); err != nil {
+		return nil, false, err
+	}
+	// skip the write for a retried vote that matches the users existing vote
+	if hasSameVote(Votes, UserID, VoteValue, ComplexityValue) {
+		return nil, false, thunderdome.ErrVoteUnchanged
+	}
+
+	// the active check is part of the update so a vote racing the story being finalized
+	// either lands before it or is rejected rather than silently discarded
+	res, err := d.DB.Exec(
 		`UPDATE thunderdome.poker_story p1
-		SET votes = (
-			SELECT json_agg(data)
+		SET updated_date = NOW(), votes = (
+			SELECT jsonb_agg(jsonb_strip_nulls(to_jsonb(data)))
 			FROM (
-				SELECT coalesce(newVote."warriorId", oldVote."warriorId") AS "warriorId", coalesce(newVote.vote, oldVote.vote) AS vote
+				SELECT coalesce(newVote."warriorId", oldVote."warriorId") AS "warriorId", coalesce(newVote.vote, oldVote.vote) AS vote,
+					CASE WHEN newVote."warriorId" IS NULL THEN oldVote.complexity ELSE newVote.complexity END AS complexity
 				FROM jsonb_populate_recordset(null::thunderdome.UsersVote,p1.votes) AS oldVote
 				FULL JOIN jsonb_populate_recordset(null::thunderdome.UsersVote,
-					('[{"warriorId":"'|| $2::TEXT ||'", "vote":"'|| $3 ||'"}]')::JSONB
+					jsonb_build_array(jsonb_build_object('warriorId', $2::TEXT, 'vote', $3::TEXT, 'complexity', NULLIF($4::TEXT, '')))
 				) AS newVote
 				ON newVote."warriorId" = oldVote."warriorId"
 			) data
 		)
-		WHERE p1.id = $1;`,
-		StoryID, UserID, VoteValue); err != nil {
+		WHERE p1.id = $1 AND p1.active = true;`,
+		StoryID, UserID, VoteValue, ComplexityValue)
+	if err != nil {
 		d.Logger.Error("CALL thunderdome.poker_user_vote_set error", zap.Error(err))
+		return nil, false, fmt.Errorf("unable to set vote: %w", err)
+	}
+	if err := votingClosedError(res); err != nil {
+		return nil, false, err
 	}
 
-	Plans := d.GetStories(PokerID, "")
-	ActiveUsers := d.GetActiveUsers(PokerID)
+	Plans := d.getStories(d.DB, PokerID, "")
+	ActiveUsers := d.getActiveUsers(d.DB, PokerID)
 
 	// determine if all active users have voted
 	AllVoted := true
@@ -138,17 +374,81 @@ func (d *Service) SetVote(PokerID string, UserID string, StoryID string, VoteVal
 		}
 	}
 
-	return Plans, AllVoted
+	return Plans, AllVoted, nil
+}
+
+// votingClosedError returns ErrVotingClosed when the vote update matched no active story
+func votingClosedError(Result sql.Result) error {
+	if affected, err := Result.RowsAffected(); err == nil && affected == 0 {
+		return thunderdome.ErrVotingClosed
+	}
+
+	return nil
+}
+
+// maskStoryVotes hides others vote values on an active story to prevent sneaky devs from peaking at votes
+// unless the facilitator has revealed them, and once votes are revealed or voting is over hides others votes
+// entirely until at least RevealThreshold users have voted so individual votes can't be deduced in small sessions
+func maskStoryVotes(Story *thunderdome.Story, UserID string, RevealThreshold int) {
+	if Story.Active && !Story.VotesRevealed {
+		for i := range Story.Votes {
+			if Story.Votes[i].UserId != UserID {
+				Story.Votes[i].VoteValue = ""
+				Story.Votes[i].ComplexityValue = ""
+			}
+		}
+		return
+	}
+
+	if RevealThreshold <= 0 {
+		return
+	}
+
+	var cast int
+	for _, v := range Story.Votes {
+		if v.VoteValue != "" {
+			cast++
+		}
+	}
+	if cast >= RevealThreshold {
+		return
+	}
+
+	own := make([]*thunderdome.Vote, 0, 1)
+	for _, v := range Story.Votes {
+		if v.UserId == UserID {
+			own = append(own, v)
+		}
+	}
+	Story.Votes = own
+}
+
+// hasSameVote checks whether the user has already cast the VoteValue and ComplexityValue
+func hasSameVote(Votes []*thunderdome.Vote, UserID string, VoteValue string, ComplexityValue string) bool {
+	for _, v := range Votes {
+		if v.UserId == UserID {
+			return v.VoteValue == VoteValue && v.ComplexityValue == ComplexityValue
+		}
+	}
+
+	return false
 }
 
 // RetractVote removes a users vote for the story
 func (d *Service) RetractVote(PokerID string, UserID string, StoryID string) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID, UserID, StoryID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+
 	if _, err := d.DB.Exec(
 		`UPDATE thunderdome.poker_story p1
-		SET votes = (
-			SELECT coalesce(json_agg(data), '[]'::JSON)
+		SET updated_date = NOW(), votes = (
+			SELECT coalesce(jsonb_agg(jsonb_strip_nulls(to_jsonb(data))), '[]'::JSONB)
 			FROM (
-				SELECT coalesce(oldVote."warriorId") AS "warriorId", coalesce(oldVote.vote) AS vote
+				SELECT coalesce(oldVote."warriorId") AS "warriorId", coalesce(oldVote.vote) AS vote, oldVote.complexity
 				FROM jsonb_populate_recordset(null::thunderdome.UsersVote,p1.votes) AS oldVote
 				WHERE oldVote."warriorId" != $2
 			) data
@@ -159,37 +459,214 @@ func (d *Service) RetractVote(PokerID string, UserID string, StoryID string) ([]
 		return nil, err
 	}
 
-	plans := d.GetStories(PokerID, "")
+	plans := d.getStories(d.DB, PokerID, "")
+
+	return plans, nil
+}
+
+// CallForRevote clears the votes on the games active story and reopens voting so everyone votes again
+func (d *Service) CallForRevote(PokerID string, FacilitatorID string) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID, FacilitatorID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+	if err := d.ConfirmFacilitator(PokerID, FacilitatorID); err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	if err := d.WithTx(ctx, func(tx *sql.Tx) error {
+		var StoryID string
+		if err := tx.QueryRowContext(ctx,
+			`WITH revote AS (
+				UPDATE thunderdome.poker_story
+				SET updated_date = NOW(), active = true, votes = '[]'::jsonb, votes_revealed = false, votestart_time = NOW()
+				WHERE poker_id = $1 AND id = (SELECT active_story_id FROM thunderdome.poker WHERE id = $1)
+				RETURNING id
+			)
+			UPDATE thunderdome.poker SET updated_date = NOW(), last_active = NOW(), voting_locked = false
+			WHERE id = $1 AND EXISTS (SELECT 1 FROM revote)
+			RETURNING (SELECT id FROM revote);`,
+			PokerID,
+		).Scan(&StoryID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return thunderdome.ErrNoActiveStory
+			}
+			d.Logger.Error("poker story revote error", zap.Error(err))
+			return fmt.Errorf("unable to call for revote: %w", err)
+		}
+
+		return d.RecordGameEventTx(ctx, tx, PokerID, StoryID, FacilitatorID, "revote_called", "")
+	}); err != nil {
+		return nil, err
+	}
+
+	plans := d.getStories(d.DB, PokerID, "")
 
 	return plans, nil
 }
 
-// EndStoryVoting sets story to active: false
-func (d *Service) EndStoryVoting(PokerID string, StoryID string) ([]*thunderdome.Story, error) {
+// RevealStoryVotes reveals everyone's votes on the active story while voting stays open,
+// giving clients a single signal to reveal on together
+func (d *Service) RevealStoryVotes(PokerID string, StoryID string, FacilitatorID string) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID, StoryID, FacilitatorID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+	if err := d.ConfirmFacilitator(PokerID, FacilitatorID); err != nil {
+		return nil, err
+	}
+
+	result, err := d.DB.Exec(
+		`UPDATE thunderdome.poker_story SET updated_date = NOW(), votes_revealed = true
+		WHERE poker_id = $1 AND id = $2 AND active = true;`,
+		PokerID, StoryID,
+	)
+	if err != nil {
+		d.Logger.Error("poker story reveal votes error", zap.Error(err))
+		return nil, fmt.Errorf("unable to reveal story votes: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, errors.New("NO_ACTIVE_STORY")
+	}
+
+	plans := d.getStories(d.DB, PokerID, "")
+
+	return plans, nil
+}
+
+// EndStoryVoting sets story to active: false, unless OverrideQuorum is set fewer voters than the games quorum is an error
+func (d *Service) EndStoryVoting(PokerID string, StoryID string, OverrideQuorum bool) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+	if !OverrideQuorum {
+		if err := d.ensureQuorum(PokerID, StoryID); err != nil {
+			return nil, err
+		}
+	}
+
 	if _, err := d.DB.Exec(
 		`CALL thunderdome.poker_plan_voting_stop($1, $2);`, PokerID, StoryID); err != nil {
 		d.Logger.Error("CALL thunderdome.poker_plan_voting_stop error", zap.Error(err))
 	}
+	d.syncParallelVoting(PokerID)
+
+	plans := d.getStories(d.DB, PokerID, "")
+
+	return plans, nil
+}
+
+// CancelStoryVoting undoes activating the story by mistake, deactivating it without the round counting as ended,
+// clearing its votes and unsetting the games activeStoryId with voting locked
+func (d *Service) CancelStoryVoting(PokerID string, StoryID string, FacilitatorID string) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID, StoryID, FacilitatorID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+	if err := d.ConfirmFacilitator(PokerID, FacilitatorID); err != nil {
+		return nil, err
+	}
+
+	if err := d.WithTx(context.Background(), func(tx *sql.Tx) error {
+		// only an active story is cancelled, checked by the update itself so a concurrent end or cancel can't slip between
+		res, err := tx.Exec(
+			`UPDATE thunderdome.poker_story SET updated_date = NOW(), active = false, votes = '[]'::jsonb, votes_revealed = false
+			WHERE poker_id = $1 AND id = $2 AND active = true;`,
+			PokerID, StoryID,
+		)
+		if err != nil {
+			d.Logger.Error("poker story cancel voting error", zap.Error(err))
+			return fmt.Errorf("unable to cancel story voting: %w", err)
+		}
+		if rows, err := res.RowsAffected(); err != nil {
+			d.Logger.Error("poker story cancel voting rows affected error", zap.Error(err))
+			return fmt.Errorf("unable to cancel story voting: %w", err)
+		} else if rows == 0 {
+			return d.inactiveStoryError(tx, PokerID, StoryID)
+		}
+		if _, err := tx.Exec(
+			`UPDATE thunderdome.poker SET updated_date = NOW(), voting_locked = true, active_story_id = null
+			WHERE id = $1 AND active_story_id = $2;`,
+			PokerID, StoryID,
+		); err != nil {
+			d.Logger.Error("poker cancel voting error", zap.Error(err))
+			return fmt.Errorf("unable to cancel story voting: %w", err)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	d.syncParallelVoting(PokerID)
 
-	plans := d.GetStories(PokerID, "")
+	plans := d.getStories(d.DB, PokerID, "")
 
 	return plans, nil
 }
 
+// inactiveStoryError gets the error for a story whose voting wasn't active to act on,
+// ErrStoryNotFound when the game has no such story
+func (d *Service) inactiveStoryError(tx *sql.Tx, PokerID string, StoryID string) error {
+	var exists bool
+	if err := tx.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM thunderdome.poker_story WHERE poker_id = $1 AND id = $2);`,
+		PokerID, StoryID,
+	).Scan(&exists); err != nil {
+		d.Logger.Error("get poker story exists error", zap.Error(err))
+		return fmt.Errorf("unable to get poker story: %w", err)
+	}
+	if !exists {
+		return thunderdome.ErrStoryNotFound
+	}
+
+	return fmt.Errorf("%w: story voting isn't active", thunderdome.ErrValidation)
+}
+
 // SkipStory sets story to active: false and unsets games activeStoryId
 func (d *Service) SkipStory(PokerID string, StoryID string) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureStoryUnlocked(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+
 	if _, err := d.DB.Exec(
 		`CALL thunderdome.poker_vote_skip($1, $2);`, PokerID, StoryID); err != nil {
 		d.Logger.Error("CALL thunderdome.poker_vote_skip error", zap.Error(err))
 	}
+	d.syncParallelVoting(PokerID)
 
-	plans := d.GetStories(PokerID, "")
+	plans := d.getStories(d.DB, PokerID, "")
 
 	return plans, nil
 }
 
 // UpdateStory updates the story by ID
 func (d *Service) UpdateStory(PokerID string, StoryID string, Name string, Type string, ReferenceID string, Link string, Description string, AcceptanceCriteria string, Priority int32) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureStoryUnlocked(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+
 	SanitizedDescription := d.HTMLSanitizerPolicy.Sanitize(Description)
 	SanitizedAcceptanceCriteria := d.HTMLSanitizerPolicy.Sanitize(AcceptanceCriteria)
 	// default priority should be 99 for sort order purposes
@@ -213,31 +690,82 @@ func (d *Service) UpdateStory(PokerID string, StoryID string, Name string, Type
 		d.Logger.Error("error getting poker story", zap.Error(err))
 	}
 
-	plans := d.GetStories(PokerID, "")
+	plans := d.getStories(d.DB, PokerID, "")
 
 	return plans, nil
 }
 
 // DeleteStory removes a story from the current game by ID
 func (d *Service) DeleteStory(PokerID string, StoryID string) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureStoryUnlocked(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+
 	if _, err := d.DB.Exec(
 		`CALL thunderdome.poker_story_delete($1, $2);`, PokerID, StoryID); err != nil {
 		d.Logger.Error("CALL thunderdome.poker_story_delete error", zap.Error(err))
 	}
+	d.syncParallelVoting(PokerID)
+	if err := d.CompactStoryPositions(PokerID); err != nil {
+		d.Logger.Error("compact poker story positions error", zap.Error(err))
+	}
 
-	plans := d.GetStories(PokerID, "")
+	plans := d.getStories(d.DB, PokerID, "")
 
 	return plans, nil
 }
 
-// FinalizeStory sets story to active: false and updates the points
-func (d *Service) FinalizeStory(PokerID string, StoryID string, Points string) ([]*thunderdome.Story, error) {
+// FinalizeStory sets story to active: false and updates the points,
+// unless OverrideQuorum is set fewer voters than the games quorum is an error
+func (d *Service) FinalizeStory(PokerID string, StoryID string, Points string, OverrideQuorum bool) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+	if err := validateStoryPoints(Points); err != nil {
+		return nil, err
+	}
+	if err := d.ensureGameOpen(PokerID); err != nil {
+		return nil, err
+	}
+	if err := d.ensureStoryUnlocked(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+	if !OverrideQuorum {
+		if err := d.ensureQuorum(PokerID, StoryID); err != nil {
+			return nil, err
+		}
+	}
+
+	finalized := true
 	if _, err := d.DB.Exec(
 		`CALL thunderdome.poker_story_finalize($1, $2, $3);`, PokerID, StoryID, Points); err != nil {
 		d.Logger.Error("CALL thunderdome.poker_story_finalize error", zap.Error(err))
+		finalized = false
 	}
+	d.syncParallelVoting(PokerID)
 
-	plans := d.GetStories(PokerID, "")
+	plans := d.getStories(d.DB, PokerID, "")
+	if finalized {
+		d.publishStoriesFinalized(PokerID, []string{StoryID}, plans)
+	}
 
 	return plans, nil
 }
+
+// storyPointsMaxLength is the max length of the poker_story points column and UsersVote vote
+const storyPointsMaxLength = 32
+
+// validateStoryPoints checks the points or vote value fit the poker_story points column and UsersVote vote
+func validateStoryPoints(Points string) error {
+	if utf8.RuneCountInString(Points) > storyPointsMaxLength {
+		return fmt.Errorf("%w: points must be at most %d characters", thunderdome.ErrValidation, storyPointsMaxLength)
+	}
+
+	return nil
+}
@@ -0,0 +1,294 @@
+package poker
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestValidateStoryPoints calls validateStoryPoints with valid and over-length points
+// and makes sure only over-length points return a validation error
+func TestValidateStoryPoints(t *testing.T) {
+	for _, points := range []string{"", "1", "½", "100", "XXL", "1000", strings.Repeat("X", storyPointsMaxLength)} {
+		if err := validateStoryPoints(points); err != nil {
+			t.Fatalf(`expected points %q to be valid got %v`, points, err)
+		}
+	}
+
+	if err := validateStoryPoints(strings.Repeat("X", storyPointsMaxLength+1)); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected over-length points to be invalid got %v`, err)
+	}
+}
+
+// TestHasSameVote calls hasSameVote and makes sure only an identical vote from the same user short-circuits
+func TestHasSameVote(t *testing.T) {
+	votes := []*thunderdome.Vote{
+		{UserId: "a", VoteValue: "3"},
+		{UserId: "b", VoteValue: "5"},
+	}
+
+	if !hasSameVote(votes, "a", "3", "") {
+		t.Fatalf(`expected identical re-vote to be detected`)
+	}
+	if hasSameVote(votes, "a", "5", "") {
+		t.Fatalf(`expected changed vote to be written`)
+	}
+	if hasSameVote(votes, "c", "3", "") {
+		t.Fatalf(`expected first vote to be written`)
+	}
+
+	twoDimensional := []*thunderdome.Vote{{UserId: "a", VoteValue: "3", ComplexityValue: "8"}}
+	if !hasSameVote(twoDimensional, "a", "3", "8") {
+		t.Fatalf(`expected identical two-dimensional re-vote to be detected`)
+	}
+	if hasSameVote(twoDimensional, "a", "3", "5") {
+		t.Fatalf(`expected changed complexity vote to be written`)
+	}
+}
+
+// TestMaskStoryVotes calls maskStoryVotes on finished stories with fewer and at least as many voters
+// as the reveal threshold and makes sure individual votes are only disclosed once the threshold is met
+func TestMaskStoryVotes(t *testing.T) {
+	newStory := func(voters int) *thunderdome.Story {
+		s := &thunderdome.Story{Points: "3"}
+		for i := 0; i < voters; i++ {
+			s.Votes = append(s.Votes, &thunderdome.Vote{UserId: string(rune('a' + i)), VoteValue: "3"})
+		}
+		return s
+	}
+
+	below := newStory(2)
+	maskStoryVotes(below, "a", 3)
+	if len(below.Votes) != 1 || below.Votes[0].UserId != "a" || below.Votes[0].VoteValue != "3" {
+		t.Fatalf(`expected only own vote below threshold got %d votes`, len(below.Votes))
+	}
+
+	observer := newStory(2)
+	maskStoryVotes(observer, "", 3)
+	if len(observer.Votes) != 0 {
+		t.Fatalf(`expected no votes disclosed below threshold got %d`, len(observer.Votes))
+	}
+	if observer.Points != "3" {
+		t.Fatalf(`expected final points to stay visible got %q`, observer.Points)
+	}
+
+	met := newStory(3)
+	maskStoryVotes(met, "a", 3)
+	if len(met.Votes) != 3 {
+		t.Fatalf(`expected all votes disclosed at threshold got %d`, len(met.Votes))
+	}
+	for _, v := range met.Votes {
+		if v.VoteValue != "3" {
+			t.Fatalf(`expected vote values disclosed at threshold got %q`, v.VoteValue)
+		}
+	}
+
+	disabled := newStory(1)
+	maskStoryVotes(disabled, "", 0)
+	if len(disabled.Votes) != 1 {
+		t.Fatalf(`expected votes disclosed when threshold is disabled got %d`, len(disabled.Votes))
+	}
+
+	active := newStory(2)
+	active.Active = true
+	maskStoryVotes(active, "a", 3)
+	if len(active.Votes) != 2 || active.Votes[0].VoteValue != "3" || active.Votes[1].VoteValue != "" {
+		t.Fatalf(`expected active story to keep voters but hide others vote values`)
+	}
+}
+
+// TestMaskStoryVotesRevealed calls maskStoryVotes on an active story before and after its votes are revealed
+// and makes sure revealed votes are disclosed while voting is still open
+func TestMaskStoryVotesRevealed(t *testing.T) {
+	newStory := func() *thunderdome.Story {
+		return &thunderdome.Story{Active: true, Votes: []*thunderdome.Vote{
+			{UserId: "a", VoteValue: "3"},
+			{UserId: "b", VoteValue: "5"},
+		}}
+	}
+
+	hidden := newStory()
+	maskStoryVotes(hidden, "a", 0)
+	if hidden.Votes[1].VoteValue != "" {
+		t.Fatalf(`expected others vote hidden before reveal got %q`, hidden.Votes[1].VoteValue)
+	}
+
+	revealed := newStory()
+	revealed.VotesRevealed = true
+	maskStoryVotes(revealed, "a", 0)
+	if revealed.Votes[0].VoteValue != "3" || revealed.Votes[1].VoteValue != "5" {
+		t.Fatalf(`expected all votes disclosed once revealed`)
+	}
+	if !revealed.Active {
+		t.Fatalf(`expected story to stay active after reveal`)
+	}
+
+	belowThreshold := newStory()
+	belowThreshold.VotesRevealed = true
+	maskStoryVotes(belowThreshold, "a", 3)
+	if len(belowThreshold.Votes) != 1 || belowThreshold.Votes[0].UserId != "a" {
+		t.Fatalf(`expected only own vote disclosed below threshold got %d votes`, len(belowThreshold.Votes))
+	}
+}
+
+// TestGetStoriesUpdatedSince calls GetStoriesUpdatedSince with a sync cursor between stories changed before and after it
+// and makes sure only the recently changed stories are returned
+func TestGetStoriesUpdatedSince(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	since := time.Now().Add(-time.Minute)
+	updated := map[string]time.Time{
+		"3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b": since.Add(-time.Hour),
+		"7a6b5c4d-3e2f-4a1b-9c8d-7e6f5a4b3c2d": since.Add(30 * time.Second),
+		"9c8b7a6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d": since,
+	}
+	f.Query("AND updated_date > $2", storyColumns, func(args []driver.Value) ([][]driver.Value, error) {
+		values := make([][]driver.Value, 0)
+		for StoryID, UpdatedDate := range updated {
+			if UpdatedDate.After(args[1].(time.Time)) {
+				row := storyRow(StoryID, false, "[]")
+				row[15] = UpdatedDate
+				values = append(values, row)
+			}
+		}
+		return values, nil
+	})
+
+	stories, err := svc.GetStoriesUpdatedSince(PokerID, "", since)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if len(stories) != 1 || stories[0].Id != "7a6b5c4d-3e2f-4a1b-9c8d-7e6f5a4b3c2d" {
+		t.Fatalf(`expected only the story changed after the cursor got %d stories`, len(stories))
+	}
+	if !stories[0].UpdatedDate.After(since) {
+		t.Fatalf(`expected the returned stories updated date to move the sync cursor forward`)
+	}
+
+	if _, err := svc.GetStoriesUpdatedSince("not-a-uuid", "", since); err == nil {
+		t.Fatalf(`expected error for an invalid game ID`)
+	}
+}
+
+// TestCountStoriesByStatus finalizes and skips some of a games stories
+// and makes sure the counts by status follow
+func TestCountStoriesByStatus(t *testing.T) {
+	svc, f := newTestService(t)
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	StoryIDs := []string{
+		"3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b",
+		"7a6b5c4d-3e2f-4a1b-9c8d-7e6f5a4b3c2d",
+		"9c8b7a6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d",
+		"5f1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d",
+	}
+	type story struct {
+		points  string
+		skipped bool
+	}
+	stories := make(map[string]*story)
+	for _, StoryID := range StoryIDs {
+		stories[StoryID] = &story{}
+	}
+	f.Exec("thunderdome.poker_story_finalize", func(args []driver.Value) (int64, error) {
+		stories[args[1].(string)].points = args[2].(string)
+		return 1, nil
+	})
+	f.Exec("thunderdome.poker_vote_skip", func(args []driver.Value) (int64, error) {
+		stories[args[1].(string)].skipped = true
+		return 1, nil
+	})
+	f.Query("COUNT(*) FILTER", []string{"unestimated", "estimated", "skipped"}, func(args []driver.Value) ([][]driver.Value, error) {
+		var unestimated, estimated, skipped int64
+		for _, s := range stories {
+			switch {
+			case s.skipped:
+				skipped++
+			case s.points == "":
+				unestimated++
+			default:
+				estimated++
+			}
+		}
+		return [][]driver.Value{{unestimated, estimated, skipped}}, nil
+	})
+
+	if _, err := svc.FinalizeStory(PokerID, StoryIDs[0], "3", false); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if _, err := svc.FinalizeStory(PokerID, StoryIDs[1], "8", false); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if _, err := svc.SkipStory(PokerID, StoryIDs[2]); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+
+	counts, err := svc.CountStoriesByStatus(PokerID)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	expected := map[string]int{
+		thunderdome.StoryStatusUnestimated: 1,
+		thunderdome.StoryStatusEstimated:   2,
+		thunderdome.StoryStatusSkipped:     1,
+	}
+	for status, count := range expected {
+		if counts[status] != count {
+			t.Fatalf(`expected %d %s stories got %d`, count, status, counts[status])
+		}
+	}
+}
+
+// TestCallForRevoteRecordsEvent calls CallForRevote with and without an active story and with the event log failing
+// and makes sure the revote_called event is recorded in the revotes transaction and only committed with it
+func TestCallForRevoteRecordsEvent(t *testing.T) {
+	PokerID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	StoryID := "3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b"
+	FacilitatorID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	svc, f := newTestService(t)
+	activeStory := StoryID
+	f.Query("WITH revote AS", []string{"id"}, func(args []driver.Value) ([][]driver.Value, error) {
+		if activeStory == "" {
+			return nil, nil
+		}
+		return [][]driver.Value{{activeStory}}, nil
+	})
+	var events [][]driver.Value
+	var eventErr error
+	f.Exec("INSERT INTO thunderdome.poker_event", func(args []driver.Value) (int64, error) {
+		if eventErr != nil {
+			return 0, eventErr
+		}
+		events = append(events, args)
+		return 1, nil
+	})
+
+	if _, err := svc.CallForRevote(PokerID, FacilitatorID); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if len(events) != 1 || events[0][1] != StoryID || events[0][2] != FacilitatorID || events[0][3] != "revote_called" {
+		t.Fatalf(`expected a revote_called event for the active story by the facilitator got %v`, events)
+	}
+	if f.Commits() != 1 || f.Rollbacks() != 0 {
+		t.Fatalf(`expected the revote and its event committed together got %d commits %d rollbacks`, f.Commits(), f.Rollbacks())
+	}
+
+	activeStory = ""
+	if _, err := svc.CallForRevote(PokerID, FacilitatorID); !errors.Is(err, thunderdome.ErrNoActiveStory) {
+		t.Fatalf(`expected ErrNoActiveStory without an active story got %v`, err)
+	}
+	if len(events) != 1 || f.Rollbacks() != 1 {
+		t.Fatalf(`expected no event and the transaction rolled back got %d events %d rollbacks`, len(events), f.Rollbacks())
+	}
+
+	activeStory, eventErr = StoryID, errors.New("event log unavailable")
+	if _, err := svc.CallForRevote(PokerID, FacilitatorID); err == nil {
+		t.Fatalf(`expected the revote to fail when its event can't be recorded`)
+	}
+	if f.Commits() != 1 || f.Rollbacks() != 2 {
+		t.Fatalf(`expected the revote rolled back with its event got %d commits %d rollbacks`, f.Commits(), f.Rollbacks())
+	}
+}
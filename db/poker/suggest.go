@@ -0,0 +1,200 @@
+package poker
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// SetGameTieBreakStrategy sets how the vote summary suggests an estimate, an empty strategy defaults to round-up
+func (d *Service) SetGameTieBreakStrategy(PokerID string, Strategy string) error {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return err
+	}
+	Strategy, err := normalizeTieBreakStrategy(Strategy)
+	if err != nil {
+		return err
+	}
+	if err := d.ensureNotArchived(PokerID); err != nil {
+		return err
+	}
+
+	if _, err := d.DB.Exec(
+		`UPDATE thunderdome.poker SET tie_break_strategy = $2, updated_date = NOW() WHERE id = $1;`,
+		PokerID, Strategy,
+	); err != nil {
+		d.Logger.Error("update poker tie_break_strategy error", zap.Error(err))
+		return fmt.Errorf("unable to update poker tie break strategy: %w", err)
+	}
+
+	return nil
+}
+
+// normalizeTieBreakStrategy defaults an empty strategy to round-up and rejects unknown strategies
+func normalizeTieBreakStrategy(Strategy string) (string, error) {
+	switch Strategy {
+	case "":
+		return thunderdome.TieBreakRoundUp, nil
+	case thunderdome.TieBreakRoundUp, thunderdome.TieBreakMode, thunderdome.TieBreakUpperMedian:
+		return Strategy, nil
+	default:
+		return "", fmt.Errorf("%w: invalid tie break strategy %q", thunderdome.ErrValidation, Strategy)
+	}
+}
+
+// applySuggestedEstimate sets the suggested estimate on the summary and its complexity summary,
+// fist-of-five votes are a confidence check rather than an estimate so get no suggestion
+func applySuggestedEstimate(Summary *thunderdome.StoryVoteSummary, Strategy string, Scale []thunderdome.ScaleValue, Votes []*thunderdome.Vote) {
+	if Summary.VoteMode == thunderdome.PokerVoteModeFistOfFive {
+		return
+	}
+	Strategy, err := normalizeTieBreakStrategy(Strategy)
+	if err != nil {
+		Strategy = thunderdome.TieBreakRoundUp
+	}
+
+	effort := make([]string, 0, len(Votes))
+	complexity := make([]string, 0, len(Votes))
+	for _, vote := range Votes {
+		effort = append(effort, vote.VoteValue)
+		complexity = append(complexity, vote.ComplexityValue)
+	}
+
+	if Summary.SuggestedEstimate = suggestEstimate(Strategy, Scale, effort); Summary.SuggestedEstimate != "" {
+		Summary.SuggestionStrategy = Strategy
+	}
+	if Summary.Complexity != nil {
+		if Summary.Complexity.SuggestedEstimate = suggestEstimate(Strategy, Scale, complexity); Summary.Complexity.SuggestedEstimate != "" {
+			Summary.Complexity.SuggestionStrategy = Strategy
+		}
+	}
+}
+
+// suggestionScale is the numeric scale votes are suggested from, either the custom scale
+// or the games allowed point values that are numbers, ordered from lowest to highest
+func suggestionScale(CustomScale []thunderdome.ScaleValue, PointValuesAllowed []string) []thunderdome.ScaleValue {
+	scale := make([]thunderdome.ScaleValue, 0)
+	if len(CustomScale) > 0 {
+		scale = append(scale, CustomScale...)
+	} else {
+		for _, pv := range PointValuesAllowed {
+			if value, ok := pointValueToFloat(pv); ok {
+				scale = append(scale, thunderdome.ScaleValue{Label: pv, Ordinal: value})
+			}
+		}
+	}
+	sort.SliceStable(scale, func(i, j int) bool {
+		return scale[i].Ordinal < scale[j].Ordinal
+	})
+
+	return scale
+}
+
+// suggestEstimate proposes an allowed value from the numeric votes using the tie break strategy,
+// returning an empty suggestion when there are no numeric votes
+func suggestEstimate(Strategy string, Scale []thunderdome.ScaleValue, VoteValues []string) string {
+	type vote struct {
+		label string
+		value float64
+	}
+	votes := make([]vote, 0, len(VoteValues))
+	for _, v := range VoteValues {
+		if v == "" {
+			continue
+		}
+		value, ok := scaleValue(Scale, v)
+		if !ok {
+			continue
+		}
+		votes = append(votes, vote{label: v, value: value})
+	}
+	if len(votes) == 0 {
+		return ""
+	}
+	sort.SliceStable(votes, func(i, j int) bool {
+		return votes[i].value < votes[j].value
+	})
+
+	switch Strategy {
+	case thunderdome.TieBreakMode:
+		counts := make(map[float64]int)
+		best := votes[0]
+		for _, v := range votes {
+			counts[v.value]++
+			// votes are sorted ascending so a later vote with the same count is the higher value
+			if counts[v.value] >= counts[best.value] {
+				best = v
+			}
+		}
+		return best.label
+	case thunderdome.TieBreakUpperMedian:
+		return votes[len(votes)/2].label
+	default:
+		var total float64
+		for _, v := range votes {
+			total += v.value
+		}
+		average := total / float64(len(votes))
+		for _, sv := range Scale {
+			if sv.Ordinal >= average-1e-9 {
+				return sv.Label
+			}
+		}
+		return votes[len(votes)-1].label
+	}
+}
+
+// scaleValue gets the numeric value of the vote from the scale, falling back to parsing it as a point value
+func scaleValue(Scale []thunderdome.ScaleValue, VoteValue string) (float64, bool) {
+	for _, sv := range Scale {
+		if sv.Label == VoteValue {
+			return sv.Ordinal, true
+		}
+	}
+	value, ok := pointValueToFloat(VoteValue)
+	if !ok || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+
+	return value, true
+}
+
+// FinalizeStoryAuto finalizes the story with the estimate recommended from its votes so the facilitator
+// only has to confirm it, the same quorum rules as finalizing by hand apply
+func (d *Service) FinalizeStoryAuto(PokerID string, StoryID string, FacilitatorID string) ([]*thunderdome.Story, error) {
+	if err := db.ValidateUUID(PokerID, StoryID, FacilitatorID); err != nil {
+		return nil, err
+	}
+	if err := d.ConfirmFacilitator(PokerID, FacilitatorID); err != nil {
+		return nil, err
+	}
+
+	summary, err := d.GetStoryVoteSummary(PokerID, StoryID)
+	if err != nil {
+		return nil, err
+	}
+	Points := recommendedEstimate(summary)
+	if Points == "" {
+		return nil, fmt.Errorf("%w: no votes to recommend an estimate from", thunderdome.ErrValidation)
+	}
+
+	return d.FinalizeStory(PokerID, StoryID, Points, false)
+}
+
+// recommendedEstimate is the summaries suggested estimate from the games tie break strategy,
+// or on scales without a suggestion such as categorical scales the mode when there's a single most common vote
+func recommendedEstimate(Summary *thunderdome.StoryVoteSummary) string {
+	if Summary.SuggestedEstimate != "" {
+		return Summary.SuggestedEstimate
+	}
+	if len(Summary.Mode) == 1 {
+		return Summary.Mode[0]
+	}
+
+	return ""
+}
@@ -0,0 +1,154 @@
+package poker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+var fibonacciScale = suggestionScale(nil, []string{"0", "1/2", "1", "2", "3", "5", "8", "13", "?"})
+
+// TestSuggestEstimateRoundUp calls suggestEstimate with the round-up strategy on split votes
+// and makes sure the average is rounded up to the next allowed value
+func TestSuggestEstimateRoundUp(t *testing.T) {
+	cases := []struct {
+		votes []string
+		want  string
+	}{
+		{[]string{"3", "5"}, "5"},
+		{[]string{"2", "3", "5", "8"}, "5"},
+		{[]string{"3", "3"}, "3"},
+		{[]string{"1/2", "1"}, "1"},
+		{[]string{"5", "8", "?", ""}, "8"},
+	}
+	for _, c := range cases {
+		if got := suggestEstimate(thunderdome.TieBreakRoundUp, fibonacciScale, c.votes); got != c.want {
+			t.Fatalf(`expected round-up of %v to suggest %s got %s`, c.votes, c.want, got)
+		}
+	}
+}
+
+// TestSuggestEstimateMode calls suggestEstimate with the mode strategy
+// and makes sure the most common vote wins with ties going to the higher value
+func TestSuggestEstimateMode(t *testing.T) {
+	cases := []struct {
+		votes []string
+		want  string
+	}{
+		{[]string{"3", "5", "3"}, "3"},
+		{[]string{"3", "3", "5", "5"}, "5"},
+		{[]string{"8", "2", "5"}, "8"},
+		{[]string{"1/2", "1/2", "13"}, "1/2"},
+	}
+	for _, c := range cases {
+		if got := suggestEstimate(thunderdome.TieBreakMode, fibonacciScale, c.votes); got != c.want {
+			t.Fatalf(`expected mode of %v to suggest %s got %s`, c.votes, c.want, got)
+		}
+	}
+}
+
+// TestSuggestEstimateUpperMedian calls suggestEstimate with the upper-median strategy
+// and makes sure the higher of the two middle votes is taken when there is an even number of votes
+func TestSuggestEstimateUpperMedian(t *testing.T) {
+	cases := []struct {
+		votes []string
+		want  string
+	}{
+		{[]string{"3", "5"}, "5"},
+		{[]string{"8", "1", "5", "2"}, "5"},
+		{[]string{"1", "3", "13"}, "3"},
+	}
+	for _, c := range cases {
+		if got := suggestEstimate(thunderdome.TieBreakUpperMedian, fibonacciScale, c.votes); got != c.want {
+			t.Fatalf(`expected upper-median of %v to suggest %s got %s`, c.votes, c.want, got)
+		}
+	}
+}
+
+// TestSuggestEstimateCustomScale calls suggestEstimate with a t-shirt custom scale
+// and makes sure the suggestion is a scale label
+func TestSuggestEstimateCustomScale(t *testing.T) {
+	scale := suggestionScale([]thunderdome.ScaleValue{
+		{Label: "L", Ordinal: 3}, {Label: "S", Ordinal: 1}, {Label: "M", Ordinal: 2},
+	}, nil)
+
+	if got := suggestEstimate(thunderdome.TieBreakRoundUp, scale, []string{"S", "M"}); got != "M" {
+		t.Fatalf(`expected round-up of S and M to suggest M got %s`, got)
+	}
+	if got := suggestEstimate(thunderdome.TieBreakUpperMedian, scale, []string{"L", "S"}); got != "L" {
+		t.Fatalf(`expected upper-median of L and S to suggest L got %s`, got)
+	}
+	if got := suggestEstimate(thunderdome.TieBreakMode, scale, []string{"?", ""}); got != "" {
+		t.Fatalf(`expected no suggestion without numeric votes got %s`, got)
+	}
+}
+
+// TestApplySuggestedEstimate calls applySuggestedEstimate in points and fist-of-five mode
+// and makes sure the strategy used is exposed only alongside a suggestion
+func TestApplySuggestedEstimate(t *testing.T) {
+	votes := []*thunderdome.Vote{{UserId: "a", VoteValue: "3"}, {UserId: "b", VoteValue: "5"}}
+
+	summary := calculateStoryVoteSummary(thunderdome.PokerVoteModePoints, nil, votes)
+	applySuggestedEstimate(summary, thunderdome.TieBreakUpperMedian, fibonacciScale, votes)
+	if summary.SuggestedEstimate != "5" || summary.SuggestionStrategy != thunderdome.TieBreakUpperMedian {
+		t.Fatalf(`expected upper-median suggestion of 5 got %q %q`, summary.SuggestedEstimate, summary.SuggestionStrategy)
+	}
+
+	fist := calculateStoryVoteSummary(thunderdome.PokerVoteModeFistOfFive, nil, votes)
+	applySuggestedEstimate(fist, thunderdome.TieBreakUpperMedian, fibonacciScale, votes)
+	if fist.SuggestedEstimate != "" || fist.SuggestionStrategy != "" {
+		t.Fatalf(`expected no suggestion in fist-of-five mode got %q %q`, fist.SuggestedEstimate, fist.SuggestionStrategy)
+	}
+}
+
+// TestRecommendedEstimate calls recommendedEstimate with the summary of the same votes under each tie break strategy
+// and on a categorical scale, making sure the strategies suggestion is used and otherwise a single mode
+func TestRecommendedEstimate(t *testing.T) {
+	allowed := []string{"0", "1/2", "1", "2", "3", "5", "8", "13", "?"}
+	votes := []*thunderdome.Vote{
+		{UserId: "a", VoteValue: "1"},
+		{UserId: "b", VoteValue: "1"},
+		{UserId: "c", VoteValue: "3"},
+		{UserId: "d", VoteValue: "13"},
+	}
+	recommend := func(Strategy string, Allowed []string, Votes []*thunderdome.Vote) string {
+		summary := calculateStoryVoteSummary(thunderdome.PokerVoteModePoints, nil, Votes)
+		applySuggestedEstimate(summary, Strategy, suggestionScale(nil, Allowed), Votes)
+		applyScaleType(summary, voteScaleType(thunderdome.PokerVoteModePoints, nil, Allowed), Allowed)
+		return recommendedEstimate(summary)
+	}
+
+	cases := map[string]string{
+		thunderdome.TieBreakMode:        "1",
+		thunderdome.TieBreakUpperMedian: "3",
+		thunderdome.TieBreakRoundUp:     "5",
+	}
+	for strategy, want := range cases {
+		if got := recommend(strategy, allowed, votes); got != want {
+			t.Fatalf(`expected %s to recommend %s got %q`, strategy, want, got)
+		}
+	}
+
+	tShirt := []string{"XS", "S", "M", "L", "XL"}
+	sizes := []*thunderdome.Vote{{UserId: "a", VoteValue: "L"}, {UserId: "b", VoteValue: "S"}, {UserId: "c", VoteValue: "L"}}
+	if got := recommend(thunderdome.TieBreakRoundUp, tShirt, sizes); got != "L" {
+		t.Fatalf(`expected the categorical mode L to be recommended got %q`, got)
+	}
+	if got := recommend(thunderdome.TieBreakRoundUp, tShirt, sizes[:2]); got != "" {
+		t.Fatalf(`expected no recommendation for a tied categorical vote got %q`, got)
+	}
+}
+
+// TestNormalizeTieBreakStrategy calls normalizeTieBreakStrategy with empty, known and unknown strategies
+func TestNormalizeTieBreakStrategy(t *testing.T) {
+	if s, err := normalizeTieBreakStrategy(""); err != nil || s != thunderdome.TieBreakRoundUp {
+		t.Fatalf(`expected empty strategy to default to round-up got %q %v`, s, err)
+	}
+	if s, err := normalizeTieBreakStrategy(thunderdome.TieBreakMode); err != nil || s != thunderdome.TieBreakMode {
+		t.Fatalf(`expected mode strategy to be kept got %q %v`, s, err)
+	}
+	if _, err := normalizeTieBreakStrategy("coin-flip"); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected unknown strategy to be invalid got %v`, err)
+	}
+}
@@ -0,0 +1,325 @@
+package poker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// fistOfFiveConcernThreshold votes below this value in fist-of-five mode are reported as concerns
+const fistOfFiveConcernThreshold = 3
+
+// GetStoryVoteSummary gets a summary of the votes cast for the story according to the games vote mode
+func (d *Service) GetStoryVoteSummary(PokerID string, StoryID string) (*thunderdome.StoryVoteSummary, error) {
+	if err := db.ValidateUUID(PokerID, StoryID); err != nil {
+		return nil, err
+	}
+
+	var VoteMode string
+	var EstimationUnit string
+	var TieBreakStrategy string
+	var cs string
+	var pv string
+	var v string
+	var CustomScale = make([]thunderdome.ScaleValue, 0)
+	var PointValuesAllowed = make([]string, 0)
+	var Votes = make([]*thunderdome.Vote, 0)
+
+	err := d.DB.QueryRow(
+		`SELECT COALESCE(p.vote_mode, 'points'), p.estimation_unit, p.tie_break_strategy,
+			COALESCE(p.custom_scale, '[]'::jsonb), p.point_values_allowed, ps.votes
+		FROM thunderdome.poker_story ps
+		JOIN thunderdome.poker p ON p.id = ps.poker_id
+		WHERE ps.id = $2 AND ps.poker_id = $1;`,
+		PokerID, StoryID,
+	).Scan(&VoteMode, &EstimationUnit, &TieBreakStrategy, &cs, &pv, &v)
+	if err != nil {
+		d.Logger.Error("get poker story votes error", zap.Error(err))
+		return nil, errors.New("not found")
+	}
+
+	if Votes, err = decodeStoryVotes(v); err != nil {
+		d.Logger.Error("get poker story corrupt votes error", zap.String("story_id", StoryID), zap.Error(err))
+		return nil, err
+	}
+	_ = json.Unmarshal([]byte(cs), &CustomScale)
+	_ = json.Unmarshal([]byte(pv), &PointValuesAllowed)
+
+	scale := suggestionScale(CustomScale, PointValuesAllowed)
+	summary := calculateStoryVoteSummary(VoteMode, CustomScale, Votes)
+	summary.EstimationUnit = EstimationUnit
+	if summary.Complexity != nil {
+		summary.Complexity.EstimationUnit = EstimationUnit
+	}
+	applyScaleMedian(summary, scale, Votes)
+	applySuggestedEstimate(summary, TieBreakStrategy, scale, Votes)
+	applyScaleType(summary, voteScaleType(VoteMode, CustomScale, PointValuesAllowed), PointValuesAllowed)
+
+	return summary, nil
+}
+
+// calculateStoryVoteSummary summarizes the votes, using the custom scale ordinals for numeric
+// operations when the game has one and counting votes below the concern threshold in fist-of-five mode,
+// in effort-complexity mode the complexity votes are summarized independently of the effort votes
+func calculateStoryVoteSummary(VoteMode string, CustomScale []thunderdome.ScaleValue, Votes []*thunderdome.Vote) *thunderdome.StoryVoteSummary {
+	values := make([]string, 0, len(Votes))
+	for _, vote := range Votes {
+		values = append(values, vote.VoteValue)
+	}
+	summary := summarizeVoteValues(VoteMode, CustomScale, values)
+
+	if VoteMode == thunderdome.PokerVoteModeEffortComplexity {
+		complexity := make([]string, 0, len(Votes))
+		for _, vote := range Votes {
+			complexity = append(complexity, vote.ComplexityValue)
+		}
+		summary.Complexity = summarizeVoteValues(VoteMode, CustomScale, complexity)
+	}
+
+	return summary
+}
+
+// summarizeVoteValues calculates the count, distribution, average and median of a single dimension of votes
+func summarizeVoteValues(VoteMode string, CustomScale []thunderdome.ScaleValue, VoteValues []string) *thunderdome.StoryVoteSummary {
+	summary := &thunderdome.StoryVoteSummary{
+		VoteMode:     VoteMode,
+		Distribution: make(map[string]int),
+	}
+
+	var values []float64
+	var total float64
+
+	for _, vote := range VoteValues {
+		if vote == "" {
+			continue
+		}
+		summary.VoteCount++
+		summary.Distribution[vote]++
+
+		value, ok := voteValueToFloat(CustomScale, vote)
+		if !ok {
+			continue
+		}
+		values = append(values, value)
+		total += value
+
+		if VoteMode == thunderdome.PokerVoteModeFistOfFive && value < fistOfFiveConcernThreshold {
+			summary.Concerns++
+		}
+	}
+
+	if len(values) == 0 {
+		return summary
+	}
+
+	sort.Float64s(values)
+	summary.Average = total / float64(len(values))
+	middle := len(values) / 2
+	if len(values)%2 == 0 {
+		summary.Median = (values[middle-1] + values[middle]) / 2
+	} else {
+		summary.Median = values[middle]
+	}
+	summary.MedianLabel = closestScaleLabel(CustomScale, summary.Median)
+
+	return summary
+}
+
+// applyScaleMedian replaces the summary median and its complexity median with the median by position within the
+// ordered scale, so on scales with gaps such as fibonacci the median is always a scale value that can be picked
+func applyScaleMedian(Summary *thunderdome.StoryVoteSummary, Scale []thunderdome.ScaleValue, Votes []*thunderdome.Vote) {
+	effort := make([]string, 0, len(Votes))
+	complexity := make([]string, 0, len(Votes))
+	for _, vote := range Votes {
+		effort = append(effort, vote.VoteValue)
+		complexity = append(complexity, vote.ComplexityValue)
+	}
+
+	if median, ok := scaleMedian(Scale, effort); ok {
+		Summary.Median, Summary.MedianLabel = median.Ordinal, median.Label
+	}
+	if Summary.Complexity != nil {
+		if median, ok := scaleMedian(Scale, complexity); ok {
+			Summary.Complexity.Median, Summary.Complexity.MedianLabel = median.Ordinal, median.Label
+		}
+	}
+}
+
+// scaleMedian finds the median vote by its position in the scale ordered lowest to highest, votes not on the scale
+// are left out and for an even count the lower of the scale values midway between the two middle votes is used
+func scaleMedian(Scale []thunderdome.ScaleValue, VoteValues []string) (thunderdome.ScaleValue, bool) {
+	positions := make([]int, 0, len(VoteValues))
+	for _, v := range VoteValues {
+		for i, sv := range Scale {
+			if v != "" && sv.Label == v {
+				positions = append(positions, i)
+				break
+			}
+		}
+	}
+	if len(positions) == 0 {
+		return thunderdome.ScaleValue{}, false
+	}
+
+	sort.Ints(positions)
+	middle := len(positions) / 2
+	if len(positions)%2 == 0 {
+		return Scale[(positions[middle-1]+positions[middle])/2], true
+	}
+
+	return Scale[positions[middle]], true
+}
+
+// voteScaleType gets whether the games votes are numeric, which custom scales with their ordinals and fist-of-five always are,
+// or categorical when fewer than half of the allowed point values are numbers such as a T-shirt scale
+func voteScaleType(VoteMode string, CustomScale []thunderdome.ScaleValue, PointValuesAllowed []string) string {
+	if len(CustomScale) > 0 || VoteMode == thunderdome.PokerVoteModeFistOfFive {
+		return thunderdome.ScaleTypeNumeric
+	}
+
+	var numeric int
+	for _, pv := range PointValuesAllowed {
+		if _, ok := pointValueToFloat(pv); ok {
+			numeric++
+		}
+	}
+	if numeric*2 < len(PointValuesAllowed) {
+		return thunderdome.ScaleTypeCategorical
+	}
+
+	return thunderdome.ScaleTypeNumeric
+}
+
+// applyScaleType sets the scale type and mode on the summary and its complexity summary,
+// clearing the average and median on categorical scales where there's no meaningful arithmetic
+func applyScaleType(Summary *thunderdome.StoryVoteSummary, ScaleType string, PointValuesAllowed []string) {
+	for _, s := range []*thunderdome.StoryVoteSummary{Summary, Summary.Complexity} {
+		if s == nil {
+			continue
+		}
+		s.ScaleType = ScaleType
+		s.Mode = voteMode(s.Distribution, PointValuesAllowed)
+		if ScaleType == thunderdome.ScaleTypeCategorical {
+			s.Average, s.Median, s.MedianLabel = 0, 0, ""
+		}
+	}
+}
+
+// voteMode finds the most common vote values in the order of the allowed point values,
+// followed by any values not allowed in alphabetical order
+func voteMode(Distribution map[string]int, PointValuesAllowed []string) []string {
+	var most int
+	for _, count := range Distribution {
+		if count > most {
+			most = count
+		}
+	}
+
+	mode := make([]string, 0)
+	if most == 0 {
+		return mode
+	}
+	for _, pv := range PointValuesAllowed {
+		if Distribution[pv] == most && !db.Contains(mode, pv) {
+			mode = append(mode, pv)
+		}
+	}
+	others := make([]string, 0)
+	for v, count := range Distribution {
+		if count == most && !db.Contains(PointValuesAllowed, v) {
+			others = append(others, v)
+		}
+	}
+	sort.Strings(others)
+
+	return append(mode, others...)
+}
+
+// voteValueToFloat converts the vote to its custom scale ordinal, or its numeric point value without a custom scale
+func voteValueToFloat(CustomScale []thunderdome.ScaleValue, VoteValue string) (float64, bool) {
+	if len(CustomScale) == 0 {
+		return pointValueToFloat(VoteValue)
+	}
+
+	for _, sv := range CustomScale {
+		if sv.Label == VoteValue {
+			return sv.Ordinal, true
+		}
+	}
+
+	return 0, false
+}
+
+// closestScaleLabel finds the label of the custom scale value nearest the ordinal, preferring the lower value on ties
+func closestScaleLabel(CustomScale []thunderdome.ScaleValue, Ordinal float64) string {
+	var label string
+	closest := math.Inf(1)
+
+	for _, sv := range CustomScale {
+		distance := math.Abs(sv.Ordinal - Ordinal)
+		if distance < closest || (distance == closest && sv.Ordinal < Ordinal) {
+			closest = distance
+			label = sv.Label
+		}
+	}
+
+	return label
+}
+
+// scaleLabels returns the custom scale labels in order for use as the allowed point values
+func scaleLabels(CustomScale []thunderdome.ScaleValue) []string {
+	labels := make([]string, 0, len(CustomScale))
+	for _, sv := range CustomScale {
+		labels = append(labels, sv.Label)
+	}
+
+	return labels
+}
+
+// normalizeVoteMode defaults an empty vote mode to points and
+// replaces the allowed point values for vote modes with a fixed scale
+func normalizeVoteMode(VoteMode string, PointValuesAllowed []string) (string, []string) {
+	switch VoteMode {
+	case thunderdome.PokerVoteModeFistOfFive:
+		return VoteMode, thunderdome.FistOfFiveValues
+	case "":
+		return thunderdome.PokerVoteModePoints, PointValuesAllowed
+	default:
+		return VoteMode, PointValuesAllowed
+	}
+}
+
+// validateVoteValue checks the vote value is allowed for the vote mode and custom scale
+func validateVoteValue(VoteMode string, CustomScale []thunderdome.ScaleValue, VoteValue string) error {
+	if VoteMode == thunderdome.PokerVoteModeFistOfFive && !db.Contains(thunderdome.FistOfFiveValues, VoteValue) {
+		return fmt.Errorf("%w: invalid fist-of-five vote %q", thunderdome.ErrValidation, VoteValue)
+	}
+	if len(CustomScale) > 0 && !db.Contains(scaleLabels(CustomScale), VoteValue) {
+		return fmt.Errorf("%w: invalid custom scale vote %q", thunderdome.ErrValidation, VoteValue)
+	}
+
+	return nil
+}
+
+// validateComplexityValue checks a complexity value is only given in effort-complexity vote mode,
+// where it's required and must be allowed by the custom scale the same as the effort vote
+func validateComplexityValue(VoteMode string, CustomScale []thunderdome.ScaleValue, ComplexityValue string) error {
+	if VoteMode != thunderdome.PokerVoteModeEffortComplexity {
+		if ComplexityValue != "" {
+			return fmt.Errorf("%w: complexity votes require effort-complexity vote mode", thunderdome.ErrValidation)
+		}
+		return nil
+	}
+	if ComplexityValue == "" {
+		return fmt.Errorf("%w: complexity vote required", thunderdome.ErrValidation)
+	}
+
+	return validateVoteValue(VoteMode, CustomScale, ComplexityValue)
+}
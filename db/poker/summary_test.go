@@ -0,0 +1,283 @@
+package poker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestCalculateStoryVoteSummaryFistOfFive calls calculateStoryVoteSummary with a fist-of-five round
+// and makes sure votes below 3 are counted as concerns
+func TestCalculateStoryVoteSummaryFistOfFive(t *testing.T) {
+	votes := []*thunderdome.Vote{
+		{UserId: "a", VoteValue: "5"},
+		{UserId: "b", VoteValue: "4"},
+		{UserId: "c", VoteValue: "2"},
+		{UserId: "d", VoteValue: "0"},
+		{UserId: "e", VoteValue: "3"},
+	}
+
+	summary := calculateStoryVoteSummary(thunderdome.PokerVoteModeFistOfFive, nil, votes)
+
+	if summary.VoteCount != 5 {
+		t.Fatalf(`expected vote count: 5 got %d`, summary.VoteCount)
+	}
+	if summary.Concerns != 2 {
+		t.Fatalf(`expected concerns: 2 got %d`, summary.Concerns)
+	}
+	if summary.Average != 2.8 {
+		t.Fatalf(`expected average: 2.8 got %v`, summary.Average)
+	}
+}
+
+// TestCalculateStoryVoteSummaryPoints calls calculateStoryVoteSummary in points mode
+// and makes sure low votes are not reported as concerns
+func TestCalculateStoryVoteSummaryPoints(t *testing.T) {
+	votes := []*thunderdome.Vote{
+		{UserId: "a", VoteValue: "1"},
+		{UserId: "b", VoteValue: "1"},
+		{UserId: "c", VoteValue: "?"},
+	}
+
+	summary := calculateStoryVoteSummary(thunderdome.PokerVoteModePoints, nil, votes)
+
+	if summary.Concerns != 0 {
+		t.Fatalf(`expected concerns: 0 got %d`, summary.Concerns)
+	}
+	if summary.Distribution["1"] != 2 || summary.Distribution["?"] != 1 {
+		t.Fatalf(`unexpected distribution %v`, summary.Distribution)
+	}
+}
+
+// TestValidateVoteValue calls validateVoteValue and makes sure fist-of-five only allows 0 through 5
+func TestValidateVoteValue(t *testing.T) {
+	if err := validateVoteValue(thunderdome.PokerVoteModeFistOfFive, nil, "4"); err != nil {
+		t.Fatalf(`expected fist-of-five vote 4 to be valid got %v`, err)
+	}
+	if err := validateVoteValue(thunderdome.PokerVoteModeFistOfFive, nil, "8"); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected fist-of-five vote 8 to be invalid got %v`, err)
+	}
+	if err := validateVoteValue(thunderdome.PokerVoteModePoints, nil, "8"); err != nil {
+		t.Fatalf(`expected points vote 8 to be valid got %v`, err)
+	}
+}
+
+var tShirtScale = []thunderdome.ScaleValue{
+	{Label: "XS", Ordinal: 1},
+	{Label: "S", Ordinal: 2},
+	{Label: "M", Ordinal: 3},
+	{Label: "L", Ordinal: 5},
+	{Label: "XL", Ordinal: 8},
+}
+
+// TestCalculateStoryVoteSummaryCustomScale calls calculateStoryVoteSummary with a custom T-shirt scale
+// and makes sure the median is computed by ordinal and displayed as a label
+func TestCalculateStoryVoteSummaryCustomScale(t *testing.T) {
+	votes := []*thunderdome.Vote{
+		{UserId: "a", VoteValue: "XL"},
+		{UserId: "b", VoteValue: "S"},
+		{UserId: "c", VoteValue: "M"},
+		{UserId: "d", VoteValue: "L"},
+		{UserId: "e", VoteValue: "M"},
+	}
+
+	summary := calculateStoryVoteSummary(thunderdome.PokerVoteModePoints, tShirtScale, votes)
+
+	if summary.Median != 3 {
+		t.Fatalf(`expected median ordinal: 3 got %v`, summary.Median)
+	}
+	if summary.MedianLabel != "M" {
+		t.Fatalf(`expected median label: M got %q`, summary.MedianLabel)
+	}
+	if summary.Average != 4.2 {
+		t.Fatalf(`expected average ordinal: 4.2 got %v`, summary.Average)
+	}
+}
+
+// TestValidateVoteValueCustomScale calls validateVoteValue and makes sure only custom scale labels are allowed
+func TestValidateVoteValueCustomScale(t *testing.T) {
+	if err := validateVoteValue(thunderdome.PokerVoteModePoints, tShirtScale, "XL"); err != nil {
+		t.Fatalf(`expected XL to be valid got %v`, err)
+	}
+	if err := validateVoteValue(thunderdome.PokerVoteModePoints, tShirtScale, "5"); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected 5 to be invalid for custom scale got %v`, err)
+	}
+}
+
+// TestCalculateStoryVoteSummaryHalfPoints calls calculateStoryVoteSummary with half point votes
+// and makes sure "½", "1/2", and "0.5" are all treated as 0.5
+func TestCalculateStoryVoteSummaryHalfPoints(t *testing.T) {
+	votes := []*thunderdome.Vote{
+		{UserId: "a", VoteValue: "½"},
+		{UserId: "b", VoteValue: "1/2"},
+		{UserId: "c", VoteValue: "0.5"},
+		{UserId: "d", VoteValue: "2.5"},
+	}
+
+	summary := calculateStoryVoteSummary(thunderdome.PokerVoteModePoints, nil, votes)
+
+	if summary.VoteCount != 4 {
+		t.Fatalf(`expected vote count: 4 got %d`, summary.VoteCount)
+	}
+	if summary.Average != 1 {
+		t.Fatalf(`expected average: 1 got %v`, summary.Average)
+	}
+	if summary.Median != 0.5 {
+		t.Fatalf(`expected median: 0.5 got %v`, summary.Median)
+	}
+}
+
+// TestCalculateStoryVoteSummaryEffortComplexity calls calculateStoryVoteSummary with two-dimensional votes
+// and makes sure effort and complexity are averaged independently of each other
+func TestCalculateStoryVoteSummaryEffortComplexity(t *testing.T) {
+	votes := []*thunderdome.Vote{
+		{UserId: "a", VoteValue: "1", ComplexityValue: "8"},
+		{UserId: "b", VoteValue: "2", ComplexityValue: "13"},
+		{UserId: "c", VoteValue: "3", ComplexityValue: "?"},
+	}
+
+	summary := calculateStoryVoteSummary(thunderdome.PokerVoteModeEffortComplexity, nil, votes)
+
+	if summary.VoteCount != 3 || summary.Average != 2 || summary.Median != 2 {
+		t.Fatalf(`expected effort count 3 average 2 median 2 got %d %v %v`, summary.VoteCount, summary.Average, summary.Median)
+	}
+	if summary.Complexity == nil {
+		t.Fatalf(`expected complexity summary in effort-complexity mode`)
+	}
+	if summary.Complexity.VoteCount != 3 || summary.Complexity.Average != 10.5 || summary.Complexity.Median != 10.5 {
+		t.Fatalf(`expected complexity count 3 average 10.5 median 10.5 got %d %v %v`,
+			summary.Complexity.VoteCount, summary.Complexity.Average, summary.Complexity.Median)
+	}
+	if summary.Complexity.Distribution["?"] != 1 || summary.Distribution["?"] != 0 {
+		t.Fatalf(`expected distributions kept per dimension got %v %v`, summary.Distribution, summary.Complexity.Distribution)
+	}
+
+	single := calculateStoryVoteSummary(thunderdome.PokerVoteModePoints, nil, votes)
+	if single.Complexity != nil || single.Average != 2 {
+		t.Fatalf(`expected points mode to only summarize the effort votes got %+v`, single)
+	}
+}
+
+// TestValidateComplexityValue calls validateComplexityValue in each vote mode
+// and makes sure a complexity vote is required only in effort-complexity mode and rejected otherwise
+func TestValidateComplexityValue(t *testing.T) {
+	if err := validateComplexityValue(thunderdome.PokerVoteModeEffortComplexity, nil, "5"); err != nil {
+		t.Fatalf(`expected complexity vote to be valid got %v`, err)
+	}
+	if err := validateComplexityValue(thunderdome.PokerVoteModeEffortComplexity, nil, ""); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected missing complexity vote to be invalid got %v`, err)
+	}
+	scale := []thunderdome.ScaleValue{{Label: "S", Ordinal: 1}, {Label: "L", Ordinal: 3}}
+	if err := validateComplexityValue(thunderdome.PokerVoteModeEffortComplexity, scale, "XL"); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected complexity vote outside the custom scale to be invalid got %v`, err)
+	}
+	if err := validateComplexityValue(thunderdome.PokerVoteModePoints, nil, ""); err != nil {
+		t.Fatalf(`expected no complexity vote in points mode to be valid got %v`, err)
+	}
+	if err := validateComplexityValue(thunderdome.PokerVoteModePoints, nil, "5"); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected complexity vote in points mode to be invalid got %v`, err)
+	}
+}
+
+// TestScaleMedianFibonacci calls applyScaleMedian with votes on a fibonacci scale
+// and makes sure the median snaps to a scale value rather than landing between them
+func TestScaleMedianFibonacci(t *testing.T) {
+	scale := suggestionScale(nil, []string{"0", "1", "2", "3", "5", "8", "13", "?", "☕️"})
+
+	cases := []struct {
+		votes  []string
+		label  string
+		median float64
+	}{
+		// arithmetic median 4 is between 3 and 5, by position it's midway 3 (index 3) and 5 (index 4) so the lower 3
+		{[]string{"3", "5"}, "3", 3},
+		// arithmetic median 7.5 is not a scale value, by position midway between 2 and 13 is 5
+		{[]string{"2", "13"}, "5", 5},
+		{[]string{"1", "8", "13", "?"}, "8", 8},
+		{[]string{"1", "2", "8", "13"}, "3", 3},
+		{[]string{"5", "5", "13"}, "5", 5},
+	}
+	for _, c := range cases {
+		votes := make([]*thunderdome.Vote, 0, len(c.votes))
+		for i, v := range c.votes {
+			votes = append(votes, &thunderdome.Vote{UserId: string(rune('a' + i)), VoteValue: v})
+		}
+		summary := calculateStoryVoteSummary(thunderdome.PokerVoteModePoints, nil, votes)
+		applyScaleMedian(summary, scale, votes)
+
+		if summary.MedianLabel != c.label || summary.Median != c.median {
+			t.Fatalf(`votes %v: expected median %s (%v) got %s (%v)`, c.votes, c.label, c.median, summary.MedianLabel, summary.Median)
+		}
+	}
+}
+
+// TestScaleMedianNoScaleVotes calls scaleMedian without votes on the scale and makes sure no median is found
+func TestScaleMedianNoScaleVotes(t *testing.T) {
+	scale := suggestionScale(nil, []string{"1", "2", "3", "5", "8"})
+	if median, ok := scaleMedian(scale, []string{"?", "", "☕️"}); ok {
+		t.Fatalf(`expected no median got %v`, median)
+	}
+}
+
+// TestApplyScaleTypeNumeric calls applyScaleType for votes on the default numeric scale
+// and makes sure the average and median are kept alongside the mode
+func TestApplyScaleTypeNumeric(t *testing.T) {
+	allowed := []string{"0", "1/2", "1", "2", "3", "5", "8", "13", "?"}
+	votes := []*thunderdome.Vote{
+		{UserId: "a", VoteValue: "3"},
+		{UserId: "b", VoteValue: "5"},
+		{UserId: "c", VoteValue: "3"},
+		{UserId: "d", VoteValue: "?"},
+	}
+
+	scaleType := voteScaleType(thunderdome.PokerVoteModePoints, nil, allowed)
+	if scaleType != thunderdome.ScaleTypeNumeric {
+		t.Fatalf(`expected numeric scale got %q`, scaleType)
+	}
+	summary := calculateStoryVoteSummary(thunderdome.PokerVoteModePoints, nil, votes)
+	applyScaleType(summary, scaleType, allowed)
+
+	if summary.ScaleType != thunderdome.ScaleTypeNumeric {
+		t.Fatalf(`expected summary scale type numeric got %q`, summary.ScaleType)
+	}
+	if summary.Average != 11.0/3 || summary.Median != 3 {
+		t.Fatalf(`expected average 3.67 and median 3 got %v and %v`, summary.Average, summary.Median)
+	}
+	if len(summary.Mode) != 1 || summary.Mode[0] != "3" {
+		t.Fatalf(`expected mode [3] got %v`, summary.Mode)
+	}
+}
+
+// TestApplyScaleTypeCategorical calls applyScaleType for votes on a T-shirt scale without ordinals
+// and makes sure only the mode and distribution are reported
+func TestApplyScaleTypeCategorical(t *testing.T) {
+	allowed := []string{"XS", "S", "M", "L", "XL", "?"}
+	votes := []*thunderdome.Vote{
+		{UserId: "a", VoteValue: "L"},
+		{UserId: "b", VoteValue: "S"},
+		{UserId: "c", VoteValue: "L"},
+		{UserId: "d", VoteValue: "S"},
+		{UserId: "e", VoteValue: "M"},
+	}
+
+	scaleType := voteScaleType(thunderdome.PokerVoteModePoints, nil, allowed)
+	if scaleType != thunderdome.ScaleTypeCategorical {
+		t.Fatalf(`expected categorical scale got %q`, scaleType)
+	}
+	if custom := voteScaleType(thunderdome.PokerVoteModePoints, tShirtScale, allowed); custom != thunderdome.ScaleTypeNumeric {
+		t.Fatalf(`expected a custom scale with ordinals to be numeric got %q`, custom)
+	}
+	summary := calculateStoryVoteSummary(thunderdome.PokerVoteModePoints, nil, votes)
+	applyScaleType(summary, scaleType, allowed)
+
+	if summary.Average != 0 || summary.Median != 0 || summary.MedianLabel != "" {
+		t.Fatalf(`expected no average or median got %v, %v and %q`, summary.Average, summary.Median, summary.MedianLabel)
+	}
+	if len(summary.Mode) != 2 || summary.Mode[0] != "S" || summary.Mode[1] != "L" {
+		t.Fatalf(`expected mode [S L] in scale order got %v`, summary.Mode)
+	}
+	if summary.Distribution["L"] != 2 || summary.Distribution["M"] != 1 {
+		t.Fatalf(`expected distribution to be kept got %v`, summary.Distribution)
+	}
+}
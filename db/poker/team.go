@@ -0,0 +1,61 @@
+package poker
+
+import (
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// GetAbsentTeamUsers gets the team's users that aren't currently active in the game
+func (d *Service) GetAbsentTeamUsers(PokerID string, TeamID string) ([]*thunderdome.TeamUser, error) {
+	if err := db.ValidateUUID(PokerID, TeamID); err != nil {
+		return nil, err
+	}
+
+	var TeamUsers = make([]*thunderdome.TeamUser, 0)
+	rows, err := d.DB.Query(
+		`SELECT u.id, u.name, COALESCE(u.email, ''), tu.role, u.avatar
+        FROM thunderdome.team_user tu
+        JOIN thunderdome.users u ON tu.user_id = u.id
+        WHERE tu.team_id = $1
+        ORDER BY u.name;`,
+		TeamID,
+	)
+	if err != nil {
+		d.Logger.Error("get absent team users query error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get team users: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var usr thunderdome.TeamUser
+		if err := rows.Scan(&usr.Id, &usr.Name, &usr.Email, &usr.Role, &usr.Avatar); err != nil {
+			d.Logger.Error("get absent team users scan error", zap.Error(err))
+			continue
+		}
+		usr.GravatarHash = db.CreateGravatarHash(usr.Email)
+		TeamUsers = append(TeamUsers, &usr)
+	}
+
+	return filterAbsentTeamUsers(TeamUsers, d.getActiveUsers(d.DB, PokerID)), nil
+}
+
+// filterAbsentTeamUsers returns the team users that aren't in the list of active game users
+func filterAbsentTeamUsers(TeamUsers []*thunderdome.TeamUser, ActiveUsers []*thunderdome.PokerUser) []*thunderdome.TeamUser {
+	active := make(map[string]bool, len(ActiveUsers))
+	for _, u := range ActiveUsers {
+		active[u.Id] = true
+	}
+
+	absent := make([]*thunderdome.TeamUser, 0)
+	for _, u := range TeamUsers {
+		if !active[u.Id] {
+			absent = append(absent, u)
+		}
+	}
+
+	return absent
+}
@@ -0,0 +1,29 @@
+package poker
+
+import (
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestFilterAbsentTeamUsers calls filterAbsentTeamUsers with some team members active in the game
+// and makes sure only the absent members are returned in order
+func TestFilterAbsentTeamUsers(t *testing.T) {
+	teamUsers := []*thunderdome.TeamUser{
+		{Id: "a", Name: "Alice"},
+		{Id: "b", Name: "Bob"},
+		{Id: "c", Name: "Carol"},
+		{Id: "d", Name: "Dave"},
+	}
+	activeUsers := []*thunderdome.PokerUser{
+		{Id: "b", Name: "Bob"},
+		{Id: "d", Name: "Dave"},
+		{Id: "x", Name: "Guest"},
+	}
+
+	absent := filterAbsentTeamUsers(teamUsers, activeUsers)
+
+	if len(absent) != 2 || absent[0].Id != "a" || absent[1].Id != "c" {
+		t.Fatalf(`expected absent users a, c got %d users`, len(absent))
+	}
+}
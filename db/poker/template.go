@@ -0,0 +1,149 @@
+package poker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// CreateGameTemplate saves a reusable set of game settings for the owner
+func (d *Service) CreateGameTemplate(OwnerID string, Template *thunderdome.PokerTemplate) (*thunderdome.PokerTemplate, error) {
+	if err := db.ValidateUUID(OwnerID); err != nil {
+		return nil, err
+	}
+	if err := validateGameTemplate(Template); err != nil {
+		return nil, err
+	}
+
+	t := *Template
+	t.OwnerID = OwnerID
+	t.VoteMode, t.PointValuesAllowed = normalizeVoteMode(t.VoteMode, t.PointValuesAllowed)
+	if t.CustomScale == nil {
+		t.CustomScale = make([]thunderdome.ScaleValue, 0)
+	}
+	if len(t.CustomScale) > 0 {
+		t.PointValuesAllowed = scaleLabels(t.CustomScale)
+	}
+	var pointValuesJSON, _ = json.Marshal(t.PointValuesAllowed)
+	var scaleJSON, _ = json.Marshal(t.CustomScale)
+
+	err := d.DB.QueryRow(
+		`INSERT INTO thunderdome.poker_template
+		(owner_id, name, point_values_allowed, auto_finish_voting, point_average_rounding, hide_voter_identity, vote_mode, custom_scale)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_date, updated_date;`,
+		OwnerID, t.Name, string(pointValuesJSON), t.AutoFinishVoting, t.PointAverageRounding,
+		t.HideVoterIdentity, t.VoteMode, string(scaleJSON),
+	).Scan(&t.Id, &t.CreatedDate, &t.UpdatedDate)
+	if err != nil {
+		d.Logger.Error("insert poker template error", zap.Error(err))
+		return nil, fmt.Errorf("unable to create poker template: %w", err)
+	}
+
+	return &t, nil
+}
+
+// ListGameTemplates gets the owners game templates
+func (d *Service) ListGameTemplates(OwnerID string) ([]*thunderdome.PokerTemplate, error) {
+	if err := db.ValidateUUID(OwnerID); err != nil {
+		return nil, err
+	}
+
+	var templates = make([]*thunderdome.PokerTemplate, 0)
+	rows, err := d.DB.Query(
+		`SELECT id, owner_id, name, point_values_allowed, auto_finish_voting, point_average_rounding,
+		hide_voter_identity, vote_mode, custom_scale, created_date, updated_date
+		FROM thunderdome.poker_template WHERE owner_id = $1 ORDER BY name;`,
+		OwnerID,
+	)
+	if err != nil {
+		d.Logger.Error("list poker templates error", zap.Error(err))
+		return nil, fmt.Errorf("unable to list poker templates: %w", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		t, err := scanGameTemplate(rows)
+		if err != nil {
+			d.Logger.Error("list poker templates scan error", zap.Error(err))
+			continue
+		}
+		templates = append(templates, t)
+	}
+
+	return templates, nil
+}
+
+// CreateGameFromTemplate creates a new game for the facilitator using the settings of their template
+func (d *Service) CreateGameFromTemplate(ctx context.Context, TemplateID string, FacilitatorID string, Name string) (*thunderdome.Poker, error) {
+	if err := db.ValidateUUID(TemplateID, FacilitatorID); err != nil {
+		return nil, err
+	}
+
+	t, err := scanGameTemplate(d.DB.QueryRowContext(ctx,
+		`SELECT id, owner_id, name, point_values_allowed, auto_finish_voting, point_average_rounding,
+		hide_voter_identity, vote_mode, custom_scale, created_date, updated_date
+		FROM thunderdome.poker_template WHERE id = $1 AND owner_id = $2;`,
+		TemplateID, FacilitatorID,
+	))
+	if err != nil {
+		d.Logger.Error("get poker template error", zap.Error(err))
+		return nil, errors.New("not found")
+	}
+
+	return d.CreateGame(ctx, FacilitatorID, Name, t.PointValuesAllowed, make([]*thunderdome.Story, 0),
+		t.AutoFinishVoting, t.PointAverageRounding, "", "", t.HideVoterIdentity, t.VoteMode, t.CustomScale, thunderdome.PokerSettings{}, "")
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanGameTemplate scans a poker_template row into a thunderdome.PokerTemplate
+func scanGameTemplate(row rowScanner) (*thunderdome.PokerTemplate, error) {
+	var pv string
+	var cs string
+	var t = &thunderdome.PokerTemplate{
+		PointValuesAllowed: make([]string, 0),
+		CustomScale:        make([]thunderdome.ScaleValue, 0),
+	}
+
+	if err := row.Scan(
+		&t.Id, &t.OwnerID, &t.Name, &pv, &t.AutoFinishVoting, &t.PointAverageRounding,
+		&t.HideVoterIdentity, &t.VoteMode, &cs, &t.CreatedDate, &t.UpdatedDate,
+	); err != nil {
+		return nil, err
+	}
+	_ = json.Unmarshal([]byte(pv), &t.PointValuesAllowed)
+	_ = json.Unmarshal([]byte(cs), &t.CustomScale)
+
+	return t, nil
+}
+
+// validateGameTemplate checks the template has a name, valid vote mode, rounding and point values
+func validateGameTemplate(Template *thunderdome.PokerTemplate) error {
+	if Template == nil || Template.Name == "" {
+		return fmt.Errorf("%w: template name required", thunderdome.ErrValidation)
+	}
+	switch Template.VoteMode {
+	case "", thunderdome.PokerVoteModePoints, thunderdome.PokerVoteModeFistOfFive, thunderdome.PokerVoteModeEffortComplexity:
+	default:
+		return fmt.Errorf("%w: invalid vote mode %q", thunderdome.ErrValidation, Template.VoteMode)
+	}
+	if !db.Contains([]string{"ceil", "round", "floor"}, Template.PointAverageRounding) {
+		return fmt.Errorf("%w: invalid point average rounding %q", thunderdome.ErrValidation, Template.PointAverageRounding)
+	}
+	if len(Template.PointValuesAllowed) == 0 && len(Template.CustomScale) == 0 &&
+		Template.VoteMode != thunderdome.PokerVoteModeFistOfFive {
+		return fmt.Errorf("%w: point values required", thunderdome.ErrValidation)
+	}
+
+	return validateCustomScale(Template.CustomScale)
+}
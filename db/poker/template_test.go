@@ -0,0 +1,37 @@
+package poker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestValidateGameTemplate calls validateGameTemplate with valid and invalid templates
+// and makes sure only the invalid ones return a validation error
+func TestValidateGameTemplate(t *testing.T) {
+	valid := []*thunderdome.PokerTemplate{
+		{Name: "Refinement", PointValuesAllowed: []string{"1", "2", "3"}, PointAverageRounding: "ceil"},
+		{Name: "Confidence", VoteMode: thunderdome.PokerVoteModeFistOfFive, PointAverageRounding: "round"},
+		{Name: "T-shirt", PointAverageRounding: "floor", CustomScale: []thunderdome.ScaleValue{{Label: "S", Ordinal: 1}, {Label: "M", Ordinal: 2}}},
+	}
+	for _, tpl := range valid {
+		if err := validateGameTemplate(tpl); err != nil {
+			t.Fatalf(`expected template %q to be valid got %v`, tpl.Name, err)
+		}
+	}
+
+	invalid := []*thunderdome.PokerTemplate{
+		nil,
+		{PointValuesAllowed: []string{"1"}, PointAverageRounding: "ceil"},
+		{Name: "No values", PointAverageRounding: "ceil"},
+		{Name: "Bad rounding", PointValuesAllowed: []string{"1"}, PointAverageRounding: "up"},
+		{Name: "Bad mode", PointValuesAllowed: []string{"1"}, PointAverageRounding: "ceil", VoteMode: "dots"},
+		{Name: "Dup scale", PointAverageRounding: "ceil", CustomScale: []thunderdome.ScaleValue{{Label: "S"}, {Label: "S"}}},
+	}
+	for i, tpl := range invalid {
+		if err := validateGameTemplate(tpl); !errors.Is(err, thunderdome.ErrValidation) {
+			t.Fatalf(`expected invalid template %d to fail validation got %v`, i, err)
+		}
+	}
+}
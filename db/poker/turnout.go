@@ -0,0 +1,84 @@
+package poker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// GetActiveStoryTurnout gets who has voted and who is outstanding on the games active story
+// in a single query of the active story votes and the active non-spectator users
+func (d *Service) GetActiveStoryTurnout(PokerID string) (*thunderdome.StoryTurnout, error) {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return nil, err
+	}
+
+	var StoryID string
+	var v string
+	var u string
+	if err := d.DB.QueryRow(
+		`SELECT COALESCE(ps.id::text, ''), COALESCE(ps.votes, '[]'::jsonb),
+			COALESCE((
+				SELECT json_agg(pu.user_id ORDER BY u.name)
+				FROM thunderdome.poker_user pu
+				JOIN thunderdome.users u ON u.id = pu.user_id
+				WHERE pu.poker_id = p.id AND pu.active = true AND pu.spectator = false
+			), '[]')
+		FROM thunderdome.poker p
+		LEFT JOIN thunderdome.poker_story ps ON ps.id = p.active_story_id
+		WHERE p.id = $1;`,
+		PokerID,
+	).Scan(&StoryID, &v, &u); err != nil {
+		d.Logger.Error("get poker active story turnout query error", zap.Error(err))
+		return nil, errors.New("not found")
+	}
+	if StoryID == "" {
+		return nil, errors.New("NO_ACTIVE_STORY")
+	}
+
+	Votes, err := decodeStoryVotes(v)
+	if err != nil {
+		d.Logger.Error("get poker active story turnout corrupt votes error", zap.String("story_id", StoryID), zap.Error(err))
+		return nil, err
+	}
+	var EligibleUserIDs []string
+	if err := json.Unmarshal([]byte(u), &EligibleUserIDs); err != nil {
+		d.Logger.Error("get poker active story turnout users error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get active story turnout: %w", err)
+	}
+
+	return calculateTurnout(StoryID, Votes, EligibleUserIDs), nil
+}
+
+// calculateTurnout splits the eligible users into those who cast a vote and those outstanding keeping their order,
+// votes from users no longer eligible (left or became a spectator) aren't counted
+func calculateTurnout(StoryID string, Votes []*thunderdome.Vote, EligibleUserIDs []string) *thunderdome.StoryTurnout {
+	voted := make(map[string]bool, len(Votes))
+	for _, v := range Votes {
+		if v.VoteValue != "" {
+			voted[v.UserId] = true
+		}
+	}
+
+	turnout := &thunderdome.StoryTurnout{
+		StoryID:     StoryID,
+		Voted:       make([]string, 0),
+		Outstanding: make([]string, 0),
+	}
+	for _, UserID := range EligibleUserIDs {
+		if voted[UserID] {
+			turnout.Voted = append(turnout.Voted, UserID)
+		} else {
+			turnout.Outstanding = append(turnout.Outstanding, UserID)
+		}
+	}
+	turnout.VotedCount = len(turnout.Voted)
+	turnout.OutstandingCount = len(turnout.Outstanding)
+
+	return turnout
+}
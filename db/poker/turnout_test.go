@@ -0,0 +1,49 @@
+package poker
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestCalculateTurnout calls calculateTurnout with only some of the active users having voted
+// and makes sure voters and outstanding users are split without counting ineligible votes
+func TestCalculateTurnout(t *testing.T) {
+	votes := []*thunderdome.Vote{
+		{UserId: "thor", VoteValue: "5"},
+		{UserId: "loki", VoteValue: "13"},
+		{UserId: "odin", VoteValue: ""},
+		{UserId: "spectator", VoteValue: "8"},
+	}
+
+	turnout := calculateTurnout("story", votes, []string{"loki", "odin", "sif", "thor"})
+
+	if turnout.StoryID != "story" {
+		t.Fatalf(`expected story ID kept got %q`, turnout.StoryID)
+	}
+	if strings.Join(turnout.Voted, ",") != "loki,thor" || turnout.VotedCount != 2 {
+		t.Fatalf(`expected loki and thor voted got %v (%d)`, turnout.Voted, turnout.VotedCount)
+	}
+	if strings.Join(turnout.Outstanding, ",") != "odin,sif" || turnout.OutstandingCount != 2 {
+		t.Fatalf(`expected odin and sif outstanding got %v (%d)`, turnout.Outstanding, turnout.OutstandingCount)
+	}
+
+	encoded, _ := json.Marshal(turnout)
+	for _, value := range []string{`"5"`, `"13"`, `"8"`} {
+		if strings.Contains(string(encoded), value) {
+			t.Fatalf(`expected vote values not to be included got %s`, encoded)
+		}
+	}
+}
+
+// TestCalculateTurnoutNoVotes calls calculateTurnout before anyone voted
+// and makes sure everyone is outstanding with empty rather than null voters
+func TestCalculateTurnoutNoVotes(t *testing.T) {
+	turnout := calculateTurnout("story", nil, []string{"thor", "loki"})
+
+	if turnout.Voted == nil || turnout.VotedCount != 0 || turnout.OutstandingCount != 2 {
+		t.Fatalf(`expected everyone outstanding got %+v`, turnout)
+	}
+}
@@ -0,0 +1,82 @@
+package poker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// WithTx runs fn in a transaction so several poker operations can be composed atomically,
+// use the Tx variants of the poker functions within fn, errors from fn are returned as is
+func (d *Service) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	err := db.WithTx(ctx, d.DB, fn)
+	var txErr *db.TxError
+	if errors.As(err, &txErr) {
+		d.Logger.Ctx(ctx).Error("poker transaction error", zap.Error(err))
+	}
+
+	return err
+}
+
+// CreateStoryTx creates the story for the game within the transaction setting its ID
+func (d *Service) CreateStoryTx(ctx context.Context, tx *sql.Tx, PokerID string, Story *thunderdome.Story) error {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return err
+	}
+
+	Story.Votes = make([]*thunderdome.Vote, 0)
+	if err := tx.QueryRowContext(ctx,
+		`INSERT INTO thunderdome.poker_story (poker_id, name, type, reference_id, link, description, acceptance_criteria)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		PokerID,
+		Story.Name,
+		Story.Type,
+		Story.ReferenceId,
+		Story.Link,
+		d.HTMLSanitizerPolicy.Sanitize(Story.Description),
+		d.HTMLSanitizerPolicy.Sanitize(Story.AcceptanceCriteria),
+	).Scan(&Story.Id); err != nil {
+		d.Logger.Ctx(ctx).Error("insert stories error", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// AddUserTx adds the user to the game as active within the transaction, returning whether they're new to the game
+func (d *Service) AddUserTx(ctx context.Context, tx *sql.Tx, PokerID string, UserID string) (bool, error) {
+	if err := db.ValidateUUID(PokerID, UserID); err != nil {
+		return false, err
+	}
+
+	return d.addUser(ctx, tx, PokerID, UserID)
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// addUser upserts the game user as active, xmax is only zero for freshly inserted rows
+// letting us tell a new user from one rejoining
+func (d *Service) addUser(ctx context.Context, q queryRower, PokerID string, UserID string) (bool, error) {
+	var isNew bool
+	if err := q.QueryRowContext(ctx,
+		`INSERT INTO thunderdome.poker_user (poker_id, user_id, active)
+		VALUES ($1, $2, true)
+		ON CONFLICT (poker_id, user_id) DO UPDATE SET active = true, abandoned = false
+		RETURNING (xmax = 0) AS inserted`,
+		PokerID,
+		UserID,
+	).Scan(&isNew); err != nil {
+		d.Logger.Ctx(ctx).Error("error adding user to poker", zap.Error(err))
+		return false, err
+	}
+
+	return isNew, nil
+}
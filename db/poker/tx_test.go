@@ -0,0 +1,44 @@
+package poker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestWithTxLogsTransactionErrors calls WithTx against a closed database
+// and makes sure the failed transaction is logged and returned without running fn
+func TestWithTxLogsTransactionErrors(t *testing.T) {
+	closedDB, err := sql.Open("pgx", "postgres://localhost/thunderdome")
+	if err != nil {
+		t.Fatalf(`unexpected error opening database: %v`, err)
+	}
+	_ = closedDB.Close()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	svc := &Service{DB: closedDB, Logger: otelzap.New(zap.New(core))}
+
+	ran := false
+	err = svc.WithTx(context.Background(), func(tx *sql.Tx) error {
+		ran = true
+		return nil
+	})
+	var txErr *db.TxError
+	if !errors.As(err, &txErr) {
+		t.Fatalf(`expected transaction error got %v`, err)
+	}
+	if ran {
+		t.Fatalf(`expected fn not to run`)
+	}
+	if logs.FilterMessage("poker transaction error").Len() != 1 {
+		t.Fatalf(`expected transaction error to be logged once got %d`, logs.Len())
+	}
+}
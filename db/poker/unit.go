@@ -0,0 +1,70 @@
+package poker
+
+import (
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// SetGameEstimationUnit sets the unit the games estimates are labeled with, an empty unit defaults to points
+func (d *Service) SetGameEstimationUnit(PokerID string, EstimationUnit string) error {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return err
+	}
+	EstimationUnit, err := normalizeEstimationUnit(EstimationUnit)
+	if err != nil {
+		return err
+	}
+	if err := d.ensureNotArchived(PokerID); err != nil {
+		return err
+	}
+
+	if _, err := d.DB.Exec(
+		`UPDATE thunderdome.poker SET estimation_unit = $2, updated_date = NOW() WHERE id = $1;`,
+		PokerID, EstimationUnit,
+	); err != nil {
+		d.Logger.Error("update poker estimation_unit error", zap.Error(err))
+		return fmt.Errorf("unable to update poker estimation unit: %w", err)
+	}
+
+	return nil
+}
+
+// SetGameVoteRevealThreshold sets how many users must vote on a story before individual votes are revealed
+// once voting is over, 0 always reveals them
+func (d *Service) SetGameVoteRevealThreshold(PokerID string, RevealThreshold int) error {
+	if err := db.ValidateUUID(PokerID); err != nil {
+		return err
+	}
+	if RevealThreshold < 0 {
+		return fmt.Errorf("%w: vote reveal threshold can't be negative", thunderdome.ErrValidation)
+	}
+	if err := d.ensureNotArchived(PokerID); err != nil {
+		return err
+	}
+
+	if _, err := d.DB.Exec(
+		`UPDATE thunderdome.poker SET vote_reveal_threshold = $2, updated_date = NOW() WHERE id = $1;`,
+		PokerID, RevealThreshold,
+	); err != nil {
+		d.Logger.Error("update poker vote_reveal_threshold error", zap.Error(err))
+		return fmt.Errorf("unable to update poker vote reveal threshold: %w", err)
+	}
+
+	return nil
+}
+
+// normalizeEstimationUnit defaults an empty estimation unit to points and rejects unknown units
+func normalizeEstimationUnit(EstimationUnit string) (string, error) {
+	switch EstimationUnit {
+	case "":
+		return thunderdome.EstimationUnitPoints, nil
+	case thunderdome.EstimationUnitPoints, thunderdome.EstimationUnitHours, thunderdome.EstimationUnitDays:
+		return EstimationUnit, nil
+	default:
+		return "", fmt.Errorf("%w: invalid estimation unit %q", thunderdome.ErrValidation, EstimationUnit)
+	}
+}
@@ -0,0 +1,28 @@
+package poker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestNormalizeEstimationUnit calls normalizeEstimationUnit with empty, known, and unknown units
+// and makes sure known units round-trip, empty defaults to points, and unknown units are rejected
+func TestNormalizeEstimationUnit(t *testing.T) {
+	for unit, expected := range map[string]string{
+		"":                               thunderdome.EstimationUnitPoints,
+		thunderdome.EstimationUnitPoints: thunderdome.EstimationUnitPoints,
+		thunderdome.EstimationUnitHours:  thunderdome.EstimationUnitHours,
+		thunderdome.EstimationUnitDays:   thunderdome.EstimationUnitDays,
+	} {
+		normalized, err := normalizeEstimationUnit(unit)
+		if err != nil || normalized != expected {
+			t.Fatalf(`expected %q for %q got %q, %v`, expected, unit, normalized, err)
+		}
+	}
+
+	if _, err := normalizeEstimationUnit("weeks"); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected validation error for unknown unit got %v`, err)
+	}
+}
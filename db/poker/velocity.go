@@ -0,0 +1,101 @@
+package poker
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// velocityStory is a team game joined with one of its stories, Story is nil for games without stories
+type velocityStory struct {
+	Game  thunderdome.VelocityPoint
+	Story *thunderdome.Story
+}
+
+// GetTeamVelocity gets the total finalized points for each of the team's LastN most recent games in chronological order
+func (d *Service) GetTeamVelocity(TeamID string, LastN int) ([]*thunderdome.VelocityPoint, error) {
+	if err := db.ValidateUUID(TeamID); err != nil {
+		return nil, err
+	}
+	if LastN < 1 {
+		return nil, fmt.Errorf("%w: number of games must be at least 1", thunderdome.ErrValidation)
+	}
+
+	rows, err := d.DB.Query(
+		`SELECT p.id, p.name, p.created_date, ps.id, ps.points, ps.points_numeric, ps.skipped
+		FROM (
+			SELECT id, name, created_date FROM thunderdome.poker
+			WHERE team_id = $1 ORDER BY created_date DESC LIMIT $2
+		) p
+		LEFT JOIN thunderdome.poker_story ps ON ps.poker_id = p.id;`,
+		TeamID, LastN,
+	)
+	if err != nil {
+		d.Logger.Error("get team poker velocity query error", zap.Error(err))
+		return nil, fmt.Errorf("unable to get team velocity: %w", err)
+	}
+	defer rows.Close()
+
+	stories := make([]velocityStory, 0)
+	for rows.Next() {
+		var vs velocityStory
+		var StoryID sql.NullString
+		var Points sql.NullString
+		var PointsNumeric sql.NullFloat64
+		var Skipped sql.NullBool
+		if err := rows.Scan(
+			&vs.Game.PokerID, &vs.Game.Name, &vs.Game.CreatedDate, &StoryID, &Points, &PointsNumeric, &Skipped,
+		); err != nil {
+			d.Logger.Error("get team poker velocity scan error", zap.Error(err))
+			continue
+		}
+		if StoryID.Valid {
+			vs.Story = &thunderdome.Story{Id: StoryID.String, Points: Points.String, Skipped: Skipped.Bool}
+			if PointsNumeric.Valid {
+				vs.Story.PointsNumeric = &PointsNumeric.Float64
+			}
+		}
+		stories = append(stories, vs)
+	}
+
+	return calculateVelocity(stories), nil
+}
+
+// calculateVelocity totals the finalized (non-skipped) stories and their numeric points per game ordered from oldest to newest,
+// games without any estimated stories are kept with zero points so gaps show in the trend
+func calculateVelocity(Stories []velocityStory) []*thunderdome.VelocityPoint {
+	games := make(map[string]*thunderdome.VelocityPoint)
+	velocity := make([]*thunderdome.VelocityPoint, 0)
+
+	for _, vs := range Stories {
+		game, ok := games[vs.Game.PokerID]
+		if !ok {
+			game = &thunderdome.VelocityPoint{
+				PokerID:     vs.Game.PokerID,
+				Name:        vs.Game.Name,
+				CreatedDate: vs.Game.CreatedDate,
+			}
+			games[game.PokerID] = game
+			velocity = append(velocity, game)
+		}
+
+		if vs.Story == nil || vs.Story.Points == "" || vs.Story.Skipped {
+			continue
+		}
+		game.StoriesEstimated++
+		if points, ok := storyPointsToFloat(vs.Story); ok {
+			game.TotalPoints += points
+		}
+	}
+
+	sort.SliceStable(velocity, func(i, j int) bool {
+		return velocity[i].CreatedDate.Before(velocity[j].CreatedDate)
+	})
+
+	return velocity
+}
@@ -0,0 +1,50 @@
+package poker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestCalculateVelocity calls calculateVelocity with stories from several games in no particular order
+// and makes sure each game's finalized points are totaled oldest game first
+func TestCalculateVelocity(t *testing.T) {
+	start := time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)
+	sprint1 := thunderdome.VelocityPoint{PokerID: "sprint-1", Name: "Sprint 1", CreatedDate: start}
+	sprint2 := thunderdome.VelocityPoint{PokerID: "sprint-2", Name: "Sprint 2", CreatedDate: start.AddDate(0, 0, 14)}
+	sprint3 := thunderdome.VelocityPoint{PokerID: "sprint-3", Name: "Sprint 3", CreatedDate: start.AddDate(0, 0, 28)}
+	eight := 8.0
+
+	velocity := calculateVelocity([]velocityStory{
+		{Game: sprint3, Story: &thunderdome.Story{Points: "5"}},
+		{Game: sprint1, Story: &thunderdome.Story{Points: "3"}},
+		{Game: sprint3, Story: &thunderdome.Story{Points: "8", PointsNumeric: &eight}},
+		{Game: sprint1, Story: &thunderdome.Story{Points: "½"}},
+		{Game: sprint1, Story: &thunderdome.Story{Points: "13", Skipped: true}},
+		{Game: sprint1, Story: &thunderdome.Story{}},
+		{Game: sprint3, Story: &thunderdome.Story{Points: "?"}},
+		{Game: sprint2},
+	})
+
+	expected := []thunderdome.VelocityPoint{
+		{PokerID: "sprint-1", StoriesEstimated: 2, TotalPoints: 3.5},
+		{PokerID: "sprint-2", StoriesEstimated: 0, TotalPoints: 0},
+		{PokerID: "sprint-3", StoriesEstimated: 3, TotalPoints: 13},
+	}
+	if len(velocity) != len(expected) {
+		t.Fatalf(`expected %d velocity points got %d`, len(expected), len(velocity))
+	}
+	for i, e := range expected {
+		v := velocity[i]
+		if v.PokerID != e.PokerID || v.StoriesEstimated != e.StoriesEstimated || v.TotalPoints != e.TotalPoints {
+			t.Fatalf(`expected velocity point %d: %+v got %+v`, i, e, *v)
+		}
+	}
+	if velocity[0].Name != "Sprint 1" || !velocity[0].CreatedDate.Equal(start) {
+		t.Fatalf(`expected game name and date kept got %+v`, *velocity[0])
+	}
+	if len(calculateVelocity(nil)) != 0 {
+		t.Fatalf(`expected no velocity points for a team without games`)
+	}
+}
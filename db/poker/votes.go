@@ -0,0 +1,33 @@
+package poker
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// decodeStoryVotes unmarshals a stories votes column, returning ErrCorruptVotes rather than
+// a partial or empty slice when the JSON is malformed or a vote is missing its user
+// so a corrupt row is never mistaken for a story nobody voted on
+func decodeStoryVotes(Raw string) ([]*thunderdome.Vote, error) {
+	var votes = make([]*thunderdome.Vote, 0)
+	if Raw == "" {
+		return votes, nil
+	}
+
+	var decoded []*thunderdome.Vote
+	if err := json.Unmarshal([]byte(Raw), &decoded); err != nil {
+		return votes, fmt.Errorf("%w: %v", thunderdome.ErrCorruptVotes, err)
+	}
+	for i, v := range decoded {
+		if v == nil || v.UserId == "" {
+			return votes, fmt.Errorf("%w: vote %d has no user", thunderdome.ErrCorruptVotes, i)
+		}
+	}
+	if decoded != nil {
+		votes = decoded
+	}
+
+	return votes, nil
+}
@@ -0,0 +1,47 @@
+package poker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestDecodeStoryVotes calls decodeStoryVotes with valid votes
+// and makes sure every vote is kept
+func TestDecodeStoryVotes(t *testing.T) {
+	votes, err := decodeStoryVotes(`[{"warriorId":"a","vote":"3"},{"warriorId":"b","vote":"5","complexity":"8"}]`)
+	if err != nil {
+		t.Fatalf(`unexpected error decoding votes %v`, err)
+	}
+	if len(votes) != 2 || votes[1].ComplexityValue != "8" {
+		t.Fatalf(`expected 2 votes with complexity kept got %d`, len(votes))
+	}
+
+	for _, raw := range []string{"", "[]", "null"} {
+		votes, err := decodeStoryVotes(raw)
+		if err != nil || votes == nil || len(votes) != 0 {
+			t.Fatalf(`expected %q to decode to no votes got %v %v`, raw, votes, err)
+		}
+	}
+}
+
+// TestDecodeStoryVotesCorrupt calls decodeStoryVotes with deliberately malformed votes JSONB values
+// and makes sure the corruption is surfaced as ErrCorruptVotes instead of returning partial votes
+func TestDecodeStoryVotesCorrupt(t *testing.T) {
+	for _, raw := range []string{
+		`[{"warriorId":"a","vote":"3"},{"warriorId":"b","vote":`,
+		`{"warriorId":"a","vote":"3"}`,
+		`[{"warriorId":"a","vote":"3"},null]`,
+		`[{"warriorId":"a","vote":"3"},{"vote":"5"}]`,
+		`[{"warriorId":"a","vote":3}]`,
+	} {
+		votes, err := decodeStoryVotes(raw)
+		if !errors.Is(err, thunderdome.ErrCorruptVotes) {
+			t.Fatalf(`expected %q to be corrupt got %v`, raw, err)
+		}
+		if len(votes) != 0 {
+			t.Fatalf(`expected no partial votes for %q got %d`, raw, len(votes))
+		}
+	}
+}
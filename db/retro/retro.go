@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/db"
 
@@ -66,7 +67,7 @@ func (d *Service) RetroCreate(OwnerID string, RetroName string, Format string, J
 	).Scan(&b.Id)
 	if e != nil {
 		d.Logger.Error("retro_create query error", zap.Error(e))
-		return nil, errors.New("error creating retro")
+		return nil, fmt.Errorf("error creating retro: %w", e)
 	}
 
 	return b, nil
@@ -118,7 +119,7 @@ func (d *Service) TeamRetroCreate(ctx context.Context, TeamID string, OwnerID st
 	).Scan(&b.Id)
 	if e != nil {
 		d.Logger.Error("team_create_retro query error", zap.Error(e))
-		return nil, errors.New("error creating retro")
+		return nil, fmt.Errorf("error creating retro: %w", e)
 	}
 
 	return b, nil
@@ -152,7 +153,7 @@ func (d *Service) EditRetro(RetroID string, RetroName string, JoinCode string, F
 		RetroID, RetroName, encryptedJoinCode, encryptedFacilitatorCode, maxVotes, brainstormVisibility,
 	); err != nil {
 		d.Logger.Error("update retro error", zap.Error(err))
-		return errors.New("unable to edit retro")
+		return fmt.Errorf("unable to edit retro: %w", err)
 	}
 
 	return nil
@@ -277,7 +278,7 @@ func (d *Service) RetroConfirmFacilitator(RetroID string, userID string) error {
 	err := d.DB.QueryRow("SELECT type FROM thunderdome.users WHERE id = $1", userID).Scan(&role)
 	if err != nil {
 		d.Logger.Error("error getting user role", zap.Error(err))
-		return errors.New("unable to get user role")
+		return fmt.Errorf("unable to get user role: %w", err)
 	}
 
 	err = d.DB.QueryRow(
@@ -368,7 +369,7 @@ func (d *Service) RetroFacilitatorAdd(RetroID string, UserID string) ([]string,
 		`INSERT INTO thunderdome.retro_facilitator (retro_id, user_id) VALUES ($1, $2);`,
 		RetroID, UserID); err != nil {
 		d.Logger.Error("insert retro facilitator error", zap.Error(err))
-		return nil, errors.New("unable to add facilitator")
+		return nil, fmt.Errorf("unable to add facilitator: %w", err)
 	}
 
 	facilitators := d.GetRetroFacilitators(RetroID)
@@ -382,7 +383,7 @@ func (d *Service) RetroFacilitatorRemove(RetroID string, UserID string) ([]strin
 		`DELETE FROM thunderdome.retro_facilitator WHERE retro_id = $1 AND user_id = $2;`,
 		RetroID, UserID); err != nil {
 		d.Logger.Error("delete retro facilitator error", zap.Error(err))
-		return nil, errors.New("unable to remove facilitator")
+		return nil, fmt.Errorf("unable to remove facilitator: %w", err)
 	}
 
 	facilitators := d.GetRetroFacilitators(RetroID)
@@ -583,7 +584,7 @@ func (d *Service) GetRetroFacilitatorCode(RetroID string) (string, error) {
 		RetroID,
 	).Scan(&EncryptedCode); err != nil {
 		d.Logger.Error("get retro facilitator_code error", zap.Error(err))
-		return "", errors.New("unable to retrieve retro facilitator_code")
+		return "", fmt.Errorf("unable to retrieve retro facilitator_code: %w", err)
 	}
 
 	if EncryptedCode == "" {
@@ -604,7 +605,7 @@ func (d *Service) CleanRetros(ctx context.Context, DaysOld int) error {
 		DaysOld,
 	); err != nil {
 		d.Logger.Ctx(ctx).Error("CALL thunderdome.clean_retros", zap.Error(err))
-		return errors.New("error attempting to clean retros")
+		return fmt.Errorf("error attempting to clean retros: %w", err)
 	}
 
 	return nil
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/db"
 
@@ -58,7 +59,7 @@ func (d *Service) CreateStoryboard(ctx context.Context, OwnerID string, Storyboa
 	).Scan(&b.Id)
 	if e != nil {
 		d.Logger.Error("sb_create query error", zap.Error(e))
-		return nil, errors.New("error creating storyboard")
+		return nil, fmt.Errorf("error creating storyboard: %w", e)
 	}
 
 	return b, nil
@@ -102,7 +103,7 @@ func (d *Service) TeamCreateStoryboard(ctx context.Context, TeamID string, Owner
 	).Scan(&b.Id)
 	if e != nil {
 		d.Logger.Error("team_create_storyboard query error", zap.Error(e))
-		return nil, errors.New("error creating storyboard")
+		return nil, fmt.Errorf("error creating storyboard: %w", e)
 	}
 
 	return b, nil
@@ -135,7 +136,7 @@ func (d *Service) EditStoryboard(StoryboardID string, StoryboardName string, Joi
 		StoryboardID, StoryboardName, encryptedJoinCode, encryptedFacilitatorCode,
 	); err != nil {
 		d.Logger.Error("update storyboard error", zap.Error(err))
-		return errors.New("unable to edit storyboard")
+		return fmt.Errorf("unable to edit storyboard: %w", err)
 	}
 
 	return nil
@@ -262,7 +263,7 @@ func (d *Service) ConfirmStoryboardFacilitator(StoryboardID string, UserID strin
 	err := d.DB.QueryRow("SELECT type FROM thunderdome.users WHERE id = $1", UserID).Scan(&role)
 	if err != nil {
 		d.Logger.Error("error getting user role", zap.Error(err))
-		return errors.New("unable to get user role")
+		return fmt.Errorf("unable to get user role: %w", err)
 	}
 
 	err = d.DB.QueryRow(
@@ -583,7 +584,7 @@ func (d *Service) StoryboardFacilitatorAdd(StoryboardId string, UserID string) (
 		`INSERT INTO thunderdome.storyboard_facilitator (storyboard_id, user_id) VALUES ($1, $2);`,
 		StoryboardId, UserID); err != nil {
 		d.Logger.Error("CALL thunderdome.sb_facilitator_add error", zap.Error(err))
-		return nil, errors.New("unable to add facilitator")
+		return nil, fmt.Errorf("unable to add facilitator: %w", err)
 	}
 
 	storyboard, err := d.GetStoryboard(StoryboardId, "")
@@ -600,7 +601,7 @@ func (d *Service) StoryboardFacilitatorRemove(StoryboardId string, UserID string
 		`DELETE FROM thunderdome.storyboard_facilitator WHERE storyboard_id = $1 AND user_id = $2;`,
 		StoryboardId, UserID); err != nil {
 		d.Logger.Error("CALL thunderdome.sb_facilitator_remove error", zap.Error(err))
-		return nil, errors.New("unable to remove facilitator")
+		return nil, fmt.Errorf("unable to remove facilitator: %w", err)
 	}
 
 	storyboard, err := d.GetStoryboard(StoryboardId, "")
@@ -621,7 +622,7 @@ func (d *Service) GetStoryboardFacilitatorCode(StoryboardID string) (string, err
 		StoryboardID,
 	).Scan(&EncryptedCode); err != nil {
 		d.Logger.Error("get retro facilitator_code error", zap.Error(err))
-		return "", errors.New("unable to retrieve storyboard facilitator_code")
+		return "", fmt.Errorf("unable to retrieve storyboard facilitator_code: %w", err)
 	}
 
 	if EncryptedCode == "" {
@@ -642,7 +643,7 @@ func (d *Service) CleanStoryboards(ctx context.Context, DaysOld int) error {
 		DaysOld,
 	); err != nil {
 		d.Logger.Ctx(ctx).Error("CALL thunderdome.clean_storyboards", zap.Error(err))
-		return errors.New("error attempting to clean storyboards")
+		return fmt.Errorf("error attempting to clean storyboards: %w", err)
 	}
 
 	return nil
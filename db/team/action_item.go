@@ -0,0 +1,155 @@
+package team
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// actionItemColumns are the columns scanned by scanActionItem
+const actionItemColumns = `id, team_id, user_id, COALESCE(assignee_id::text, ''), content, done, created_date, updated_date`
+
+// CheckinActionItemList gets a list of the team's action items
+func (d *CheckinService) CheckinActionItemList(ctx context.Context, TeamId string) ([]*thunderdome.CheckinActionItem, error) {
+	Items := make([]*thunderdome.CheckinActionItem, 0)
+
+	rows, err := d.DB.QueryContext(ctx,
+		`SELECT `+actionItemColumns+`
+		FROM thunderdome.team_checkin_action_item
+		WHERE team_id = $1
+		ORDER BY done, created_date;`,
+		TeamId,
+	)
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("team_checkin_action_item list query error", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanActionItem(rows)
+		if err != nil {
+			d.Logger.Ctx(ctx).Error("team_checkin_action_item list scan error", zap.Error(err))
+			continue
+		}
+		Items = append(Items, item)
+	}
+
+	return Items, nil
+}
+
+// CheckinActionItemCreate creates a team action item
+func (d *CheckinService) CheckinActionItemCreate(ctx context.Context, TeamId string, UserId string, AssigneeId string, Content string) (*thunderdome.CheckinActionItem, error) {
+	if err := d.requireTeamUser(ctx, TeamId, UserId); err != nil {
+		return nil, err
+	}
+	if AssigneeId != "" {
+		if err := d.requireTeamUser(ctx, TeamId, AssigneeId); err != nil {
+			return nil, err
+		}
+	}
+
+	return scanActionItem(d.DB.QueryRowContext(ctx,
+		`INSERT INTO thunderdome.team_checkin_action_item (team_id, user_id, assignee_id, content)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, $4)
+		RETURNING `+actionItemColumns+`;`,
+		TeamId,
+		UserId,
+		AssigneeId,
+		d.HTMLSanitizerPolicy.Sanitize(Content),
+	))
+}
+
+// CheckinActionItemUpdate updates a team action item's content and assignee
+func (d *CheckinService) CheckinActionItemUpdate(ctx context.Context, TeamId string, ItemId string, AssigneeId string, Content string) (*thunderdome.CheckinActionItem, error) {
+	if AssigneeId != "" {
+		if err := d.requireTeamUser(ctx, TeamId, AssigneeId); err != nil {
+			return nil, err
+		}
+	}
+
+	return scanActionItem(d.DB.QueryRowContext(ctx,
+		`UPDATE thunderdome.team_checkin_action_item
+		SET assignee_id = NULLIF($3, '')::uuid, content = $4, updated_date = NOW()
+		WHERE id = $1 AND team_id = $2
+		RETURNING `+actionItemColumns+`;`,
+		ItemId,
+		TeamId,
+		AssigneeId,
+		d.HTMLSanitizerPolicy.Sanitize(Content),
+	))
+}
+
+// CheckinActionItemComplete sets whether a team action item is done
+func (d *CheckinService) CheckinActionItemComplete(ctx context.Context, TeamId string, ItemId string, Done bool) (*thunderdome.CheckinActionItem, error) {
+	return scanActionItem(d.DB.QueryRowContext(ctx,
+		`UPDATE thunderdome.team_checkin_action_item
+		SET done = $3, updated_date = NOW()
+		WHERE id = $1 AND team_id = $2
+		RETURNING `+actionItemColumns+`;`,
+		ItemId,
+		TeamId,
+		Done,
+	))
+}
+
+// CheckinActionItemDelete deletes a team action item
+func (d *CheckinService) CheckinActionItemDelete(ctx context.Context, TeamId string, ItemId string) error {
+	_, err := d.DB.ExecContext(ctx,
+		`DELETE FROM thunderdome.team_checkin_action_item WHERE id = $1 AND team_id = $2;`,
+		ItemId,
+		TeamId,
+	)
+
+	return err
+}
+
+// requireTeamUser checks the user is on the team
+func (d *CheckinService) requireTeamUser(ctx context.Context, TeamId string, UserId string) error {
+	var userCount int
+	usrErr := d.DB.QueryRowContext(ctx, `SELECT count(user_id) FROM thunderdome.team_user WHERE team_id = $1 AND user_id = $2;`,
+		TeamId,
+		UserId,
+	).Scan(&userCount)
+	if usrErr != nil {
+		return usrErr
+	}
+	if userCount != 1 {
+		return errors.New("REQUIRES_TEAM_USER")
+	}
+
+	return nil
+}
+
+// actionItemScanner is implemented by *sql.Row and *sql.Rows
+type actionItemScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanActionItem scans an action item selected with actionItemColumns
+func scanActionItem(row actionItemScanner) (*thunderdome.CheckinActionItem, error) {
+	var item thunderdome.CheckinActionItem
+
+	err := row.Scan(
+		&item.ID,
+		&item.TeamID,
+		&item.UserID,
+		&item.AssigneeID,
+		&item.Content,
+		&item.Done,
+		&item.CreateDate,
+		&item.UpdatedDate,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("ACTION_ITEM_NOT_FOUND")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
@@ -5,6 +5,9 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
+	"unicode/utf8"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/db"
 
@@ -15,6 +18,12 @@ import (
 	"go.uber.org/zap"
 )
 
+// checkinContentMaxLength and checkinCommentMaxLength limit the characters of each checkin answer and comment
+const (
+	checkinContentMaxLength = 10000
+	checkinCommentMaxLength = 2000
+)
+
 // CheckinService represents a PostgreSQL implementation of thunderdome.CheckinDataSvc.
 type CheckinService struct {
 	DB                  *sql.DB
@@ -78,7 +87,7 @@ func (d *CheckinService) CheckinList(ctx context.Context, TeamId string, Date st
 				if jsonErr != nil {
 					d.Logger.Ctx(ctx).Error("checkin comments json error", zap.Error(jsonErr))
 				}
-				checkin.Comments = Comments
+				checkin.Comments = pinnedCommentsFirst(Comments)
 
 				Checkins = append(Checkins, &checkin)
 			}
@@ -107,6 +116,9 @@ func (d *CheckinService) CheckinCreate(
 	if userCount != 1 {
 		return errors.New("REQUIRES_TEAM_USER")
 	}
+	if err := validateCheckinAnswers(Yesterday, Today, Blockers, Discuss); err != nil {
+		return err
+	}
 
 	SanitizedYesterday := d.HTMLSanitizerPolicy.Sanitize(Yesterday)
 	SanitizedToday := d.HTMLSanitizerPolicy.Sanitize(Today)
@@ -138,6 +150,10 @@ func (d *CheckinService) CheckinUpdate(
 	Yesterday string, Today string, Blockers string, Discuss string,
 	GoalsMet bool,
 ) error {
+	if err := validateCheckinAnswers(Yesterday, Today, Blockers, Discuss); err != nil {
+		return err
+	}
+
 	SanitizedYesterday := d.HTMLSanitizerPolicy.Sanitize(Yesterday)
 	SanitizedToday := d.HTMLSanitizerPolicy.Sanitize(Today)
 	SanitizedBlockers := d.HTMLSanitizerPolicy.Sanitize(Blockers)
@@ -196,12 +212,16 @@ func (d *CheckinService) CheckinComment(
 		return errors.New("REQUIRES_TEAM_USER")
 	}
 
+	if err := validateCheckinContent("comment", Comment, checkinCommentMaxLength); err != nil {
+		return err
+	}
+
 	if _, err := d.DB.ExecContext(ctx, `
 		INSERT INTO thunderdome.team_checkin_comment (checkin_id, user_id, comment) VALUES ($1, $2, $3);
 		`,
 		CheckinId,
 		UserId,
-		Comment,
+		d.HTMLSanitizerPolicy.Sanitize(Comment),
 	); err != nil {
 		return err
 	}
@@ -224,10 +244,14 @@ func (d *CheckinService) CheckinCommentEdit(ctx context.Context, TeamId string,
 		return errors.New("REQUIRES_TEAM_USER")
 	}
 
+	if err := validateCheckinContent("comment", Comment, checkinCommentMaxLength); err != nil {
+		return err
+	}
+
 	_, err := d.DB.ExecContext(ctx,
 		`UPDATE thunderdome.team_checkin_comment SET comment = $2, updated_date = NOW() WHERE id = $1;`,
 		CommentId,
-		Comment,
+		d.HTMLSanitizerPolicy.Sanitize(Comment),
 	)
 
 	if err != nil {
@@ -250,3 +274,61 @@ func (d *CheckinService) CheckinCommentDelete(ctx context.Context, CommentId str
 
 	return nil
 }
+
+// CheckinCommentPinToggle pins or unpins a comment on one of the team's checkins returning whether it's now pinned
+func (d *CheckinService) CheckinCommentPinToggle(ctx context.Context, TeamId string, CommentId string) (bool, error) {
+	var Pinned bool
+	err := d.DB.QueryRowContext(ctx,
+		`UPDATE thunderdome.team_checkin_comment SET pinned = NOT pinned
+		WHERE id = $2 AND checkin_id IN (SELECT id FROM thunderdome.team_checkin WHERE team_id = $1)
+		RETURNING pinned;`,
+		TeamId,
+		CommentId,
+	).Scan(&Pinned)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, errors.New("COMMENT_NOT_FOUND")
+	}
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("team_checkin_comment pin toggle error", zap.Error(err))
+		return false, err
+	}
+
+	return Pinned, nil
+}
+
+// pinnedCommentsFirst orders pinned comments before the rest keeping each in the order they were made
+func pinnedCommentsFirst(Comments []*thunderdome.CheckinComment) []*thunderdome.CheckinComment {
+	sort.SliceStable(Comments, func(i, j int) bool {
+		return Comments[i].Pinned && !Comments[j].Pinned
+	})
+
+	return Comments
+}
+
+// validateCheckinAnswers checks each of the checkin answers is within checkinContentMaxLength
+func validateCheckinAnswers(Yesterday string, Today string, Blockers string, Discuss string) error {
+	for _, answer := range []struct {
+		field   string
+		content string
+	}{
+		{"yesterday", Yesterday},
+		{"today", Today},
+		{"blockers", Blockers},
+		{"discuss", Discuss},
+	} {
+		if err := validateCheckinContent(answer.field, answer.content, checkinContentMaxLength); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateCheckinContent checks the content is at most MaxLength characters before it is sanitized
+func validateCheckinContent(Field string, Content string, MaxLength int) error {
+	if utf8.RuneCountInString(Content) > MaxLength {
+		return fmt.Errorf("%w: %s must be %d characters or less", thunderdome.ErrCheckinContentTooLong, Field, MaxLength)
+	}
+
+	return nil
+}
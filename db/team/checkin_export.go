@@ -0,0 +1,129 @@
+package team
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+var (
+	checkinHTMLLineBreaks = regexp.MustCompile(`(?i)<br\s*/?>|</(p|li|h[1-6]|div|pre|blockquote)>`)
+	checkinHTMLListItems  = regexp.MustCompile(`(?i)<li[^>]*>`)
+	checkinHTMLTags       = regexp.MustCompile(`<[^>]*>`)
+)
+
+// ExportCheckinsMarkdown renders the team checkins for the day of Date, in Date's time zone,
+// as a markdown digest for pasting into chat for standups
+func (d *CheckinService) ExportCheckinsMarkdown(ctx context.Context, TeamId string, Date time.Time) ([]byte, error) {
+	TimeZone := Date.Location().String()
+	if TimeZone == "Local" {
+		TimeZone = "UTC"
+		Date = Date.UTC()
+	}
+
+	Checkins, err := d.CheckinList(ctx, TeamId, Date.Format("2006-01-02"), TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderCheckinsMarkdown(Date, Checkins), nil
+}
+
+// renderCheckinsMarkdown renders the checkins ordered by user name with a section each for
+// yesterday, today, blockers, discuss and comments leaving out the empty ones
+func renderCheckinsMarkdown(Date time.Time, Checkins []*thunderdome.TeamCheckin) []byte {
+	var md bytes.Buffer
+	fmt.Fprintf(&md, "# Checkins for %s\n", Date.Format("Monday, January 2, 2006"))
+
+	if len(Checkins) == 0 {
+		md.WriteString("\nNo checkins yet.\n")
+		return md.Bytes()
+	}
+
+	sorted := make([]*thunderdome.TeamCheckin, len(Checkins))
+	copy(sorted, Checkins)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.ToLower(checkinUserName(sorted[i])) < strings.ToLower(checkinUserName(sorted[j]))
+	})
+
+	UserNames := make(map[string]string)
+	for _, c := range sorted {
+		if c.User != nil {
+			UserNames[c.User.Id] = c.User.Name
+		}
+	}
+
+	for _, c := range sorted {
+		heading := checkinUserName(c)
+		if c.GoalsMet {
+			heading += " (goals met)"
+		}
+		fmt.Fprintf(&md, "\n## %s\n", heading)
+
+		for _, section := range []struct {
+			title   string
+			content string
+		}{
+			{"Yesterday", c.Yesterday},
+			{"Today", c.Today},
+			{"Blockers", c.Blockers},
+			{"Discuss", c.Discuss},
+		} {
+			content := checkinHTMLToMarkdown(section.content)
+			if content == "" {
+				continue
+			}
+			fmt.Fprintf(&md, "\n**%s**\n%s\n", section.title, content)
+		}
+
+		comments := make([]string, 0, len(c.Comments))
+		for _, comment := range c.Comments {
+			content := strings.ReplaceAll(checkinHTMLToMarkdown(comment.Comment), "\n", " ")
+			if content == "" {
+				continue
+			}
+			if name, ok := UserNames[comment.UserID]; ok {
+				content = fmt.Sprintf("%s: %s", name, content)
+			}
+			comments = append(comments, "> "+content)
+		}
+		if len(comments) > 0 {
+			fmt.Fprintf(&md, "\n**Comments**\n%s\n", strings.Join(comments, "\n"))
+		}
+	}
+
+	return md.Bytes()
+}
+
+// checkinUserName gets the name of the user who checked in
+func checkinUserName(c *thunderdome.TeamCheckin) string {
+	if c.User == nil || c.User.Name == "" {
+		return "Unknown"
+	}
+
+	return c.User.Name
+}
+
+// checkinHTMLToMarkdown converts the rich text checkin content to plain markdown lines,
+// list items become bullets and any other markup is dropped
+func checkinHTMLToMarkdown(Content string) string {
+	text := checkinHTMLLineBreaks.ReplaceAllString(Content, "\n")
+	text = checkinHTMLListItems.ReplaceAllString(text, "- ")
+	text = html.UnescapeString(checkinHTMLTags.ReplaceAllString(text, ""))
+
+	lines := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
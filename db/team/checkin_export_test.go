@@ -0,0 +1,75 @@
+package team
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// TestRenderCheckinsMarkdown calls renderCheckinsMarkdown with several checkins
+// and makes sure the digest has a section per user ordered by name with their non-empty answers and comments
+func TestRenderCheckinsMarkdown(t *testing.T) {
+	date := time.Date(2023, 8, 21, 9, 0, 0, 0, time.UTC)
+	checkins := []*thunderdome.TeamCheckin{
+		{
+			User:      &thunderdome.TeamUser{Id: "u2", Name: "Max"},
+			Yesterday: "<p>Fixed the <strong>fuel</strong> pump</p>",
+			Today:     `<ul><li data-list="bullet">Tune engine</li><li data-list="bullet">Polish chrome</li></ul>`,
+			Blockers:  "<p><br></p>",
+			Comments:  []*thunderdome.CheckinComment{{UserID: "u1", Comment: "Need a hand &amp; tools?"}},
+		},
+		{
+			User:     &thunderdome.TeamUser{Id: "u1", Name: "aunty"},
+			Today:    "<p>Run Bartertown</p>",
+			Blockers: "<p>Power outage</p>",
+			Discuss:  "<p>Who runs Bartertown?</p>",
+			GoalsMet: true,
+		},
+	}
+
+	expected := `# Checkins for Monday, August 21, 2023
+
+## aunty (goals met)
+
+**Today**
+Run Bartertown
+
+**Blockers**
+Power outage
+
+**Discuss**
+Who runs Bartertown?
+
+## Max
+
+**Yesterday**
+Fixed the fuel pump
+
+**Today**
+- Tune engine
+- Polish chrome
+
+**Comments**
+> aunty: Need a hand & tools?
+`
+	if md := string(renderCheckinsMarkdown(date, checkins)); md != expected {
+		t.Fatalf(`expected markdown
+%s
+got
+%s`, expected, md)
+	}
+	if checkins[0].User.Name != "Max" {
+		t.Fatalf(`expected the checkins not to be reordered in place`)
+	}
+}
+
+// TestRenderCheckinsMarkdownEmpty calls renderCheckinsMarkdown without checkins
+// and makes sure the digest says there are none
+func TestRenderCheckinsMarkdownEmpty(t *testing.T) {
+	md := string(renderCheckinsMarkdown(time.Date(2023, 8, 21, 0, 0, 0, 0, time.UTC), nil))
+	if !strings.HasPrefix(md, "# Checkins for Monday, August 21, 2023\n") || !strings.Contains(md, "No checkins yet.") {
+		t.Fatalf(`expected an empty digest got %q`, md)
+	}
+}
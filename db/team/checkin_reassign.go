@@ -0,0 +1,57 @@
+package team
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
+)
+
+// ReassignUserCheckins moves a user's checkins, checkin comments and action items within the team to another
+// team user, e.g. when they leave the team so their checkin history isn't orphaned
+func (d *CheckinService) ReassignUserCheckins(ctx context.Context, FromUserId string, ToUserId string, TeamId string) error {
+	if err := db.ValidateUUID(FromUserId, ToUserId, TeamId); err != nil {
+		return err
+	}
+	if FromUserId == ToUserId {
+		return fmt.Errorf("%w: checkins must be reassigned to a different user", thunderdome.ErrValidation)
+	}
+	if err := d.requireTeamUser(ctx, TeamId, ToUserId); err != nil {
+		return err
+	}
+
+	if err := db.WithTx(ctx, d.DB, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE thunderdome.team_checkin SET user_id = $2 WHERE team_id = $3 AND user_id = $1;`,
+			FromUserId, ToUserId, TeamId,
+		); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE thunderdome.team_checkin_comment SET user_id = $2
+			WHERE user_id = $1 AND checkin_id IN (SELECT id FROM thunderdome.team_checkin WHERE team_id = $3);`,
+			FromUserId, ToUserId, TeamId,
+		); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx,
+			`UPDATE thunderdome.team_checkin_action_item SET
+				user_id = CASE WHEN user_id = $1 THEN $2 ELSE user_id END,
+				assignee_id = CASE WHEN assignee_id = $1 THEN $2 ELSE assignee_id END
+			WHERE team_id = $3 AND (user_id = $1 OR assignee_id = $1);`,
+			FromUserId, ToUserId, TeamId,
+		)
+		return err
+	}); err != nil {
+		d.Logger.Ctx(ctx).Error("reassign team user checkins error", zap.Error(err))
+		return fmt.Errorf("unable to reassign checkins: %w", err)
+	}
+
+	return nil
+}
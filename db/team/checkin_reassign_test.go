@@ -0,0 +1,152 @@
+package team
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/dbtest"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+)
+
+type reassignCheckin struct{ id, team, user string }
+type reassignComment struct{ checkin, user string }
+type reassignItem struct{ team, user, assignee string }
+
+// reassignDB is a fake database standing in for a teams users, checkins, comments
+// and action items applying the reassign updates to them
+type reassignDB struct {
+	teamUsers map[string]bool
+	checkins  []*reassignCheckin
+	comments  []*reassignComment
+	items     []*reassignItem
+}
+
+func newReassignCheckinService(t *testing.T) (*CheckinService, *reassignDB) {
+	f := dbtest.New()
+	d := &reassignDB{}
+	f.Exec("UPDATE thunderdome.team_checkin SET", func(args []driver.Value) (int64, error) {
+		from, to, team := args[0].(string), args[1].(string), args[2].(string)
+		var affected int64
+		for _, c := range d.checkins {
+			if c.team == team && c.user == from {
+				c.user = to
+				affected++
+			}
+		}
+		return affected, nil
+	})
+	f.Exec("UPDATE thunderdome.team_checkin_comment", func(args []driver.Value) (int64, error) {
+		from, to, team := args[0].(string), args[1].(string), args[2].(string)
+		var affected int64
+		teamCheckins := make(map[string]bool)
+		for _, c := range d.checkins {
+			teamCheckins[c.id] = c.team == team
+		}
+		for _, c := range d.comments {
+			if c.user == from && teamCheckins[c.checkin] {
+				c.user = to
+				affected++
+			}
+		}
+		return affected, nil
+	})
+	f.Exec("UPDATE thunderdome.team_checkin_action_item", func(args []driver.Value) (int64, error) {
+		from, to, team := args[0].(string), args[1].(string), args[2].(string)
+		var affected int64
+		for _, i := range d.items {
+			if i.team != team || (i.user != from && i.assignee != from) {
+				continue
+			}
+			if i.user == from {
+				i.user = to
+			}
+			if i.assignee == from {
+				i.assignee = to
+			}
+			affected++
+		}
+		return affected, nil
+	})
+	f.Query("FROM thunderdome.team_user", []string{"count"}, func(args []driver.Value) ([][]driver.Value, error) {
+		count := int64(0)
+		if d.teamUsers[args[0].(string)+"/"+args[1].(string)] {
+			count = 1
+		}
+		return [][]driver.Value{{count}}, nil
+	})
+
+	return &CheckinService{DB: f.Open(t), Logger: otelzap.New(zap.NewNop())}, d
+}
+
+// TestReassignUserCheckins reassigns a leaving users checkins to a teammate and makes sure their checkins, comments
+// and action items in the team move to the teammate while records in other teams and of other users stay put
+func TestReassignUserCheckins(t *testing.T) {
+	d, reassign := newReassignCheckinService(t)
+	TeamID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	OtherTeamID := "3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b"
+	From := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	To := "9c8b7a6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+	Other := "7a6b5c4d-3e2f-4a1b-9c8d-7e6f5a4b3c2d"
+
+	reassign.teamUsers = map[string]bool{TeamID + "/" + To: true}
+	reassign.checkins = []*reassignCheckin{
+		{"c1", TeamID, From}, {"c2", TeamID, Other}, {"c3", OtherTeamID, From},
+	}
+	reassign.comments = []*reassignComment{
+		{"c2", From}, {"c1", Other}, {"c3", From},
+	}
+	reassign.items = []*reassignItem{
+		{TeamID, From, ""}, {TeamID, Other, From}, {TeamID, Other, Other}, {OtherTeamID, From, From},
+	}
+
+	if err := d.ReassignUserCheckins(context.Background(), From, To, TeamID); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+
+	var owners []string
+	for _, c := range reassign.checkins {
+		owners = append(owners, c.user)
+	}
+	for _, c := range reassign.comments {
+		owners = append(owners, c.user)
+	}
+	for _, i := range reassign.items {
+		owners = append(owners, i.user, i.assignee)
+	}
+	expected := []string{
+		To, Other, From,
+		To, Other, From,
+		To, "", Other, To, Other, Other, From, From,
+	}
+	if strings.Join(owners, ",") != strings.Join(expected, ",") {
+		t.Fatalf(`expected owners
+%v
+got
+%v`, expected, owners)
+	}
+}
+
+// TestReassignUserCheckinsValidation makes sure checkins can only be reassigned to a different user on the team
+func TestReassignUserCheckinsValidation(t *testing.T) {
+	d, reassign := newReassignCheckinService(t)
+	TeamID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	From := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	To := "9c8b7a6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+	reassign.teamUsers = map[string]bool{}
+
+	if err := d.ReassignUserCheckins(context.Background(), From, From, TeamID); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected ErrValidation reassigning to the same user got %v`, err)
+	}
+	if err := d.ReassignUserCheckins(context.Background(), From, "not-a-user", TeamID); !errors.Is(err, thunderdome.ErrValidation) {
+		t.Fatalf(`expected ErrValidation for an invalid user id got %v`, err)
+	}
+	if err := d.ReassignUserCheckins(context.Background(), From, To, TeamID); err == nil || err.Error() != "REQUIRES_TEAM_USER" {
+		t.Fatalf(`expected REQUIRES_TEAM_USER reassigning to a user off the team got %v`, err)
+	}
+}
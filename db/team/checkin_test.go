@@ -0,0 +1,112 @@
+package team
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/dbtest"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+)
+
+// contentDB is a fake database where every user is on the team,
+// recording the arguments of the checkin and comment writes
+type contentDB struct {
+	writes [][]driver.Value
+}
+
+func newContentCheckinService(t *testing.T) (*CheckinService, *contentDB) {
+	f := dbtest.New()
+	content := &contentDB{}
+	f.Exec("", func(args []driver.Value) (int64, error) {
+		content.writes = append(content.writes, args)
+		return 1, nil
+	})
+	f.Rows("FROM thunderdome.team_user", []string{"count"}, []driver.Value{int64(1)})
+
+	return &CheckinService{DB: f.Open(t), Logger: otelzap.New(zap.NewNop()), HTMLSanitizerPolicy: bluemonday.UGCPolicy()}, content
+}
+
+// TestCheckinContentSanitized saves a checkin and comments with script tags
+// and makes sure the scripts are stripped before being saved
+func TestCheckinContentSanitized(t *testing.T) {
+	d, content := newContentCheckinService(t)
+	ctx := context.Background()
+	script := `<p>shipped it</p><script>alert("pwned")</script>`
+
+	if err := d.CheckinCreate(ctx, "team", "user", script, script, "", "", true); err != nil {
+		t.Fatalf(`unexpected error creating checkin %v`, err)
+	}
+	if err := d.CheckinUpdate(ctx, "checkin", script, "", "", script, false); err != nil {
+		t.Fatalf(`unexpected error updating checkin %v`, err)
+	}
+	if err := d.CheckinComment(ctx, "team", "checkin", "user", script); err != nil {
+		t.Fatalf(`unexpected error commenting %v`, err)
+	}
+	if err := d.CheckinCommentEdit(ctx, "team", "user", "comment", script); err != nil {
+		t.Fatalf(`unexpected error editing comment %v`, err)
+	}
+
+	if len(content.writes) != 4 {
+		t.Fatalf(`expected 4 writes got %d`, len(content.writes))
+	}
+	for _, args := range content.writes {
+		for _, arg := range args {
+			if s, ok := arg.(string); ok && strings.Contains(s, "<script") {
+				t.Fatalf(`expected scripts to be stripped got %q`, s)
+			}
+		}
+	}
+	if content.writes[2][2] != "<p>shipped it</p>" {
+		t.Fatalf(`expected the comment markup to be kept without the script got %q`, content.writes[2][2])
+	}
+}
+
+// TestCheckinContentTooLong saves checkins and comments over their length limit
+// and makes sure they are rejected with ErrCheckinContentTooLong without being saved
+func TestCheckinContentTooLong(t *testing.T) {
+	d, content := newContentCheckinService(t)
+	ctx := context.Background()
+	longAnswer := strings.Repeat("a", checkinContentMaxLength+1)
+	longComment := strings.Repeat("ü", checkinCommentMaxLength+1)
+
+	for name, err := range map[string]error{
+		"checkin create": d.CheckinCreate(ctx, "team", "user", "", longAnswer, "", "", false),
+		"checkin update": d.CheckinUpdate(ctx, "checkin", "", "", longAnswer, "", false),
+		"comment":        d.CheckinComment(ctx, "team", "checkin", "user", longComment),
+		"comment edit":   d.CheckinCommentEdit(ctx, "team", "user", "comment", longComment),
+	} {
+		if !errors.Is(err, thunderdome.ErrCheckinContentTooLong) {
+			t.Fatalf(`%s: expected ErrCheckinContentTooLong got %v`, name, err)
+		}
+	}
+	if len(content.writes) != 0 {
+		t.Fatalf(`expected nothing to be saved got %d writes`, len(content.writes))
+	}
+
+	if err := d.CheckinComment(ctx, "team", "checkin", "user", strings.Repeat("ü", checkinCommentMaxLength)); err != nil {
+		t.Fatalf(`expected a comment at the limit to be saved got %v`, err)
+	}
+}
+
+// TestPinnedCommentsFirst calls pinnedCommentsFirst and makes sure pinned comments come first
+// with both pinned and unpinned comments keeping the order they were made
+func TestPinnedCommentsFirst(t *testing.T) {
+	comments := pinnedCommentsFirst([]*thunderdome.CheckinComment{
+		{ID: "1"}, {ID: "2", Pinned: true}, {ID: "3"}, {ID: "4", Pinned: true},
+	})
+
+	var order []string
+	for _, c := range comments {
+		order = append(order, c.ID)
+	}
+	if strings.Join(order, ",") != "2,4,1,3" {
+		t.Fatalf(`expected comments ordered 2,4,1,3 got %v`, order)
+	}
+}
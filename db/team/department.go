@@ -3,6 +3,7 @@ package team
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/db"
 
@@ -30,7 +31,7 @@ func (d *OrganizationService) DepartmentUserRole(ctx context.Context, UserID str
 	)
 	if e != nil {
 		d.Logger.Ctx(ctx).Error("department_get_user_role query error", zap.Error(e))
-		return "", "", errors.New("error getting department users role")
+		return "", "", fmt.Errorf("error getting department users role: %w", e)
 	}
 
 	return orgRole, departmentRole, nil
@@ -270,7 +271,7 @@ func (d *OrganizationService) DepartmentTeamUserRole(ctx context.Context, UserID
 	)
 	if e != nil {
 		d.Logger.Ctx(ctx).Error("department_team_user_role query error", zap.Error(e))
-		return "", "", "", errors.New("error getting department team users role")
+		return "", "", "", fmt.Errorf("error getting department team users role: %w", e)
 	}
 
 	return orgRole, departmentRole, teamRole, nil
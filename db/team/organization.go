@@ -3,7 +3,7 @@ package team
 import (
 	"context"
 	"database/sql"
-	"errors"
+	"fmt"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/db"
 
@@ -36,7 +36,7 @@ func (d *OrganizationService) OrganizationGet(ctx context.Context, OrgID string)
 	)
 	if e != nil {
 		d.Logger.Ctx(ctx).Error("organization_get_by_id query error", zap.Error(e))
-		return nil, errors.New("error getting organization")
+		return nil, fmt.Errorf("error getting organization: %w", e)
 	}
 
 	return org, nil
@@ -57,7 +57,7 @@ func (d *OrganizationService) OrganizationUserRole(ctx context.Context, UserID s
 	)
 	if e != nil {
 		d.Logger.Ctx(ctx).Error("organization_get_user_role query error", zap.Error(e))
-		return "", errors.New("error getting organization users role")
+		return "", fmt.Errorf("error getting organization users role: %w", e)
 	}
 
 	return role, nil
@@ -270,7 +270,7 @@ func (d *OrganizationService) OrganizationTeamUserRole(ctx context.Context, User
 	)
 	if e != nil {
 		d.Logger.Ctx(ctx).Error("organization_team_user_role query error", zap.Error(e))
-		return "", "", errors.New("error getting organization team users role")
+		return "", "", fmt.Errorf("error getting organization team users role: %w", e)
 	}
 
 	return orgRole, teamRole, nil
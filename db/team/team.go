@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/db"
 
@@ -34,7 +35,7 @@ func (d *Service) TeamUserRole(ctx context.Context, UserID string, TeamID string
 	)
 	if err != nil {
 		d.Logger.Ctx(ctx).Error("team_get_user_role query error", zap.Error(err))
-		return "", errors.New("error getting team users role")
+		return "", fmt.Errorf("error getting team users role: %w", err)
 	}
 
 	return teamRole, nil
@@ -10,16 +10,23 @@ import (
 
 // Config holds all the configuration for the db
 type Config struct {
+	URL             string
 	Host            string
 	Port            int
 	User            string
 	Password        string
 	Name            string
 	SSLMode         string
+	SSLRootCert     string
+	SSLCert         string
+	SSLKey          string
 	AESHashkey      string
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime int
+	ConnMaxIdleTime int
+	// ReadHost is an optional read replica host used for read only queries in place of Host or the URL host
+	ReadHost string
 }
 
 // Service contains all the methods to interact with DB
@@ -28,4 +35,6 @@ type Service struct {
 	DB                  *sql.DB
 	HTMLSanitizerPolicy *bluemonday.Policy
 	Logger              *otelzap.Logger
+	// ReadDB is the read replica connection pool, the same pool as DB when no replica is configured
+	ReadDB *sql.DB
 }
@@ -0,0 +1,58 @@
+package user
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+// guestNameAdjectives and guestNameNouns are combined for generated guest names
+var guestNameAdjectives = []string{
+	"Brave", "Chrome", "Dusty", "Fearless", "Feral", "Fierce", "Gallant", "Gritty",
+	"Hardy", "Heroic", "Howling", "Iron", "Jolly", "Lucky", "Mighty", "Nimble",
+	"Noble", "Plucky", "Rapid", "Roaring", "Rugged", "Rusty", "Savage", "Scrappy",
+	"Shiny", "Steady", "Stormy", "Swift", "Thunderous", "Valiant", "Wild", "Wily",
+}
+
+var guestNameNouns = []string{
+	"Badger", "Brawler", "Champion", "Challenger", "Contender", "Drifter", "Duelist", "Falcon",
+	"Gearhead", "Gladiator", "Guardian", "Hawk", "Jackal", "Knight", "Lancer", "Marauder",
+	"Mechanic", "Nomad", "Outrider", "Pilot", "Raider", "Ranger", "Roadster", "Rover",
+	"Scavenger", "Scout", "Sentinel", "Striker", "Titan", "Voyager", "Warrior", "Wolf",
+}
+
+// GenerateGuestName generates a random Thunderdome themed name e.g. "Rusty Gladiator" for guests that don't name themselves
+func GenerateGuestName() string {
+	return randomWord(guestNameAdjectives) + " " + randomWord(guestNameNouns)
+}
+
+// IsGeneratedGuestName checks whether the name was generated by GenerateGuestName rather than chosen by the user
+func IsGeneratedGuestName(Name string) bool {
+	words := strings.Split(Name, " ")
+	if len(words) != 2 {
+		return false
+	}
+
+	return containsWord(guestNameAdjectives, words[0]) && containsWord(guestNameNouns, words[1])
+}
+
+// containsWord checks whether the word is in the list
+func containsWord(Words []string, Word string) bool {
+	for _, w := range Words {
+		if w == Word {
+			return true
+		}
+	}
+
+	return false
+}
+
+// randomWord picks a random word from the list, falling back to the first word if the random source fails
+func randomWord(Words []string) string {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(Words))))
+	if err != nil {
+		return Words[0]
+	}
+
+	return Words[i.Int64()]
+}
@@ -0,0 +1,58 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestGenerateGuestName calls GenerateGuestName many times
+// and makes sure every name is a non-empty adjective and noun that fits the users name column and most are unique
+func TestGenerateGuestName(t *testing.T) {
+	names := make(map[string]bool)
+	for i := 0; i < 200; i++ {
+		name := GenerateGuestName()
+		if len(strings.Fields(name)) != 2 {
+			t.Fatalf(`expected an adjective and noun got %q`, name)
+		}
+		if len(name) > 64 {
+			t.Fatalf(`expected name to fit the users name column got %q`, name)
+		}
+		names[name] = true
+	}
+
+	if len(names) < 150 {
+		t.Fatalf(`expected generated names to be reasonably unique got %d unique of 200`, len(names))
+	}
+}
+
+// TestIsGeneratedGuestName calls IsGeneratedGuestName with generated and chosen names
+// and makes sure only generated names match
+func TestIsGeneratedGuestName(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		if name := GenerateGuestName(); !IsGeneratedGuestName(name) {
+			t.Fatalf(`expected %q to be a generated name`, name)
+		}
+	}
+	for _, name := range []string{"", "Ada", "Rusty", "Ada Gladiator", "Rusty Gladiator Jr", "rusty gladiator"} {
+		if IsGeneratedGuestName(name) {
+			t.Fatalf(`expected %q not to be a generated name`, name)
+		}
+	}
+}
+
+// TestCreateUserGuestGeneratedName calls CreateUserGuest without a name
+// and makes sure the guest is given a generated name instead of an empty one
+func TestCreateUserGuestGeneratedName(t *testing.T) {
+	d, _ := newGuestsService(t)
+
+	for _, name := range []string{"", "   "} {
+		guest, err := d.CreateUserGuest(context.Background(), name, "")
+		if err != nil {
+			t.Fatalf(`unexpected error %v`, err)
+		}
+		if strings.TrimSpace(guest.Name) == "" {
+			t.Fatalf(`expected guest without a name to get a generated name got %q`, guest.Name)
+		}
+	}
+}
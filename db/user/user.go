@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/db"
 
@@ -74,8 +76,12 @@ func (d *Service) GetRegisteredUsers(ctx context.Context, Limit int, Offset int)
 	return users, Count, nil
 }
 
-// GetUser gets a user by ID
+// GetUser gets a user by ID, returning thunderdome.ErrUserNotFound when there is no user with the ID
 func (d *Service) GetUser(ctx context.Context, UserID string) (*thunderdome.User, error) {
+	if err := db.ValidateUUID(UserID); err != nil {
+		return nil, err
+	}
+
 	var w thunderdome.User
 	var UserEmail sql.NullString
 	var UserCountry sql.NullString
@@ -107,9 +113,12 @@ func (d *Service) GetUser(ctx context.Context, UserID string) (*thunderdome.User
 		&w.Disabled,
 		&w.MFAEnabled,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, thunderdome.ErrUserNotFound
+	}
 	if err != nil {
 		d.Logger.Ctx(ctx).Error("get user query error", zap.Error(err))
-		return nil, errors.New("user not found")
+		return nil, fmt.Errorf("get user query error: %w", err)
 	}
 
 	w.Email = UserEmail.String
@@ -128,6 +137,10 @@ func (d *Service) GetUser(ctx context.Context, UserID string) (*thunderdome.User
 
 // GetGuestUser gets a guest user by ID
 func (d *Service) GetGuestUser(ctx context.Context, UserID string) (*thunderdome.User, error) {
+	if err := db.ValidateUUID(UserID); err != nil {
+		return nil, err
+	}
+
 	var w thunderdome.User
 	var UserEmail sql.NullString
 	var UserCountry sql.NullString
@@ -197,18 +210,51 @@ func (d *Service) GetUserByEmail(ctx context.Context, UserEmail string) (*thunde
 	return &w, nil
 }
 
-// CreateUserGuest adds a new guest user
-func (d *Service) CreateUserGuest(ctx context.Context, UserName string) (*thunderdome.User, error) {
+// CreateUserGuest adds a new guest user, when UserEmail is set the guest user already using the email is reused
+// so returning guests keep their identity, guest emails are unverified and kept apart from registered user emails
+// so they can't be used to claim or block a registered user, guests without a name are given a generated one
+func (d *Service) CreateUserGuest(ctx context.Context, UserName string, UserEmail string) (*thunderdome.User, error) {
+	if strings.TrimSpace(UserName) == "" {
+		UserName = GenerateGuestName()
+	}
+	if UserEmail != "" {
+		return d.createUserGuestWithEmail(ctx, UserName, db.SanitizeEmail(UserEmail))
+	}
+
 	var UserID string
 	err := d.DB.QueryRowContext(ctx, `INSERT INTO thunderdome.users (name) VALUES ($1) RETURNING id`, UserName).Scan(&UserID)
 	if err != nil {
 		d.Logger.Ctx(ctx).Error("create guest user query error", zap.Error(err))
-		return nil, errors.New("unable to create new user")
+		return nil, fmt.Errorf("unable to create new user: %w", err)
 	}
 
 	return &thunderdome.User{Id: UserID, Name: UserName, Avatar: "robohash", NotificationsEnabled: true, Locale: "en", GravatarHash: db.CreateGravatarHash(UserID)}, nil
 }
 
+// createUserGuestWithEmail creates the guest user with the email or returns the existing guest user with it,
+// the existing guest keeps their name so entering someone's email can't rename them
+func (d *Service) createUserGuestWithEmail(ctx context.Context, UserName string, UserEmail string) (*thunderdome.User, error) {
+	w := thunderdome.User{Email: UserEmail}
+	err := d.DB.QueryRowContext(ctx,
+		`INSERT INTO thunderdome.users AS u (name, guest_email) VALUES ($1, $2)
+		ON CONFLICT ((LOWER(guest_email))) DO UPDATE SET last_active = NOW()
+		WHERE u.type = 'GUEST'
+		RETURNING id, name, type, avatar, notifications_enabled, COALESCE(locale, 'en');`,
+		UserName, UserEmail,
+	).Scan(&w.Id, &w.Name, &w.Type, &w.Avatar, &w.NotificationsEnabled, &w.Locale)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the guest with the email has since registered
+		return nil, thunderdome.ErrGuestEmailRegistered
+	}
+	if err != nil {
+		d.Logger.Ctx(ctx).Error("create guest user with email query error", zap.Error(err))
+		return nil, fmt.Errorf("unable to create new user: %w", err)
+	}
+	w.GravatarHash = db.CreateGravatarHash(w.Id)
+
+	return &w, nil
+}
+
 // CreateUserRegistered adds a new registered user
 func (d *Service) CreateUserRegistered(ctx context.Context, UserName string, UserEmail string, UserPassword string, ActiveUserID string) (NewUser *thunderdome.User, VerifyID string, RegisterErr error) {
 	hashedPassword, hashErr := db.HashSaltPassword(UserPassword)
@@ -229,6 +275,9 @@ func (d *Service) CreateUserRegistered(ctx context.Context, UserName string, Use
 	}
 
 	if ActiveUserID != "" {
+		if err := db.ValidateUUID(ActiveUserID); err != nil {
+			return nil, "", err
+		}
 		err := d.DB.QueryRowContext(ctx,
 			`SELECT userId, verifyId FROM thunderdome.user_register_existing($1, $2, $3, $4, $5);`,
 			ActiveUserID,
@@ -294,6 +343,9 @@ func (d *Service) CreateUser(ctx context.Context, UserName string, UserEmail str
 
 // UpdateUserProfile updates the users profile (excludes: email, password)
 func (d *Service) UpdateUserProfile(ctx context.Context, UserID string, UserName string, UserAvatar string, NotificationsEnabled bool, Country string, Locale string, Company string, JobTitle string) error {
+	if err := db.ValidateUUID(UserID); err != nil {
+		return err
+	}
 	if UserAvatar == "" {
 		UserAvatar = "robohash"
 	}
@@ -320,7 +372,7 @@ func (d *Service) UpdateUserProfile(ctx context.Context, UserID string, UserName
 		JobTitle,
 	); err != nil {
 		d.Logger.Ctx(ctx).Error("user_profile_update query error", zap.Error(err))
-		return errors.New("error attempting to update users profile")
+		return fmt.Errorf("error attempting to update users profile: %w", err)
 	}
 
 	return nil
@@ -328,6 +380,9 @@ func (d *Service) UpdateUserProfile(ctx context.Context, UserID string, UserName
 
 // UpdateUserProfileLdap updates the users profile (excludes: username, email, password)
 func (d *Service) UpdateUserProfileLdap(ctx context.Context, UserID string, UserAvatar string, NotificationsEnabled bool, Country string, Locale string, Company string, JobTitle string) error {
+	if err := db.ValidateUUID(UserID); err != nil {
+		return err
+	}
 	if UserAvatar == "" {
 		UserAvatar = "robohash"
 	}
@@ -352,7 +407,7 @@ func (d *Service) UpdateUserProfileLdap(ctx context.Context, UserID string, User
 		JobTitle,
 	); err != nil {
 		d.Logger.Ctx(ctx).Error("user_profile_ldap_update query error", zap.Error(err))
-		return errors.New("error attempting to update users profile")
+		return fmt.Errorf("error attempting to update users profile: %w", err)
 	}
 
 	return nil
@@ -360,6 +415,9 @@ func (d *Service) UpdateUserProfileLdap(ctx context.Context, UserID string, User
 
 // UpdateUserAccount updates the users profile including email (excludes: password)
 func (d *Service) UpdateUserAccount(ctx context.Context, UserID string, UserName string, UserEmail string, UserAvatar string, NotificationsEnabled bool, Country string, Locale string, Company string, JobTitle string) error {
+	if err := db.ValidateUUID(UserID); err != nil {
+		return err
+	}
 	if UserAvatar == "" {
 		UserAvatar = "robohash"
 	}
@@ -395,12 +453,15 @@ func (d *Service) UpdateUserAccount(ctx context.Context, UserID string, UserName
 
 // DeleteUser deletes a user
 func (d *Service) DeleteUser(ctx context.Context, UserID string) error {
+	if err := db.ValidateUUID(UserID); err != nil {
+		return err
+	}
 	if _, err := d.DB.ExecContext(ctx,
 		`DELETE FROM thunderdome.users WHERE id = $1;`,
 		UserID,
 	); err != nil {
 		d.Logger.Ctx(ctx).Error("delete_user query error", zap.Error(err))
-		return errors.New("error attempting to delete user")
+		return fmt.Errorf("error attempting to delete user: %w", err)
 	}
 
 	return nil
@@ -451,12 +512,15 @@ func (d *Service) SearchRegisteredUsersByEmail(ctx context.Context, Email string
 
 // PromoteUser promotes a user to admin type
 func (d *Service) PromoteUser(ctx context.Context, UserID string) error {
+	if err := db.ValidateUUID(UserID); err != nil {
+		return err
+	}
 	if _, err := d.DB.ExecContext(ctx,
 		`UPDATE thunderdome.users SET type = 'ADMIN', updated_date = NOW() WHERE id = $1;`,
 		UserID,
 	); err != nil {
 		d.Logger.Ctx(ctx).Error("CALL thunderdome.promote_user error", zap.Error(err))
-		return errors.New("error attempting to promote user to admin")
+		return fmt.Errorf("error attempting to promote user to admin: %w", err)
 	}
 
 	return nil
@@ -464,12 +528,15 @@ func (d *Service) PromoteUser(ctx context.Context, UserID string) error {
 
 // DemoteUser demotes a user to registered type
 func (d *Service) DemoteUser(ctx context.Context, UserID string) error {
+	if err := db.ValidateUUID(UserID); err != nil {
+		return err
+	}
 	if _, err := d.DB.ExecContext(ctx,
 		`UPDATE thunderdome.users SET type = 'REGISTERED', updated_date = NOW() WHERE id = $1;`,
 		UserID,
 	); err != nil {
 		d.Logger.Ctx(ctx).Error("CALL thunderdome.demote_user error", zap.Error(err))
-		return errors.New("error attempting to demote user to registered")
+		return fmt.Errorf("error attempting to demote user to registered: %w", err)
 	}
 
 	return nil
@@ -477,12 +544,15 @@ func (d *Service) DemoteUser(ctx context.Context, UserID string) error {
 
 // DisableUser disables a user from logging in
 func (d *Service) DisableUser(ctx context.Context, UserID string) error {
+	if err := db.ValidateUUID(UserID); err != nil {
+		return err
+	}
 	if _, err := d.DB.ExecContext(ctx,
 		`CALL thunderdome.user_disable($1);`,
 		UserID,
 	); err != nil {
 		d.Logger.Ctx(ctx).Error("CALL thunderdome.user_disable error", zap.Error(err))
-		return errors.New("error attempting to disable user")
+		return fmt.Errorf("error attempting to disable user: %w", err)
 	}
 
 	return nil
@@ -490,13 +560,16 @@ func (d *Service) DisableUser(ctx context.Context, UserID string) error {
 
 // EnableUser enables a user allowing login
 func (d *Service) EnableUser(ctx context.Context, UserID string) error {
+	if err := db.ValidateUUID(UserID); err != nil {
+		return err
+	}
 	if _, err := d.DB.ExecContext(ctx,
 		`UPDATE thunderdome.users SET disabled = false, updated_date = NOW()
         WHERE id = $1;`,
 		UserID,
 	); err != nil {
 		d.Logger.Ctx(ctx).Error("CALL thunderdome.user_enable error", zap.Error(err))
-		return errors.New("error attempting to enable user")
+		return fmt.Errorf("error attempting to enable user: %w", err)
 	}
 
 	return nil
@@ -509,7 +582,7 @@ func (d *Service) CleanGuests(ctx context.Context, DaysOld int) error {
 		DaysOld,
 	); err != nil {
 		d.Logger.Ctx(ctx).Error("CALL thunderdome.clean_guest_users", zap.Error(err))
-		return errors.New("error attempting to clean Guest Users")
+		return fmt.Errorf("error attempting to clean Guest Users: %w", err)
 	}
 
 	return nil
@@ -606,7 +679,7 @@ func (d *Service) GetActiveCountries(ctx context.Context) ([]string, error) {
 		}
 	} else {
 		d.Logger.Ctx(ctx).Error("countries_active query error", zap.Error(err))
-		return nil, errors.New("error attempting to get active countries")
+		return nil, fmt.Errorf("error attempting to get active countries: %w", err)
 	}
 
 	return countries, nil
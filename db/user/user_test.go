@@ -0,0 +1,163 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/db/dbtest"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestGetUserNotFound calls GetUser with an ID that has no user
+// and makes sure ErrUserNotFound is returned without logging an error
+func TestGetUserNotFound(t *testing.T) {
+	f := dbtest.New()
+	f.Rows("", []string{"id"})
+	DB := f.Open(t)
+	core, logs := observer.New(zapcore.DebugLevel)
+	d := &Service{DB: DB, Logger: otelzap.New(zap.New(core))}
+
+	_, err := d.GetUser(context.Background(), "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11")
+	if !errors.Is(err, thunderdome.ErrUserNotFound) {
+		t.Fatalf(`expected ErrUserNotFound got %v`, err)
+	}
+	if logs.Len() != 0 {
+		t.Fatalf(`expected a missing user not to be logged got %d entries`, logs.Len())
+	}
+}
+
+// TestGetUserQueryError calls GetUser against a closed database
+// and makes sure the query error is logged and returned rather than reported as not found
+func TestGetUserQueryError(t *testing.T) {
+	closedDB, err := sql.Open("pgx", "postgres://localhost/thunderdome")
+	if err != nil {
+		t.Fatalf(`unexpected error opening database: %v`, err)
+	}
+	_ = closedDB.Close()
+	core, logs := observer.New(zapcore.DebugLevel)
+	d := &Service{DB: closedDB, Logger: otelzap.New(zap.New(core))}
+
+	_, err = d.GetUser(context.Background(), "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11")
+	if err == nil || errors.Is(err, thunderdome.ErrUserNotFound) {
+		t.Fatalf(`expected query error got %v`, err)
+	}
+	if logs.FilterMessage("get user query error").Len() != 1 {
+		t.Fatalf(`expected query error to be logged once got %d`, logs.Len())
+	}
+}
+
+// TestUserInvalidIDs calls the user updates with an ID that isn't a UUID
+// and makes sure each returns ErrValidation without the database being queried
+func TestUserInvalidIDs(t *testing.T) {
+	f := dbtest.New()
+	d := &Service{DB: f.Open(t), Logger: otelzap.New(zap.NewNop())}
+	ctx := context.Background()
+	UserID := "not-a-uuid"
+
+	_, _, registerErr := d.CreateUserRegistered(ctx, "Thor", "thor@thunderdome.dev", "lokiIsAJoke", UserID)
+	errs := map[string]error{
+		"CreateUserRegistered":  registerErr,
+		"UpdateUserProfile":     d.UpdateUserProfile(ctx, UserID, "Thor", "", true, "", "", "", ""),
+		"UpdateUserProfileLdap": d.UpdateUserProfileLdap(ctx, UserID, "", true, "", "", "", ""),
+		"UpdateUserAccount":     d.UpdateUserAccount(ctx, UserID, "Thor", "thor@thunderdome.dev", "", true, "", "", "", ""),
+		"DeleteUser":            d.DeleteUser(ctx, UserID),
+		"PromoteUser":           d.PromoteUser(ctx, UserID),
+		"DemoteUser":            d.DemoteUser(ctx, UserID),
+		"DisableUser":           d.DisableUser(ctx, UserID),
+		"EnableUser":            d.EnableUser(ctx, UserID),
+	}
+	for name, err := range errs {
+		if !errors.Is(err, thunderdome.ErrValidation) {
+			t.Fatalf(`expected ErrValidation from %s got %v`, name, err)
+		}
+	}
+	if calls := f.Calls(""); calls != 0 {
+		t.Fatalf(`expected no queries for an invalid user ID got %d`, calls)
+	}
+}
+
+// newGuestsService returns a service whose fake database stands in for the users table,
+// guest emails upsert like the unique guest_email index only returning existing guests
+func newGuestsService(t *testing.T) (*Service, map[string][]driver.Value) {
+	f := dbtest.New()
+	users := make(map[string][]driver.Value)
+	nextID := 0
+	f.Query("INSERT INTO thunderdome.users", []string{"id"}, func(args []driver.Value) ([][]driver.Value, error) {
+		nextID++
+		return [][]driver.Value{{fmt.Sprintf("user-%d", nextID)}}, nil
+	})
+	f.Query("guest_email", []string{"id", "name", "type", "avatar", "notifications_enabled", "locale"}, func(args []driver.Value) ([][]driver.Value, error) {
+		email := strings.ToLower(args[1].(string))
+		if existing, ok := users[email]; ok {
+			if existing[2] != "GUEST" {
+				return nil, nil
+			}
+			return [][]driver.Value{existing}, nil
+		}
+		nextID++
+		users[email] = []driver.Value{fmt.Sprintf("user-%d", nextID), args[0], "GUEST", "robohash", true, "en"}
+		return [][]driver.Value{users[email]}, nil
+	})
+
+	return &Service{DB: f.Open(t), Logger: otelzap.New(zap.NewNop())}, users
+}
+
+// TestCreateUserGuestEmail calls CreateUserGuest anonymously, with a new email, and with a returning guests email
+// and makes sure only the returning guest gets their existing user back keeping their name
+func TestCreateUserGuestEmail(t *testing.T) {
+	d, _ := newGuestsService(t)
+	ctx := context.Background()
+
+	anonymous, err := d.CreateUserGuest(ctx, "Anon", "")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if anonymous.Id == "" || anonymous.Email != "" || anonymous.Name != "Anon" {
+		t.Fatalf(`expected anonymous guest without email got %+v`, anonymous)
+	}
+
+	created, err := d.CreateUserGuest(ctx, "Thor", "thor@asgard.com")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if created.Id == "" || created.Id == anonymous.Id || created.Email != "thor@asgard.com" {
+		t.Fatalf(`expected new guest with email got %+v`, created)
+	}
+
+	reused, err := d.CreateUserGuest(ctx, "Loki", "Thor@Asgard.com")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if reused.Id != created.Id || reused.Name != "Thor" {
+		t.Fatalf(`expected existing guest %s named Thor got %+v`, created.Id, reused)
+	}
+
+	another, err := d.CreateUserGuest(ctx, "Anon", "")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if another.Id == anonymous.Id {
+		t.Fatalf(`expected anonymous guests to always be new users`)
+	}
+}
+
+// TestCreateUserGuestEmailRegistered calls CreateUserGuest with the email of a guest who has since registered
+// and makes sure the registered user isn't returned
+func TestCreateUserGuestEmailRegistered(t *testing.T) {
+	d, users := newGuestsService(t)
+	users["odin@asgard.com"] = []driver.Value{"odin", "Odin", "REGISTERED", "robohash", true, "en"}
+
+	if _, err := d.CreateUserGuest(context.Background(), "Odin", "odin@asgard.com"); !errors.Is(err, thunderdome.ErrGuestEmailRegistered) {
+		t.Fatalf(`expected ErrGuestEmailRegistered got %v`, err)
+	}
+}
@@ -9,11 +9,14 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
 	"math/big"
 	"regexp"
 	"strings"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -37,6 +40,17 @@ func Contains(s []string, str string) bool {
 	return false
 }
 
+// ValidateUUID checks that each ID is a valid UUID so malformed IDs never reach postgres
+func ValidateUUID(IDs ...string) error {
+	for _, id := range IDs {
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("%w: invalid id %q", thunderdome.ErrValidation, id)
+		}
+	}
+
+	return nil
+}
+
 // random generates a random secure byte of X length
 func random(length int) ([]byte, error) {
 	chars := "-_+=!$0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
@@ -1,7 +1,10 @@
 package db
 
 import (
+	"errors"
 	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 )
 
 // TestHashString calls hashString and makes sure the return is not the same as the input
@@ -24,3 +27,20 @@ func TestHashString(t *testing.T) {
 		t.Fatalf(`expected HashedResult1: %s to match HashedString: %s`, HashedResult1, HashedString)
 	}
 }
+
+// TestValidateUUID calls ValidateUUID with valid and malformed IDs
+// and makes sure malformed IDs return ErrValidation
+func TestValidateUUID(t *testing.T) {
+	ValidID := "6e3b29a5-fb5b-4b2e-8ab9-6a8e64a8f7b4"
+
+	if err := ValidateUUID(ValidID, ValidID); err != nil {
+		t.Fatalf(`ValidateUUID(%s) = %v, want nil`, ValidID, err)
+	}
+
+	for _, id := range []string{"", "infinitystones", "6e3b29a5-fb5b-4b2e-8ab9", "' OR 1=1 --"} {
+		err := ValidateUUID(ValidID, id)
+		if !errors.Is(err, thunderdome.ErrValidation) {
+			t.Fatalf(`ValidateUUID(%q) = %v, want ErrValidation`, id, err)
+		}
+	}
+}
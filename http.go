@@ -83,7 +83,7 @@ func (s *server) routes() {
 	s.AlertService = &alert.Service{DB: s.db.DB, Logger: s.logger}
 	authService := &auth.Service{DB: s.db.DB, Logger: s.logger, AESHashkey: s.db.Config.AESHashkey}
 	battleService := &poker.Service{
-		DB: s.db.DB, Logger: s.logger, AESHashKey: s.db.Config.AESHashkey,
+		DB: s.db.DB, ReadDB: s.db.ReadDB, Logger: s.logger, AESHashKey: s.db.Config.AESHashkey,
 		HTMLSanitizerPolicy: s.db.HTMLSanitizerPolicy,
 	}
 	checkinService := &team.CheckinService{DB: s.db.DB, Logger: s.logger, HTMLSanitizerPolicy: s.db.HTMLSanitizerPolicy}
@@ -111,6 +111,7 @@ func (s *server) routes() {
 		OrganizationDataSvc: organizationService,
 		AdminDataSvc:        adminService,
 		UIConfig:            uiConfig,
+		PingDB:              s.db.Ping,
 	}
 
 	api.Init(a, FSS, HFS)
@@ -2,6 +2,7 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strings"
@@ -274,17 +275,19 @@ func (s *Service) handleLogout() http.HandlerFunc {
 }
 
 type guestUserCreateRequestBody struct {
-	Name string `json:"name" validate:"required"`
+	Name  string `json:"name"`
+	Email string `json:"email" validate:"omitempty,email"`
 }
 
 // handleCreateGuestUser registers a user as a guest user
 // @Summary      Create Guest User
-// @Description  Registers a user as a guest (non-authenticated)
+// @Description  Registers a user as a guest (non-authenticated), a returning guest with the same email gets their existing guest user, guests without a name get a generated one
 // @Tags         auth
 // @Produce      json
 // @Param        user  body    guestUserCreateRequestBody  false  "guest user object"
 // @Success      200   object  standardJsonResponse{data=thunderdome.User}
 // @Failure      400   object  standardJsonResponse{}
+// @Failure      409   object  standardJsonResponse{}
 // @Failure      500   object  standardJsonResponse{}
 // @Router       /auth/guest [post]
 func (s *Service) handleCreateGuestUser() http.HandlerFunc {
@@ -313,8 +316,17 @@ func (s *Service) handleCreateGuestUser() http.HandlerFunc {
 			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
 			return
 		}
+		// guests without a name get a generated one unless the server requires guests to name themselves
+		if strings.TrimSpace(u.Name) == "" && !viper.GetBool("config.generate_guest_names") {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "NAME_REQUIRED"))
+			return
+		}
 
-		newUser, err := s.UserDataSvc.CreateUserGuest(r.Context(), u.Name)
+		newUser, err := s.UserDataSvc.CreateUserGuest(r.Context(), u.Name, u.Email)
+		if errors.Is(err, thunderdome.ErrGuestEmailRegistered) {
+			s.Failure(w, r, http.StatusConflict, Errorf(ECONFLICT, "GUEST_EMAIL_REGISTERED"))
+			return
+		}
 		if err != nil {
 			s.Failure(w, r, http.StatusInternalServerError, err)
 			return
@@ -2,11 +2,14 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/StevenWeathers/thunderdome-planning-poker/http/checkin"
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 
 	"github.com/gorilla/mux"
 )
@@ -53,6 +56,62 @@ func (s *Service) handleCheckinsGet() http.HandlerFunc {
 	}
 }
 
+// handleCheckinsMarkdownGet gets the days team checkins as a markdown digest for standups
+// @Summary      Get Team Checkins Markdown
+// @Description  Get the days team checkins as markdown for pasting into chat
+// @Tags         team
+// @Produce      text/markdown
+// @Param        teamId  path    string  true   "the team ID"
+// @Param        date    query   string  false  "the date in YYYY-MM-DD format"
+// @Param        tz      query   string  false  "the timezone name e.g. America/New_York"
+// @Success      200     string  string
+// @Security     ApiKeyAuth
+// @Router       /teams/{teamId}/checkins/markdown [get]
+func (s *Service) handleCheckinsMarkdownGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		vars := mux.Vars(r)
+		TeamID := vars["teamId"]
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+		query := r.URL.Query()
+		tz := query.Get("tz")
+		if tz == "" {
+			tz = "America/New_York"
+		}
+		loc, tzErr := time.LoadLocation(tz)
+		if tzErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "INVALID_TIMEZONE"))
+			return
+		}
+
+		date := time.Now().In(loc)
+		if d := query.Get("date"); d != "" {
+			parsed, dateErr := time.ParseInLocation("2006-01-02", d, loc)
+			if dateErr != nil {
+				s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "INVALID_DATE"))
+				return
+			}
+			date = parsed
+		}
+
+		md, err := s.CheckinDataSvc.ExportCheckinsMarkdown(ctx, TeamID, date)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Length", strconv.Itoa(len(md)))
+		if _, err := w.Write(md); err != nil {
+			s.Logger.Ctx(ctx).Error("unable to write checkins markdown.")
+		}
+	}
+}
+
 type checkinCreateRequestBody struct {
 	UserId    string `json:"userId" validate:"required,uuid"`
 	Yesterday string `json:"yesterday"`
@@ -105,7 +164,7 @@ func (s *Service) handleCheckinCreate(tc *checkin.Service) http.HandlerFunc {
 
 		err := tc.APIEvent(r.Context(), TeamId, c.UserId, "checkin_create", string(body))
 		if err != nil {
-			if err.Error() == "REQUIRES_TEAM_USER" {
+			if err.Error() == "REQUIRES_TEAM_USER" || errors.Is(err, thunderdome.ErrCheckinContentTooLong) {
 				s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
 				return
 			}
@@ -178,6 +237,10 @@ func (s *Service) handleCheckinUpdate(tc *checkin.Service) http.HandlerFunc {
 		}
 
 		err := tc.APIEvent(ctx, TeamId, userId, "checkin_update", string(cu))
+		if errors.Is(err, thunderdome.ErrCheckinContentTooLong) {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
 		if err != nil {
 			s.Failure(w, r, http.StatusInternalServerError, err)
 			return
@@ -304,7 +367,7 @@ func (s *Service) handleCheckinComment(tc *checkin.Service) http.HandlerFunc {
 
 		err := tc.APIEvent(ctx, TeamId, c.UserID, "comment_create", string(cu))
 		if err != nil {
-			if err.Error() == "REQUIRES_TEAM_USER" {
+			if err.Error() == "REQUIRES_TEAM_USER" || errors.Is(err, thunderdome.ErrCheckinContentTooLong) {
 				s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
 				return
 			}
@@ -374,7 +437,7 @@ func (s *Service) handleCheckinCommentEdit(tc *checkin.Service) http.HandlerFunc
 
 		err := tc.APIEvent(ctx, TeamId, c.UserID, "comment_update", string(cu))
 		if err != nil {
-			if err.Error() == "REQUIRES_TEAM_USER" {
+			if err.Error() == "REQUIRES_TEAM_USER" || errors.Is(err, thunderdome.ErrCheckinContentTooLong) {
 				s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
 				return
 			}
@@ -435,3 +498,32 @@ func (s *Service) handleCheckinCommentDelete(tc *checkin.Service) http.HandlerFu
 		s.Success(w, r, http.StatusOK, nil, nil)
 	}
 }
+
+// handleCheckinActionItemsGet gets a list of team action items
+// @Summary      Get Team Action Items
+// @Description  Get a list of team check in action items
+// @Tags         team
+// @Produce      json
+// @Param        teamId  path    string  true  "the team ID"
+// @Success      200     object  standardJsonResponse{data=[]thunderdome.CheckinActionItem}
+// @Security     ApiKeyAuth
+// @Router       /teams/{teamId}/checkins/action-items [get]
+func (s *Service) handleCheckinActionItemsGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		TeamID := vars["teamId"]
+		idErr := validate.Var(TeamID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		Items, err := s.CheckinDataSvc.CheckinActionItemList(r.Context(), TeamID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, Items, nil)
+	}
+}
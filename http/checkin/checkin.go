@@ -18,6 +18,7 @@ type Service struct {
 	AuthService           thunderdome.AuthDataSvc
 	CheckinService        thunderdome.CheckinDataSvc
 	TeamService           thunderdome.TeamDataSvc
+	updates               *broadcastCoalescer
 }
 
 // New returns a new retro with websocket hub/client and event handlers
@@ -36,15 +37,24 @@ func New(
 		AuthService:           authService,
 		CheckinService:        checkinService,
 		TeamService:           teamService,
+		updates: newBroadcastCoalescer(checkinUpdateWindow, func(m message) {
+			h.broadcast <- m
+		}),
 	}
 
 	c.eventHandlers = map[string]func(context.Context, string, string, string) ([]byte, error, bool){
-		"checkin_create": c.CheckinCreate,
-		"checkin_update": c.CheckinUpdate,
-		"checkin_delete": c.CheckinDelete,
-		"comment_create": c.CommentCreate,
-		"comment_update": c.CommentUpdate,
-		"comment_delete": c.CommentDelete,
+		"checkin_create":       c.CheckinCreate,
+		"checkin_update":       c.CheckinUpdate,
+		"checkin_delete":       c.CheckinDelete,
+		"comment_create":       c.CommentCreate,
+		"comment_update":       c.CommentUpdate,
+		"comment_delete":       c.CommentDelete,
+		"comment_pin_toggle":   c.CommentPinToggle,
+		"action_item_create":   c.ActionItemCreate,
+		"action_item_update":   c.ActionItemUpdate,
+		"action_item_complete": c.ActionItemComplete,
+		"action_item_delete":   c.ActionItemDelete,
+		"sync":                 c.Sync,
 	}
 
 	go h.run()
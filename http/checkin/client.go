@@ -99,10 +99,16 @@ func (sub subscription) readPump(b *Service, ctx context.Context) {
 				if !forceClosed {
 					b.logger.Ctx(ctx).Error("unexpected close error", zap.Error(eventErr))
 				}
+
+				// handlers can return an event with their error to let the user know why it was rejected
+				if msg != nil {
+					h.broadcast <- message{msg, sub.arena}
+				}
 			}
 		}
 
-		if !badEvent {
+		// handlers return no message when their broadcast is deferred e.g. coalesced checkin updates
+		if !badEvent && msg != nil {
 			m := message{msg, sub.arena}
 			h.broadcast <- m
 		}
@@ -235,7 +241,7 @@ func (b *Service) APIEvent(ctx context.Context, arenaID string, UserID, eventTyp
 			return eventErr
 		}
 
-		if _, ok := h.arenas[arenaID]; ok {
+		if _, ok := h.arenas[arenaID]; ok && msg != nil {
 			m := message{msg, arenaID}
 			h.broadcast <- m
 		}
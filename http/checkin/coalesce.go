@@ -0,0 +1,49 @@
+package checkin
+
+import (
+	"sync"
+	"time"
+)
+
+// checkinUpdateWindow is how long checkin updates are collected before broadcasting,
+// rapid updates e.g. from a user typing within the window are sent as one event
+const checkinUpdateWindow = 250 * time.Millisecond
+
+// broadcastCoalescer collapses messages sharing a key within the window into one
+// broadcast of the latest message sent once the window ends
+type broadcastCoalescer struct {
+	mu        sync.Mutex
+	window    time.Duration
+	pending   map[string]message
+	broadcast func(message)
+}
+
+func newBroadcastCoalescer(window time.Duration, broadcast func(message)) *broadcastCoalescer {
+	return &broadcastCoalescer{
+		window:    window,
+		pending:   make(map[string]message),
+		broadcast: broadcast,
+	}
+}
+
+// add queues the message replacing any message pending for the key,
+// the first message for a key starts its window
+func (c *broadcastCoalescer) add(key string, m message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, waiting := c.pending[key]
+	c.pending[key] = m
+	if waiting {
+		return
+	}
+
+	time.AfterFunc(c.window, func() {
+		c.mu.Lock()
+		latest := c.pending[key]
+		delete(c.pending, key)
+		c.mu.Unlock()
+
+		c.broadcast(latest)
+	})
+}
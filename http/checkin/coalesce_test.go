@@ -0,0 +1,113 @@
+package checkin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// broadcastRecorder records the messages a coalescer broadcasts
+type broadcastRecorder struct {
+	mu       sync.Mutex
+	messages []message
+}
+
+func (r *broadcastRecorder) broadcast(m message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, m)
+}
+
+func (r *broadcastRecorder) sent() []message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]message(nil), r.messages...)
+}
+
+// TestBroadcastCoalescer adds rapid messages for two keys and makes sure
+// each key is broadcast once with its latest message after the window
+func TestBroadcastCoalescer(t *testing.T) {
+	recorder := &broadcastRecorder{}
+	c := newBroadcastCoalescer(50*time.Millisecond, recorder.broadcast)
+
+	for i := 1; i <= 20; i++ {
+		c.add("a", message{[]byte(fmt.Sprintf("a%d", i)), "team"})
+	}
+	c.add("b", message{[]byte("b1"), "team"})
+
+	if sent := recorder.sent(); len(sent) != 0 {
+		t.Fatalf(`expected no broadcasts within the window got %d`, len(sent))
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	latest := make(map[string]int)
+	for _, m := range recorder.sent() {
+		latest[string(m.data)]++
+	}
+	if len(latest) != 2 || latest["a20"] != 1 || latest["b1"] != 1 {
+		t.Fatalf(`expected one broadcast of a20 and b1 got %v`, latest)
+	}
+
+	c.add("a", message{[]byte("a21"), "team"})
+	time.Sleep(150 * time.Millisecond)
+	if sent := recorder.sent(); len(sent) != 3 || string(sent[2].data) != "a21" {
+		t.Fatalf(`expected an update after the window to start a new broadcast got %d`, len(sent))
+	}
+}
+
+// updateCheckinDataSvc stubs CheckinUpdate counting the saved updates
+type updateCheckinDataSvc struct {
+	thunderdome.CheckinDataSvc
+	mu      sync.Mutex
+	updates int
+	today   string
+}
+
+func (s *updateCheckinDataSvc) CheckinUpdate(ctx context.Context, CheckinId string, Yesterday string, Today string, Blockers string, Discuss string, GoalsMet bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updates++
+	s.today = Today
+	return nil
+}
+
+// TestCheckinUpdateCoalesced calls CheckinUpdate rapidly as a user typing and makes sure
+// every update is saved while only one checkin_updated event is broadcast for the checkin
+func TestCheckinUpdateCoalesced(t *testing.T) {
+	svc := &updateCheckinDataSvc{}
+	recorder := &broadcastRecorder{}
+	b := &Service{CheckinService: svc, updates: newBroadcastCoalescer(50*time.Millisecond, recorder.broadcast)}
+
+	typed := ""
+	for _, r := range "fixing the build" {
+		typed += string(r)
+		msg, err, _ := b.CheckinUpdate(context.Background(), "team", "user", fmt.Sprintf(`{"checkinId":"checkin","today":%q}`, typed))
+		if err != nil {
+			t.Fatalf(`unexpected error %v`, err)
+		}
+		if msg != nil {
+			t.Fatalf(`expected the update broadcast to be coalesced got %s`, msg)
+		}
+	}
+	if svc.updates != len(typed) || svc.today != "fixing the build" {
+		t.Fatalf(`expected all %d updates saved got %d ending with %q`, len(typed), svc.updates, svc.today)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	sent := recorder.sent()
+	if len(sent) != 1 {
+		t.Fatalf(`expected 1 broadcast got %d`, len(sent))
+	}
+	var event socketEvent
+	if err := json.Unmarshal(sent[0].data, &event); err != nil {
+		t.Fatalf(`unexpected error decoding event %v`, err)
+	}
+	if sent[0].arena != "team" || event.Type != "checkin_updated" || event.Value != "checkin" {
+		t.Fatalf(`unexpected broadcast %s to %s`, sent[0].data, sent[0].arena)
+	}
+}
@@ -3,6 +3,10 @@ package checkin
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
 )
 
 // CheckinCreate creates a checkin
@@ -22,7 +26,7 @@ func (b *Service) CheckinCreate(ctx context.Context, TeamID string, UserID strin
 
 	err = b.CheckinService.CheckinCreate(context.Background(), TeamID, c.UserId, c.Yesterday, c.Today, c.Blockers, c.Discuss, c.GoalsMet)
 	if err != nil {
-		return nil, err, false
+		return contentRejectedEvent(err, UserID), err, false
 	}
 
 	msg := createSocketEvent("checkin_added", "", "")
@@ -30,7 +34,7 @@ func (b *Service) CheckinCreate(ctx context.Context, TeamID string, UserID strin
 	return msg, nil, false
 }
 
-// CheckinUpdate updates a checkin
+// CheckinUpdate updates a checkin, returning no message when its broadcast is coalesced
 func (b *Service) CheckinUpdate(ctx context.Context, TeamID string, UserID string, EventValue string) ([]byte, error, bool) {
 	var c struct {
 		CheckinId string `json:"checkinId"`
@@ -47,10 +51,17 @@ func (b *Service) CheckinUpdate(ctx context.Context, TeamID string, UserID strin
 
 	err = b.CheckinService.CheckinUpdate(context.Background(), c.CheckinId, c.Yesterday, c.Today, c.Blockers, c.Discuss, c.GoalsMet)
 	if err != nil {
-		return nil, err, false
+		return contentRejectedEvent(err, UserID), err, false
 	}
 
-	msg := createSocketEvent("checkin_updated", "", "")
+	msg := createSocketEvent("checkin_updated", c.CheckinId, "")
+
+	// every update is saved but the broadcast is coalesced per checkin so clients
+	// only reload once for the latest state of a checkin being live edited
+	if b.updates != nil {
+		b.updates.add(TeamID+"/"+c.CheckinId, message{msg, TeamID})
+		return nil, nil, false
+	}
 
 	return msg, nil, false
 }
@@ -89,7 +100,7 @@ func (b *Service) CommentCreate(ctx context.Context, TeamID string, UserID strin
 
 	err = b.CheckinService.CheckinComment(ctx, TeamID, c.CheckinId, c.UserID, c.Comment)
 	if err != nil {
-		return nil, err, false
+		return contentRejectedEvent(err, UserID), err, false
 	}
 
 	msg := createSocketEvent("comment_added", "", "")
@@ -111,7 +122,7 @@ func (b *Service) CommentUpdate(ctx context.Context, TeamID string, UserID strin
 
 	err = b.CheckinService.CheckinCommentEdit(ctx, TeamID, c.UserID, c.CommentId, c.Comment)
 	if err != nil {
-		return nil, err, false
+		return contentRejectedEvent(err, UserID), err, false
 	}
 
 	msg := createSocketEvent("comment_updated", "", "")
@@ -139,6 +150,191 @@ func (b *Service) CommentDelete(ctx context.Context, TeamID string, UserID strin
 	return msg, nil, false
 }
 
+// CommentPinToggle pins or unpins a checkin comment so decisions stay at the top of the thread, team admins only
+func (b *Service) CommentPinToggle(ctx context.Context, TeamID string, UserID string, EventValue string) ([]byte, error, bool) {
+	var c struct {
+		CommentId string `json:"commentId"`
+	}
+	err := json.Unmarshal([]byte(EventValue), &c)
+	if err != nil {
+		return nil, err, false
+	}
+
+	Role, err := b.TeamService.TeamUserRole(ctx, UserID, TeamID)
+	if err != nil {
+		return nil, err, false
+	}
+	if Role != "ADMIN" {
+		return nil, errors.New("REQUIRES_TEAM_ADMIN"), false
+	}
+
+	Pinned, err := b.CheckinService.CheckinCommentPinToggle(ctx, TeamID, c.CommentId)
+	if err != nil {
+		return nil, err, false
+	}
+
+	value, _ := json.Marshal(map[string]interface{}{
+		"commentId": c.CommentId,
+		"pinned":    Pinned,
+	})
+	msg := createSocketEvent("comment_updated", string(value), "")
+
+	return msg, nil, false
+}
+
+// ActionItemCreate creates a team action item
+func (b *Service) ActionItemCreate(ctx context.Context, TeamID string, UserID string, EventValue string) ([]byte, error, bool) {
+	var c struct {
+		UserID     string `json:"userId"`
+		AssigneeID string `json:"assigneeId"`
+		Content    string `json:"content"`
+	}
+	err := json.Unmarshal([]byte(EventValue), &c)
+	if err != nil {
+		return nil, err, false
+	}
+
+	if c.UserID == "" {
+		c.UserID = UserID
+	}
+
+	item, err := b.CheckinService.CheckinActionItemCreate(ctx, TeamID, c.UserID, c.AssigneeID, c.Content)
+	if err != nil {
+		return nil, err, false
+	}
+
+	itemJSON, _ := json.Marshal(item)
+	msg := createSocketEvent("action_item_added", string(itemJSON), "")
+
+	return msg, nil, false
+}
+
+// ActionItemUpdate updates a team action item
+func (b *Service) ActionItemUpdate(ctx context.Context, TeamID string, UserID string, EventValue string) ([]byte, error, bool) {
+	var c struct {
+		ActionItemID string `json:"actionItemId"`
+		AssigneeID   string `json:"assigneeId"`
+		Content      string `json:"content"`
+	}
+	err := json.Unmarshal([]byte(EventValue), &c)
+	if err != nil {
+		return nil, err, false
+	}
+
+	item, err := b.CheckinService.CheckinActionItemUpdate(ctx, TeamID, c.ActionItemID, c.AssigneeID, c.Content)
+	if err != nil {
+		return nil, err, false
+	}
+
+	itemJSON, _ := json.Marshal(item)
+	msg := createSocketEvent("action_item_updated", string(itemJSON), "")
+
+	return msg, nil, false
+}
+
+// ActionItemComplete sets whether a team action item is done
+func (b *Service) ActionItemComplete(ctx context.Context, TeamID string, UserID string, EventValue string) ([]byte, error, bool) {
+	var c struct {
+		ActionItemID string `json:"actionItemId"`
+		Done         bool   `json:"done"`
+	}
+	err := json.Unmarshal([]byte(EventValue), &c)
+	if err != nil {
+		return nil, err, false
+	}
+
+	item, err := b.CheckinService.CheckinActionItemComplete(ctx, TeamID, c.ActionItemID, c.Done)
+	if err != nil {
+		return nil, err, false
+	}
+
+	itemJSON, _ := json.Marshal(item)
+	msg := createSocketEvent("action_item_completed", string(itemJSON), "")
+
+	return msg, nil, false
+}
+
+// ActionItemDelete deletes a team action item
+func (b *Service) ActionItemDelete(ctx context.Context, TeamID string, UserID string, EventValue string) ([]byte, error, bool) {
+	var c struct {
+		ActionItemID string `json:"actionItemId"`
+	}
+	err := json.Unmarshal([]byte(EventValue), &c)
+	if err != nil {
+		return nil, err, false
+	}
+
+	err = b.CheckinService.CheckinActionItemDelete(ctx, TeamID, c.ActionItemID)
+	if err != nil {
+		return nil, err, false
+	}
+
+	msg := createSocketEvent("action_item_deleted", c.ActionItemID, "")
+
+	return msg, nil, false
+}
+
+// syncState is the team's current checkin state sent to a (re)connecting client
+type syncState struct {
+	Date        string                           `json:"date"`
+	Checkins    []*thunderdome.TeamCheckin       `json:"checkins"`
+	ActionItems []*thunderdome.CheckinActionItem `json:"actionItems"`
+}
+
+// Sync sends the user the team's checkins with their comments for the day and the action items
+// so a reconnecting client can rebuild its view, the date defaults to today in the time zone
+func (b *Service) Sync(ctx context.Context, TeamID string, UserID string, EventValue string) ([]byte, error, bool) {
+	var c struct {
+		Date     string `json:"date"`
+		TimeZone string `json:"timeZone"`
+	}
+	if EventValue != "" {
+		if err := json.Unmarshal([]byte(EventValue), &c); err != nil {
+			return nil, err, false
+		}
+	}
+	if c.TimeZone == "" {
+		c.TimeZone = "America/New_York"
+	}
+	loc, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return nil, errors.New("INVALID_TIMEZONE"), false
+	}
+	if c.Date == "" {
+		c.Date = time.Now().In(loc).Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", c.Date); err != nil {
+		return nil, errors.New("INVALID_DATE"), false
+	}
+
+	Checkins, err := b.CheckinService.CheckinList(ctx, TeamID, c.Date, c.TimeZone)
+	if err != nil {
+		return nil, err, false
+	}
+	ActionItems, err := b.CheckinService.CheckinActionItemList(ctx, TeamID)
+	if err != nil {
+		return nil, err, false
+	}
+
+	state, _ := json.Marshal(syncState{
+		Date:        c.Date,
+		Checkins:    Checkins,
+		ActionItems: ActionItems,
+	})
+	msg := createSocketEvent("sync", string(state), UserID)
+
+	return msg, nil, false
+}
+
+// contentRejectedEvent creates the event letting the user know their checkin or comment was too long to save,
+// other errors have no event
+func contentRejectedEvent(err error, UserID string) []byte {
+	if !errors.Is(err, thunderdome.ErrCheckinContentTooLong) {
+		return nil
+	}
+
+	return createSocketEvent("content_rejected", err.Error(), UserID)
+}
+
 // socketEvent is the event structure used for socket messages
 type socketEvent struct {
 	Type  string `json:"type"`
@@ -0,0 +1,236 @@
+package checkin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// actionItemCheckinDataSvc stubs the action item create and complete methods recording their arguments
+type actionItemCheckinDataSvc struct {
+	thunderdome.CheckinDataSvc
+	userID string
+	done   bool
+}
+
+func (s *actionItemCheckinDataSvc) CheckinActionItemCreate(ctx context.Context, TeamId string, UserId string, AssigneeId string, Content string) (*thunderdome.CheckinActionItem, error) {
+	s.userID = UserId
+	return &thunderdome.CheckinActionItem{ID: "item", TeamID: TeamId, UserID: UserId, AssigneeID: AssigneeId, Content: Content}, nil
+}
+
+func (s *actionItemCheckinDataSvc) CheckinActionItemComplete(ctx context.Context, TeamId string, ItemId string, Done bool) (*thunderdome.CheckinActionItem, error) {
+	s.done = Done
+	return &thunderdome.CheckinActionItem{ID: ItemId, TeamID: TeamId, Done: Done}, nil
+}
+
+// decodeActionItemEvent decodes the socket event and its action item value
+func decodeActionItemEvent(t *testing.T, msg []byte) (socketEvent, thunderdome.CheckinActionItem) {
+	var event socketEvent
+	var item thunderdome.CheckinActionItem
+	if err := json.Unmarshal(msg, &event); err != nil {
+		t.Fatalf(`unexpected error decoding event %v`, err)
+	}
+	if err := json.Unmarshal([]byte(event.Value), &item); err != nil {
+		t.Fatalf(`unexpected error decoding action item %v`, err)
+	}
+
+	return event, item
+}
+
+// TestActionItemCreate calls ActionItemCreate and makes sure the created item is broadcast
+// with the connected user as the creator when none is provided
+func TestActionItemCreate(t *testing.T) {
+	svc := &actionItemCheckinDataSvc{}
+	b := &Service{CheckinService: svc}
+
+	msg, err, _ := b.ActionItemCreate(context.Background(), "team", "user", `{"assigneeId":"assignee","content":"fix the build"}`)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if svc.userID != "user" {
+		t.Fatalf(`expected creator: user got %q`, svc.userID)
+	}
+
+	event, item := decodeActionItemEvent(t, msg)
+	if event.Type != "action_item_added" {
+		t.Fatalf(`expected event type: action_item_added got %q`, event.Type)
+	}
+	if item.ID != "item" || item.AssigneeID != "assignee" || item.Content != "fix the build" || item.Done {
+		t.Fatalf(`unexpected action item payload %s`, event.Value)
+	}
+}
+
+// TestActionItemComplete calls ActionItemComplete and makes sure the completed item is broadcast
+func TestActionItemComplete(t *testing.T) {
+	svc := &actionItemCheckinDataSvc{}
+	b := &Service{CheckinService: svc}
+
+	msg, err, _ := b.ActionItemComplete(context.Background(), "team", "user", `{"actionItemId":"item","done":true}`)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if !svc.done {
+		t.Fatalf(`expected item to be marked done`)
+	}
+
+	event, item := decodeActionItemEvent(t, msg)
+	if event.Type != "action_item_completed" {
+		t.Fatalf(`expected event type: action_item_completed got %q`, event.Type)
+	}
+	if item.ID != "item" || !item.Done {
+		t.Fatalf(`unexpected action item payload %s`, event.Value)
+	}
+}
+
+// commentCheckinDataSvc stubs CheckinComment returning its err
+type commentCheckinDataSvc struct {
+	thunderdome.CheckinDataSvc
+	err error
+}
+
+func (s *commentCheckinDataSvc) CheckinComment(ctx context.Context, TeamId string, CheckinId string, UserId string, Comment string) error {
+	return s.err
+}
+
+// TestCommentCreateTooLong calls CommentCreate with an overlong comment and makes sure
+// the error comes with a content_rejected event for the user while other errors have no event
+func TestCommentCreateTooLong(t *testing.T) {
+	tooLong := fmt.Errorf("%w: comment must be 2000 characters or less", thunderdome.ErrCheckinContentTooLong)
+	b := &Service{CheckinService: &commentCheckinDataSvc{err: tooLong}}
+
+	msg, err, _ := b.CommentCreate(context.Background(), "team", "user", `{"checkinId":"checkin","userId":"user","comment":"long"}`)
+	if !errors.Is(err, thunderdome.ErrCheckinContentTooLong) {
+		t.Fatalf(`expected ErrCheckinContentTooLong got %v`, err)
+	}
+	if string(msg) != string(createSocketEvent("content_rejected", tooLong.Error(), "user")) {
+		t.Fatalf(`unexpected event %s`, msg)
+	}
+
+	b = &Service{CheckinService: &commentCheckinDataSvc{err: errors.New("REQUIRES_TEAM_USER")}}
+	if msg, err, _ := b.CommentCreate(context.Background(), "team", "user", `{"checkinId":"checkin","userId":"user","comment":"hi"}`); err == nil || msg != nil {
+		t.Fatalf(`expected an error without an event got %s (%v)`, msg, err)
+	}
+}
+
+// roleTeamDataSvc stubs TeamUserRole returning role for every user
+type roleTeamDataSvc struct {
+	thunderdome.TeamDataSvc
+	role string
+}
+
+func (s *roleTeamDataSvc) TeamUserRole(ctx context.Context, UserID string, TeamID string) (string, error) {
+	return s.role, nil
+}
+
+// pinCheckinDataSvc stubs CheckinCommentPinToggle flipping the pinned state of comments
+type pinCheckinDataSvc struct {
+	thunderdome.CheckinDataSvc
+	pinned map[string]bool
+}
+
+func (s *pinCheckinDataSvc) CheckinCommentPinToggle(ctx context.Context, TeamId string, CommentId string) (bool, error) {
+	s.pinned[CommentId] = !s.pinned[CommentId]
+	return s.pinned[CommentId], nil
+}
+
+// TestCommentPinToggle calls CommentPinToggle as a team admin and member and makes sure
+// only the admin can pin and unpin the comment with the new state broadcast
+func TestCommentPinToggle(t *testing.T) {
+	svc := &pinCheckinDataSvc{pinned: make(map[string]bool)}
+	b := &Service{CheckinService: svc, TeamService: &roleTeamDataSvc{role: "ADMIN"}}
+
+	for _, expected := range []bool{true, false} {
+		msg, err, _ := b.CommentPinToggle(context.Background(), "team", "user", `{"commentId":"comment"}`)
+		if err != nil {
+			t.Fatalf(`unexpected error %v`, err)
+		}
+		var event socketEvent
+		var value struct {
+			CommentId string `json:"commentId"`
+			Pinned    bool   `json:"pinned"`
+		}
+		if err := json.Unmarshal(msg, &event); err != nil {
+			t.Fatalf(`unexpected error decoding event %v`, err)
+		}
+		if err := json.Unmarshal([]byte(event.Value), &value); err != nil {
+			t.Fatalf(`unexpected error decoding event value %v`, err)
+		}
+		if event.Type != "comment_updated" || value.CommentId != "comment" || value.Pinned != expected {
+			t.Fatalf(`expected comment pinned %v got %s`, expected, msg)
+		}
+	}
+
+	b.TeamService = &roleTeamDataSvc{role: "MEMBER"}
+	if _, err, _ := b.CommentPinToggle(context.Background(), "team", "user", `{"commentId":"comment"}`); err == nil || err.Error() != "REQUIRES_TEAM_ADMIN" {
+		t.Fatalf(`expected REQUIRES_TEAM_ADMIN got %v`, err)
+	}
+	if svc.pinned["comment"] {
+		t.Fatalf(`expected a team member not to pin the comment`)
+	}
+}
+
+// syncCheckinDataSvc stubs listing the team's checkins and action items recording the requested day
+type syncCheckinDataSvc struct {
+	thunderdome.CheckinDataSvc
+	date     string
+	timeZone string
+}
+
+func (s *syncCheckinDataSvc) CheckinList(ctx context.Context, TeamId string, Date string, TimeZone string) ([]*thunderdome.TeamCheckin, error) {
+	s.date, s.timeZone = Date, TimeZone
+	return []*thunderdome.TeamCheckin{{
+		Id:       "checkin",
+		User:     &thunderdome.TeamUser{Id: "user", Name: "Max"},
+		Today:    "fix the rig",
+		Comments: []*thunderdome.CheckinComment{{ID: "comment", CheckinID: "checkin", Comment: "need parts?"}},
+	}}, nil
+}
+
+func (s *syncCheckinDataSvc) CheckinActionItemList(ctx context.Context, TeamId string) ([]*thunderdome.CheckinActionItem, error) {
+	return []*thunderdome.CheckinActionItem{{ID: "item", TeamID: TeamId, Content: "order parts"}}, nil
+}
+
+// TestSync calls Sync and makes sure the sync event for the user has the day's checkins
+// with their comments and the team's action items
+func TestSync(t *testing.T) {
+	svc := &syncCheckinDataSvc{}
+	b := &Service{CheckinService: svc}
+
+	msg, err, _ := b.Sync(context.Background(), "team", "user", `{"date":"2023-08-21","timeZone":"Europe/London"}`)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if svc.date != "2023-08-21" || svc.timeZone != "Europe/London" {
+		t.Fatalf(`expected checkins for 2023-08-21 Europe/London got %s %s`, svc.date, svc.timeZone)
+	}
+
+	var event socketEvent
+	var state syncState
+	if err := json.Unmarshal(msg, &event); err != nil {
+		t.Fatalf(`unexpected error decoding event %v`, err)
+	}
+	if err := json.Unmarshal([]byte(event.Value), &state); err != nil {
+		t.Fatalf(`unexpected error decoding sync state %v`, err)
+	}
+	if event.Type != "sync" || event.User != "user" || state.Date != "2023-08-21" {
+		t.Fatalf(`unexpected sync event %s`, msg)
+	}
+	if len(state.Checkins) != 1 || state.Checkins[0].Today != "fix the rig" ||
+		len(state.Checkins[0].Comments) != 1 || state.Checkins[0].Comments[0].Comment != "need parts?" {
+		t.Fatalf(`expected the day's checkin with its comment got %s`, event.Value)
+	}
+	if len(state.ActionItems) != 1 || state.ActionItems[0].Content != "order parts" {
+		t.Fatalf(`expected the team's action item got %s`, event.Value)
+	}
+
+	if _, err, _ := b.Sync(context.Background(), "team", "user", ""); err != nil || svc.date == "" || svc.timeZone != "America/New_York" {
+		t.Fatalf(`expected sync without a value to default to today got %s %s (%v)`, svc.date, svc.timeZone, err)
+	}
+	if _, err, _ := b.Sync(context.Background(), "team", "user", `{"timeZone":"Nowhere/Bartertown"}`); err == nil {
+		t.Fatalf(`expected an invalid time zone to be rejected`)
+	}
+}
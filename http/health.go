@@ -0,0 +1,24 @@
+package http
+
+import (
+	"net/http"
+)
+
+// handleHealthCheck checks the application can reach its database
+// @Summary      Health Check
+// @Description  Checks the application is healthy and can reach its database
+// @Tags         health
+// @Produce      json
+// @Success      200  object  standardJsonResponse{}
+// @Failure      503  object  standardJsonResponse{}
+// @Router       /health [get]
+func (s *Service) handleHealthCheck() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.PingDB(r.Context()); err != nil {
+			s.Failure(w, r, http.StatusServiceUnavailable, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, map[string]string{"database": "ok"}, nil)
+	}
+}
@@ -79,6 +79,7 @@ type Service struct {
 	TeamDataSvc         thunderdome.TeamDataSvc
 	OrganizationDataSvc thunderdome.OrganizationDataSvc
 	AdminDataSvc        thunderdome.AdminDataSvc
+	PingDB              func(ctx context.Context) error
 }
 
 // standardJsonResponse structure used for all restful APIs response body
@@ -145,6 +146,8 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 	teamRouter := apiRouter.PathPrefix("/teams").Subrouter()
 	adminRouter := apiRouter.PathPrefix("/admin").Subrouter()
 
+	apiRouter.HandleFunc("/health", a.handleHealthCheck()).Methods("GET")
+
 	// user authentication, profile
 	if a.Config.LdapEnabled {
 		apiRouter.HandleFunc("/auth/ldap", a.handleLdapLogin()).Methods("POST")
@@ -204,6 +207,8 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/users", a.userOnly(a.departmentTeamAdminOnly(a.handleDepartmentTeamAddUser()))).Methods("POST")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/users/{userId}", a.userOnly(a.departmentTeamAdminOnly(a.handleTeamRemoveUser()))).Methods("DELETE")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins", a.userOnly(a.departmentTeamUserOnly(a.handleCheckinsGet()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/markdown", a.userOnly(a.departmentTeamUserOnly(a.handleCheckinsMarkdownGet()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/action-items", a.userOnly(a.departmentTeamUserOnly(a.handleCheckinActionItemsGet()))).Methods("GET")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins", a.userOnly(a.departmentTeamUserOnly(a.handleCheckinCreate(checkinSvc)))).Methods("POST")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/{checkinId}", a.userOnly(a.departmentTeamUserOnly(a.handleCheckinUpdate(checkinSvc)))).Methods("PUT")
 	orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/checkins/{checkinId}", a.userOnly(a.departmentTeamUserOnly(a.handleCheckinDelete(checkinSvc)))).Methods("DELETE")
@@ -219,6 +224,8 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/users", a.userOnly(a.orgTeamAdminOnly(a.handleOrganizationTeamAddUser()))).Methods("POST")
 	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/users/{userId}", a.userOnly(a.orgTeamAdminOnly(a.handleTeamRemoveUser()))).Methods("DELETE")
 	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins", a.userOnly(a.orgTeamOnly(a.handleCheckinsGet()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins/markdown", a.userOnly(a.orgTeamOnly(a.handleCheckinsMarkdownGet()))).Methods("GET")
+	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins/action-items", a.userOnly(a.orgTeamOnly(a.handleCheckinActionItemsGet()))).Methods("GET")
 	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins", a.userOnly(a.orgTeamOnly(a.handleCheckinCreate(checkinSvc)))).Methods("POST")
 	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins/{checkinId}", a.userOnly(a.orgTeamOnly(a.handleCheckinUpdate(checkinSvc)))).Methods("PUT")
 	orgRouter.HandleFunc("/{orgId}/teams/{teamId}/checkins/{checkinId}", a.userOnly(a.orgTeamOnly(a.handleCheckinDelete(checkinSvc)))).Methods("DELETE")
@@ -237,6 +244,8 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 	teamRouter.HandleFunc("/{teamId}/users/{userId}", a.userOnly(a.teamAdminOnly(a.handleTeamRemoveUser()))).Methods("DELETE")
 	teamRouter.HandleFunc("/{teamId}/checkin", checkinSvc.ServeWs())
 	teamRouter.HandleFunc("/{teamId}/checkins", a.userOnly(a.teamUserOnly(a.handleCheckinsGet()))).Methods("GET")
+	teamRouter.HandleFunc("/{teamId}/checkins/markdown", a.userOnly(a.teamUserOnly(a.handleCheckinsMarkdownGet()))).Methods("GET")
+	teamRouter.HandleFunc("/{teamId}/checkins/action-items", a.userOnly(a.teamUserOnly(a.handleCheckinActionItemsGet()))).Methods("GET")
 	teamRouter.HandleFunc("/{teamId}/checkins", a.userOnly(a.teamUserOnly(a.handleCheckinCreate(checkinSvc)))).Methods("POST")
 	teamRouter.HandleFunc("/{teamId}/checkins/{checkinId}", a.userOnly(a.teamUserOnly(a.handleCheckinUpdate(checkinSvc)))).Methods("PUT")
 	teamRouter.HandleFunc("/{teamId}/checkins/{checkinId}", a.userOnly(a.teamUserOnly(a.handleCheckinDelete(checkinSvc)))).Methods("DELETE")
@@ -268,6 +277,10 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 	if a.Config.FeaturePoker {
 		userRouter.HandleFunc("/{userId}/battles", a.userOnly(a.entityUserOnly(a.handlePokerCreate()))).Methods("POST")
 		userRouter.HandleFunc("/{userId}/battles", a.userOnly(a.entityUserOnly(a.handleGetUserGames()))).Methods("GET")
+		userRouter.HandleFunc("/{userId}/battles/resumable", a.userOnly(a.entityUserOnly(a.handleGetUserResumableGames()))).Methods("GET")
+		userRouter.HandleFunc("/{userId}/battle-templates", a.userOnly(a.entityUserOnly(a.handleGetUserPokerTemplates()))).Methods("GET")
+		userRouter.HandleFunc("/{userId}/battle-templates", a.userOnly(a.entityUserOnly(a.handlePokerTemplateCreate()))).Methods("POST")
+		userRouter.HandleFunc("/{userId}/battle-templates/{templateId}/battles", a.userOnly(a.entityUserOnly(a.handlePokerCreateFromTemplate()))).Methods("POST")
 		orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/battles", a.userOnly(a.departmentTeamUserOnly(a.handleGetTeamBattles()))).Methods("GET")
 		orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/battles/{battleId}", a.userOnly(a.departmentTeamAdminOnly(a.handleTeamRemoveBattle()))).Methods("DELETE")
 		orgRouter.HandleFunc("/{orgId}/departments/{departmentId}/teams/{teamId}/users/{userId}/battles", a.userOnly(a.departmentTeamUserOnly(a.handlePokerCreate()))).Methods("POST")
@@ -278,11 +291,18 @@ func Init(apiService Service, FSS fs.FS, HFS http.FileSystem) *Service {
 		teamRouter.HandleFunc("/{teamId}/battles/{battleId}", a.userOnly(a.teamAdminOnly(a.handleTeamRemoveBattle()))).Methods("DELETE")
 		teamRouter.HandleFunc("/{teamId}/users/{userId}/battles", a.userOnly(a.teamUserOnly(a.entityUserOnly(a.handlePokerCreate())))).Methods("POST")
 		apiRouter.HandleFunc("/maintenance/clean-battles", a.userOnly(a.adminOnly(a.handleCleanBattles()))).Methods("DELETE")
+		apiRouter.HandleFunc("/maintenance/clean-battle-users", a.userOnly(a.adminOnly(a.handleCleanBattleUsers()))).Methods("DELETE")
+		apiRouter.HandleFunc("/maintenance/battles/{battleId}/promote-leader", a.userOnly(a.adminOnly(a.handleBattlePromoteActiveUser()))).Methods("PATCH")
 		apiRouter.HandleFunc("/battles", a.userOnly(a.adminOnly(a.handleGetPokerGames()))).Methods("GET")
+		apiRouter.HandleFunc("/battles/code/{shortCode}", a.userOnly(a.handleGetPokerGameByShortCode())).Methods("GET")
 		apiRouter.HandleFunc("/battles/{battleId}", a.userOnly(a.handleGetPokerGame())).Methods("GET")
 		apiRouter.HandleFunc("/battles/{battleId}", a.userOnly(a.handlePokerDelete(pokerSvc))).Methods("DELETE")
+		apiRouter.HandleFunc("/battles/{battleId}/settings", a.userOnly(a.handlePokerSettingsUpdate(pokerSvc))).Methods("PATCH")
 		apiRouter.HandleFunc("/battles/{battleId}/plans", a.userOnly(a.handlePokerStoryAdd(pokerSvc))).Methods("POST")
 		apiRouter.HandleFunc("/battles/{battleId}/plans/{planId}", a.userOnly(a.handlePokerStoryDelete(pokerSvc))).Methods("DELETE")
+		apiRouter.HandleFunc("/battles/{battleId}/invites", a.userOnly(a.handlePokerInviteCreate())).Methods("POST")
+		apiRouter.HandleFunc("/battles/{battleId}/invites/{inviteToken}", a.userOnly(a.handlePokerInviteRevoke())).Methods("DELETE")
+		apiRouter.HandleFunc("/battle-invites/{inviteToken}", a.userOnly(a.handlePokerInviteRedeem())).Methods("GET")
 		apiRouter.HandleFunc("/arena/{battleId}", pokerSvc.ServeBattleWs())
 	}
 	// retro(s)
@@ -1,8 +1,12 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/gorilla/mux"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
@@ -30,6 +34,64 @@ func (s *Service) handleCleanBattles() http.HandlerFunc {
 	}
 }
 
+// handleCleanBattleUsers handles cleaning up battle users whose user or battle no longer exists (ADMIN Manually Triggered)
+// @Summary      Clean Orphaned Battle Users
+// @Description  Deletes battle users left without their user or battle e.g. after a restore
+// @Tags         maintenance
+// @Produce      json
+// @Success      200  object  standardJsonResponse{}
+// @Failure      500  object  standardJsonResponse{}
+// @Security     ApiKeyAuth
+// @Router       /maintenance/clean-battle-users [delete]
+func (s *Service) handleCleanBattleUsers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		removed, err := s.PokerDataSvc.CleanOrphanedUsers(r.Context())
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Logger.Info("Cleaned orphaned battle users", zap.Int("count", removed))
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
+
+// handleBattlePromoteActiveUser handles making an active user the leader of a battle left without one (ADMIN Manually Triggered)
+// @Summary      Promote Battle Active User
+// @Description  Makes the most recently seen active user the leader of a battle whose leaders no longer exist
+// @Tags         maintenance
+// @Produce      json
+// @Param        battleId  path    string  true  "the battle ID to promote an active user in"
+// @Success      200       object  standardJsonResponse{data=thunderdome.Poker}
+// @Failure      400       object  standardJsonResponse{}
+// @Failure      500       object  standardJsonResponse{}
+// @Security     ApiKeyAuth
+// @Router       /maintenance/battles/{battleId}/promote-leader [patch]
+func (s *Service) handleBattlePromoteActiveUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		BattleID := vars["battleId"]
+		idErr := validate.Var(BattleID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+
+		b, err := s.PokerDataSvc.PromoteFirstActiveUser(BattleID)
+		if errors.Is(err, thunderdome.ErrNoActiveUsers) {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, b, nil)
+	}
+}
+
 // handleCleanRetros handles cleaning up old retros (ADMIN Manually Triggered)
 // @Summary      Clean Old Retros
 // @Description  Deletes retros older than {config.cleanup_retros_days_old} based on last activity date
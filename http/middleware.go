@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -133,6 +134,10 @@ func (s *Service) verifiedUserOnly(h http.HandlerFunc) http.HandlerFunc {
 		}
 
 		EntityUser, EntityUserErr := s.UserDataSvc.GetUser(ctx, EntityUserID)
+		if errors.Is(EntityUserErr, thunderdome.ErrUserNotFound) {
+			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, "USER_NOT_FOUND"))
+			return
+		}
 		if EntityUserErr != nil {
 			s.Failure(w, r, http.StatusInternalServerError, EntityUserErr)
 			return
@@ -2,6 +2,7 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
@@ -49,16 +50,48 @@ func (s *Service) handleGetUserGames() http.HandlerFunc {
 	}
 }
 
+// handleGetUserResumableGames looks up the users non-archived poker games that still have stories to estimate
+// @Summary      Get Resumable PokerGames
+// @Description  get list of the users poker games with unestimated stories to continue
+// @Tags         poker
+// @Produce      json
+// @Param        userId  path    string  true   "the user ID to get resumable poker games for"
+// @Success      200     object  standardJsonResponse{data=[]thunderdome.Poker}
+// @Failure      403     object  standardJsonResponse{}
+// @Failure      500     object  standardJsonResponse{}
+// @Security     ApiKeyAuth
+// @Router       /users/{userId}/battles/resumable [get]
+func (s *Service) handleGetUserResumableGames() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		UserID := vars["userId"]
+
+		battles, err := s.PokerDataSvc.GetResumableGames(UserID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, battles, nil)
+	}
+}
+
 type battleRequestBody struct {
-	BattleName           string               `json:"name" validate:"required"`
-	PointValuesAllowed   []string             `json:"pointValuesAllowed" validate:"required"`
-	AutoFinishVoting     bool                 `json:"autoFinishVoting"`
-	Plans                []*thunderdome.Story `json:"plans"`
-	PointAverageRounding string               `json:"pointAverageRounding" validate:"required,oneof=ceil round floor"`
-	HideVoterIdentity    bool                 `json:"hideVoterIdentity"`
-	BattleLeaders        []string             `json:"battleLeaders"`
-	JoinCode             string               `json:"joinCode"`
-	LeaderCode           string               `json:"leaderCode"`
+	BattleName           string                   `json:"name" validate:"required"`
+	PointValuesAllowed   []string                 `json:"pointValuesAllowed" validate:"required"`
+	AutoFinishVoting     bool                     `json:"autoFinishVoting"`
+	Plans                []*thunderdome.Story     `json:"plans"`
+	PointAverageRounding string                   `json:"pointAverageRounding" validate:"required,oneof=ceil round floor"`
+	HideVoterIdentity    bool                     `json:"hideVoterIdentity"`
+	BattleLeaders        []string                 `json:"battleLeaders"`
+	JoinCode             string                   `json:"joinCode"`
+	LeaderCode           string                   `json:"leaderCode"`
+	VoteMode             string                   `json:"voteMode" validate:"omitempty,oneof=points fist-of-five effort-complexity"`
+	CustomScale          []thunderdome.ScaleValue `json:"customScale" validate:"omitempty,unique=Label"`
+	EstimationUnit       string                   `json:"estimationUnit" validate:"omitempty,oneof=points hours days"`
+	TieBreakStrategy     string                   `json:"tieBreakStrategy" validate:"omitempty,oneof=round-up mode upper-median"`
+	MinVotersToFinalize  int                      `json:"minVotersToFinalize" validate:"min=0"`
+	VoteRevealThreshold  int                      `json:"voteRevealThreshold" validate:"min=0"`
 }
 
 // handlePokerCreate handles creating a poker game
@@ -71,8 +104,11 @@ type battleRequestBody struct {
 // @Param        departmentId  path    string             false  "the department ID"
 // @Param        teamId        path    string             false  "the team ID"
 // @Param        battle        body    battleRequestBody  false  "new poker game object"
+// @Param        Idempotency-Key  header  string          false  "optional key to safely retry creating the game"
 // @Success      200           object  standardJsonResponse{data=thunderdome.Poker}
+// @Failure      400           object  standardJsonResponse{}
 // @Failure      403           object  standardJsonResponse{}
+// @Failure      404           object  standardJsonResponse{}
 // @Failure      500           object  standardJsonResponse{}
 // @Security     ApiKeyAuth
 // @Router       /users/{userId}/battles [post]
@@ -110,26 +146,46 @@ func (s *Service) handlePokerCreate() http.HandlerFunc {
 			return
 		}
 
+		// the settings are applied as part of creating the game so a retry replaying the game doesn't reapply them
+		var Settings thunderdome.PokerSettings
+		if b.EstimationUnit != "" {
+			Settings.EstimationUnit = &b.EstimationUnit
+		}
+		if b.TieBreakStrategy != "" {
+			Settings.TieBreakStrategy = &b.TieBreakStrategy
+		}
+		if b.MinVotersToFinalize > 0 {
+			Settings.MinVotersToFinalize = &b.MinVotersToFinalize
+		}
+		if b.VoteRevealThreshold > 0 {
+			Settings.VoteRevealThreshold = &b.VoteRevealThreshold
+		}
+
+		// retries after a timeout send the same Idempotency-Key to get the originally created game
+		IdempotencyKey := r.Header.Get("Idempotency-Key")
 		var newBattle *thunderdome.Poker
 		var err error
 		// if battle created with team association
 		if teamIdExists {
-			if isTeamUserOrAnAdmin(r) {
-				newBattle, err = s.PokerDataSvc.TeamCreateGame(ctx, TeamID, UserID, b.BattleName, b.PointValuesAllowed, b.Plans, b.AutoFinishVoting, b.PointAverageRounding, b.JoinCode, b.LeaderCode, b.HideVoterIdentity)
-				if err != nil {
-					s.Failure(w, r, http.StatusInternalServerError, err)
-					return
-				}
-			} else {
+			if !isTeamUserOrAnAdmin(r) {
 				s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_TEAM_USER"))
 				return
 			}
+			newBattle, err = s.PokerDataSvc.TeamCreateGame(ctx, TeamID, UserID, b.BattleName, b.PointValuesAllowed, b.Plans, b.AutoFinishVoting, b.PointAverageRounding, b.JoinCode, b.LeaderCode, b.HideVoterIdentity, b.VoteMode, b.CustomScale, Settings, IdempotencyKey)
 		} else {
-			newBattle, err = s.PokerDataSvc.CreateGame(ctx, UserID, b.BattleName, b.PointValuesAllowed, b.Plans, b.AutoFinishVoting, b.PointAverageRounding, b.JoinCode, b.LeaderCode, b.HideVoterIdentity)
-			if err != nil {
-				s.Failure(w, r, http.StatusInternalServerError, err)
-				return
-			}
+			newBattle, err = s.PokerDataSvc.CreateGame(ctx, UserID, b.BattleName, b.PointValuesAllowed, b.Plans, b.AutoFinishVoting, b.PointAverageRounding, b.JoinCode, b.LeaderCode, b.HideVoterIdentity, b.VoteMode, b.CustomScale, Settings, IdempotencyKey)
+		}
+		if errors.Is(err, thunderdome.ErrUserNotFound) {
+			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, "USER_NOT_FOUND"))
+			return
+		}
+		if errors.Is(err, thunderdome.ErrValidation) {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
 		}
 
 		// when battleLeaders array is passed add additional leaders to battle
@@ -193,10 +249,12 @@ func (s *Service) handleGetPokerGames() http.HandlerFunc {
 // @Description  get poker game by ID
 // @Tags         poker
 // @Produce      json
-// @Param        battleId  path    string  true  "the poker game ID to get"
-// @Success      200       object  standardJsonResponse{data=thunderdome.Poker}
-// @Failure      403       object  standardJsonResponse{}
-// @Failure      404       object  standardJsonResponse{}
+// @Param        battleId       path    string  true   "the poker game ID to get"
+// @Param        If-None-Match  header  string  false  "the ETag of the previously fetched game version"
+// @Success      200            object  standardJsonResponse{data=thunderdome.Poker}
+// @Success      304            "game unchanged since the provided version"
+// @Failure      403            object  standardJsonResponse{}
+// @Failure      404            object  standardJsonResponse{}
 // @Security     ApiKeyAuth
 // @Router       /battles/{battleId} [get]
 func (s *Service) handleGetPokerGame() http.HandlerFunc {
@@ -211,7 +269,23 @@ func (s *Service) handleGetPokerGame() http.HandlerFunc {
 		UserId := r.Context().Value(contextKeyUserID).(string)
 		UserType := r.Context().Value(contextKeyUserType).(string)
 
-		b, err := s.PokerDataSvc.GetGame(BattleId, UserId)
+		var b *thunderdome.Poker
+		var err error
+		if KnownVersion, ok := getVersionFromETag(r); ok {
+			var changed bool
+			b, changed, err = s.PokerDataSvc.GetGameIfChanged(BattleId, UserId, KnownVersion)
+			if err == nil && !changed {
+				w.Header().Set("ETag", versionETag(KnownVersion))
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		} else {
+			b, err = s.PokerDataSvc.GetGame(BattleId, UserId)
+		}
+		if errors.Is(err, thunderdome.ErrValidation) {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
 		if err != nil {
 			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, "BATTLE_NOT_FOUND"))
 			return
@@ -226,10 +300,103 @@ func (s *Service) handleGetPokerGame() http.HandlerFunc {
 			}
 		}
 
+		w.Header().Set("ETag", versionETag(b.Version))
 		s.Success(w, r, http.StatusOK, b, nil)
 	}
 }
 
+// handleGetPokerGameByShortCode gets the poker game by its short join code
+// @Summary      Get Poker Game by Short Code
+// @Description  get poker game by its short join code, only the ID is returned when the game requires a join code the user hasn't joined with
+// @Tags         poker
+// @Produce      json
+// @Param        shortCode  path    string  true  "the poker game short code to get"
+// @Success      200        object  standardJsonResponse{data=thunderdome.Poker}
+// @Failure      400        object  standardJsonResponse{}
+// @Failure      404        object  standardJsonResponse{}
+// @Security     ApiKeyAuth
+// @Router       /battles/code/{shortCode} [get]
+func (s *Service) handleGetPokerGameByShortCode() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		ShortCode := vars["shortCode"]
+		UserId := r.Context().Value(contextKeyUserID).(string)
+		UserType := r.Context().Value(contextKeyUserType).(string)
+
+		b, err := s.PokerDataSvc.GetGameByShortCode(ShortCode, UserId)
+		if errors.Is(err, thunderdome.ErrValidation) {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, "BATTLE_NOT_FOUND"))
+			return
+		}
+
+		// only return the battle ID when battle has JoinCode and user hasn't joined yet so they can be sent to join it
+		if b.JoinCode != "" {
+			UserErr := s.PokerDataSvc.GetUserActiveStatus(b.Id, UserId)
+			if UserErr != nil && UserType != adminUserType {
+				s.Success(w, r, http.StatusOK, &thunderdome.Poker{Id: b.Id, ShortCode: b.ShortCode}, nil)
+				return
+			}
+		}
+
+		s.Success(w, r, http.StatusOK, b, nil)
+	}
+}
+
+// handlePokerSettingsUpdate handles updating several poker game settings together
+// @Summary      Update Poker Settings
+// @Description  Updates the provided poker game settings together, omitted settings are left unchanged
+// @Param        battleId  path  string                     true  "the poker game ID"
+// @Param        settings  body  thunderdome.PokerSettings  true  "poker settings to update"
+// @Tags         poker
+// @Produce      json
+// @Success      200  object  standardJsonResponse{}
+// @Failure      400  object  standardJsonResponse{}
+// @Failure      403  object  standardJsonResponse{}
+// @Failure      500  object  standardJsonResponse{}
+// @Security     ApiKeyAuth
+// @Router       /battles/{battleId}/settings [patch]
+func (s *Service) handlePokerSettingsUpdate(b *poker.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		BattleID := vars["battleId"]
+		idErr := validate.Var(BattleID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+		UserID := r.Context().Value(contextKeyUserID).(string)
+
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+			return
+		}
+
+		var settings = thunderdome.PokerSettings{}
+		jsonErr := json.Unmarshal(body, &settings)
+		if jsonErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+			return
+		}
+
+		err := b.APIEvent(r.Context(), BattleID, UserID, "update_settings", string(body))
+		if errors.Is(err, thunderdome.ErrValidation) {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
+
 type planRequestBody struct {
 	Name               string `json:"planName"`
 	Type               string `json:"type"`
@@ -12,6 +12,7 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 )
@@ -32,18 +33,34 @@ const (
 
 // leaderOnlyOperations contains a map of operations that only a battle leader can execute
 var leaderOnlyOperations = map[string]struct{}{
-	"add_plan":       {},
-	"revise_plan":    {},
-	"burn_plan":      {},
-	"activate_plan":  {},
-	"skip_plan":      {},
-	"end_voting":     {},
-	"finalize_plan":  {},
-	"jab_warrior":    {},
-	"promote_leader": {},
-	"demote_leader":  {},
-	"revise_battle":  {},
-	"concede_battle": {},
+	"add_plan":        {},
+	"revise_plan":     {},
+	"burn_plan":       {},
+	"activate_plan":   {},
+	"skip_plan":       {},
+	"end_voting":      {},
+	"cancel_voting":   {},
+	"call_revote":     {},
+	"reveal_votes":    {},
+	"finalize_plan":   {},
+	"auto_finalize":   {},
+	"finalize_active": {},
+	"finalize_plans":  {},
+	"lock_plan":       {},
+	"unlock_plan":     {},
+	"group_plan":      {},
+	"requeue_plans":   {},
+	"jab_warrior":     {},
+	"promote_leader":  {},
+	"demote_leader":   {},
+	"revise_battle":   {},
+	"update_settings": {},
+	"concede_battle":  {},
+	"archive_battle":  {},
+	"pause_battle":    {},
+	"resume_battle":   {},
+	"take_control":    {},
+	"resume_control":  {},
 }
 
 var upgrader = websocket.Upgrader{
@@ -68,6 +85,7 @@ func (sub subscription) readPump(b *Service, ctx context.Context) {
 	BattleID := sub.arena
 
 	defer func() {
+		control.release(BattleID, sub.SessionID)
 		Users := b.BattleService.RetreatUser(BattleID, UserID)
 		UpdatedUsers, _ := json.Marshal(Users)
 
@@ -122,10 +140,20 @@ func (sub subscription) readPump(b *Service, ctx context.Context) {
 			}
 		}
 
+		// another leader session is in control of the battle, let this session know so it can take over
+		if _, ok := controlledOperations[eventType]; ok && !badEvent && !control.claim(BattleID, sub.SessionID) {
+			badEvent = true
+			h.direct <- directMessage{createSocketEvent("control_conflict", eventType, UserID), sub.arena, c}
+		}
+
 		// find event handler and execute otherwise invalid event
 		if _, ok := b.eventHandlers[eventType]; ok && !badEvent {
-			msg, eventErr, forceClosed = b.eventHandlers[eventType](ctx, BattleID, UserID, eventValue)
-			if eventErr != nil {
+			msg, eventErr, forceClosed = b.eventHandlers[eventType](withSession(ctx, sub.SessionID), BattleID, UserID, eventValue)
+			var ve *voterEvent
+			if errors.As(eventErr, &ve) {
+				badEvent = true
+				h.direct <- directMessage{ve.event, sub.arena, c}
+			} else if eventErr != nil {
 				badEvent = true
 
 				// don't log forceClosed events e.g. Abandon
@@ -135,9 +163,10 @@ func (sub subscription) readPump(b *Service, ctx context.Context) {
 			}
 		}
 
-		if !badEvent {
-			m := message{msg, sub.arena}
-			h.broadcast <- m
+		if !badEvent && msg != nil {
+			for _, bm := range b.broadcastMessages(ctx, BattleID, eventType, msg) {
+				h.broadcast <- message{bm, sub.arena}
+			}
 		}
 
 		if forceClosed {
@@ -287,17 +316,23 @@ func (b *Service) ServeBattleWs() http.HandlerFunc {
 		}
 
 		if UserAuthed {
-			ss := subscription{c, battleID, User.Id}
+			ss := subscription{c, battleID, User.Id, uuid.New().String()}
 			h.register <- ss
 
-			Users, _ := b.BattleService.AddUser(ss.arena, User.Id)
+			Users, IsNew, _ := b.BattleService.AddUser(ss.arena, User.Id)
 			UpdatedUsers, _ := json.Marshal(Users)
 
 			Battle, _ := json.Marshal(battle)
 			initEvent := createSocketEvent("init", string(Battle), User.Id)
 			_ = c.write(websocket.TextMessage, initEvent)
+			sessionEvent := createSocketEvent("session_assigned", ss.SessionID, User.Id)
+			_ = c.write(websocket.TextMessage, sessionEvent)
 
-			joinedEvent := createSocketEvent("warrior_joined", string(UpdatedUsers), User.Id)
+			joinedEventType := "warrior_rejoined"
+			if IsNew {
+				joinedEventType = "warrior_joined"
+			}
+			joinedEvent := createSocketEvent(joinedEventType, string(UpdatedUsers), User.Id)
 			m := message{joinedEvent, ss.arena}
 			h.broadcast <- m
 
@@ -325,9 +360,10 @@ func (b *Service) APIEvent(ctx context.Context, arenaID string, UserID, eventTyp
 			return eventErr
 		}
 
-		if _, ok := h.arenas[arenaID]; ok {
-			m := message{msg, arenaID}
-			h.broadcast <- m
+		if _, ok := h.arenas[arenaID]; ok && msg != nil {
+			for _, bm := range b.broadcastMessages(ctx, arenaID, eventType, msg) {
+				h.broadcast <- message{bm, arenaID}
+			}
 		}
 	}
 
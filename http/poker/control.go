@@ -0,0 +1,115 @@
+package poker
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// controlledOperations contains the leader operations that change which plan is being voted on or its outcome,
+// only the leader session in control of the battle can run them so two leader tabs don't flap the battle state
+var controlledOperations = map[string]struct{}{
+	"activate_plan":   {},
+	"skip_plan":       {},
+	"end_voting":      {},
+	"cancel_voting":   {},
+	"call_revote":     {},
+	"reveal_votes":    {},
+	"finalize_plan":   {},
+	"auto_finalize":   {},
+	"finalize_active": {},
+	"finalize_plans":  {},
+}
+
+// sessionControl tracks which leader session controls each battle so leaders tabs, whether their own or another
+// leaders, don't fight over the battle, control is advisory and only held in memory so it's lost on restart
+// and the next leader session to run a controlled operation claims it again
+type sessionControl struct {
+	mu       sync.Mutex
+	sessions map[string]string
+}
+
+var control = &sessionControl{sessions: make(map[string]string)}
+
+// claim gives the session control of the battle when no other session has it, returning whether the session is in control
+func (sc *sessionControl) claim(BattleID string, SessionID string) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if current, ok := sc.sessions[BattleID]; ok && current != SessionID {
+		return false
+	}
+	sc.sessions[BattleID] = SessionID
+
+	return true
+}
+
+// takeOver gives the session control of the battle even when another session has it
+func (sc *sessionControl) takeOver(BattleID string, SessionID string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.sessions[BattleID] = SessionID
+}
+
+// resume moves control of the battle from a previous session to the session it reconnected as,
+// so a reconnecting tab isn't locked out by its own stale session, another sessions control is left alone
+func (sc *sessionControl) resume(BattleID string, PreviousSessionID string, SessionID string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if current, ok := sc.sessions[BattleID]; ok && current == PreviousSessionID {
+		sc.sessions[BattleID] = SessionID
+	}
+}
+
+// release gives up the sessions control of the battle e.g. when it disconnects, another sessions control is left alone
+func (sc *sessionControl) release(BattleID string, SessionID string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.sessions[BattleID] == SessionID {
+		delete(sc.sessions, BattleID)
+	}
+}
+
+// sessionContextKey is the context key of the session sending a websocket event
+type sessionContextKey struct{}
+
+// errNoSession is returned by the control event handlers when the event didn't come from a websocket session e.g. the api
+var errNoSession = errors.New("control events require a websocket session")
+
+// withSession adds the session sending the event to the context passed to its event handler
+func withSession(ctx context.Context, SessionID string) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, SessionID)
+}
+
+// sessionFromContext gets the session sending the event, empty when it didn't come from a websocket session
+func sessionFromContext(ctx context.Context) string {
+	SessionID, _ := ctx.Value(sessionContextKey{}).(string)
+	return SessionID
+}
+
+// ControlTake handles the leader session taking control of the battle from whichever session had it,
+// the other sessions learn they lost it
+func (b *Service) ControlTake(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	SessionID := sessionFromContext(ctx)
+	if SessionID == "" {
+		return nil, errNoSession, false
+	}
+	control.takeOver(BattleID, SessionID)
+	msg := createSocketEvent("control_taken", SessionID, UserID)
+
+	return msg, nil, false
+}
+
+// ControlResume handles a reconnected leader session picking up the control its previous session had, nothing is broadcast
+func (b *Service) ControlResume(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	SessionID := sessionFromContext(ctx)
+	if SessionID == "" {
+		return nil, errNoSession, false
+	}
+	control.resume(BattleID, EventValue, SessionID)
+
+	return nil, nil, false
+}
@@ -0,0 +1,128 @@
+package poker
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// TestSessionControl simulates two leader sessions issuing competing commands
+// and makes sure only the session in control can run them until the other takes over
+func TestSessionControl(t *testing.T) {
+	sc := &sessionControl{sessions: make(map[string]string)}
+
+	if !sc.claim("battle", "tab1") {
+		t.Fatalf(`expected the first leader session to claim control`)
+	}
+	if !sc.claim("battle", "tab1") {
+		t.Fatalf(`expected the controlling session to keep running commands`)
+	}
+	if sc.claim("battle", "tab2") {
+		t.Fatalf(`expected a competing command from the second session to conflict`)
+	}
+	if !sc.claim("other-battle", "tab2") {
+		t.Fatalf(`expected control to be per battle`)
+	}
+
+	sc.takeOver("battle", "tab2")
+	if !sc.claim("battle", "tab2") {
+		t.Fatalf(`expected the second session to run commands after taking over`)
+	}
+	if sc.claim("battle", "tab1") {
+		t.Fatalf(`expected the first session to conflict once control was taken over`)
+	}
+
+	sc.release("battle", "tab1")
+	if sc.claim("battle", "tab1") {
+		t.Fatalf(`expected the first session disconnecting not to release the second sessions control`)
+	}
+	sc.release("battle", "tab2")
+	if !sc.claim("battle", "tab1") {
+		t.Fatalf(`expected the first session to claim control after the controlling session disconnected`)
+	}
+}
+
+// TestSessionControlAcrossLeaders simulates two leaders of the same battle issuing competing commands
+// and makes sure control is held by one session for the battle rather than one per leader
+func TestSessionControlAcrossLeaders(t *testing.T) {
+	sc := &sessionControl{sessions: make(map[string]string)}
+
+	if !sc.claim("battle", "leader1-tab") {
+		t.Fatalf(`expected the first leaders session to claim control`)
+	}
+	if sc.claim("battle", "leader2-tab") {
+		t.Fatalf(`expected another leaders session to conflict with the controlling session`)
+	}
+}
+
+// TestSessionControlResume simulates a leader tab reconnecting before its old connection timed out
+// and makes sure the new session picks up control instead of conflicting with its stale session
+func TestSessionControlResume(t *testing.T) {
+	sc := &sessionControl{sessions: make(map[string]string)}
+
+	if !sc.claim("battle", "old") {
+		t.Fatalf(`expected the leader session to claim control`)
+	}
+
+	sc.resume("battle", "old", "new")
+	if !sc.claim("battle", "new") {
+		t.Fatalf(`expected the reconnected session to resume control`)
+	}
+	if sc.claim("battle", "old") {
+		t.Fatalf(`expected the stale session to have lost control`)
+	}
+
+	sc.release("battle", "old")
+	if !sc.claim("battle", "new") {
+		t.Fatalf(`expected the stale session disconnecting not to release the resumed control`)
+	}
+
+	sc.resume("battle", "unknown", "other")
+	if sc.claim("battle", "other") {
+		t.Fatalf(`expected resuming from a session without control to leave control alone`)
+	}
+}
+
+// TestControlEventHandlers calls the take_control and resume_control event handlers
+// and makes sure they move the battles control to the sending session and reject events without a session
+func TestControlEventHandlers(t *testing.T) {
+	b := &Service{}
+	b.eventHandlers = map[string]func(context.Context, string, string, string) ([]byte, error, bool){
+		"take_control":   b.ControlTake,
+		"resume_control": b.ControlResume,
+	}
+	BattleID := "control-handlers-battle"
+	defer control.release(BattleID, "tab2")
+
+	if !control.claim(BattleID, "tab1") {
+		t.Fatalf(`expected the first session to claim control`)
+	}
+
+	msg, err, _ := b.eventHandlers["take_control"](withSession(context.Background(), "tab2"), BattleID, "leader", "")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	var event socketEvent
+	if err := json.Unmarshal(msg, &event); err != nil {
+		t.Fatalf(`unexpected error decoding event %v`, err)
+	}
+	if event.Type != "control_taken" || event.Value != "tab2" {
+		t.Fatalf(`expected control_taken by tab2 got %s %s`, event.Type, event.Value)
+	}
+	if control.claim(BattleID, "tab1") {
+		t.Fatalf(`expected the first session to lose control once it was taken`)
+	}
+
+	msg, err, _ = b.eventHandlers["resume_control"](withSession(context.Background(), "tab3"), BattleID, "leader", "tab2")
+	if err != nil || msg != nil {
+		t.Fatalf(`expected resume_control to broadcast nothing got %s %v`, msg, err)
+	}
+	if !control.claim(BattleID, "tab3") {
+		t.Fatalf(`expected the reconnected session to resume control`)
+	}
+	defer control.release(BattleID, "tab3")
+
+	if _, err, _ := b.eventHandlers["take_control"](context.Background(), BattleID, "leader", ""); err != errNoSession {
+		t.Fatalf(`expected errNoSession without a session got %v`, err)
+	}
+}
@@ -4,8 +4,29 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"go.uber.org/zap"
 )
 
+// recordEvent appends the action to the game event log, a failure is logged without failing the action
+func (b *Service) recordEvent(ctx context.Context, BattleID string, PlanID string, UserID string, EventType string, Value string) {
+	if err := b.BattleService.RecordGameEvent(BattleID, PlanID, UserID, EventType, Value); err != nil {
+		b.logger.Ctx(ctx).Error("record poker event error", zap.Error(err))
+	}
+}
+
+// voterEvent is the error an event handler returns when its event is only for the user who sent it e.g. their vote
+// was rejected, the event is sent to the sending connection alone rather than broadcast to the arena
+type voterEvent struct {
+	event []byte
+	err   error
+}
+
+func (e *voterEvent) Error() string { return e.err.Error() }
+func (e *voterEvent) Unwrap() error { return e.err }
+
 // UserNudge handles notifying user that they need to vote
 func (b *Service) UserNudge(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
 	msg := createSocketEvent("jab_warrior", EventValue, UserID)
@@ -19,6 +40,7 @@ func (b *Service) UserVote(ctx context.Context, BattleID string, UserID string,
 	var msg []byte
 	var wv struct {
 		VoteValue        string `json:"voteValue"`
+		ComplexityValue  string `json:"complexityValue"`
 		PlanID           string `json:"planId"`
 		AutoFinishVoting bool   `json:"autoFinishVoting"`
 	}
@@ -27,16 +49,38 @@ func (b *Service) UserVote(ctx context.Context, BattleID string, UserID string,
 		return nil, err, false
 	}
 
-	Plans, AllVoted := b.BattleService.SetVote(BattleID, UserID, wv.PlanID, wv.VoteValue)
+	Plans, AllVoted, err := b.BattleService.SetVote(BattleID, UserID, wv.PlanID, wv.VoteValue, wv.ComplexityValue)
+	// a retried vote that didn't change anything has nothing to broadcast
+	if errors.Is(err, thunderdome.ErrVoteUnchanged) {
+		return nil, nil, false
+	}
+	// the story was finalized, voting ended or the game was paused before the vote landed, let the voter know it wasn't counted
+	if errors.Is(err, thunderdome.ErrVotingClosed) || errors.Is(err, thunderdome.ErrGamePaused) {
+		return nil, &voterEvent{createSocketEvent("vote_rejected", wv.PlanID, UserID), err}, false
+	}
+	// the game requires named users, prompt the voter to set a name before voting
+	if errors.Is(err, thunderdome.ErrUserNameRequired) {
+		return nil, &voterEvent{createSocketEvent("name_required", wv.PlanID, UserID), err}, false
+	}
+	if err != nil {
+		return nil, err, false
+	}
+	// the vote value is left out so the log doesn't leak votes during active voting
+	b.recordEvent(ctx, BattleID, wv.PlanID, UserID, "vote_set", "")
 
 	updatedPlans, _ := json.Marshal(Plans)
 	msg = createSocketEvent("vote_activity", string(updatedPlans), UserID)
 
 	if AllVoted && wv.AutoFinishVoting {
-		plans, err := b.BattleService.EndStoryVoting(BattleID, wv.PlanID)
+		plans, err := b.BattleService.EndStoryVoting(BattleID, wv.PlanID, false)
+		// everyone voted but there are fewer active users than the quorum, leave voting open for the facilitator
+		if errors.Is(err, thunderdome.ErrQuorumNotMet) {
+			return msg, nil, false
+		}
 		if err != nil {
 			return nil, err, false
 		}
+		b.recordEvent(ctx, BattleID, wv.PlanID, UserID, "voting_ended", "")
 		updatedPlans, _ := json.Marshal(plans)
 		msg = createSocketEvent("voting_ended", string(updatedPlans), "")
 	}
@@ -127,29 +171,108 @@ func (b *Service) UserSpectatorToggle(ctx context.Context, BattleID string, User
 	return msg, nil, false
 }
 
-// PlanVoteEnd handles ending plan voting
+// UserRevealIdentityToggle handles the user choosing whether to show who they are with their votes in an anonymous battle
+func (b *Service) UserRevealIdentityToggle(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	var ri struct {
+		RevealIdentity bool `json:"revealIdentity"`
+	}
+	err := json.Unmarshal([]byte(EventValue), &ri)
+	if err != nil {
+		return nil, err, false
+	}
+	users, err := b.BattleService.SetUserRevealIdentity(BattleID, UserID, ri.RevealIdentity)
+	if err != nil {
+		return nil, err, false
+	}
+	usersJson, _ := json.Marshal(users)
+
+	msg := createSocketEvent("users_updated", string(usersJson), "")
+
+	return msg, nil, false
+}
+
+// UserHeartbeat handles a client signaling the user is still present, nothing is broadcast
+func (b *Service) UserHeartbeat(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	if err := b.BattleService.UserHeartbeat(BattleID, UserID); err != nil {
+		return nil, err, false
+	}
+
+	return nil, nil, false
+}
+
+// PlanVoteEnd handles ending plan voting, the value is either the plan ID
+// or a JSON object with the planId and whether to override the quorum
 func (b *Service) PlanVoteEnd(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
-	plans, err := b.BattleService.EndStoryVoting(BattleID, EventValue)
+	var p struct {
+		Id             string `json:"planId"`
+		OverrideQuorum bool   `json:"overrideQuorum"`
+	}
+	if err := json.Unmarshal([]byte(EventValue), &p); err != nil {
+		p.Id = EventValue
+	}
+
+	plans, err := b.BattleService.EndStoryVoting(BattleID, p.Id, p.OverrideQuorum)
 	if err != nil {
 		return nil, err, false
 	}
+	b.recordEvent(ctx, BattleID, p.Id, UserID, "voting_ended", "")
 	updatedPlans, _ := json.Marshal(plans)
 	msg := createSocketEvent("voting_ended", string(updatedPlans), "")
 
 	return msg, nil, false
 }
 
+// PlanVoteCancel handles undoing a plan activated by mistake
+func (b *Service) PlanVoteCancel(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	plans, err := b.BattleService.CancelStoryVoting(BattleID, EventValue, UserID)
+	if err != nil {
+		return nil, err, false
+	}
+	b.recordEvent(ctx, BattleID, EventValue, UserID, "voting_cancelled", "")
+	updatedPlans, _ := json.Marshal(plans)
+	msg := createSocketEvent("voting_cancelled", string(updatedPlans), "")
+
+	return msg, nil, false
+}
+
+// PlanRevote handles clearing the active plans votes and prompting everyone to vote again
+func (b *Service) PlanRevote(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	plans, err := b.BattleService.CallForRevote(BattleID, UserID)
+	if err != nil {
+		return nil, err, false
+	}
+	updatedPlans, _ := json.Marshal(plans)
+	msg := createSocketEvent("revote_called", string(updatedPlans), "")
+
+	return msg, nil, false
+}
+
+// PlanVotesReveal handles revealing the active plans votes to everyone at once without ending voting
+func (b *Service) PlanVotesReveal(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	plans, err := b.BattleService.RevealStoryVotes(BattleID, EventValue, UserID)
+	if err != nil {
+		return nil, err, false
+	}
+	b.recordEvent(ctx, BattleID, EventValue, UserID, "votes_revealed", "")
+	updatedPlans, _ := json.Marshal(plans)
+	msg := createSocketEvent("votes_revealed", string(updatedPlans), "")
+
+	return msg, nil, false
+}
+
 // Revise handles editing the battle settings
 func (b *Service) Revise(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
 	var rb struct {
-		BattleName           string   `json:"battleName"`
-		PointValuesAllowed   []string `json:"pointValuesAllowed"`
-		AutoFinishVoting     bool     `json:"autoFinishVoting"`
-		PointAverageRounding string   `json:"pointAverageRounding"`
-		HideVoterIdentity    bool     `json:"hideVoterIdentity"`
-		JoinCode             string   `json:"joinCode"`
-		LeaderCode           string   `json:"leaderCode"`
-		TeamID               string   `json:"teamId"`
+		BattleName           string                   `json:"battleName"`
+		PointValuesAllowed   []string                 `json:"pointValuesAllowed"`
+		AutoFinishVoting     bool                     `json:"autoFinishVoting"`
+		PointAverageRounding string                   `json:"pointAverageRounding"`
+		HideVoterIdentity    bool                     `json:"hideVoterIdentity"`
+		JoinCode             string                   `json:"joinCode"`
+		LeaderCode           string                   `json:"leaderCode"`
+		TeamID               string                   `json:"teamId"`
+		VoteMode             string                   `json:"voteMode"`
+		CustomScale          []thunderdome.ScaleValue `json:"customScale"`
 	}
 	err := json.Unmarshal([]byte(EventValue), &rb)
 	if err != nil {
@@ -166,12 +289,30 @@ func (b *Service) Revise(ctx context.Context, BattleID string, UserID string, Ev
 		rb.JoinCode,
 		rb.LeaderCode,
 		rb.TeamID,
+		rb.VoteMode,
 	)
 	if err != nil {
 		return nil, err, false
 	}
 
+	// only change the custom scale when included so clients unaware of it don't clear it
+	if rb.CustomScale != nil {
+		err = b.BattleService.SetGameCustomScale(BattleID, rb.CustomScale)
+		if err != nil {
+			return nil, err, false
+		}
+	}
+
 	rb.LeaderCode = ""
+	if rb.VoteMode == thunderdome.PokerVoteModeFistOfFive {
+		rb.PointValuesAllowed = thunderdome.FistOfFiveValues
+	}
+	if len(rb.CustomScale) > 0 {
+		rb.PointValuesAllowed = make([]string, 0, len(rb.CustomScale))
+		for _, sv := range rb.CustomScale {
+			rb.PointValuesAllowed = append(rb.PointValuesAllowed, sv.Label)
+		}
+	}
 
 	updatedBattle, _ := json.Marshal(rb)
 	msg := createSocketEvent("battle_revised", string(updatedBattle), "")
@@ -190,6 +331,41 @@ func (b *Service) Delete(ctx context.Context, BattleID string, UserID string, Ev
 	return msg, nil, false
 }
 
+// Archive handles archiving the battle making it read only
+func (b *Service) Archive(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	err := b.BattleService.ArchiveGame(BattleID)
+	if err != nil {
+		return nil, err, false
+	}
+	msg := createSocketEvent("battle_archived", "", "")
+
+	return msg, nil, false
+}
+
+// Pause handles pausing the battle so votes and plan changes are rejected until it's resumed
+func (b *Service) Pause(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	err := b.BattleService.PauseGame(BattleID, UserID)
+	if err != nil {
+		return nil, err, false
+	}
+	b.recordEvent(ctx, BattleID, "", UserID, "battle_paused", "")
+	msg := createSocketEvent("battle_paused", "", "")
+
+	return msg, nil, false
+}
+
+// Resume handles resuming the paused battle
+func (b *Service) Resume(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	err := b.BattleService.ResumeGame(BattleID, UserID)
+	if err != nil {
+		return nil, err, false
+	}
+	b.recordEvent(ctx, BattleID, "", UserID, "battle_resumed", "")
+	msg := createSocketEvent("battle_resumed", "", "")
+
+	return msg, nil, false
+}
+
 // PlanAdd adds a new plan to the battle
 func (b *Service) PlanAdd(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
 	var p struct {
@@ -200,22 +376,51 @@ func (b *Service) PlanAdd(ctx context.Context, BattleID string, UserID string, E
 		Description        string `json:"description"`
 		AcceptanceCriteria string `json:"acceptanceCriteria"`
 		Priority           int32  `json:"priority"`
+		IdempotencyKey     string `json:"idempotencyKey"`
 	}
 	err := json.Unmarshal([]byte(EventValue), &p)
 	if err != nil {
 		return nil, err, false
 	}
 
-	plans, err := b.BattleService.CreateStory(BattleID, p.Name, p.Type, p.ReferenceId, p.Link, p.Description, p.AcceptanceCriteria, p.Priority)
+	plans, err := b.BattleService.CreateStory(BattleID, p.Name, p.Type, p.ReferenceId, p.Link, p.Description, p.AcceptanceCriteria, p.Priority, p.IdempotencyKey)
 	if err != nil {
 		return nil, err, false
 	}
+	b.recordEvent(ctx, BattleID, "", UserID, "plan_added", p.Name)
 	updatedPlans, _ := json.Marshal(plans)
 	msg := createSocketEvent("plan_added", string(updatedPlans), "")
 
 	return msg, nil, false
 }
 
+// SettingsUpdate handles updating several battle settings together
+func (b *Service) SettingsUpdate(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	var settings thunderdome.PokerSettings
+	if err := json.Unmarshal([]byte(EventValue), &settings); err != nil {
+		return nil, err, false
+	}
+
+	battle, err := b.BattleService.UpdateGameSettings(BattleID, UserID, settings)
+	if err != nil {
+		return nil, err, false
+	}
+
+	// only the settings are broadcast as the battle is loaded for the leader including their own vote
+	updatedSettings, _ := json.Marshal(thunderdome.PokerSettings{
+		AutoFinishVoting:     &battle.AutoFinishVoting,
+		PointAverageRounding: &battle.PointAverageRounding,
+		HideVoterIdentity:    &battle.HideVoterIdentity,
+		EstimationUnit:       &battle.EstimationUnit,
+		TieBreakStrategy:     &battle.TieBreakStrategy,
+		MinVotersToFinalize:  &battle.MinVotersToFinalize,
+		VoteRevealThreshold:  &battle.VoteRevealThreshold,
+	})
+	msg := createSocketEvent("settings_updated", string(updatedSettings), "")
+
+	return msg, nil, false
+}
+
 // PlanRevise handles editing a battle plan
 func (b *Service) PlanRevise(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
 	var p struct {
@@ -261,6 +466,7 @@ func (b *Service) PlanActivate(ctx context.Context, BattleID string, UserID stri
 	if err != nil {
 		return nil, err, false
 	}
+	b.recordEvent(ctx, BattleID, EventValue, UserID, "plan_activated", "")
 	updatedPlans, _ := json.Marshal(plans)
 	msg := createSocketEvent("plan_activated", string(updatedPlans), "")
 
@@ -273,33 +479,152 @@ func (b *Service) PlanSkip(ctx context.Context, BattleID string, UserID string,
 	if err != nil {
 		return nil, err, false
 	}
+	b.recordEvent(ctx, BattleID, EventValue, UserID, "plan_skipped", "")
 	updatedPlans, _ := json.Marshal(plans)
 	msg := createSocketEvent("plan_skipped", string(updatedPlans), "")
 
 	return msg, nil, false
 }
 
+// PlanFinalizeAuto handles setting a plan point value to the estimate recommended from its votes
+func (b *Service) PlanFinalizeAuto(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	plans, err := b.BattleService.FinalizeStoryAuto(BattleID, EventValue, UserID)
+	if err != nil {
+		return nil, err, false
+	}
+	var Points string
+	for _, p := range plans {
+		if p.Id == EventValue {
+			Points = p.Points
+		}
+	}
+	b.recordEvent(ctx, BattleID, EventValue, UserID, "plan_finalized", Points)
+	updatedPlans, _ := json.Marshal(plans)
+	msg := createSocketEvent("plan_finalized", string(updatedPlans), "")
+
+	return msg, nil, false
+}
+
+// PlanLock handles locking a finalized plan against further changes
+func (b *Service) PlanLock(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	plans, err := b.BattleService.LockStory(BattleID, EventValue, UserID)
+	if err != nil {
+		return nil, err, false
+	}
+	b.recordEvent(ctx, BattleID, EventValue, UserID, "plan_locked", "")
+	updatedPlans, _ := json.Marshal(plans)
+	msg := createSocketEvent("plan_locked", string(updatedPlans), "")
+
+	return msg, nil, false
+}
+
+// PlanUnlock handles unlocking a plan so it can be changed again
+func (b *Service) PlanUnlock(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	plans, err := b.BattleService.UnlockStory(BattleID, EventValue, UserID)
+	if err != nil {
+		return nil, err, false
+	}
+	b.recordEvent(ctx, BattleID, EventValue, UserID, "plan_unlocked", "")
+	updatedPlans, _ := json.Marshal(plans)
+	msg := createSocketEvent("plan_unlocked", string(updatedPlans), "")
+
+	return msg, nil, false
+}
+
+// PlanGroup handles moving a plan into a parallel voting group
+func (b *Service) PlanGroup(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	var p struct {
+		Id      string `json:"planId"`
+		GroupID string `json:"groupId"`
+	}
+	err := json.Unmarshal([]byte(EventValue), &p)
+	if err != nil {
+		return nil, err, false
+	}
+
+	plans, err := b.BattleService.SetStoryGroup(BattleID, p.Id, p.GroupID)
+	if err != nil {
+		return nil, err, false
+	}
+	b.recordEvent(ctx, BattleID, p.Id, UserID, "plan_grouped", p.GroupID)
+	updatedPlans, _ := json.Marshal(plans)
+	msg := createSocketEvent("plan_grouped", string(updatedPlans), "")
+
+	return msg, nil, false
+}
+
 // PlanFinalize handles setting a plan point value
 func (b *Service) PlanFinalize(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
 	var p struct {
-		Id     string `json:"planId"`
-		Points string `json:"planPoints"`
+		Id             string `json:"planId"`
+		Points         string `json:"planPoints"`
+		OverrideQuorum bool   `json:"overrideQuorum"`
 	}
 	err := json.Unmarshal([]byte(EventValue), &p)
 	if err != nil {
 		return nil, err, false
 	}
 
-	plans, err := b.BattleService.FinalizeStory(BattleID, p.Id, p.Points)
+	plans, err := b.BattleService.FinalizeStory(BattleID, p.Id, p.Points, p.OverrideQuorum)
 	if err != nil {
 		return nil, err, false
 	}
+	b.recordEvent(ctx, BattleID, p.Id, UserID, "plan_finalized", p.Points)
 	updatedPlans, _ := json.Marshal(plans)
 	msg := createSocketEvent("plan_finalized", string(updatedPlans), "")
 
 	return msg, nil, false
 }
 
+// PlanFinalizeActive handles setting the point value of whichever plan is active, the value is the points
+func (b *Service) PlanFinalizeActive(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	plans, PlanID, err := b.BattleService.FinalizeActiveStory(BattleID, UserID, EventValue)
+	if err != nil {
+		return nil, err, false
+	}
+	b.recordEvent(ctx, BattleID, PlanID, UserID, "plan_finalized", EventValue)
+	updatedPlans, _ := json.Marshal(plans)
+	msg := createSocketEvent("plan_finalized", string(updatedPlans), "")
+
+	return msg, nil, false
+}
+
+// PlansFinalize handles setting the same points on multiple plans at once
+func (b *Service) PlansFinalize(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	var p struct {
+		Ids    []string `json:"planIds"`
+		Points string   `json:"planPoints"`
+	}
+	err := json.Unmarshal([]byte(EventValue), &p)
+	if err != nil {
+		return nil, err, false
+	}
+
+	plans, err := b.BattleService.FinalizeStoriesBulk(BattleID, UserID, p.Ids, p.Points)
+	if err != nil {
+		return nil, err, false
+	}
+	for _, id := range p.Ids {
+		b.recordEvent(ctx, BattleID, id, UserID, "plan_finalized", p.Points)
+	}
+	updatedPlans, _ := json.Marshal(plans)
+	msg := createSocketEvent("plan_finalized", string(updatedPlans), "")
+
+	return msg, nil, false
+}
+
+// PlansRequeue handles moving the unestimated and skipped plans to the end of the list for a cleanup pass
+func (b *Service) PlansRequeue(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
+	plans, err := b.BattleService.RequeueUnestimated(BattleID)
+	if err != nil {
+		return nil, err, false
+	}
+	updatedPlans, _ := json.Marshal(plans)
+	msg := createSocketEvent("plans_requeued", string(updatedPlans), "")
+
+	return msg, nil, false
+}
+
 // Abandon handles setting abandoned true so battle doesn't show up in users battle list, then leaves battle
 func (b *Service) Abandon(ctx context.Context, BattleID string, UserID string, EventValue string) ([]byte, error, bool) {
 	_, err := b.BattleService.AbandonGame(BattleID, UserID)
@@ -310,6 +635,51 @@ func (b *Service) Abandon(ctx context.Context, BattleID string, UserID string, E
 	return nil, errors.New("ABANDONED_BATTLE"), true
 }
 
+// snapshotOperations contains the operations that change the battle enough to follow their event
+// with the full battle so clients that missed an earlier event resync without polling
+var snapshotOperations = map[string]struct{}{
+	"activate_plan":   {},
+	"skip_plan":       {},
+	"end_voting":      {},
+	"cancel_voting":   {},
+	"finalize_plan":   {},
+	"finalize_active": {},
+	"auto_finalize":   {},
+	"finalize_plans":  {},
+	"call_revote":     {},
+}
+
+// broadcastMessages lists the messages to broadcast for the event in order, snapshot operations are followed
+// by the battle_snapshot event while every other event only sends its own change to keep payloads small
+func (b *Service) broadcastMessages(ctx context.Context, BattleID string, EventType string, msg []byte) [][]byte {
+	messages := [][]byte{msg}
+	if _, ok := snapshotOperations[EventType]; !ok {
+		return messages
+	}
+
+	if snapshot := b.battleSnapshot(ctx, BattleID); snapshot != nil {
+		messages = append(messages, snapshot)
+	}
+
+	return messages
+}
+
+// battleSnapshot builds the battle_snapshot event with the full battle, the battle is read without a user
+// so no ones own votes end up in a message sent to everyone, and the join and facilitator codes are left out
+// as everyone in the arena gets the snapshot
+func (b *Service) battleSnapshot(ctx context.Context, BattleID string) []byte {
+	battle, err := b.BattleService.GetGame(BattleID, "")
+	if err != nil {
+		b.logger.Ctx(ctx).Error("get battle snapshot error", zap.Error(err))
+		return nil
+	}
+	battle.JoinCode = ""
+	battle.FacilitatorCode = ""
+	Battle, _ := json.Marshal(battle)
+
+	return createSocketEvent("battle_snapshot", string(Battle), "")
+}
+
 // socketEvent is the event structure used for socket messages
 type socketEvent struct {
 	Type  string `json:"type"`
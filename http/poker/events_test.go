@@ -0,0 +1,494 @@
+package poker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+)
+
+// revotePokerDataSvc stubs CallForRevote returning the active story with its votes cleared
+type revotePokerDataSvc struct {
+	thunderdome.PokerDataSvc
+	facilitatorID string
+}
+
+func (s *revotePokerDataSvc) CallForRevote(PokerID string, FacilitatorID string) ([]*thunderdome.Story, error) {
+	s.facilitatorID = FacilitatorID
+	return []*thunderdome.Story{
+		{Id: "story", Active: true, Votes: make([]*thunderdome.Vote, 0)},
+	}, nil
+}
+
+// TestPlanRevote calls PlanRevote and makes sure the revote_called event is built with the cleared votes
+func TestPlanRevote(t *testing.T) {
+	svc := &revotePokerDataSvc{}
+	b := &Service{BattleService: svc}
+
+	msg, err, _ := b.PlanRevote(context.Background(), "battle", "leader", "")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if svc.facilitatorID != "leader" {
+		t.Fatalf(`expected revote called by leader got %q`, svc.facilitatorID)
+	}
+
+	var event socketEvent
+	if err := json.Unmarshal(msg, &event); err != nil {
+		t.Fatalf(`unexpected error decoding event %v`, err)
+	}
+	if event.Type != "revote_called" {
+		t.Fatalf(`expected event type: revote_called got %q`, event.Type)
+	}
+
+	var plans []*thunderdome.Story
+	_ = json.Unmarshal([]byte(event.Value), &plans)
+	if len(plans) != 1 || len(plans[0].Votes) != 0 {
+		t.Fatalf(`expected active plan with cleared votes got %v`, event.Value)
+	}
+}
+
+// revealPokerDataSvc stubs revealing the active story votes and records the logged game events
+type revealPokerDataSvc struct {
+	thunderdome.PokerDataSvc
+	events []thunderdome.PokerEvent
+}
+
+func (s *revealPokerDataSvc) RecordGameEvent(PokerID string, StoryID string, UserID string, EventType string, Value string) error {
+	s.events = append(s.events, thunderdome.PokerEvent{PokerID: PokerID, StoryID: StoryID, UserID: UserID, Type: EventType, Value: Value})
+	return nil
+}
+
+func (s *revealPokerDataSvc) RevealStoryVotes(PokerID string, StoryID string, FacilitatorID string) ([]*thunderdome.Story, error) {
+	return []*thunderdome.Story{
+		{Id: StoryID, Active: true, VotesRevealed: true, Votes: []*thunderdome.Vote{{UserId: "voter", VoteValue: "5"}}},
+	}, nil
+}
+
+// TestPlanVotesReveal calls PlanVotesReveal before voting ends
+// and makes sure the votes_revealed event carries the still active plan with its votes
+func TestPlanVotesReveal(t *testing.T) {
+	svc := &revealPokerDataSvc{}
+	b := &Service{BattleService: svc}
+
+	msg, err, _ := b.PlanVotesReveal(context.Background(), "battle", "leader", "story")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+
+	var event socketEvent
+	if err := json.Unmarshal(msg, &event); err != nil {
+		t.Fatalf(`unexpected error decoding event %v`, err)
+	}
+	if event.Type != "votes_revealed" {
+		t.Fatalf(`expected event type: votes_revealed got %q`, event.Type)
+	}
+
+	var plans []*thunderdome.Story
+	_ = json.Unmarshal([]byte(event.Value), &plans)
+	if len(plans) != 1 || !plans[0].Active || !plans[0].VotesRevealed || plans[0].Votes[0].VoteValue != "5" {
+		t.Fatalf(`expected active revealed plan with votes got %v`, event.Value)
+	}
+	if len(svc.events) != 1 || svc.events[0].Type != "votes_revealed" || svc.events[0].UserID != "leader" {
+		t.Fatalf(`expected reveal to be recorded by leader got %+v`, svc.events)
+	}
+}
+
+// unchangedVotePokerDataSvc stubs SetVote as an identical re-vote
+type unchangedVotePokerDataSvc struct {
+	thunderdome.PokerDataSvc
+}
+
+func (s *unchangedVotePokerDataSvc) SetVote(PokerID string, UserID string, StoryID string, VoteValue string, ComplexityValue string) ([]*thunderdome.Story, bool, error) {
+	return nil, false, thunderdome.ErrVoteUnchanged
+}
+
+// TestUserVoteUnchanged calls UserVote with an identical re-vote
+// and makes sure no event is built for broadcast and voting isn't ended
+func TestUserVoteUnchanged(t *testing.T) {
+	b := &Service{BattleService: &unchangedVotePokerDataSvc{}}
+
+	msg, err, _ := b.UserVote(context.Background(), "battle", "user", `{"voteValue":"3","planId":"story","autoFinishVoting":true}`)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if msg != nil {
+		t.Fatalf(`expected no event for an unchanged vote got %s`, msg)
+	}
+}
+
+// closedVotePokerDataSvc stubs SetVote as a vote landing after the story was finalized
+type closedVotePokerDataSvc struct {
+	thunderdome.PokerDataSvc
+}
+
+func (s *closedVotePokerDataSvc) SetVote(PokerID string, UserID string, StoryID string, VoteValue string, ComplexityValue string) ([]*thunderdome.Story, bool, error) {
+	return nil, false, thunderdome.ErrVotingClosed
+}
+
+// TestUserVoteClosed calls UserVote for a story finalized while the vote was in flight
+// and makes sure a vote_rejected event is built for the voter alone instead of being broadcast
+func TestUserVoteClosed(t *testing.T) {
+	b := &Service{BattleService: &closedVotePokerDataSvc{}}
+
+	msg, err, _ := b.UserVote(context.Background(), "battle", "user", `{"voteValue":"3","planId":"story","autoFinishVoting":true}`)
+	if msg != nil {
+		t.Fatalf(`expected nothing to broadcast got %s`, msg)
+	}
+	var ve *voterEvent
+	if !errors.As(err, &ve) || !errors.Is(err, thunderdome.ErrVotingClosed) {
+		t.Fatalf(`expected a voter event wrapping ErrVotingClosed got %v`, err)
+	}
+	if string(ve.event) != string(createSocketEvent("vote_rejected", "story", "user")) {
+		t.Fatalf(`expected vote_rejected event for the voter got %s`, ve.event)
+	}
+}
+
+// unnamedVotePokerDataSvc stubs SetVote as a vote from a guest without a name in a game requiring named users
+type unnamedVotePokerDataSvc struct {
+	thunderdome.PokerDataSvc
+}
+
+func (s *unnamedVotePokerDataSvc) SetVote(PokerID string, UserID string, StoryID string, VoteValue string, ComplexityValue string) ([]*thunderdome.Story, bool, error) {
+	return nil, false, thunderdome.ErrUserNameRequired
+}
+
+// TestUserVoteNameRequired calls UserVote for a voter without a name in a game requiring named users
+// and makes sure a name_required event is built for the voter alone instead of being broadcast
+func TestUserVoteNameRequired(t *testing.T) {
+	b := &Service{BattleService: &unnamedVotePokerDataSvc{}}
+
+	msg, err, _ := b.UserVote(context.Background(), "battle", "user", `{"voteValue":"3","planId":"story"}`)
+	if msg != nil {
+		t.Fatalf(`expected nothing to broadcast got %s`, msg)
+	}
+	var ve *voterEvent
+	if !errors.As(err, &ve) || !errors.Is(err, thunderdome.ErrUserNameRequired) {
+		t.Fatalf(`expected a voter event wrapping ErrUserNameRequired got %v`, err)
+	}
+	if string(ve.event) != string(createSocketEvent("name_required", "story", "user")) {
+		t.Fatalf(`expected name_required event for the voter got %s`, ve.event)
+	}
+}
+
+// quorumPokerDataSvc stubs ending voting and finalizing as below quorum unless overridden
+type quorumPokerDataSvc struct {
+	thunderdome.PokerDataSvc
+	storyID string
+}
+
+func (s *quorumPokerDataSvc) RecordGameEvent(PokerID string, StoryID string, UserID string, EventType string, Value string) error {
+	return nil
+}
+
+func (s *quorumPokerDataSvc) SetVote(PokerID string, UserID string, StoryID string, VoteValue string, ComplexityValue string) ([]*thunderdome.Story, bool, error) {
+	return []*thunderdome.Story{{Id: StoryID, Active: true}}, true, nil
+}
+
+func (s *quorumPokerDataSvc) EndStoryVoting(PokerID string, StoryID string, OverrideQuorum bool) ([]*thunderdome.Story, error) {
+	s.storyID = StoryID
+	if !OverrideQuorum {
+		return nil, thunderdome.ErrQuorumNotMet
+	}
+	return []*thunderdome.Story{{Id: StoryID}}, nil
+}
+
+func (s *quorumPokerDataSvc) FinalizeStory(PokerID string, StoryID string, Points string, OverrideQuorum bool) ([]*thunderdome.Story, error) {
+	if !OverrideQuorum {
+		return nil, thunderdome.ErrQuorumNotMet
+	}
+	return []*thunderdome.Story{{Id: StoryID, Points: Points}}, nil
+}
+
+// TestPlanFinalizeQuorum calls PlanFinalize below quorum with and without the override
+// and makes sure only the override finalizes the plan
+func TestPlanFinalizeQuorum(t *testing.T) {
+	b := &Service{BattleService: &quorumPokerDataSvc{}}
+
+	if _, err, _ := b.PlanFinalize(context.Background(), "battle", "leader", `{"planId":"story","planPoints":"3"}`); !errors.Is(err, thunderdome.ErrQuorumNotMet) {
+		t.Fatalf(`expected quorum not met error got %v`, err)
+	}
+
+	msg, err, _ := b.PlanFinalize(context.Background(), "battle", "leader", `{"planId":"story","planPoints":"3","overrideQuorum":true}`)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	var event socketEvent
+	_ = json.Unmarshal(msg, &event)
+	if event.Type != "plan_finalized" {
+		t.Fatalf(`expected event type: plan_finalized got %q`, event.Type)
+	}
+}
+
+// TestPlanVoteEndQuorum calls PlanVoteEnd with a plain plan ID and an override
+// and makes sure both value formats are accepted and only the override ends voting
+func TestPlanVoteEndQuorum(t *testing.T) {
+	svc := &quorumPokerDataSvc{}
+	b := &Service{BattleService: svc}
+
+	if _, err, _ := b.PlanVoteEnd(context.Background(), "battle", "leader", "story"); !errors.Is(err, thunderdome.ErrQuorumNotMet) {
+		t.Fatalf(`expected quorum not met error got %v`, err)
+	}
+	if svc.storyID != "story" {
+		t.Fatalf(`expected plain plan ID to be used got %q`, svc.storyID)
+	}
+
+	if _, err, _ := b.PlanVoteEnd(context.Background(), "battle", "leader", `{"planId":"story","overrideQuorum":true}`); err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+}
+
+// TestUserVoteAutoFinishBelowQuorum calls UserVote when everyone voted but the quorum isn't met
+// and makes sure the vote is still broadcast with voting left open
+func TestUserVoteAutoFinishBelowQuorum(t *testing.T) {
+	b := &Service{BattleService: &quorumPokerDataSvc{}}
+
+	msg, err, _ := b.UserVote(context.Background(), "battle", "user", `{"voteValue":"3","planId":"story","autoFinishVoting":true}`)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	var event socketEvent
+	_ = json.Unmarshal(msg, &event)
+	if event.Type != "vote_activity" {
+		t.Fatalf(`expected event type: vote_activity got %q`, event.Type)
+	}
+}
+
+// eventLogPokerDataSvc stubs the main plan actions and records the logged game events
+type eventLogPokerDataSvc struct {
+	thunderdome.PokerDataSvc
+	events []thunderdome.PokerEvent
+}
+
+func (s *eventLogPokerDataSvc) RecordGameEvent(PokerID string, StoryID string, UserID string, EventType string, Value string) error {
+	s.events = append(s.events, thunderdome.PokerEvent{PokerID: PokerID, StoryID: StoryID, UserID: UserID, Type: EventType, Value: Value})
+	return nil
+}
+
+func (s *eventLogPokerDataSvc) CreateStory(PokerID string, Name string, Type string, ReferenceID string, Link string, Description string, AcceptanceCriteria string, Priority int32, IdempotencyKey string) ([]*thunderdome.Story, error) {
+	return []*thunderdome.Story{{Id: "story", Name: Name}}, nil
+}
+
+func (s *eventLogPokerDataSvc) ActivateStoryVoting(PokerID string, StoryID string) ([]*thunderdome.Story, error) {
+	return []*thunderdome.Story{{Id: StoryID, Active: true}}, nil
+}
+
+func (s *eventLogPokerDataSvc) SetVote(PokerID string, UserID string, StoryID string, VoteValue string, ComplexityValue string) ([]*thunderdome.Story, bool, error) {
+	return []*thunderdome.Story{{Id: StoryID, Active: true}}, false, nil
+}
+
+func (s *eventLogPokerDataSvc) FinalizeStory(PokerID string, StoryID string, Points string, OverrideQuorum bool) ([]*thunderdome.Story, error) {
+	return []*thunderdome.Story{{Id: StoryID, Points: Points}}, nil
+}
+
+// TestEventLogRecordsMainActions runs the add, activate, vote, and finalize plan events
+// and makes sure each is recorded with its actor and the vote value is left out
+func TestEventLogRecordsMainActions(t *testing.T) {
+	svc := &eventLogPokerDataSvc{}
+	b := &Service{BattleService: svc}
+	ctx := context.Background()
+
+	_, _, _ = b.PlanAdd(ctx, "battle", "leader", `{"planName":"Login page"}`)
+	_, _, _ = b.PlanActivate(ctx, "battle", "leader", "story")
+	_, _, _ = b.UserVote(ctx, "battle", "voter", `{"voteValue":"8","planId":"story"}`)
+	_, _, _ = b.PlanFinalize(ctx, "battle", "leader", `{"planId":"story","planPoints":"5"}`)
+
+	expected := []thunderdome.PokerEvent{
+		{PokerID: "battle", UserID: "leader", Type: "plan_added", Value: "Login page"},
+		{PokerID: "battle", StoryID: "story", UserID: "leader", Type: "plan_activated"},
+		{PokerID: "battle", StoryID: "story", UserID: "voter", Type: "vote_set"},
+		{PokerID: "battle", StoryID: "story", UserID: "leader", Type: "plan_finalized", Value: "5"},
+	}
+	if len(svc.events) != len(expected) {
+		t.Fatalf(`expected %d events got %d`, len(expected), len(svc.events))
+	}
+	for i, e := range expected {
+		if svc.events[i] != e {
+			t.Fatalf(`expected event %d: %+v got %+v`, i, e, svc.events[i])
+		}
+	}
+}
+
+// settingsPokerDataSvc stubs UpdateGameSettings applying the settings to a game
+type settingsPokerDataSvc struct {
+	thunderdome.PokerDataSvc
+	settings thunderdome.PokerSettings
+}
+
+func (s *settingsPokerDataSvc) UpdateGameSettings(PokerID string, FacilitatorID string, Settings thunderdome.PokerSettings) (*thunderdome.Poker, error) {
+	s.settings = Settings
+	game := &thunderdome.Poker{
+		Id:                   PokerID,
+		AutoFinishVoting:     true,
+		PointAverageRounding: "ceil",
+		EstimationUnit:       thunderdome.EstimationUnitPoints,
+		TieBreakStrategy:     thunderdome.TieBreakRoundUp,
+		Stories:              []*thunderdome.Story{{Id: "story", Votes: []*thunderdome.Vote{{UserId: FacilitatorID, VoteValue: "3"}}}},
+	}
+	if Settings.MinVotersToFinalize != nil {
+		game.MinVotersToFinalize = *Settings.MinVotersToFinalize
+	}
+
+	return game, nil
+}
+
+// TestSettingsUpdate calls SettingsUpdate with a subset of settings
+// and makes sure only the set settings are passed on and the broadcast holds the settings without stories
+func TestSettingsUpdate(t *testing.T) {
+	svc := &settingsPokerDataSvc{}
+	b := &Service{BattleService: svc}
+
+	msg, err, _ := b.SettingsUpdate(context.Background(), "battle", "leader", `{"minVotersToFinalize":3}`)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if svc.settings.MinVotersToFinalize == nil || *svc.settings.MinVotersToFinalize != 3 {
+		t.Fatalf(`expected min voters setting of 3 passed on`)
+	}
+	if svc.settings.AutoFinishVoting != nil || svc.settings.HideVoterIdentity != nil {
+		t.Fatalf(`expected unset settings to stay unset`)
+	}
+
+	var event socketEvent
+	if err := json.Unmarshal(msg, &event); err != nil {
+		t.Fatalf(`unexpected error decoding event %v`, err)
+	}
+	if event.Type != "settings_updated" {
+		t.Fatalf(`expected event type: settings_updated got %q`, event.Type)
+	}
+	var broadcast map[string]interface{}
+	_ = json.Unmarshal([]byte(event.Value), &broadcast)
+	if broadcast["minVotersToFinalize"] != float64(3) || broadcast["autoFinishVoting"] != true {
+		t.Fatalf(`expected current settings broadcast got %v`, event.Value)
+	}
+	if _, ok := broadcast["plans"]; ok {
+		t.Fatalf(`expected stories not to be broadcast`)
+	}
+}
+
+// snapshotPokerDataSvc stubs finalizing a story and reading the battle after it for the snapshot
+type snapshotPokerDataSvc struct {
+	thunderdome.PokerDataSvc
+	snapshotUserID *string
+}
+
+func (s *snapshotPokerDataSvc) RecordGameEvent(PokerID string, StoryID string, UserID string, EventType string, Value string) error {
+	return nil
+}
+
+func (s *snapshotPokerDataSvc) FinalizeStory(PokerID string, StoryID string, Points string, OverrideQuorum bool) ([]*thunderdome.Story, error) {
+	return []*thunderdome.Story{{Id: StoryID, Points: Points}}, nil
+}
+
+func (s *snapshotPokerDataSvc) GetGame(PokerID string, UserID string) (*thunderdome.Poker, error) {
+	s.snapshotUserID = &UserID
+	return &thunderdome.Poker{
+		Id: PokerID, Version: 7, Stories: []*thunderdome.Story{{Id: "story", Points: "5"}},
+		JoinCode: "open-sesame", FacilitatorCode: "lead-on",
+	}, nil
+}
+
+// TestBroadcastMessagesSnapshot calls broadcastMessages after PlanFinalize and a vote
+// and makes sure only the finalize is followed by a battle_snapshot of the full battle read without a user
+// and without its join or facilitator codes
+func TestBroadcastMessagesSnapshot(t *testing.T) {
+	svc := &snapshotPokerDataSvc{}
+	b := &Service{BattleService: svc}
+	ctx := context.Background()
+
+	msg, err, _ := b.PlanFinalize(ctx, "battle", "leader", `{"planId":"story","planPoints":"5"}`)
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+
+	messages := b.broadcastMessages(ctx, "battle", "finalize_plan", msg)
+	if len(messages) != 2 || string(messages[0]) != string(msg) {
+		t.Fatalf(`expected the plan_finalized event followed by a snapshot got %d messages`, len(messages))
+	}
+	if svc.snapshotUserID == nil || *svc.snapshotUserID != "" {
+		t.Fatalf(`expected the snapshot battle to be read without a user got %v`, svc.snapshotUserID)
+	}
+
+	var event socketEvent
+	if err := json.Unmarshal(messages[1], &event); err != nil {
+		t.Fatalf(`unexpected error decoding event %v`, err)
+	}
+	if event.Type != "battle_snapshot" {
+		t.Fatalf(`expected event type: battle_snapshot got %q`, event.Type)
+	}
+	var battle thunderdome.Poker
+	_ = json.Unmarshal([]byte(event.Value), &battle)
+	if battle.Id != "battle" || battle.Version != 7 || len(battle.Stories) != 1 || battle.Stories[0].Points != "5" {
+		t.Fatalf(`expected the full battle in the snapshot got %v`, event.Value)
+	}
+	if strings.Contains(event.Value, "open-sesame") || strings.Contains(event.Value, "lead-on") {
+		t.Fatalf(`expected the join and facilitator codes left out of the snapshot got %v`, event.Value)
+	}
+
+	svc.snapshotUserID = nil
+	voted := createSocketEvent("vote_activity", "", "user")
+	if messages := b.broadcastMessages(ctx, "battle", "vote", voted); len(messages) != 1 || svc.snapshotUserID != nil {
+		t.Fatalf(`expected a vote to only broadcast its own event got %d messages`, len(messages))
+	}
+}
+
+// TestBroadcastMessagesSnapshotOperations calls broadcastMessages for the operations that move voting along
+// and makes sure each one is followed by a battle_snapshot
+func TestBroadcastMessagesSnapshotOperations(t *testing.T) {
+	b := &Service{BattleService: &snapshotPokerDataSvc{}}
+	ctx := context.Background()
+
+	for _, eventType := range []string{"activate_plan", "skip_plan", "end_voting", "cancel_voting", "call_revote"} {
+		msg := createSocketEvent(eventType, "", "leader")
+		messages := b.broadcastMessages(ctx, "battle", eventType, msg)
+		if len(messages) != 2 {
+			t.Fatalf(`expected %s to be followed by a snapshot got %d messages`, eventType, len(messages))
+		}
+		var event socketEvent
+		if err := json.Unmarshal(messages[1], &event); err != nil || event.Type != "battle_snapshot" {
+			t.Fatalf(`expected %s to be followed by battle_snapshot got %q (%v)`, eventType, event.Type, err)
+		}
+	}
+}
+
+// finalizeActivePokerDataSvc stubs finalizing the active story and records the events logged
+type finalizeActivePokerDataSvc struct {
+	thunderdome.PokerDataSvc
+	storyID string
+	events  []string
+}
+
+func (s *finalizeActivePokerDataSvc) FinalizeActiveStory(PokerID string, FacilitatorID string, Points string) ([]*thunderdome.Story, string, error) {
+	// another story finalized later e.g. by a bulk finalize racing this one
+	return []*thunderdome.Story{
+		{Id: s.storyID, Points: Points, FinalizedTime: time.Now().Add(-time.Minute)},
+		{Id: "other", Points: "8", FinalizedTime: time.Now()},
+	}, s.storyID, nil
+}
+
+func (s *finalizeActivePokerDataSvc) RecordGameEvent(PokerID string, StoryID string, UserID string, EventType string, Value string) error {
+	s.events = append(s.events, StoryID)
+	return nil
+}
+
+// TestPlanFinalizeActive calls PlanFinalizeActive while another story has a later finalized time
+// and makes sure the event is logged for the story the data service finalized
+func TestPlanFinalizeActive(t *testing.T) {
+	svc := &finalizeActivePokerDataSvc{storyID: "active"}
+	b := &Service{BattleService: svc}
+
+	msg, err, _ := b.PlanFinalizeActive(context.Background(), "battle", "leader", "5")
+	if err != nil {
+		t.Fatalf(`unexpected error %v`, err)
+	}
+	if msg == nil {
+		t.Fatalf(`expected plan_finalized to be broadcast`)
+	}
+	if len(svc.events) != 1 || svc.events[0] != "active" {
+		t.Fatalf(`expected the finalize logged for the active story got %v`, svc.events)
+	}
+}
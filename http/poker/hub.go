@@ -5,10 +5,19 @@ type message struct {
 	arena string
 }
 
+// directMessage is a message for a single connection of the arena e.g. telling a session its command was rejected
+type directMessage struct {
+	data  []byte
+	arena string
+	conn  *connection
+}
+
 type subscription struct {
 	conn   *connection
 	arena  string
 	UserID string
+	// SessionID identifies the connection e.g. one of a leaders tabs, see sessionControl
+	SessionID string
 }
 
 // hub maintains the set of active connections and broadcasts messages to the
@@ -25,12 +34,16 @@ type hub struct {
 
 	// Unregister requests from connections.
 	unregister chan subscription
+
+	// Outbound messages to a single connection.
+	direct chan directMessage
 }
 
 var h = hub{
 	broadcast:  make(chan message),
 	register:   make(chan subscription),
 	unregister: make(chan subscription),
+	direct:     make(chan directMessage),
 	arenas:     make(map[string]map[*connection]struct{}),
 }
 
@@ -55,6 +68,19 @@ func (h *hub) run() {
 					}
 				}
 			}
+		case m := <-h.direct:
+			connections := h.arenas[m.arena]
+			if _, ok := connections[m.conn]; ok {
+				select {
+				case m.conn.send <- m.data:
+				default:
+					close(m.conn.send)
+					delete(connections, m.conn)
+					if len(connections) == 0 {
+						delete(h.arenas, m.arena)
+					}
+				}
+			}
 		case m := <-h.broadcast:
 			connections := h.arenas[m.arena]
 			for c := range connections {
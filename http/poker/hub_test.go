@@ -0,0 +1,85 @@
+package poker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+)
+
+// TestNewRegistersEventHandlers calls New and makes sure the plan and vote events clients send have handlers,
+// along with every leader only operation so none are silently dropped
+func TestNewRegistersEventHandlers(t *testing.T) {
+	b := New(otelzap.New(zap.NewNop()), nil, nil, nil, nil, nil)
+
+	for _, eventType := range []string{"add_plan", "activate_plan", "vote", "retract_vote", "end_voting", "finalize_plan", "skip_plan"} {
+		if _, ok := b.eventHandlers[eventType]; !ok {
+			t.Fatalf(`expected a handler for %s`, eventType)
+		}
+	}
+	for eventType := range leaderOnlyOperations {
+		if _, ok := b.eventHandlers[eventType]; !ok {
+			t.Fatalf(`expected a handler for leader only operation %s`, eventType)
+		}
+	}
+	for eventType := range controlledOperations {
+		if _, ok := b.eventHandlers[eventType]; !ok {
+			t.Fatalf(`expected a handler for controlled operation %s`, eventType)
+		}
+	}
+}
+
+// receive gets the next message sent to the connection, failing the test when none arrives in time
+func receive(t *testing.T, c *connection) string {
+	t.Helper()
+	select {
+	case data := <-c.send:
+		return string(data)
+	case <-time.After(time.Second):
+		t.Fatalf(`expected a message for the connection`)
+		return ""
+	}
+}
+
+// TestHubBroadcastScopedToBattle registers connections in two battles and broadcasts to one of them
+// and makes sure only that battles connections get the message while direct messages reach a single connection
+func TestHubBroadcastScopedToBattle(t *testing.T) {
+	hb := &hub{
+		broadcast:  make(chan message),
+		register:   make(chan subscription),
+		unregister: make(chan subscription),
+		direct:     make(chan directMessage),
+		arenas:     make(map[string]map[*connection]struct{}),
+	}
+	go hb.run()
+
+	warrior1 := &connection{send: make(chan []byte, 2)}
+	warrior2 := &connection{send: make(chan []byte, 2)}
+	otherBattle := &connection{send: make(chan []byte, 2)}
+	hb.register <- subscription{conn: warrior1, arena: "battle"}
+	hb.register <- subscription{conn: warrior2, arena: "battle"}
+	hb.register <- subscription{conn: otherBattle, arena: "other-battle"}
+
+	hb.broadcast <- message{[]byte("plan_added"), "battle"}
+	if got := receive(t, warrior1); got != "plan_added" {
+		t.Fatalf(`expected the first warrior to get plan_added got %s`, got)
+	}
+	if got := receive(t, warrior2); got != "plan_added" {
+		t.Fatalf(`expected the second warrior to get plan_added got %s`, got)
+	}
+
+	hb.broadcast <- message{[]byte("vote_activity"), "other-battle"}
+	if got := receive(t, otherBattle); got != "vote_activity" {
+		t.Fatalf(`expected the other battle to only get its own vote_activity got %s`, got)
+	}
+
+	hb.direct <- directMessage{[]byte("vote_rejected"), "battle", warrior2}
+	hb.broadcast <- message{[]byte("voting_ended"), "battle"}
+	if got := receive(t, warrior1); got != "voting_ended" {
+		t.Fatalf(`expected the first warrior not to get the direct message got %s`, got)
+	}
+	if got := receive(t, warrior2); got != "vote_rejected" {
+		t.Fatalf(`expected the second warrior to get the direct message got %s`, got)
+	}
+}
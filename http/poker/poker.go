@@ -42,19 +42,37 @@ func New(
 		"vote":             b.UserVote,
 		"retract_vote":     b.UserVoteRetract,
 		"end_voting":       b.PlanVoteEnd,
+		"cancel_voting":    b.PlanVoteCancel,
+		"call_revote":      b.PlanRevote,
+		"reveal_votes":     b.PlanVotesReveal,
 		"add_plan":         b.PlanAdd,
 		"revise_plan":      b.PlanRevise,
 		"burn_plan":        b.PlanDelete,
 		"activate_plan":    b.PlanActivate,
 		"skip_plan":        b.PlanSkip,
 		"finalize_plan":    b.PlanFinalize,
+		"auto_finalize":    b.PlanFinalizeAuto,
+		"finalize_active":  b.PlanFinalizeActive,
+		"finalize_plans":   b.PlansFinalize,
+		"lock_plan":        b.PlanLock,
+		"unlock_plan":      b.PlanUnlock,
+		"group_plan":       b.PlanGroup,
+		"requeue_plans":    b.PlansRequeue,
 		"promote_leader":   b.UserPromote,
 		"demote_leader":    b.UserDemote,
 		"become_leader":    b.UserPromoteSelf,
 		"spectator_toggle": b.UserSpectatorToggle,
+		"identity_toggle":  b.UserRevealIdentityToggle,
+		"heartbeat":        b.UserHeartbeat,
 		"revise_battle":    b.Revise,
+		"update_settings":  b.SettingsUpdate,
 		"concede_battle":   b.Delete,
+		"archive_battle":   b.Archive,
+		"pause_battle":     b.Pause,
+		"resume_battle":    b.Resume,
 		"abandon_battle":   b.Abandon,
+		"take_control":     b.ControlTake,
+		"resume_control":   b.ControlResume,
 	}
 
 	go h.run()
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/gorilla/mux"
+)
+
+// pokerInviteDefaultExpiry is how long an invite lasts when no expire date is requested
+const pokerInviteDefaultExpiry = 7 * 24 * time.Hour
+
+type pokerInviteRequestBody struct {
+	ExpireDate *time.Time `json:"expireDate"`
+}
+
+type pokerInviteResponse struct {
+	InviteToken string    `json:"inviteToken"`
+	ExpireDate  time.Time `json:"expireDate"`
+}
+
+type pokerInviteRedeemResponse struct {
+	BattleID string `json:"battleId"`
+}
+
+// handlePokerInviteCreate handles creating a revocable invite for the poker game
+// @Summary      Create Poker Invite
+// @Description  Creates a revocable invite token for the poker game, expiring in 7 days unless an expire date is given
+// @Tags         poker
+// @Produce      json
+// @Param        battleId  path    string                  true  "the poker game ID"
+// @Param        invite    body    pokerInviteRequestBody  false  "invite expire date"
+// @Success      200       object  standardJsonResponse{data=pokerInviteResponse}
+// @Failure      400       object  standardJsonResponse{}
+// @Failure      403       object  standardJsonResponse{}
+// @Failure      500       object  standardJsonResponse{}
+// @Security     ApiKeyAuth
+// @Router       /battles/{battleId}/invites [post]
+func (s *Service) handlePokerInviteCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		BattleID := vars["battleId"]
+		idErr := validate.Var(BattleID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+		UserID := r.Context().Value(contextKeyUserID).(string)
+
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+			return
+		}
+
+		var i = pokerInviteRequestBody{}
+		if len(body) > 0 {
+			jsonErr := json.Unmarshal(body, &i)
+			if jsonErr != nil {
+				s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+				return
+			}
+		}
+		ExpireDate := time.Now().Add(pokerInviteDefaultExpiry)
+		if i.ExpireDate != nil {
+			if !i.ExpireDate.After(time.Now()) {
+				s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, "INVITE_EXPIRE_DATE_MUST_BE_IN_FUTURE"))
+				return
+			}
+			ExpireDate = *i.ExpireDate
+		}
+
+		if err := s.PokerDataSvc.ConfirmFacilitator(BattleID, UserID); err != nil {
+			s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_BATTLE_FACILITATOR"))
+			return
+		}
+
+		token, err := s.PokerDataSvc.CreateGameInvite(BattleID, UserID, ExpireDate)
+		if errors.Is(err, thunderdome.ErrValidation) {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, &pokerInviteResponse{InviteToken: token, ExpireDate: ExpireDate}, nil)
+	}
+}
+
+// handlePokerInviteRedeem handles redeeming a poker game invite
+// @Summary      Redeem Poker Invite
+// @Description  Gets the poker game ID for the invite token when it exists and hasn't expired
+// @Tags         poker
+// @Produce      json
+// @Param        inviteToken  path    string  true  "the invite token"
+// @Success      200          object  standardJsonResponse{data=pokerInviteRedeemResponse}
+// @Failure      404          object  standardJsonResponse{}
+// @Security     ApiKeyAuth
+// @Router       /battle-invites/{inviteToken} [get]
+func (s *Service) handlePokerInviteRedeem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		InviteToken := vars["inviteToken"]
+
+		BattleID, err := s.PokerDataSvc.RedeemGameInvite(InviteToken)
+		if err != nil {
+			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, "INVALID_INVITE"))
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, &pokerInviteRedeemResponse{BattleID: BattleID}, nil)
+	}
+}
+
+// handlePokerInviteRevoke handles revoking a poker game invite
+// @Summary      Revoke Poker Invite
+// @Description  Revokes the poker game invite token so it can no longer be redeemed
+// @Tags         poker
+// @Produce      json
+// @Param        battleId     path    string  true  "the poker game ID"
+// @Param        inviteToken  path    string  true  "the invite token"
+// @Success      200          object  standardJsonResponse{}
+// @Failure      400          object  standardJsonResponse{}
+// @Failure      403          object  standardJsonResponse{}
+// @Failure      500          object  standardJsonResponse{}
+// @Security     ApiKeyAuth
+// @Router       /battles/{battleId}/invites/{inviteToken} [delete]
+func (s *Service) handlePokerInviteRevoke() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		BattleID := vars["battleId"]
+		idErr := validate.Var(BattleID, "required,uuid")
+		if idErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, idErr.Error()))
+			return
+		}
+		InviteToken := vars["inviteToken"]
+		UserID := r.Context().Value(contextKeyUserID).(string)
+
+		if err := s.PokerDataSvc.ConfirmFacilitator(BattleID, UserID); err != nil {
+			s.Failure(w, r, http.StatusForbidden, Errorf(EUNAUTHORIZED, "REQUIRES_BATTLE_FACILITATOR"))
+			return
+		}
+
+		err := s.PokerDataSvc.RevokeGameInvite(BattleID, UserID, InviteToken)
+		if errors.Is(err, thunderdome.ErrValidation) {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, nil, nil)
+	}
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/gorilla/mux"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+)
+
+// invitePokerDataSvc stubs the game invites keeping the tokens in memory, only the facilitator can manage them
+type invitePokerDataSvc struct {
+	thunderdome.PokerDataSvc
+	facilitator string
+	invites     map[string]string
+	expireDate  time.Time
+}
+
+func (s *invitePokerDataSvc) ConfirmFacilitator(PokerID string, UserID string) error {
+	if UserID != s.facilitator {
+		return errors.New("not a poker facilitator")
+	}
+	return nil
+}
+
+func (s *invitePokerDataSvc) CreateGameInvite(PokerID string, FacilitatorID string, ExpireDate time.Time) (string, error) {
+	s.expireDate = ExpireDate
+	s.invites["invite-token"] = PokerID
+	return "invite-token", nil
+}
+
+func (s *invitePokerDataSvc) RedeemGameInvite(InviteToken string) (string, error) {
+	PokerID, ok := s.invites[InviteToken]
+	if !ok {
+		return "", errors.New("INVALID_INVITE")
+	}
+	return PokerID, nil
+}
+
+func (s *invitePokerDataSvc) RevokeGameInvite(PokerID string, FacilitatorID string, InviteToken string) error {
+	if s.invites[InviteToken] == PokerID {
+		delete(s.invites, InviteToken)
+	}
+	return nil
+}
+
+// TestPokerInviteHandlers creates, redeems and revokes an invite through the handlers
+// and makes sure only the facilitator can manage it and a revoked invite can't be redeemed
+func TestPokerInviteHandlers(t *testing.T) {
+	BattleID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	FacilitatorID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	UserID := "3f1c2b4a-5d6e-4f70-8a9b-0c1d2e3f4a5b"
+	svc := &invitePokerDataSvc{facilitator: FacilitatorID, invites: make(map[string]string)}
+	s := &Service{Logger: otelzap.New(zap.NewNop()), PokerDataSvc: svc}
+
+	w := httptest.NewRecorder()
+	s.handlePokerInviteCreate()(w, newPokerRequest(BattleID, UserID))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf(`expected a non facilitator creating an invite to be forbidden got %d`, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	s.handlePokerInviteCreate()(w, newPokerRequest(BattleID, FacilitatorID))
+	if w.Code != http.StatusOK {
+		t.Fatalf(`expected the facilitator to create an invite got %d`, w.Code)
+	}
+	var created struct {
+		Data pokerInviteResponse `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Data.InviteToken != "invite-token" || svc.expireDate.Before(time.Now().Add(pokerInviteDefaultExpiry-time.Minute)) {
+		t.Fatalf(`expected the invite token expiring by default in 7 days got %s expiring %v`, created.Data.InviteToken, svc.expireDate)
+	}
+
+	redeem := func() *httptest.ResponseRecorder {
+		r := newPokerRequest(BattleID, UserID)
+		r = mux.SetURLVars(r, map[string]string{"inviteToken": "invite-token"})
+		w := httptest.NewRecorder()
+		s.handlePokerInviteRedeem()(w, r)
+		return w
+	}
+	w = redeem()
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), BattleID) {
+		t.Fatalf(`expected redeeming the invite to return the battle got %d %s`, w.Code, w.Body.String())
+	}
+
+	revoke := func(UserID string) *httptest.ResponseRecorder {
+		r := newPokerRequest(BattleID, UserID)
+		r = mux.SetURLVars(r, map[string]string{"battleId": BattleID, "inviteToken": "invite-token"})
+		w := httptest.NewRecorder()
+		s.handlePokerInviteRevoke()(w, r)
+		return w
+	}
+	if w := revoke(UserID); w.Code != http.StatusForbidden {
+		t.Fatalf(`expected a non facilitator revoking the invite to be forbidden got %d`, w.Code)
+	}
+	if w := revoke(FacilitatorID); w.Code != http.StatusOK {
+		t.Fatalf(`expected the facilitator to revoke the invite got %d`, w.Code)
+	}
+	if w := redeem(); w.Code != http.StatusNotFound {
+		t.Fatalf(`expected a revoked invite not to be found got %d`, w.Code)
+	}
+}
+
+// TestPokerInviteCreateExpireDate calls handlePokerInviteCreate with a past and a future expire date
+// and makes sure the past date is rejected and the future one is used
+func TestPokerInviteCreateExpireDate(t *testing.T) {
+	BattleID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	FacilitatorID := "5b1f3c2a-7d4e-4f6a-8b9c-0a1b2c3d4e5f"
+	svc := &invitePokerDataSvc{facilitator: FacilitatorID, invites: make(map[string]string)}
+	s := &Service{Logger: otelzap.New(zap.NewNop()), PokerDataSvc: svc}
+
+	create := func(ExpireDate time.Time) int {
+		body, _ := json.Marshal(pokerInviteRequestBody{ExpireDate: &ExpireDate})
+		r := newPokerRequest(BattleID, FacilitatorID)
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+		w := httptest.NewRecorder()
+		s.handlePokerInviteCreate()(w, r)
+		return w.Code
+	}
+
+	if code := create(time.Now().Add(-time.Hour)); code != http.StatusBadRequest {
+		t.Fatalf(`expected a past expire date to be a bad request got %d`, code)
+	}
+	ExpireDate := time.Now().Add(time.Hour).Truncate(time.Second)
+	if code := create(ExpireDate); code != http.StatusOK || !svc.expireDate.Equal(ExpireDate) {
+		t.Fatalf(`expected the invite to expire at %v got %d expiring %v`, ExpireDate, code, svc.expireDate)
+	}
+}
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/gorilla/mux"
+)
+
+type pokerTemplateRequestBody struct {
+	Name                 string                   `json:"name" validate:"required"`
+	PointValuesAllowed   []string                 `json:"pointValuesAllowed"`
+	AutoFinishVoting     bool                     `json:"autoFinishVoting"`
+	PointAverageRounding string                   `json:"pointAverageRounding" validate:"required,oneof=ceil round floor"`
+	HideVoterIdentity    bool                     `json:"hideVoterIdentity"`
+	VoteMode             string                   `json:"voteMode" validate:"omitempty,oneof=points fist-of-five effort-complexity"`
+	CustomScale          []thunderdome.ScaleValue `json:"customScale" validate:"omitempty,unique=Label"`
+}
+
+type pokerFromTemplateRequestBody struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// handleGetUserPokerTemplates gets the poker game templates owned by the user
+// @Summary      Get Poker Templates
+// @Description  get list of poker game templates for the user
+// @Tags         poker
+// @Produce      json
+// @Param        userId  path    string  true  "the user ID to get poker templates for"
+// @Success      200     object  standardJsonResponse{data=[]thunderdome.PokerTemplate}
+// @Failure      403     object  standardJsonResponse{}
+// @Failure      500     object  standardJsonResponse{}
+// @Security     ApiKeyAuth
+// @Router       /users/{userId}/battle-templates [get]
+func (s *Service) handleGetUserPokerTemplates() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		UserID := vars["userId"]
+
+		templates, err := s.PokerDataSvc.ListGameTemplates(UserID)
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, templates, nil)
+	}
+}
+
+// handlePokerTemplateCreate handles creating a poker game template
+// @Summary      Create Poker Template
+// @Description  Create a reusable poker game settings template for the user
+// @Tags         poker
+// @Produce      json
+// @Param        userId    path    string                    true  "the user ID"
+// @Param        template  body    pokerTemplateRequestBody  true  "new poker template object"
+// @Success      200       object  standardJsonResponse{data=thunderdome.PokerTemplate}
+// @Failure      400       object  standardJsonResponse{}
+// @Failure      403       object  standardJsonResponse{}
+// @Failure      500       object  standardJsonResponse{}
+// @Security     ApiKeyAuth
+// @Router       /users/{userId}/battle-templates [post]
+func (s *Service) handlePokerTemplateCreate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		UserID := vars["userId"]
+
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+			return
+		}
+
+		var t = pokerTemplateRequestBody{}
+		jsonErr := json.Unmarshal(body, &t)
+		if jsonErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+			return
+		}
+
+		inputErr := validate.Struct(t)
+		if inputErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+			return
+		}
+
+		template, err := s.PokerDataSvc.CreateGameTemplate(UserID, &thunderdome.PokerTemplate{
+			Name:                 t.Name,
+			PointValuesAllowed:   t.PointValuesAllowed,
+			AutoFinishVoting:     t.AutoFinishVoting,
+			PointAverageRounding: t.PointAverageRounding,
+			HideVoterIdentity:    t.HideVoterIdentity,
+			VoteMode:             t.VoteMode,
+			CustomScale:          t.CustomScale,
+		})
+		if errors.Is(err, thunderdome.ErrValidation) {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, template, nil)
+	}
+}
+
+// handlePokerCreateFromTemplate handles creating a poker game from the users template
+// @Summary      Create Poker Game from Template
+// @Description  Create a poker game using the settings of the users poker template
+// @Tags         poker
+// @Produce      json
+// @Param        userId      path    string                        true  "the user ID"
+// @Param        templateId  path    string                        true  "the template ID"
+// @Param        battle      body    pokerFromTemplateRequestBody  true  "new poker game name"
+// @Success      200         object  standardJsonResponse{data=thunderdome.Poker}
+// @Failure      400         object  standardJsonResponse{}
+// @Failure      403         object  standardJsonResponse{}
+// @Failure      404         object  standardJsonResponse{}
+// @Security     ApiKeyAuth
+// @Router       /users/{userId}/battle-templates/{templateId}/battles [post]
+func (s *Service) handlePokerCreateFromTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		UserID := vars["userId"]
+		TemplateID := vars["templateId"]
+
+		body, bodyErr := io.ReadAll(r.Body)
+		if bodyErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, bodyErr.Error()))
+			return
+		}
+
+		var b = pokerFromTemplateRequestBody{}
+		jsonErr := json.Unmarshal(body, &b)
+		if jsonErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, jsonErr.Error()))
+			return
+		}
+
+		inputErr := validate.Struct(b)
+		if inputErr != nil {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, inputErr.Error()))
+			return
+		}
+
+		newBattle, err := s.PokerDataSvc.CreateGameFromTemplate(r.Context(), TemplateID, UserID, b.Name)
+		if errors.Is(err, thunderdome.ErrValidation) {
+			s.Failure(w, r, http.StatusBadRequest, Errorf(EINVALID, err.Error()))
+			return
+		}
+		if err != nil {
+			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, "TEMPLATE_NOT_FOUND"))
+			return
+		}
+
+		s.Success(w, r, http.StatusOK, newBattle, nil)
+	}
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
+	"github.com/gorilla/mux"
+	"github.com/uptrace/opentelemetry-go-extra/otelzap"
+	"go.uber.org/zap"
+)
+
+// getGamePokerDataSvc stubs GetGame returning the configured error
+type getGamePokerDataSvc struct {
+	thunderdome.PokerDataSvc
+	err error
+}
+
+func (s *getGamePokerDataSvc) GetGame(PokerID string, UserID string) (*thunderdome.Poker, error) {
+	return nil, s.err
+}
+
+// newPokerRequest builds a request for the game as the user, with the route vars and user context the router would set
+func newPokerRequest(BattleID string, UserID string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/api/battles/"+BattleID, nil)
+	r = mux.SetURLVars(r, map[string]string{"battleId": BattleID})
+	ctx := context.WithValue(r.Context(), contextKeyUserID, UserID)
+	ctx = context.WithValue(ctx, contextKeyUserType, "REGISTERED")
+
+	return r.WithContext(ctx)
+}
+
+// TestHandleGetPokerGameErrors calls handleGetPokerGame with the data service failing validation and failing otherwise
+// and makes sure a validation error is a bad request while any other error is not found
+func TestHandleGetPokerGameErrors(t *testing.T) {
+	BattleID := "0d6d8ee4-1a4a-4a3a-9b8c-6f2c1f0e9a11"
+	cases := map[error]int{
+		fmt.Errorf("%w: invalid id %q", thunderdome.ErrValidation, "guest"): http.StatusBadRequest,
+		thunderdome.ErrUserNotFound:                                         http.StatusNotFound,
+	}
+	for err, code := range cases {
+		s := &Service{Logger: otelzap.New(zap.NewNop()), PokerDataSvc: &getGamePokerDataSvc{err: err}}
+		w := httptest.NewRecorder()
+
+		s.handleGetPokerGame()(w, newPokerRequest(BattleID, "guest"))
+
+		if w.Code != code {
+			t.Fatalf(`expected status %d for %v got %d`, code, err, w.Code)
+		}
+	}
+}
@@ -3,12 +3,15 @@ package http
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"image"
 	"image/png"
 	"io"
 	"net/http"
 	"strconv"
 
+	"github.com/StevenWeathers/thunderdome-planning-poker/thunderdome"
+
 	"github.com/anthonynsimon/bild/transform"
 	"github.com/ipsn/go-adorable"
 	"github.com/o1egl/govatar"
@@ -49,6 +52,7 @@ func (s *Service) handleSessionUserProfile() http.HandlerFunc {
 // @Param        userId  path    string  true  "the user ID"
 // @Success      200     object  standardJsonResponse{data=thunderdome.User}
 // @Failure      403     object  standardJsonResponse{}
+// @Failure      404     object  standardJsonResponse{}
 // @Failure      500     object  standardJsonResponse{}
 // @Security     ApiKeyAuth
 // @Router       /users/{userId} [get]
@@ -58,6 +62,10 @@ func (s *Service) handleUserProfile() http.HandlerFunc {
 		UserID := vars["userId"]
 
 		User, UserErr := s.UserDataSvc.GetUser(r.Context(), UserID)
+		if errors.Is(UserErr, thunderdome.ErrUserNotFound) {
+			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, "USER_NOT_FOUND"))
+			return
+		}
 		if UserErr != nil {
 			s.Failure(w, r, http.StatusInternalServerError, UserErr)
 			return
@@ -162,6 +170,7 @@ func (s *Service) handleUserProfileUpdate() http.HandlerFunc {
 // @Param        userId  path    string  true  "the user ID"
 // @Success      200     object  standardJsonResponse{}
 // @Failure      403     object  standardJsonResponse{}
+// @Failure      404     object  standardJsonResponse{}
 // @Failure      500     object  standardJsonResponse{}
 // @Security     ApiKeyAuth
 // @Router       /users/{userId} [delete]
@@ -173,6 +182,10 @@ func (s *Service) handleUserDelete() http.HandlerFunc {
 		UserCookieID := ctx.Value(contextKeyUserID).(string)
 
 		User, UserErr := s.UserDataSvc.GetUser(ctx, UserID)
+		if errors.Is(UserErr, thunderdome.ErrUserNotFound) {
+			s.Failure(w, r, http.StatusNotFound, Errorf(ENOTFOUND, "USER_NOT_FOUND"))
+			return
+		}
 		if UserErr != nil {
 			s.Failure(w, r, http.StatusInternalServerError, UserErr)
 			return
@@ -279,6 +279,22 @@ func getSearchFromRequest(r *http.Request) (search string, err error) {
 	return Search, nil
 }
 
+// versionETag formats a version number as a weak ETag
+func versionETag(Version int64) string {
+	return fmt.Sprintf(`W/"%d"`, Version)
+}
+
+// getVersionFromETag gets the version number from the requests If-None-Match header
+func getVersionFromETag(r *http.Request) (int64, bool) {
+	etag := strings.TrimPrefix(strings.TrimSpace(r.Header.Get("If-None-Match")), "W/")
+	Version, err := strconv.ParseInt(strings.Trim(etag, `"`), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return Version, true
+}
+
 // for logging purposes sanitize strings by removing new lines
 func sanitizeUserInputForLogs(unescapedInput string) string {
 	escapedString := strings.Replace(unescapedInput, "\n", "", -1)
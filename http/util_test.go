@@ -1,6 +1,9 @@
 package http
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
 
 	"github.com/go-playground/validator/v10"
@@ -8,6 +11,7 @@ import (
 
 func TestMain(m *testing.M) {
 	validate = validator.New()
+	os.Exit(m.Run())
 }
 
 // TestValidUserAccount calls validateUserAccountWithPasswords with valid user inputs for name, email, password1, and password2
@@ -49,3 +53,21 @@ func TestInvalidUserPassword(t *testing.T) {
 		t.Fatalf(`validateUserAccountWithPasswords = %v, want error`, err)
 	}
 }
+
+// TestGetVersionFromETag calls getVersionFromETag with weak, strong, and invalid If-None-Match headers
+func TestGetVersionFromETag(t *testing.T) {
+	for header, expected := range map[string]int64{versionETag(42): 42, `"7"`: 7} {
+		r := httptest.NewRequest(http.MethodGet, "/api/battles/id", nil)
+		r.Header.Set("If-None-Match", header)
+		Version, ok := getVersionFromETag(r)
+		if !ok || Version != expected {
+			t.Fatalf(`expected version %d for %s got %d, %v`, expected, header, Version, ok)
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/battles/id", nil)
+	r.Header.Set("If-None-Match", "*")
+	if _, ok := getVersionFromETag(r); ok {
+		t.Fatalf(`expected invalid ETag to be ignored`)
+	}
+}
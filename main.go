@@ -77,7 +77,10 @@ type server struct {
 }
 
 func main() {
-	zlog, _ := zap.NewProduction(
+	logLevel := zap.NewAtomicLevel()
+	zapConfig := zap.NewProductionConfig()
+	zapConfig.Level = logLevel
+	zlog, _ := zapConfig.Build(
 		zap.Fields(
 			zap.String("version", version),
 		),
@@ -91,6 +94,10 @@ func main() {
 
 	InitConfig(logger)
 
+	if err := logLevel.UnmarshalText([]byte(viper.GetString("log.level"))); err != nil {
+		logger.Error("invalid log level, defaulting to info", zap.Error(err))
+	}
+
 	if viper.GetBool("otel.enabled") {
 		cleanup := initTracer(
 			logger,
@@ -136,16 +143,22 @@ func main() {
 
 	s.email = email.New(s.config.AppDomain, s.config.PathPrefix, s.logger)
 	s.db = db.New(s.config.AdminEmail, &db.Config{
+		URL:             viper.GetString("db.url"),
 		Host:            viper.GetString("db.host"),
+		ReadHost:        viper.GetString("db.read_host"),
 		Port:            viper.GetInt("db.port"),
 		User:            viper.GetString("db.user"),
 		Password:        viper.GetString("db.pass"),
 		Name:            viper.GetString("db.name"),
 		SSLMode:         viper.GetString("db.sslmode"),
+		SSLRootCert:     viper.GetString("db.sslrootcert"),
+		SSLCert:         viper.GetString("db.sslcert"),
+		SSLKey:          viper.GetString("db.sslkey"),
 		AESHashkey:      viper.GetString("config.aes_hashkey"),
 		MaxIdleConns:    viper.GetInt("db.max_idle_conns"),
 		MaxOpenConns:    viper.GetInt("db.max_open_conns"),
 		ConnMaxLifetime: viper.GetInt("db.conn_max_lifetime"),
+		ConnMaxIdleTime: viper.GetInt("db.conn_max_idle_time"),
 	}, s.logger)
 
 	s.routes()
@@ -1,6 +1,13 @@
 package thunderdome
 
-import "context"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrCheckinContentTooLong is returned when checkin or comment content is over its length limit
+var ErrCheckinContentTooLong = errors.New("CHECKIN_CONTENT_TOO_LONG")
 
 type TeamCheckin struct {
 	Id          string            `json:"id"`
@@ -21,16 +28,37 @@ type CheckinComment struct {
 	CheckinID   string `json:"checkin_id"`
 	UserID      string `json:"user_id"`
 	Comment     string `json:"comment"`
+	Pinned      bool   `json:"pinned"`
+	CreateDate  string `json:"created_date"`
+	UpdatedDate string `json:"updated_date"`
+}
+
+// CheckinActionItem A team action item with an optional assignee
+type CheckinActionItem struct {
+	ID          string `json:"id"`
+	TeamID      string `json:"team_id"`
+	UserID      string `json:"user_id"`
+	AssigneeID  string `json:"assignee_id"`
+	Content     string `json:"content"`
+	Done        bool   `json:"done"`
 	CreateDate  string `json:"created_date"`
 	UpdatedDate string `json:"updated_date"`
 }
 
 type CheckinDataSvc interface {
 	CheckinList(ctx context.Context, TeamId string, Date string, TimeZone string) ([]*TeamCheckin, error)
+	ExportCheckinsMarkdown(ctx context.Context, TeamId string, Date time.Time) ([]byte, error)
 	CheckinCreate(ctx context.Context, TeamId string, UserId string, Yesterday string, Today string, Blockers string, Discuss string, GoalsMet bool) error
 	CheckinUpdate(ctx context.Context, CheckinId string, Yesterday string, Today string, Blockers string, Discuss string, GoalsMet bool) error
 	CheckinDelete(ctx context.Context, CheckinId string) error
 	CheckinComment(ctx context.Context, TeamId string, CheckinId string, UserId string, Comment string) error
 	CheckinCommentEdit(ctx context.Context, TeamId string, UserId string, CommentId string, Comment string) error
 	CheckinCommentDelete(ctx context.Context, CommentId string) error
+	CheckinCommentPinToggle(ctx context.Context, TeamId string, CommentId string) (bool, error)
+	CheckinActionItemList(ctx context.Context, TeamId string) ([]*CheckinActionItem, error)
+	CheckinActionItemCreate(ctx context.Context, TeamId string, UserId string, AssigneeId string, Content string) (*CheckinActionItem, error)
+	CheckinActionItemUpdate(ctx context.Context, TeamId string, ItemId string, AssigneeId string, Content string) (*CheckinActionItem, error)
+	CheckinActionItemComplete(ctx context.Context, TeamId string, ItemId string, Done bool) (*CheckinActionItem, error)
+	CheckinActionItemDelete(ctx context.Context, TeamId string, ItemId string) error
+	ReassignUserCheckins(ctx context.Context, FromUserId string, ToUserId string, TeamId string) error
 }
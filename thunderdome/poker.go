@@ -2,9 +2,86 @@ package thunderdome
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var (
+	// ErrValidation is returned when an input such as an ID fails validation before being used
+	ErrValidation = errors.New("VALIDATION_ERROR")
+	// ErrGameArchived is returned when attempting to modify an archived game
+	ErrGameArchived = errors.New("GAME_ARCHIVED")
+	// ErrGamePaused is returned when voting or changing stories while the facilitator has paused the game
+	ErrGamePaused = errors.New("GAME_PAUSED")
+	// ErrVoteUnchanged is returned when a user re-sends the vote they've already cast
+	ErrVoteUnchanged = errors.New("VOTE_UNCHANGED")
+	// ErrQuorumNotMet is returned when ending voting or finalizing a story with fewer voters than the games minimum
+	ErrQuorumNotMet = errors.New("QUORUM_NOT_MET")
+	// ErrUserNotFound is returned when a referenced user such as a games facilitator doesn't exist
+	ErrUserNotFound = errors.New("USER_NOT_FOUND")
+	// ErrCorruptVotes is returned when a stories stored votes can't be read
+	ErrCorruptVotes = errors.New("CORRUPT_VOTES")
+	// ErrSpectatorFacilitator is returned when making a spectator a facilitator, facilitators must be able to vote
+	ErrSpectatorFacilitator = errors.New("SPECTATOR_CANNOT_FACILITATE")
+	// ErrVotingClosed is returned when a vote lands on a story that's no longer being voted on e.g. it was just finalized
+	ErrVotingClosed = errors.New("VOTING_CLOSED")
+	// ErrStoryNotFound is returned when a story doesn't exist in the game
+	ErrStoryNotFound = errors.New("STORY_NOT_FOUND")
+	// ErrStoryLocked is returned when changing a story the facilitator locked, it must be unlocked first
+	ErrStoryLocked = errors.New("STORY_LOCKED")
+	// ErrNoActiveUsers is returned when a game has no active users to choose from e.g. to promote to facilitator
+	ErrNoActiveUsers = errors.New("NO_ACTIVE_USERS")
+	// ErrVotesHidden is returned when getting who voted what on a story whose votes haven't been revealed yet
+	ErrVotesHidden = errors.New("VOTES_NOT_REVEALED")
+	// ErrUserNameRequired is returned when an unnamed user votes in a game requiring named users, they must set a name first
+	ErrUserNameRequired = errors.New("USER_NAME_REQUIRED")
+	// ErrNoActiveStory is returned when acting on the games active story while no story is active
+	ErrNoActiveStory = errors.New("NO_ACTIVE_STORY")
+)
+
+const (
+	// PokerVoteModePoints is the default vote mode using the games allowed point values
+	PokerVoteModePoints = "points"
+	// PokerVoteModeFistOfFive is a confidence vote from 0 to 5 where votes below 3 are concerns
+	PokerVoteModeFistOfFive = "fist-of-five"
+	// PokerVoteModeEffortComplexity votes on effort and complexity separately, both using the games allowed point values
+	PokerVoteModeEffortComplexity = "effort-complexity"
+
+	// TieBreakRoundUp suggests the average vote rounded up to the nearest allowed value
+	TieBreakRoundUp = "round-up"
+	// TieBreakMode suggests the most common vote, preferring the higher value on ties
+	TieBreakMode = "mode"
+	// TieBreakUpperMedian suggests the median vote, taking the higher of the two middle votes
+	TieBreakUpperMedian = "upper-median"
+
+	// EstimationUnitPoints is the default estimation unit
+	EstimationUnitPoints = "points"
+	// EstimationUnitHours labels estimates as hours
+	EstimationUnitHours = "hours"
+	// EstimationUnitDays labels estimates as days
+	EstimationUnitDays = "days"
+
+	// StoryStatusUnestimated is a story that has not been pointed or skipped
+	StoryStatusUnestimated = "unestimated"
+	// StoryStatusEstimated is a story that has been finalized with points
+	StoryStatusEstimated = "estimated"
+	// StoryStatusSkipped is a story that was skipped
+	StoryStatusSkipped = "skipped"
+
+	// ScaleTypeNumeric is a vote scale with numeric values or ordinals where the average and median are meaningful
+	ScaleTypeNumeric = "numeric"
+	// ScaleTypeCategorical is a vote scale of labels such as T-shirt sizes summarized only by mode and distribution
+	ScaleTypeCategorical = "categorical"
+
+	// VotingDurationUntilFinalized measures story voting durations until the story was finalized
+	VotingDurationUntilFinalized = "finalized"
+	// VotingDurationUntilVoteEnd measures story voting durations until voting ended
+	VotingDurationUntilVoteEnd = "vote-end"
+)
+
+// FistOfFiveValues are the allowed vote values for the fist-of-five vote mode
+var FistOfFiveValues = []string{"0", "1", "2", "3", "4", "5"}
+
 // PokerUser aka user
 type PokerUser struct {
 	Id           string `json:"id"`
@@ -15,6 +92,14 @@ type PokerUser struct {
 	Abandoned    bool   `json:"abandoned"`
 	Spectator    bool   `json:"spectator"`
 	GravatarHash string `json:"gravatarHash"`
+	// RevealIdentity opts the user into showing who they are with their votes in games that hide voter identity
+	RevealIdentity bool `json:"revealIdentity"`
+}
+
+// ScaleValue is a custom vote value label with the ordinal used for numeric operations
+type ScaleValue struct {
+	Label   string  `json:"label"`
+	Ordinal float64 `json:"ordinal"`
 }
 
 // Poker aka arena
@@ -31,66 +116,390 @@ type Poker struct {
 	PointAverageRounding string       `json:"pointAverageRounding"`
 	HideVoterIdentity    bool         `json:"hideVoterIdentity"`
 	JoinCode             string       `json:"joinCode"`
+	ShortCode            string       `json:"shortCode"`
 	FacilitatorCode      string       `json:"leaderCode,omitempty"`
 	TeamID               string       `json:"teamId"`
+	VoteMode             string       `json:"voteMode"`
+	Archived             bool         `json:"archived"`
+	CustomScale          []ScaleValue `json:"customScale"`
+	EstimationUnit       string       `json:"estimationUnit"`
+	TieBreakStrategy     string       `json:"tieBreakStrategy"`
+	MinVotersToFinalize  int          `json:"minVotersToFinalize"`
+	VoteRevealThreshold  int          `json:"voteRevealThreshold"`
+	Version              int64        `json:"version"`
+	CreatedDate          time.Time    `json:"createdDate"`
+	UpdatedDate          time.Time    `json:"updatedDate"`
+	// ParallelVoting lets several stories be voted on at once, ActiveStoryID is then the most recently activated
+	ParallelVoting bool     `json:"parallelVoting"`
+	ActiveStoryIDs []string `json:"activePlanIds"`
+	// RequireNamedUsers rejects votes from users without a name of their own, e.g. a generated guest name
+	RequireNamedUsers bool `json:"requireNamedUsers"`
+	// Paused is set while the facilitator has paused the game, votes and story changes are rejected
+	Paused bool `json:"paused"`
+	// Leaderless is set when none of the games facilitators exist anymore, see PromoteFirstActiveUser
+	Leaderless bool `json:"leaderless"`
+	// VoteAliases maps variants of vote values e.g. "Extra Large" to the scale value they're counted as
+	VoteAliases map[string]string `json:"voteAliases"`
+}
+
+// PokerSettings are the poker game settings to update together, nil fields are left unchanged
+type PokerSettings struct {
+	AutoFinishVoting     *bool   `json:"autoFinishVoting,omitempty"`
+	PointAverageRounding *string `json:"pointAverageRounding,omitempty"`
+	HideVoterIdentity    *bool   `json:"hideVoterIdentity,omitempty"`
+	EstimationUnit       *string `json:"estimationUnit,omitempty"`
+	TieBreakStrategy     *string `json:"tieBreakStrategy,omitempty"`
+	MinVotersToFinalize  *int    `json:"minVotersToFinalize,omitempty"`
+	VoteRevealThreshold  *int    `json:"voteRevealThreshold,omitempty"`
+	ParallelVoting       *bool   `json:"parallelVoting,omitempty"`
+	RequireNamedUsers    *bool   `json:"requireNamedUsers,omitempty"`
+	// VoteAliases replaces the games vote aliases when set, an empty map removes them
+	VoteAliases map[string]string `json:"voteAliases,omitempty"`
+}
+
+// PokerTemplate is a reusable set of poker game settings
+type PokerTemplate struct {
+	Id                   string       `json:"id"`
+	OwnerID              string       `json:"ownerId"`
+	Name                 string       `json:"name"`
+	PointValuesAllowed   []string     `json:"pointValuesAllowed"`
+	AutoFinishVoting     bool         `json:"autoFinishVoting"`
+	PointAverageRounding string       `json:"pointAverageRounding"`
+	HideVoterIdentity    bool         `json:"hideVoterIdentity"`
+	VoteMode             string       `json:"voteMode"`
+	CustomScale          []ScaleValue `json:"customScale"`
 	CreatedDate          time.Time    `json:"createdDate"`
 	UpdatedDate          time.Time    `json:"updatedDate"`
 }
 
+// PokerConfig is a games configuration in a portable format for creating the same game on another instance,
+// it never includes the games stories, votes, users or codes
+type PokerConfig struct {
+	Version              int               `json:"version"`
+	VoteMode             string            `json:"voteMode"`
+	PointValuesAllowed   []string          `json:"pointValuesAllowed"`
+	CustomScale          []ScaleValue      `json:"customScale"`
+	AutoFinishVoting     bool              `json:"autoFinishVoting"`
+	PointAverageRounding string            `json:"pointAverageRounding"`
+	HideVoterIdentity    bool              `json:"hideVoterIdentity"`
+	VoteRevealThreshold  int               `json:"voteRevealThreshold"`
+	MinVotersToFinalize  int               `json:"minVotersToFinalize"`
+	EstimationUnit       string            `json:"estimationUnit"`
+	TieBreakStrategy     string            `json:"tieBreakStrategy"`
+	ParallelVoting       bool              `json:"parallelVoting"`
+	RequireNamedUsers    bool              `json:"requireNamedUsers"`
+	VoteAliases          map[string]string `json:"voteAliases"`
+}
+
 // Vote structure
 type Vote struct {
-	UserId    string `json:"warriorId"`
-	VoteValue string `json:"vote"`
+	UserId          string `json:"warriorId"`
+	VoteValue       string `json:"vote"`
+	ComplexityValue string `json:"complexity,omitempty"`
 }
 
 // Story aka Story structure
 type Story struct {
-	Id                 string    `json:"id"`
-	Name               string    `json:"name"`
-	Type               string    `json:"type"`
-	ReferenceId        string    `json:"referenceId"`
-	Link               string    `json:"link"`
-	Description        string    `json:"description"`
-	AcceptanceCriteria string    `json:"acceptanceCriteria"`
-	Priority           int32     `json:"priority"`
-	Votes              []*Vote   `json:"votes"`
-	Points             string    `json:"points"`
-	Active             bool      `json:"active"`
-	Skipped            bool      `json:"skipped"`
-	VoteStartTime      time.Time `json:"voteStartTime"`
-	VoteEndTime        time.Time `json:"voteEndTime"`
+	Id                 string  `json:"id"`
+	Name               string  `json:"name"`
+	Type               string  `json:"type"`
+	ReferenceId        string  `json:"referenceId"`
+	Link               string  `json:"link"`
+	Description        string  `json:"description"`
+	AcceptanceCriteria string  `json:"acceptanceCriteria"`
+	Priority           int32   `json:"priority"`
+	Position           int32   `json:"position"`
+	Votes              []*Vote `json:"votes"`
+	// VotesCorrupt is set when the stored votes couldn't be read, the votes are withheld rather than shown as empty
+	VotesCorrupt  bool      `json:"votesCorrupt,omitempty"`
+	Points        string    `json:"points"`
+	PointsNumeric *float64  `json:"pointsNumeric,omitempty"`
+	Active        bool      `json:"active"`
+	Skipped       bool      `json:"skipped"`
+	VoteStartTime time.Time `json:"voteStartTime"`
+	VoteEndTime   time.Time `json:"voteEndTime"`
+	FinalizedTime time.Time `json:"finalizedTime"`
+	UpdatedDate   time.Time `json:"updatedDate"`
+	// VotesRevealed is set when the facilitator reveals the votes while voting is still active
+	VotesRevealed bool `json:"votesRevealed"`
+	// Locked is set when the facilitator locks the finalized story against further changes
+	Locked bool `json:"locked"`
+	// GroupID is the parallel voting group the story is estimated in, only one story per group can be active at a time
+	GroupID string `json:"groupId"`
+}
+
+// StoryEstimate is a story's finalized estimate from a game
+type StoryEstimate struct {
+	StoryID       string    `json:"planId"`
+	PokerID       string    `json:"battleId"`
+	Points        string    `json:"points"`
+	PointsNumeric *float64  `json:"pointsNumeric,omitempty"`
+	FinalizedDate time.Time `json:"finalizedDate"`
+}
+
+// PokerEvent is an entry in a game's append-only event log, vote events never include the vote value
+type PokerEvent struct {
+	Id          int64     `json:"id"`
+	PokerID     string    `json:"battleId"`
+	StoryID     string    `json:"planId"`
+	UserID      string    `json:"warriorId"`
+	Type        string    `json:"type"`
+	Value       string    `json:"value"`
+	CreatedDate time.Time `json:"createdDate"`
+}
+
+// StoryWithHistory is a finalized story with its event log entries e.g. for exporting a game's votes
+type StoryWithHistory struct {
+	Story   *Story        `json:"plan"`
+	History []*PokerEvent `json:"history"`
+}
+
+// StoryVoteTiming is how quickly a finalized story was voted on after voting started,
+// TimeToFullTurnout is until the last of the stories voters cast their first vote
+type StoryVoteTiming struct {
+	StoryID           string        `json:"storyId"`
+	Name              string        `json:"name"`
+	TimeToFirstVote   time.Duration `json:"timeToFirstVote"`
+	TimeToFullTurnout time.Duration `json:"timeToFullTurnout"`
+}
+
+// TimingStats are the median vote timings across a poker game's finalized stories
+type TimingStats struct {
+	PokerID                 string             `json:"pokerId"`
+	StoriesTimed            int                `json:"storiesTimed"`
+	MedianTimeToFirstVote   time.Duration      `json:"medianTimeToFirstVote"`
+	MedianTimeToFullTurnout time.Duration      `json:"medianTimeToFullTurnout"`
+	Stories                 []*StoryVoteTiming `json:"stories"`
+}
+
+// FastVoter is a user whose first vote on many stories came implausibly quickly after voting started,
+// it's an advisory flag for the facilitator and doesn't block the users votes
+type FastVoter struct {
+	UserID       string        `json:"warriorId"`
+	StoriesVoted int           `json:"plansVoted"`
+	FastVotes    int           `json:"fastVotes"`
+	FastestVote  time.Duration `json:"fastestVote"`
+}
+
+// StoryImportResult is the result of bulk importing stories,
+// TruncatedRows are the 1-based line numbers of stories whose names were truncated
+type StoryImportResult struct {
+	Stories       []*Story `json:"plans"`
+	TruncatedRows []int    `json:"truncatedRows"`
+}
+
+// StoryTurnout is who has and hasn't voted on the active story among the active non-spectator users,
+// it holds user IDs only and never the vote values
+type StoryTurnout struct {
+	StoryID          string   `json:"planId"`
+	Voted            []string `json:"voted"`
+	Outstanding      []string `json:"outstanding"`
+	VotedCount       int      `json:"votedCount"`
+	OutstandingCount int      `json:"outstandingCount"`
+}
+
+// StoryColumnMapping maps the header columns of a tracker CSV export (e.g. Trello or JIRA) to story fields,
+// only the Name column is required
+type StoryColumnMapping struct {
+	Name        string `json:"name"`
+	ReferenceID string `json:"referenceId"`
+	Description string `json:"description"`
+}
+
+// StoryVoteSummary summarizes the votes cast for a story
+type StoryVoteSummary struct {
+	VoteMode       string         `json:"voteMode"`
+	EstimationUnit string         `json:"estimationUnit"`
+	VoteCount      int            `json:"voteCount"`
+	Distribution   map[string]int `json:"distribution"`
+	Average        float64        `json:"average"`
+	Median         float64        `json:"median"`
+	MedianLabel    string         `json:"medianLabel,omitempty"`
+	Concerns       int            `json:"concerns"`
+	// ScaleType is whether the votes are on a numeric or categorical scale, the average and median are left zero on categorical scales
+	ScaleType string `json:"scaleType"`
+	// Mode is the most common vote values, more than one when tied, in the scales order
+	Mode []string `json:"mode"`
+	// SuggestedEstimate is the allowed value proposed by the games tie break strategy
+	SuggestedEstimate string `json:"suggestedEstimate,omitempty"`
+	// SuggestionStrategy is the tie break strategy used for the SuggestedEstimate
+	SuggestionStrategy string `json:"suggestionStrategy,omitempty"`
+	// Complexity summarizes the complexity votes separately from the effort votes in effort-complexity vote mode
+	Complexity *StoryVoteSummary `json:"complexity,omitempty"`
+}
+
+// StoryFinalizedEvent is the message published when a story is finalized for downstream consumers
+type StoryFinalizedEvent struct {
+	PokerID       string    `json:"pokerId"`
+	StoryID       string    `json:"storyId"`
+	Name          string    `json:"name"`
+	Type          string    `json:"type"`
+	ReferenceID   string    `json:"referenceId"`
+	Link          string    `json:"link"`
+	Points        string    `json:"points"`
+	PointsNumeric *float64  `json:"pointsNumeric,omitempty"`
+	VoteCount     int       `json:"voteCount"`
+	FinalizedTime time.Time `json:"finalizedTime"`
+}
+
+// PokerEventPublisher publishes poker events to a message queue such as NATS or Kafka,
+// implementations provide the transport
+type PokerEventPublisher interface {
+	Publish(ctx context.Context, Topic string, Message []byte) error
+}
+
+// TeamEstimationStats aggregate estimation statistics across a team's or facilitator's poker games
+type TeamEstimationStats struct {
+	GameCount           int     `json:"gameCount"`
+	StoriesEstimated    int     `json:"storiesEstimated"`
+	TotalPoints         float64 `json:"totalPoints"`
+	AvgStoriesPerGame   float64 `json:"avgStoriesPerGame"`
+	ConsensusRate       float64 `json:"consensusRate"`
+	ConsensusCount      int     `json:"consensusCount"`
+	NonNumericEstimates int     `json:"nonNumericEstimates"`
+}
+
+// VelocityPoint is the total finalized points of one of a team's poker games for charting velocity over time
+type VelocityPoint struct {
+	PokerID          string    `json:"pokerId"`
+	Name             string    `json:"name"`
+	CreatedDate      time.Time `json:"createdDate"`
+	StoriesEstimated int       `json:"storiesEstimated"`
+	TotalPoints      float64   `json:"totalPoints"`
+}
+
+// StoryAccuracy compares a story's finalized estimate to the actual effort recorded after delivery
+type StoryAccuracy struct {
+	StoryID  string  `json:"storyId"`
+	Name     string  `json:"name"`
+	Estimate string  `json:"estimate"`
+	Actual   string  `json:"actual"`
+	Variance float64 `json:"variance"`
+}
+
+// AccuracyReport is how closely a poker game's estimates matched actual effort,
+// Accuracy is from 0 to 1 where 1 means every compared story's estimate matched its actual effort
+type AccuracyReport struct {
+	PokerID         string           `json:"pokerId"`
+	StoriesCompared int              `json:"storiesCompared"`
+	TotalEstimated  float64          `json:"totalEstimated"`
+	TotalActual     float64          `json:"totalActual"`
+	Accuracy        float64          `json:"accuracy"`
+	Stories         []*StoryAccuracy `json:"stories"`
+}
+
+// WarriorParticipation is a user's participation across a team's poker games,
+// UserID and UserName are empty for participation in games that hide voter identity
+type WarriorParticipation struct {
+	UserID           string `json:"warriorId"`
+	UserName         string `json:"warriorName"`
+	GamesAttended    int    `json:"gamesAttended"`
+	StoriesVoted     int    `json:"storiesVoted"`
+	ConsensusMatches int    `json:"consensusMatches"`
 }
 
 type PokerDataSvc interface {
-	CreateGame(ctx context.Context, FacilitatorID string, Name string, PointValuesAllowed []string, Stories []*Story, AutoFinishVoting bool, PointAverageRounding string, JoinCode string, FacilitatorCode string, HideVoterIdentity bool) (*Poker, error)
-	TeamCreateGame(ctx context.Context, TeamID string, FacilitatorID string, Name string, PointValuesAllowed []string, Stories []*Story, AutoFinishVoting bool, PointAverageRounding string, JoinCode string, FacilitatorCode string, HideVoterIdentity bool) (*Poker, error)
-	UpdateGame(PokerID string, Name string, PointValuesAllowed []string, AutoFinishVoting bool, PointAverageRounding string, HideVoterIdentity bool, JoinCode string, FacilitatorCode string, TeamID string) error
+	CreateGame(ctx context.Context, FacilitatorID string, Name string, PointValuesAllowed []string, Stories []*Story, AutoFinishVoting bool, PointAverageRounding string, JoinCode string, FacilitatorCode string, HideVoterIdentity bool, VoteMode string, CustomScale []ScaleValue, Settings PokerSettings, IdempotencyKey string) (*Poker, error)
+	TeamCreateGame(ctx context.Context, TeamID string, FacilitatorID string, Name string, PointValuesAllowed []string, Stories []*Story, AutoFinishVoting bool, PointAverageRounding string, JoinCode string, FacilitatorCode string, HideVoterIdentity bool, VoteMode string, CustomScale []ScaleValue, Settings PokerSettings, IdempotencyKey string) (*Poker, error)
+	UpdateGame(PokerID string, Name string, PointValuesAllowed []string, AutoFinishVoting bool, PointAverageRounding string, HideVoterIdentity bool, JoinCode string, FacilitatorCode string, TeamID string, VoteMode string) error
 	GetFacilitatorCode(PokerID string) (string, error)
 	GetGame(PokerID string, UserID string) (*Poker, error)
+	GetGameIfChanged(PokerID string, UserID string, KnownVersion int64) (*Poker, bool, error)
 	GetGamesByUser(UserID string, Limit int, Offset int) ([]*Poker, int, error)
+	GetResumableGames(UserID string) ([]*Poker, error)
 	ConfirmFacilitator(PokerID string, UserID string) error
+	IsFacilitator(PokerID string, UserID string) (bool, error)
 	GetUserActiveStatus(PokerID string, UserID string) error
 	GetUsers(PokerID string) []*PokerUser
 	GetActiveUsers(PokerID string) []*PokerUser
-	AddUser(PokerID string, UserID string) ([]*PokerUser, error)
+	CountActiveUsers(PokerID string) (int, error)
+	GetAbsentTeamUsers(PokerID string, TeamID string) ([]*TeamUser, error)
+	AddUser(PokerID string, UserID string) (Users []*PokerUser, IsNew bool, err error)
+	AddUsers(PokerID string, UserIDs []string) ([]*PokerUser, error)
 	RetreatUser(PokerID string, UserID string) []*PokerUser
+	UserHeartbeat(PokerID string, UserID string) error
+	PruneInactiveUsers(PokerID string, FacilitatorID string) (int, error)
 	AbandonGame(PokerID string, UserID string) ([]*PokerUser, error)
 	AddFacilitator(PokerID string, UserID string) ([]string, error)
 	RemoveFacilitator(PokerID string, UserID string) ([]string, error)
 	ToggleSpectator(PokerID string, UserID string, Spectator bool) ([]*PokerUser, error)
 	DeleteGame(PokerID string) error
+	ArchiveGame(PokerID string) error
+	PauseGame(PokerID string, FacilitatorID string) error
+	PromoteFirstActiveUser(PokerID string) (*Poker, error)
+	ResumeGame(PokerID string, FacilitatorID string) error
+	RepairGameState(PokerID string) error
+	SnapshotGame(PokerID string) ([]byte, error)
+	RestoreGame(Data []byte) (*Poker, error)
+	RecordActualEffort(PokerID string, StoryID string, Actual string) error
+	GetEstimationAccuracy(PokerID string) (*AccuracyReport, error)
+	SetGameCustomScale(PokerID string, CustomScale []ScaleValue) error
+	SetGameEstimationUnit(PokerID string, EstimationUnit string) error
+	SetGameTieBreakStrategy(PokerID string, Strategy string) error
+	UpdateGameSettings(PokerID string, FacilitatorID string, Settings PokerSettings) (*Poker, error)
+	SetGameMinVotersToFinalize(PokerID string, MinVoters int) error
+	SetGameVoteRevealThreshold(PokerID string, RevealThreshold int) error
+	MergeUsers(PrimaryUserID string, DuplicateUserID string) error
+	GetGameByShortCode(ShortCode string, UserID string) (*Poker, error)
+	RequeueUnestimated(PokerID string) ([]*Story, error)
+	UpdateGameScale(PokerID string, FacilitatorID string, NewScale []string) ([]*Story, error)
 	AddFacilitatorsByEmail(ctx context.Context, PokerID string, FacilitatorEmails []string) ([]string, error)
 	GetGames(Limit int, Offset int) ([]*Poker, int, error)
 	GetActiveGames(Limit int, Offset int) ([]*Poker, int, error)
 	PurgeOldGames(ctx context.Context, DaysOld int) error
+	CleanOrphanedUsers(ctx context.Context) (int, error)
 	GetStories(PokerID string, UserID string) []*Story
-	CreateStory(PokerID string, Name string, Type string, ReferenceID string, Link string, Description string, AcceptanceCriteria string, Priority int32) ([]*Story, error)
+	GetStory(PokerID string, StoryID string, UserID string) (*Story, error)
+	GetStoriesUpdatedSince(PokerID string, UserID string, Since time.Time) ([]*Story, error)
+	CreateStory(PokerID string, Name string, Type string, ReferenceID string, Link string, Description string, AcceptanceCriteria string, Priority int32, IdempotencyKey string) ([]*Story, error)
+	CreateStoriesBulk(PokerID string, Stories []*Story, TruncateNames bool) (*StoryImportResult, error)
+	ImportStoriesFromCSV(PokerID string, CSV []byte, Mapping StoryColumnMapping) ([]*Story, error)
 	ActivateStoryVoting(PokerID string, StoryID string) ([]*Story, error)
-	SetVote(PokerID string, UserID string, StoryID string, VoteValue string) (BattlePlans []*Story, AllUsersVoted bool)
+	GetStoryVoteCount(StoryID string) (int, error)
+	GetActiveStoryTurnout(PokerID string) (*StoryTurnout, error)
+	GetActiveStoriesTurnout(PokerID string) ([]*StoryTurnout, error)
+	CountStoriesByStatus(PokerID string) (map[string]int, error)
+	GetSprintReadyStories(PokerID string) ([]*Story, error)
+	SetVote(PokerID string, UserID string, StoryID string, VoteValue string, ComplexityValue string) (Stories []*Story, AllUsersVoted bool, err error)
 	RetractVote(PokerID string, UserID string, StoryID string) ([]*Story, error)
-	EndStoryVoting(PokerID string, StoryID string) ([]*Story, error)
+	CallForRevote(PokerID string, FacilitatorID string) ([]*Story, error)
+	RevealStoryVotes(PokerID string, StoryID string, FacilitatorID string) ([]*Story, error)
+	EndStoryVoting(PokerID string, StoryID string, OverrideQuorum bool) ([]*Story, error)
+	CancelStoryVoting(PokerID string, StoryID string, FacilitatorID string) ([]*Story, error)
 	SkipStory(PokerID string, StoryID string) ([]*Story, error)
 	UpdateStory(PokerID string, StoryID string, Name string, Type string, ReferenceID string, Link string, Description string, AcceptanceCriteria string, Priority int32) ([]*Story, error)
 	DeleteStory(PokerID string, StoryID string) ([]*Story, error)
-	FinalizeStory(PokerID string, StoryID string, Points string) ([]*Story, error)
+	LockStory(PokerID string, StoryID string, FacilitatorID string) ([]*Story, error)
+	UnlockStory(PokerID string, StoryID string, FacilitatorID string) ([]*Story, error)
+	SetStoryGroup(PokerID string, StoryID string, GroupID string) ([]*Story, error)
+	CompactStoryPositions(PokerID string) error
+	FinalizeStory(PokerID string, StoryID string, Points string, OverrideQuorum bool) ([]*Story, error)
+	FinalizeStoryAuto(PokerID string, StoryID string, FacilitatorID string) ([]*Story, error)
+	FinalizeStoriesBulk(PokerID string, FacilitatorID string, StoryIDs []string, Points string) ([]*Story, error)
+	FinalizeActiveStory(PokerID string, FacilitatorID string, Points string) ([]*Story, string, error)
+	GetLastEstimateForReference(ReferenceID string) (*StoryEstimate, error)
+	CreateGameInvite(PokerID string, FacilitatorID string, ExpireDate time.Time) (string, error)
+	RedeemGameInvite(InviteToken string) (string, error)
+	RevokeGameInvite(PokerID string, FacilitatorID string, InviteToken string) error
+	GetTeamEstimationStats(TeamID string, From time.Time, To time.Time) (*TeamEstimationStats, error)
+	GetFacilitatorEstimationStats(FacilitatorID string, From time.Time, To time.Time) (*TeamEstimationStats, error)
+	GetTeamVelocity(TeamID string, LastN int) ([]*VelocityPoint, error)
+	GetTeamParticipationReport(TeamID string, From time.Time, To time.Time) ([]*WarriorParticipation, error)
+	GetGameDuration(PokerID string) (time.Duration, error)
+	GetStoryVotingDurations(PokerID string, Until string) (map[string]time.Duration, error)
+	RecordGameEvent(PokerID string, StoryID string, UserID string, EventType string, Value string) error
+	GetGameEventLog(PokerID string) ([]*PokerEvent, error)
+	GetStoriesWithHistory(PokerID string) ([]*StoryWithHistory, error)
+	GetVoteTimingStats(PokerID string) (*TimingStats, error)
+	GetFastVoters(PokerID string, FacilitatorID string, Threshold time.Duration, MinStories int) ([]*FastVoter, error)
+	GetStoryVoteSummary(PokerID string, StoryID string) (*StoryVoteSummary, error)
+	GetVoteFrequency(PokerID string) (map[string]int, error)
+	GetUserEngagement(PokerID string) (map[string]float64, error)
+	GetStoryVoteBreakdown(PokerID string, StoryID string) (map[string][]*PokerUser, error)
+	SetUserRevealIdentity(PokerID string, UserID string, RevealIdentity bool) ([]*PokerUser, error)
+	CreateGameTemplate(OwnerID string, Template *PokerTemplate) (*PokerTemplate, error)
+	ListGameTemplates(OwnerID string) ([]*PokerTemplate, error)
+	CreateGameFromTemplate(ctx context.Context, TemplateID string, FacilitatorID string, Name string) (*Poker, error)
+	ExportGameSettings(PokerID string) ([]byte, error)
+	CreateGameFromSettings(ctx context.Context, FacilitatorID string, Name string, Settings []byte) (*Poker, error)
 }
@@ -2,9 +2,13 @@ package thunderdome
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrGuestEmailRegistered is returned when joining as a guest with the email of a guest who has since registered
+var ErrGuestEmailRegistered = errors.New("GUEST_EMAIL_REGISTERED")
+
 // User aka user
 type User struct {
 	Id                   string    `json:"id"`
@@ -33,7 +37,7 @@ type UserDataSvc interface {
 	GetRegisteredUsers(ctx context.Context, Limit int, Offset int) ([]*User, int, error)
 	SearchRegisteredUsersByEmail(ctx context.Context, Email string, Limit int, Offset int) ([]*User, int, error)
 	CreateUser(ctx context.Context, UserName string, UserEmail string, UserPassword string) (NewUser *User, VerifyID string, RegisterErr error)
-	CreateUserGuest(ctx context.Context, UserName string) (*User, error)
+	CreateUserGuest(ctx context.Context, UserName string, UserEmail string) (*User, error)
 	CreateUserRegistered(ctx context.Context, UserName string, UserEmail string, UserPassword string, ActiveUserID string) (NewUser *User, VerifyID string, RegisterErr error)
 	UpdateUserAccount(ctx context.Context, UserID string, UserName string, UserEmail string, UserAvatar string, NotificationsEnabled bool, Country string, Locale string, Company string, JobTitle string) error
 	UpdateUserProfile(ctx context.Context, UserID string, UserName string, UserAvatar string, NotificationsEnabled bool, Country string, Locale string, Company string, JobTitle string) error
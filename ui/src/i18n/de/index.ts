@@ -97,6 +97,8 @@ const de: Translation = {
   completed: 'Completed',
   conciseVotingResults: 'Concise Voting Results',
   confirmDeleteRetro: 'Are you sure you want to delete this retrospective?',
+  controlConflict:
+    'Eine andere Ihrer Sitzungen steuert die Abstimmung dieses Spiels.',
   createAccount: 'Konto erstellen',
   createAlertError: 'Error encountered creating alert',
   createAlertSuccess: 'Alert created successfully',
@@ -418,6 +420,7 @@ const de: Translation = {
   storyboardRemoveSuccess: 'Storyboard removed successfully.',
   storyboards: 'Storyboards',
   storyboardStories: 'Storyboard Stories',
+  takeControl: 'Steuerung übernehmen',
   team: 'Team',
   teamCheckins: 'Team Checkins',
   teamCreate: 'Team erstellen',
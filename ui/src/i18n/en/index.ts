@@ -96,6 +96,8 @@ const en: BaseTranslation = {
   completed: 'Completed',
   conciseVotingResults: 'Concise Voting Results',
   confirmDeleteRetro: 'Are you sure you want to delete this retrospective?',
+  controlConflict:
+    "Another of your sessions is in control of this game's voting.",
   createAccount: 'Create Account',
   createAlertError: 'Error encountered creating alert',
   createAlertSuccess: 'Alert created successfully',
@@ -415,6 +417,7 @@ const en: BaseTranslation = {
   storyboardRemoveSuccess: 'Storyboard removed successfully.',
   storyboards: 'Storyboards',
   storyboardStories: 'Storyboard Stories',
+  takeControl: 'Take Control',
   team: 'Team',
   teamCheckins: 'Team Checkins',
   teamCreate: 'Create Team',
@@ -96,6 +96,7 @@ const es: Translation = {
   completed: 'Completed',
   conciseVotingResults: 'Concise Voting Results',
   confirmDeleteRetro: 'Are you sure you want to delete this retrospective?',
+  controlConflict: 'Otra de tus sesiones controla la votación de este juego.',
   createAccount: 'Create Account',
   createAlertError: 'Error encountered creating alert',
   createAlertSuccess: 'Alert created successfully',
@@ -415,6 +416,7 @@ const es: Translation = {
   storyboardRemoveSuccess: 'Storyboard removed successfully.',
   storyboards: 'Storyboards',
   storyboardStories: 'Storyboard Stories',
+  takeControl: 'Tomar el control',
   team: 'Team',
   teamCheckins: 'Team Checkins',
   teamCreate: 'Create Team',
@@ -99,6 +99,8 @@ const fr: Translation = {
   completed: 'Completed',
   conciseVotingResults: 'Concise Voting Results',
   confirmDeleteRetro: 'Are you sure you want to delete this retrospective?',
+  controlConflict:
+    'Une autre de vos sessions contrôle le vote de cette partie.',
   createAccount: 'Créer un Compte',
   createAlertError: 'Error encountered creating alert',
   createAlertSuccess: 'Alert created successfully',
@@ -423,6 +425,7 @@ const fr: Translation = {
   storyboardRemoveSuccess: 'Storyboard removed successfully.',
   storyboards: 'Storyboards',
   storyboardStories: 'Storyboard Stories',
+  takeControl: 'Prendre le contrôle',
   team: 'Equipe',
   teamCheckins: 'Team Checkins',
   teamCreate: 'Créer une équipe',
@@ -391,6 +391,10 @@ type RootTranslation = {
    * A​r​e​ ​y​o​u​ ​s​u​r​e​ ​y​o​u​ ​w​a​n​t​ ​t​o​ ​d​e​l​e​t​e​ ​t​h​i​s​ ​r​e​t​r​o​s​p​e​c​t​i​v​e​?
    */
   confirmDeleteRetro: string;
+  /**
+   * A​n​o​t​h​e​r​ ​o​f​ ​y​o​u​r​ ​s​e​s​s​i​o​n​s​ ​i​s​ ​i​n​ ​c​o​n​t​r​o​l​ ​o​f​ ​t​h​i​s​ ​g​a​m​e​'​s​ ​v​o​t​i​n​g​.
+   */
+  controlConflict: string;
   /**
    * C​r​e​a​t​e​ ​A​c​c​o​u​n​t
    */
@@ -1593,6 +1597,10 @@ type RootTranslation = {
    * S​t​o​r​y​b​o​a​r​d​ ​S​t​o​r​i​e​s
    */
   storyboardStories: string;
+  /**
+   * T​a​k​e​ ​C​o​n​t​r​o​l
+   */
+  takeControl: string;
   /**
    * T​e​a​m
    */
@@ -2680,6 +2688,10 @@ export type TranslationFunctions = {
    * Are you sure you want to delete this retrospective?
    */
   confirmDeleteRetro: () => LocalizedString;
+  /**
+   * Another of your sessions is in control of this game's voting.
+   */
+  controlConflict: () => LocalizedString;
   /**
    * Create Account
    */
@@ -3869,6 +3881,10 @@ export type TranslationFunctions = {
    * Storyboard Stories
    */
   storyboardStories: () => LocalizedString;
+  /**
+   * Take Control
+   */
+  takeControl: () => LocalizedString;
   /**
    * Team
    */
@@ -101,6 +101,8 @@ const it: Translation = {
   completed: 'Completato',
   conciseVotingResults: 'Risultati Votazione Sintetici',
   confirmDeleteRetro: 'Sei sicuro di voler eliminare questa retrospettiva?',
+  controlConflict:
+    "Un'altra delle tue sessioni controlla la votazione di questo gioco.",
   createAccount: 'Creare un account',
   createAlertError: "Errore durante la creazione dell'avviso",
   createAlertSuccess: 'Avviso creato con successo',
@@ -438,6 +440,7 @@ const it: Translation = {
   storyboardRemoveSuccess: 'Storyboard rimosso con successo.',
   storyboards: 'Storyboards',
   storyboardStories: 'Storie dello storyboard',
+  takeControl: 'Prendi il controllo',
   team: 'Team',
   teamCheckins: 'Checkins di squadra',
   teamCreate: 'Crea Team',
@@ -99,6 +99,7 @@ const pt: Translation = {
   completed: 'Concluído',
   conciseVotingResults: 'Resultados Concisos da Votação',
   confirmDeleteRetro: 'Tem certeza de que deseja excluir esta retrospectiva?',
+  controlConflict: 'Outra das suas sessões controla a votação deste jogo.',
   createAccount: 'Criar conta',
   createAlertError: 'Erro ao criar alerta',
   createAlertSuccess: 'Alerta criado com sucesso',
@@ -425,6 +426,7 @@ const pt: Translation = {
   storyboardRemoveSuccess: 'Storyboard removido com sucesso.',
   storyboards: 'Storyboards',
   storyboardStories: 'Histórias do Storyboard',
+  takeControl: 'Assumir o controle',
   team: 'Equipe',
   teamCheckins: 'Team Check-ins',
   teamCreate: 'Criar equipe',
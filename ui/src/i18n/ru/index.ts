@@ -96,6 +96,7 @@ const ru: Translation = {
   completed: 'Completed',
   conciseVotingResults: 'Concise Voting Results',
   confirmDeleteRetro: 'Are you sure you want to delete this retrospective?',
+  controlConflict: 'Голосованием в этой игре управляет другая ваша сессия.',
   createAccount: 'Создать профиль',
   createAlertError: 'Error encountered creating alert',
   createAlertSuccess: 'Alert created successfully',
@@ -415,6 +416,7 @@ const ru: Translation = {
   storyboardRemoveSuccess: 'Storyboard removed successfully.',
   storyboards: 'Storyboards',
   storyboardStories: 'Storyboard Stories',
+  takeControl: 'Взять управление',
   team: 'Team',
   teamCheckins: 'Team Checkins',
   teamCreate: 'Create Team',